@@ -1,16 +1,112 @@
 package v1
 
 import (
+	"context"
+	"log"
+	"time"
+
 	"awesome-trade/src/examples"
+	"awesome-trade/src/internal/chain"
+	"awesome-trade/src/internal/config"
+	"awesome-trade/src/internal/database"
 	"awesome-trade/src/internal/handler"
+	"awesome-trade/src/internal/middleware"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/internal/sequencer"
+	"awesome-trade/src/internal/service"
+	"awesome-trade/src/internal/stream"
+	"awesome-trade/src/pkg/decimal"
+	"awesome-trade/src/pkg/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-// SetupRoutes 设置API路由
-func SetupRoutes(r *gin.Engine) {
+// SetupRoutes 设置API路由，chainClient为nil时不注册充值提现路由
+func SetupRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config, engine *sequencer.Manager, chainClient chain.Client) {
+	// 创建仓储和服务实例
+	repos := repository.NewRepositories(db)
+	txManager := database.NewTxManager(db)
+	cipher := utils.NewCipher(cfg.Security.EncryptionKey)
+	userService := service.NewUserService(txManager, repos.Users)
+	twoFactorService := service.NewTwoFactorService(repos.TwoFactor, repos.Users, cipher, cfg.TwoFactor)
+	authService := service.NewAuthService(repos.Users, repos.RefreshTokens, userService, twoFactorService, service.NewMemoryDenylist(), cfg.JWT)
+	apiKeyService := service.NewAPIKeyService(repos.APIKeys, cipher, cfg.APIKey)
+	rbacService := service.NewRBACService(txManager, repos.Roles, repos.Users)
+	ledgerService := service.NewLedgerService(txManager, repos.Ledger, decimal.NewScales(cfg.Assets.Scales))
+	marketDataService := service.NewMarketDataService(repos.Markets, repos.Klines, engine, cfg.MarketData)
+	riskService := service.NewRiskService(repos.RiskLimits, repos.Users, repos.Markets, repos.Orders, repos.Ledger, marketDataService, cfg.Risk)
+	feeService := service.NewFeeService(txManager, repos.Fees, repos.Fills, repos.Markets, marketDataService, cfg.Fee)
+	orderService := service.NewOrderService(txManager, repos.Orders, repos.Markets, ledgerService, riskService, feeService, marketDataService, engine, cfg.Order)
+	orderGroupService := service.NewOrderGroupService(txManager, repos.OrderGroups, orderService)
+	marketService := service.NewMarketService(txManager, repos.Markets, orderService)
+	transferService := service.NewTransferService(txManager, repos.Transfers, ledgerService, chainClient, cfg.Transfer)
+	hub := stream.NewHub(cfg.Stream.SendBuffer, cfg.Stream.ReplayBuffer)
+	service.NewStreamService(hub, repos.Orders, ledgerService, marketDataService, engine)
+
+	// 加载风控限额，并定期同步其他实例的修改
+	if err := riskService.Reload(context.Background()); err != nil {
+		log.Printf("Failed to load risk limits: %v", err)
+	}
+	if cfg.Risk.ReloadInterval > 0 {
+		go riskService.Run(context.Background(), time.Duration(cfg.Risk.ReloadInterval)*time.Second)
+	}
+
+	// 加载手续费配置，定期同步其他实例的修改，并每天重新计算用户等级
+	if err := feeService.Reload(context.Background()); err != nil {
+		log.Printf("Failed to load fee schedule: %v", err)
+	}
+	if cfg.Fee.ReloadInterval > 0 {
+		go feeService.Run(context.Background(), time.Duration(cfg.Fee.ReloadInterval)*time.Second)
+	}
+	if cfg.Fee.RecomputeHour >= 0 {
+		go feeService.RunDaily(context.Background(), cfg.Fee.RecomputeHour)
+	}
+
+	// 加载等待触发的条件单，并在行情变化时检查触发
+	if err := orderService.LoadTriggers(context.Background()); err != nil {
+		log.Printf("Failed to load conditional orders: %v", err)
+	}
+	go orderService.RunTriggers(context.Background())
+
+	// 重新设置撤单倒计时，停止期间已到期的倒计时立即撤单，并定期处理其他实例上到期的倒计时
+	if err := orderService.LoadCancelAfter(context.Background()); err != nil {
+		log.Printf("Failed to load cancel deadlines: %v", err)
+	}
+	go orderService.RunCancelAfter(context.Background(), time.Duration(cfg.Order.CancelAfterSweep)*time.Second)
+
+	// 后台轮询链上交易状态
+	if chainClient != nil && cfg.Transfer.PollInterval > 0 {
+		go transferService.Run(context.Background(), time.Duration(cfg.Transfer.PollInterval)*time.Second)
+	}
+
+	// 定期写入K线
+	if cfg.MarketData.FlushInterval > 0 {
+		go marketDataService.Run(context.Background(), time.Duration(cfg.MarketData.FlushInterval)*time.Second)
+	}
+
 	// 创建处理器实例
 	healthHandler := handler.NewHealthHandler()
+	userHandler := handler.NewUserHandler(userService)
+	authHandler := handler.NewAuthHandler(authService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	roleHandler := handler.NewRoleHandler(rbacService)
+	twoFactorHandler := handler.NewTwoFactorHandler(twoFactorService)
+	orderHandler := handler.NewOrderHandler(orderService)
+	orderGroupHandler := handler.NewOrderGroupHandler(orderGroupService)
+	ledgerHandler := handler.NewLedgerHandler(ledgerService)
+	transferHandler := handler.NewTransferHandler(transferService)
+	marketHandler := handler.NewMarketHandler(marketService)
+	marketDataHandler := handler.NewMarketDataHandler(marketDataService)
+	streamHandler := handler.NewStreamHandler(hub, authService, cfg.Stream)
+	riskHandler := handler.NewRiskHandler(riskService)
+	feeHandler := handler.NewFeeHandler(feeService)
+
+	// 认证中间件
+	authRequired := middleware.JWTAuth(authService)
+	fresh2FA := middleware.RequireFresh2FA(twoFactorService)
+	tradingAuth := middleware.Authenticate(authService, apiKeyService)
 
 	// 健康检查路由
 	r.GET("/health", healthHandler.CheckHealth)
@@ -21,38 +117,170 @@ func SetupRoutes(r *gin.Engine) {
 		// 基础路由
 		v1.GET("/ping", healthHandler.Ping)
 
+		// 交易对信息（公开）
+		v1.GET("/markets", marketHandler.List)
+		v1.GET("/markets/:symbol", marketHandler.Get)
+
+		// 行情（公开）
+		marketDataGroup := v1.Group("/market/:symbol")
+		{
+			marketDataGroup.GET("/ticker", marketDataHandler.Ticker)
+			marketDataGroup.GET("/depth", marketDataHandler.Depth)
+			marketDataGroup.GET("/trades", marketDataHandler.Trades)
+			marketDataGroup.GET("/klines", marketDataHandler.Klines)
+		}
+
+		// 实时推送（公开频道可匿名订阅，私有频道须认证）
+		v1.GET("/ws", middleware.OptionalAuthenticate(authService, apiKeyService), streamHandler.WebSocket)
+		v1.GET("/stream", streamHandler.SSE)
+
 		// 用户相关路由
 		userGroup := v1.Group("/users")
+		userGroup.Use(authRequired)
 		{
-			userGroup.GET("/", func(c *gin.Context) {
-				// TODO: 实现获取用户列表
-			})
-			userGroup.POST("/", func(c *gin.Context) {
-				// TODO: 实现创建用户
-			})
-			userGroup.GET("/:id", func(c *gin.Context) {
-				// TODO: 实现获取用户详情
-			})
-			userGroup.PUT("/:id", func(c *gin.Context) {
-				// TODO: 实现更新用户
-			})
-			userGroup.DELETE("/:id", func(c *gin.Context) {
-				// TODO: 实现删除用户
-			})
+			canRead := middleware.RequirePermission(rbacService, model.PermUsersRead)
+			canWrite := middleware.RequirePermission(rbacService, model.PermUsersWrite)
+
+			userGroup.GET("/", canRead, userHandler.List)
+			userGroup.POST("/", canWrite, userHandler.Create)
+			userGroup.GET("/:id", canRead, userHandler.Get)
+			userGroup.PUT("/:id", canWrite, userHandler.Update)
+			userGroup.DELETE("/:id", canWrite, userHandler.Delete)
 		}
 
 		// 认证相关路由
 		authGroup := v1.Group("/auth")
 		{
-			authGroup.POST("/login", func(c *gin.Context) {
-				// TODO: 实现用户登录
-			})
-			authGroup.POST("/register", func(c *gin.Context) {
-				// TODO: 实现用户注册
-			})
-			authGroup.POST("/logout", func(c *gin.Context) {
-				// TODO: 实现用户登出
-			})
+			authGroup.POST("/login", authHandler.Login)
+			authGroup.POST("/login/2fa", authHandler.LoginTwoFactor)
+			authGroup.POST("/register", authHandler.Register)
+			authGroup.POST("/refresh", authHandler.Refresh)
+			authGroup.POST("/logout", authRequired, authHandler.Logout)
+		}
+
+		// 两步验证路由
+		twoFactorGroup := v1.Group("/2fa")
+		twoFactorGroup.Use(authRequired)
+		{
+			twoFactorGroup.GET("", twoFactorHandler.Status)
+			twoFactorGroup.POST("/enroll", twoFactorHandler.Enroll)
+			twoFactorGroup.POST("/confirm", twoFactorHandler.Confirm)
+			twoFactorGroup.POST("/verify", twoFactorHandler.Verify)
+			twoFactorGroup.POST("/disable", twoFactorHandler.Disable)
+		}
+
+		// API Key管理路由（仅支持交互式登录）
+		apiKeyGroup := v1.Group("/api-keys")
+		apiKeyGroup.Use(authRequired)
+		{
+			apiKeyGroup.GET("", apiKeyHandler.List)
+			apiKeyGroup.POST("", fresh2FA, apiKeyHandler.Create)
+			apiKeyGroup.DELETE("/:id", apiKeyHandler.Delete)
+		}
+
+		// 订单路由（支持JWT和API Key签名认证）
+		orderGroup := v1.Group("/orders")
+		orderGroup.Use(tradingAuth)
+		{
+			canRead := middleware.RequireScope(model.ScopeRead)
+			canTrade := middleware.RequireScope(model.ScopeTrade)
+
+			orderGroup.POST("", canTrade, orderHandler.Place)
+			orderGroup.POST("/batch", canTrade, orderHandler.Batch)
+			orderGroup.POST("/cancel-all", canTrade, orderHandler.CancelAll)
+			orderGroup.POST("/cancel-after", canTrade, orderHandler.CancelAfter)
+			orderGroup.GET("/open", canRead, orderHandler.ListOpen)
+			orderGroup.GET("/history", canRead, orderHandler.ListHistory)
+			orderGroup.GET("/:id", canRead, orderHandler.Get)
+			orderGroup.DELETE("/:id", canTrade, orderHandler.Cancel)
+		}
+
+		// 订单组路由（二选一和括号单）
+		orderGroupsGroup := v1.Group("/order-groups")
+		orderGroupsGroup.Use(tradingAuth)
+		{
+			canRead := middleware.RequireScope(model.ScopeRead)
+			canTrade := middleware.RequireScope(model.ScopeTrade)
+
+			orderGroupsGroup.POST("", canTrade, orderGroupHandler.Create)
+			orderGroupsGroup.GET("/open", canRead, orderGroupHandler.ListOpen)
+			orderGroupsGroup.GET("/:id", canRead, orderGroupHandler.Get)
+			orderGroupsGroup.DELETE("/:id", canTrade, orderGroupHandler.Cancel)
+		}
+
+		// 账户余额路由
+		v1.GET("/balances", tradingAuth, middleware.RequireScope(model.ScopeRead), ledgerHandler.Balances)
+
+		// 手续费等级路由
+		v1.GET("/fees", tradingAuth, middleware.RequireScope(model.ScopeRead), feeHandler.Summary)
+
+		// 充值提现路由，申报充值要求API Key具备交易权限，提现要求API Key具备提现权限，且用户在新鲜度窗口内完成过两步验证。
+		// 未配置链客户端时不开放
+		if chainClient != nil {
+			transferGroup := v1.Group("")
+			transferGroup.Use(tradingAuth)
+			canRead := middleware.RequireScope(model.ScopeRead)
+			canTrade := middleware.RequireScope(model.ScopeTrade)
+			canWithdraw := middleware.RequireScope(model.ScopeWithdraw)
+
+			transferGroup.GET("/deposit-address", canRead, transferHandler.DepositAddress)
+			transferGroup.POST("/deposits", canTrade, transferHandler.Deposit)
+			transferGroup.POST("/withdrawals", canWithdraw, fresh2FA, transferHandler.Withdraw)
+			transferGroup.GET("/transfers", canRead, transferHandler.List)
+			transferGroup.GET("/transfers/:id", canRead, transferHandler.Get)
+		}
+
+		// 管理后台路由
+		adminGroup := v1.Group("/admin")
+		adminGroup.Use(authRequired)
+		{
+			canAssign := middleware.RequirePermission(rbacService, model.PermRolesAssign)
+
+			adminGroup.GET("/roles", canAssign, roleHandler.ListRoles)
+			adminGroup.GET("/users/:id/roles", canAssign, roleHandler.UserRoles)
+			adminGroup.POST("/users/:id/roles", canAssign, roleHandler.AssignRole)
+			adminGroup.DELETE("/users/:id/roles/:role", canAssign, roleHandler.RevokeRole)
+
+			canReadOrders := middleware.RequirePermission(rbacService, model.PermOrdersReadAny)
+			canCancelOrders := middleware.RequirePermission(rbacService, model.PermOrdersCancelAny)
+			adminGroup.GET("/orders/:id", canReadOrders, orderHandler.AdminGet)
+			adminGroup.GET("/users/:id/orders/open", canReadOrders, orderHandler.AdminListOpen)
+			adminGroup.DELETE("/orders/:id", canCancelOrders, orderHandler.AdminCancel)
+
+			canReadLedger := middleware.RequirePermission(rbacService, model.PermLedgerRead)
+			adminGroup.GET("/ledger/reconcile", canReadLedger, ledgerHandler.Reconcile)
+
+			if chainClient != nil {
+				canApprove := middleware.RequirePermission(rbacService, model.PermWithdrawalsApprove)
+				adminGroup.GET("/transfers", canApprove, transferHandler.AdminList)
+				adminGroup.GET("/transfers/:id", canApprove, transferHandler.AdminGet)
+				adminGroup.POST("/transfers/:id/approve", canApprove, transferHandler.Approve)
+				adminGroup.POST("/transfers/:id/reject", canApprove, transferHandler.Reject)
+				adminGroup.POST("/transfers/:id/sync", canApprove, transferHandler.Sync)
+			}
+
+			canManageMarkets := middleware.RequirePermission(rbacService, model.PermMarketsManage)
+			adminGroup.POST("/markets", canManageMarkets, marketHandler.Create)
+			adminGroup.PUT("/markets/:symbol", canManageMarkets, marketHandler.Update)
+			adminGroup.POST("/markets/:symbol/status", canManageMarkets, marketHandler.SetStatus)
+
+			canManageRisk := middleware.RequirePermission(rbacService, model.PermRiskManage)
+			adminGroup.GET("/risk/limits", canManageRisk, riskHandler.ListLimits)
+			adminGroup.PUT("/risk/limits", canManageRisk, riskHandler.SetLimit)
+			adminGroup.DELETE("/risk/limits", canManageRisk, riskHandler.DeleteLimit)
+			adminGroup.PUT("/users/:id/tier", canManageRisk, riskHandler.SetUserTier)
+
+			canManageFees := middleware.RequirePermission(rbacService, model.PermFeesManage)
+			adminGroup.GET("/fees/tiers", canManageFees, feeHandler.ListTiers)
+			adminGroup.PUT("/fees/tiers", canManageFees, feeHandler.SetTier)
+			adminGroup.DELETE("/fees/tiers", canManageFees, feeHandler.DeleteTier)
+			adminGroup.GET("/fees/overrides", canManageFees, feeHandler.ListOverrides)
+			adminGroup.PUT("/fees/overrides", canManageFees, feeHandler.SetOverride)
+			adminGroup.DELETE("/fees/overrides", canManageFees, feeHandler.DeleteOverride)
+			adminGroup.GET("/fees/promotions", canManageFees, feeHandler.ListPromotions)
+			adminGroup.POST("/fees/promotions", canManageFees, feeHandler.CreatePromotion)
+			adminGroup.DELETE("/fees/promotions/:id", canManageFees, feeHandler.DeletePromotion)
+			adminGroup.POST("/fees/recompute", canManageFees, feeHandler.Recompute)
 		}
 	}
 
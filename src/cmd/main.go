@@ -2,8 +2,16 @@ package main
 
 import (
 	"awesome-trade/src/api/v1"
+	"awesome-trade/src/internal/chain"
 	"awesome-trade/src/internal/config"
+	"awesome-trade/src/internal/database"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/internal/sequencer"
+	"awesome-trade/src/internal/service"
+	"context"
 	"log"
+	"os"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,14 +23,97 @@ func main() {
 		log.Fatal("Failed to load config:", err)
 	}
 
+	// 数据库迁移子命令
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg.Database, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if cfg.JWT.Secret == "" {
+		log.Fatal("jwt.secret must be configured")
+	}
+	if cfg.Security.EncryptionKey == "" {
+		log.Fatal("security.encryption_key must be configured")
+	}
+
+	// 连接数据库
+	db, err := database.Open(cfg.Database)
+	if err != nil {
+		log.Fatal("Failed to connect database:", err)
+	}
+	defer database.Close(db)
+
+	// 检查数据库结构版本
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		log.Fatal("Failed to load migrations:", err)
+	}
+	version, dirty, err := migrator.Version(context.Background())
+	if err != nil {
+		log.Fatal("Failed to read schema version:", err)
+	}
+	if dirty {
+		log.Fatalf("Schema version %d is dirty, fix it and run `migrate force`", version)
+	}
+	if version < migrator.Latest() {
+		log.Printf("Warning: schema version %d is behind %d, run `migrate up`", version, migrator.Latest())
+	}
+
+	// 初始化内置角色和权限
+	repos := repository.NewRepositories(db)
+	rbac := service.NewRBACService(database.NewTxManager(db), repos.Roles, repos.Users)
+	if err := rbac.Seed(context.Background()); err != nil {
+		log.Fatal("Failed to seed roles:", err)
+	}
+	for _, username := range cfg.RBAC.BootstrapAdmins {
+		if err := rbac.AssignRoleByUsername(context.Background(), username, model.RoleAdmin); err != nil {
+			log.Printf("Warning: could not grant admin role to %s: %v", username, err)
+		}
+	}
+
 	// 设置Gin模式
 	gin.SetMode(cfg.Server.Mode)
 
 	// 创建Gin路由器
 	r := gin.Default()
 
+	// 创建撮合定序器，订阅者在设置路由时注册，随后从快照和日志恢复订单簿
+	engine := sequencer.NewManager(sequencer.Options{
+		Dir:              cfg.Matching.JournalDir,
+		SnapshotInterval: cfg.Matching.SnapshotInterval,
+		Fsync:            cfg.Matching.Fsync,
+		QueueSize:        cfg.Matching.QueueSize,
+		ApplyRetries:     cfg.Matching.ApplyRetries,
+	})
+	defer engine.Close()
+
+	// 链上签名与广播，未配置链客户端时不开放充值提现，发布模式下必须配置真实的链客户端
+	var chainClient chain.Client
+	release := cfg.Server.Mode == gin.ReleaseMode
+	switch cfg.Transfer.Chain {
+	case "":
+		if release {
+			log.Fatal("transfer.chain must be configured in release mode")
+		}
+		log.Printf("Warning: transfer.chain is not configured, deposit and withdrawal routes are disabled")
+	case "fake":
+		if release {
+			log.Fatal("transfer.chain \"fake\" cannot be used in release mode")
+		}
+		log.Printf("Warning: using in-memory chain client, withdrawals are not broadcast to any network")
+		chainClient = chain.NewFakeClient()
+	default:
+		log.Fatalf("Unknown transfer.chain %q", cfg.Transfer.Chain)
+	}
+
 	// 设置路由
-	v1.SetupRoutes(r)
+	v1.SetupRoutes(r, db, cfg, engine, chainClient)
+
+	if err := engine.Start(); err != nil {
+		log.Fatal("Failed to recover order books:", err)
+	}
 
 	// 启动服务器
 	port := ":" + cfg.Server.Port
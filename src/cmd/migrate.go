@@ -0,0 +1,110 @@
+package main
+
+import (
+	"awesome-trade/src/internal/config"
+	"awesome-trade/src/internal/database"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+// migrateUsage migrate子命令用法
+const migrateUsage = `Usage: awesome-trade migrate <command>
+
+Commands:
+  up [N]        Apply all or N pending migrations
+  down N|-all   Roll back N migrations, or all of them with -all
+  goto V        Migrate up or down to version V (0 rolls back everything)
+  version       Print the current version and dirty state
+  force V       Set the version without running migrations (-1 clears it)
+  unlock        Release a migration lock left behind by a crashed process`
+
+// runMigrate 执行migrate子命令
+func runMigrate(cfg config.DatabaseConfig, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+	defer database.Close(db)
+
+	m, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		n := len(m.Migrations())
+		if len(args) > 1 {
+			if n, err = positiveArg(args[1]); err != nil {
+				return err
+			}
+		}
+		err = m.Steps(ctx, n)
+	case "down":
+		if len(args) < 2 {
+			return errors.New("down requires N or -all")
+		}
+		if args[1] == "-all" {
+			err = m.Down(ctx)
+			break
+		}
+		n, perr := positiveArg(args[1])
+		if perr != nil {
+			return perr
+		}
+		err = m.Steps(ctx, -n)
+	case "goto":
+		if len(args) < 2 {
+			return errors.New("goto requires a version")
+		}
+		v, perr := strconv.ParseUint(args[1], 10, 32)
+		if perr != nil {
+			return fmt.Errorf("invalid version: %s", args[1])
+		}
+		err = m.Goto(ctx, uint(v))
+	case "force":
+		if len(args) < 2 {
+			return errors.New("force requires a version")
+		}
+		v, perr := strconv.Atoi(args[1])
+		if perr != nil {
+			return fmt.Errorf("invalid version: %s", args[1])
+		}
+		err = m.Force(ctx, v)
+	case "unlock":
+		err = m.Unlock(ctx)
+	case "version":
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	if errors.Is(err, database.ErrNoChange) {
+		log.Println("No change")
+	} else if err != nil {
+		return err
+	}
+
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("Schema version: %d (dirty: %t, latest: %d)", version, dirty, m.Latest())
+	return nil
+}
+
+// positiveArg 解析正整数参数
+func positiveArg(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid step count: %s", s)
+	}
+	return n, nil
+}
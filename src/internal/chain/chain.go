@@ -0,0 +1,130 @@
+// Package chain 链上转账接口。提现通过Client签名并广播，充值地址由Client生成，
+// 充值和提现的确认状态及转账详情也通过Client查询，测试和本地开发使用内存实现FakeClient。
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"awesome-trade/src/pkg/decimal"
+)
+
+// ErrUnknownTx 交易不存在
+var ErrUnknownTx = errors.New("unknown transaction")
+
+// TxStatus 链上交易状态
+type TxStatus uint8
+
+// 链上交易状态取值
+const (
+	TxPending   TxStatus = iota + 1 // 已广播，确认数不足
+	TxConfirmed                     // 已达到所需确认数
+	TxFailed                        // 交易失败或被丢弃
+)
+
+// Transfer 待广播的提现
+type Transfer struct {
+	ID      uint
+	Asset   string
+	Address string
+	Amount  decimal.Decimal
+}
+
+// Tx 链上交易详情，Address和Amount为该交易转入的地址和金额
+type Tx struct {
+	Hash          string
+	Status        TxStatus
+	Confirmations int
+	Address       string
+	Amount        decimal.Decimal
+}
+
+// Client 链上交互接口
+type Client interface {
+	// Broadcast 签名并广播提现交易，返回交易哈希。返回错误时交易未发出。
+	Broadcast(ctx context.Context, t Transfer) (string, error)
+	// Lookup 查询交易的确认状态、确认数以及转入的地址和金额，交易不存在时返回ErrUnknownTx
+	Lookup(ctx context.Context, asset, txHash string) (Tx, error)
+	// NewAddress 为用户生成该资产的充值地址
+	NewAddress(ctx context.Context, asset string, userID uint) (string, error)
+}
+
+// FakeClient 内存中的链客户端，广播的交易保持待确认，直到调用SetStatus
+type FakeClient struct {
+	// BroadcastErr 非nil时Broadcast返回该错误，用于模拟签名或广播失败
+	BroadcastErr error
+
+	mu         sync.Mutex
+	seq        int
+	txs        map[string]Tx
+	broadcasts []Transfer
+}
+
+// NewFakeClient 创建内存链客户端
+func NewFakeClient() *FakeClient {
+	return &FakeClient{
+		txs: make(map[string]Tx),
+	}
+}
+
+// Broadcast 记录提现并返回伪造的交易哈希
+func (f *FakeClient) Broadcast(ctx context.Context, t Transfer) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.BroadcastErr != nil {
+		return "", f.BroadcastErr
+	}
+	f.seq++
+	hash := fmt.Sprintf("0xfake%060x", f.seq)
+	f.txs[hash] = Tx{Hash: hash, Status: TxPending, Address: t.Address, Amount: t.Amount}
+	f.broadcasts = append(f.broadcasts, t)
+	return hash, nil
+}
+
+// Lookup 返回交易详情，未知交易返回ErrUnknownTx
+func (f *FakeClient) Lookup(ctx context.Context, asset, txHash string) (Tx, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tx, ok := f.txs[txHash]
+	if !ok {
+		return Tx{}, ErrUnknownTx
+	}
+	return tx, nil
+}
+
+// NewAddress 返回由资产和用户ID生成的伪造地址，同一用户和资产每次返回相同地址
+func (f *FakeClient) NewAddress(ctx context.Context, asset string, userID uint) (string, error) {
+	return fmt.Sprintf("fake%s%08d", asset, userID), nil
+}
+
+// SetStatus 设置已登记交易的状态，已确认的交易确认数记为1
+func (f *FakeClient) SetStatus(txHash string, status TxStatus) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tx := f.txs[txHash]
+	tx.Hash, tx.Status = txHash, status
+	if status == TxConfirmed && tx.Confirmations == 0 {
+		tx.Confirmations = 1
+	}
+	f.txs[txHash] = tx
+}
+
+// AddDeposit 登记模拟的充值交易：向address转入amount，状态为status
+func (f *FakeClient) AddDeposit(txHash, address string, amount decimal.Decimal, status TxStatus) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tx := Tx{Hash: txHash, Status: status, Address: address, Amount: amount}
+	if status == TxConfirmed {
+		tx.Confirmations = 1
+	}
+	f.txs[txHash] = tx
+}
+
+// Broadcasts 返回已广播的提现
+func (f *FakeClient) Broadcasts() []Transfer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Transfer(nil), f.broadcasts...)
+}
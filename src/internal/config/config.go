@@ -8,10 +8,22 @@ import (
 
 // Config 应用程序配置结构
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
+	Server     ServerConfig     `mapstructure:"server"`
+	Database   DatabaseConfig   `mapstructure:"database"`
+	Redis      RedisConfig      `mapstructure:"redis"`
+	JWT        JWTConfig        `mapstructure:"jwt"`
+	Security   SecurityConfig   `mapstructure:"security"`
+	APIKey     APIKeyConfig     `mapstructure:"api_key"`
+	RBAC       RBACConfig       `mapstructure:"rbac"`
+	TwoFactor  TwoFactorConfig  `mapstructure:"two_factor"`
+	Matching   MatchingConfig   `mapstructure:"matching"`
+	Transfer   TransferConfig   `mapstructure:"transfer"`
+	MarketData MarketDataConfig `mapstructure:"market_data"`
+	Stream     StreamConfig     `mapstructure:"stream"`
+	Risk       RiskConfig       `mapstructure:"risk"`
+	Fee        FeeConfig        `mapstructure:"fee"`
+	Order      OrderConfig      `mapstructure:"order"`
+	Assets     AssetConfig      `mapstructure:"assets"`
 }
 
 // ServerConfig 服务器配置
@@ -22,12 +34,21 @@ type ServerConfig struct {
 
 // DatabaseConfig 数据库配置
 type DatabaseConfig struct {
+	Driver   string `mapstructure:"driver"` // postgres 或 sqlite
 	Host     string `mapstructure:"host"`
 	Port     int    `mapstructure:"port"`
 	User     string `mapstructure:"user"`
 	Password string `mapstructure:"password"`
 	DBName   string `mapstructure:"dbname"`
 	SSLMode  string `mapstructure:"sslmode"`
+	Path     string `mapstructure:"path"` // SQLite数据库文件路径，":memory:"表示内存库
+
+	MaxOpenConns    int `mapstructure:"max_open_conns"`
+	MaxIdleConns    int `mapstructure:"max_idle_conns"`
+	ConnMaxLifetime int `mapstructure:"conn_max_lifetime"`  // 秒
+	ConnMaxIdleTime int `mapstructure:"conn_max_idle_time"` // 秒
+	ConnectRetries  int `mapstructure:"connect_retries"`
+	RetryInterval   int `mapstructure:"retry_interval"` // 首次重试间隔（秒），之后按指数退避
 }
 
 // RedisConfig Redis配置
@@ -40,8 +61,96 @@ type RedisConfig struct {
 
 // JWTConfig JWT配置
 type JWTConfig struct {
-	Secret     string `mapstructure:"secret"`
-	ExpireTime int    `mapstructure:"expire_time"`
+	Secret            string `mapstructure:"secret"`
+	ExpireTime        int    `mapstructure:"expire_time"`
+	RefreshExpireTime int    `mapstructure:"refresh_expire_time"`
+}
+
+// SecurityConfig 安全配置
+type SecurityConfig struct {
+	EncryptionKey string `mapstructure:"encryption_key"`
+}
+
+// APIKeyConfig API Key签名配置，时间单位为毫秒
+type APIKeyConfig struct {
+	RecvWindow    int `mapstructure:"recv_window"`
+	MaxRecvWindow int `mapstructure:"max_recv_window"`
+}
+
+// RBACConfig 角色权限配置
+type RBACConfig struct {
+	// BootstrapAdmins 启动时自动授予admin角色的用户名
+	BootstrapAdmins []string `mapstructure:"bootstrap_admins"`
+}
+
+// TwoFactorConfig 两步验证配置
+type TwoFactorConfig struct {
+	Issuer      string `mapstructure:"issuer"`
+	FreshWindow int    `mapstructure:"fresh_window"` // 敏感操作要求的验证新鲜度（秒）
+	MaxAttempts int    `mapstructure:"max_attempts"` // 连续验证失败多少次后锁定两步验证
+	LockoutTime int    `mapstructure:"lockout_time"` // 锁定时长（秒）
+}
+
+// MatchingConfig 撮合定序与日志配置
+type MatchingConfig struct {
+	JournalDir       string `mapstructure:"journal_dir"`       // 预写日志和快照目录，每个交易对一个子目录
+	SnapshotInterval int    `mapstructure:"snapshot_interval"` // 每处理多少条命令生成一次快照，0表示不生成
+	Fsync            bool   `mapstructure:"fsync"`             // 每条命令写入日志后是否立即刷盘
+	QueueSize        int    `mapstructure:"queue_size"`        // 每个交易对的命令队列长度
+	ApplyRetries     int    `mapstructure:"apply_retries"`     // 结算临时失败时的最多尝试次数，用尽后搁置事件
+}
+
+// TransferConfig 充值提现配置
+type TransferConfig struct {
+	// ApprovalThresholds 各资产提现免审批的最大金额，超过须管理员审批；未配置的资产全部须审批。
+	// 配置键不区分大小写。
+	ApprovalThresholds map[string]string `mapstructure:"approval_thresholds"`
+	PollInterval       int               `mapstructure:"poll_interval"` // 轮询链上交易状态的间隔（秒），0表示不轮询
+	// Chain 链客户端实现，为空时不开放充值提现；fake为内存实现，仅用于本地开发，发布模式下不允许使用
+	Chain string `mapstructure:"chain"`
+}
+
+// MarketDataConfig 行情数据配置
+type MarketDataConfig struct {
+	FlushInterval int `mapstructure:"flush_interval"` // K线写入数据库的间隔（秒）
+	RecentTrades  int `mapstructure:"recent_trades"`  // 每个交易对在内存中保留的最近成交数
+}
+
+// StreamConfig 实时推送配置
+type StreamConfig struct {
+	SendBuffer       int `mapstructure:"send_buffer"`       // 每个连接的发送缓冲消息数，缓冲满时断开该连接
+	PingInterval     int `mapstructure:"ping_interval"`     // 心跳间隔（秒）
+	PongTimeout      int `mapstructure:"pong_timeout"`      // 未收到客户端心跳回应时断开连接的超时（秒）
+	MaxSubscriptions int `mapstructure:"max_subscriptions"` // 每个连接最多订阅的频道数
+	ReplayBuffer     int `mapstructure:"replay_buffer"`     // 保留的最近公开消息数，供SSE按Last-Event-ID续传
+}
+
+// RiskConfig 下单前风控配置，限额本身保存在数据库中
+type RiskConfig struct {
+	ReloadInterval int    `mapstructure:"reload_interval"` // 从数据库重新加载风控限额的间隔（秒），用于多实例部署时同步其他实例的修改
+	LossAsset      string `mapstructure:"loss_asset"`      // 当日亏损限额的计量资产，各交易对的盈亏按最新成交价折算后合计
+}
+
+// FeeConfig 手续费配置，等级和费率本身保存在数据库中
+type FeeConfig struct {
+	VolumeAsset    string `mapstructure:"volume_asset"`    // 统计近30天成交额使用的计量资产，其他计价资产按最新成交价折算
+	RecomputeHour  int    `mapstructure:"recompute_hour"`  // 每天重新计算用户手续费等级的时刻（UTC小时），-1表示不自动计算
+	ReloadInterval int    `mapstructure:"reload_interval"` // 从数据库重新加载费率配置的间隔（秒），用于多实例部署时同步其他实例的修改
+}
+
+// OrderConfig 订单接口配置
+type OrderConfig struct {
+	MaxBatchSize   int `mapstructure:"max_batch_size"`   // 批量下单撤单接口每次最多处理的订单数
+	MaxCancelAfter int `mapstructure:"max_cancel_after"` // 撤单倒计时的最长时间（秒）
+	// CancelAfterSweep 检查已到期撤单倒计时的间隔（秒），处理设置倒计时的实例已停止的情况
+	CancelAfterSweep int `mapstructure:"cancel_after_sweep"`
+}
+
+// AssetConfig 资产配置
+type AssetConfig struct {
+	// Scales 各资产金额的小数位数，账本分录、下单数量、成交额和手续费都按该精度校验或舍入；
+	// 未配置的资产最多18位。配置键不区分大小写。
+	Scales map[string]int32 `mapstructure:"scales"`
 }
 
 // LoadConfig 加载配置文件
@@ -73,11 +182,46 @@ func LoadConfig() (*Config, error) {
 func setDefaults() {
 	viper.SetDefault("server.port", "8080")
 	viper.SetDefault("server.mode", "debug")
+	viper.SetDefault("database.driver", "postgres")
 	viper.SetDefault("database.host", "localhost")
 	viper.SetDefault("database.port", 5432)
 	viper.SetDefault("database.sslmode", "disable")
+	viper.SetDefault("database.max_open_conns", 25)
+	viper.SetDefault("database.max_idle_conns", 10)
+	viper.SetDefault("database.conn_max_lifetime", 1800)
+	viper.SetDefault("database.conn_max_idle_time", 300)
+	viper.SetDefault("database.connect_retries", 5)
+	viper.SetDefault("database.retry_interval", 1)
 	viper.SetDefault("redis.host", "localhost")
 	viper.SetDefault("redis.port", 6379)
 	viper.SetDefault("redis.db", 0)
 	viper.SetDefault("jwt.expire_time", 3600)
+	viper.SetDefault("jwt.refresh_expire_time", 604800)
+	viper.SetDefault("api_key.recv_window", 5000)
+	viper.SetDefault("api_key.max_recv_window", 60000)
+	viper.SetDefault("two_factor.issuer", "Awesome Trade")
+	viper.SetDefault("two_factor.fresh_window", 300)
+	viper.SetDefault("two_factor.max_attempts", 5)
+	viper.SetDefault("two_factor.lockout_time", 900)
+	viper.SetDefault("matching.journal_dir", "data/journal")
+	viper.SetDefault("matching.snapshot_interval", 10000)
+	viper.SetDefault("matching.fsync", true)
+	viper.SetDefault("matching.queue_size", 1024)
+	viper.SetDefault("matching.apply_retries", 10)
+	viper.SetDefault("transfer.poll_interval", 15)
+	viper.SetDefault("market_data.flush_interval", 5)
+	viper.SetDefault("market_data.recent_trades", 500)
+	viper.SetDefault("stream.send_buffer", 256)
+	viper.SetDefault("stream.ping_interval", 20)
+	viper.SetDefault("stream.pong_timeout", 60)
+	viper.SetDefault("stream.max_subscriptions", 50)
+	viper.SetDefault("stream.replay_buffer", 4096)
+	viper.SetDefault("risk.reload_interval", 30)
+	viper.SetDefault("risk.loss_asset", "USDT")
+	viper.SetDefault("fee.volume_asset", "USDT")
+	viper.SetDefault("fee.recompute_hour", 0)
+	viper.SetDefault("fee.reload_interval", 30)
+	viper.SetDefault("order.max_batch_size", 20)
+	viper.SetDefault("order.max_cancel_after", 600)
+	viper.SetDefault("order.cancel_after_sweep", 5)
 }
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"awesome-trade/src/internal/config"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// 支持的数据库驱动
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// maxRetryInterval 连接重试的最大间隔
+const maxRetryInterval = 30 * time.Second
+
+// Open 根据配置打开数据库连接，设置连接池参数，并在启动时按指数退避重试
+func Open(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	dialector, err := dialector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts := cfg.ConnectRetries + 1
+	if attempts < 1 {
+		attempts = 1
+	}
+	interval := time.Duration(cfg.RetryInterval) * time.Second
+
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			log.Printf("Database connection failed (attempt %d/%d): %v, retrying in %s", i, attempts, lastErr, interval)
+			time.Sleep(interval)
+			interval *= 2
+			if interval > maxRetryInterval {
+				interval = maxRetryInterval
+			}
+		}
+
+		db, err := connect(dialector, cfg)
+		if err == nil {
+			return db, nil
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("connect database after %d attempts: %w", attempts, lastErr)
+}
+
+// Close 关闭数据库连接池
+func Close(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// DSN 根据配置构建PostgreSQL连接串，各值加单引号，密码等含空格或引号时仍可正确解析
+func DSN(cfg config.DatabaseConfig) string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		dsnValue(cfg.Host), cfg.Port, dsnValue(cfg.User), dsnValue(cfg.Password), dsnValue(cfg.DBName), dsnValue(cfg.SSLMode))
+}
+
+// dsnEscaper 转义key=value连接串值中的反斜杠和单引号
+var dsnEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// dsnValue 将值转义后加单引号
+func dsnValue(v string) string {
+	return "'" + dsnEscaper.Replace(v) + "'"
+}
+
+// dialector 根据驱动类型创建GORM方言
+func dialector(cfg config.DatabaseConfig) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case DriverPostgres, "":
+		return postgres.Open(DSN(cfg)), nil
+	case DriverSQLite:
+		return sqlite.Open(sqlitePath(cfg.Path)), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", cfg.Driver)
+	}
+}
+
+// sqlitePath 补全SQLite连接参数，启用外键约束
+func sqlitePath(path string) string {
+	if path == "" || path == ":memory:" {
+		path = "file::memory:"
+	}
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + "_foreign_keys=on&_busy_timeout=5000"
+}
+
+// connect 建立连接、设置连接池并验证连通性
+func connect(dialector gorm.Dialector, cfg config.DatabaseConfig) (*gorm.DB, error) {
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Warn),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Driver == DriverSQLite && (cfg.Path == "" || cfg.Path == ":memory:") {
+		// 内存数据库的每个连接都是独立的库，只能使用单个永不回收的连接
+		sqlDB.SetMaxOpenConns(1)
+	} else {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+		sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime) * time.Second)
+		sqlDB.SetConnMaxIdleTime(time.Duration(cfg.ConnMaxIdleTime) * time.Second)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sqlDB.PingContext(ctx); err != nil {
+		_ = sqlDB.Close()
+		return nil, err
+	}
+	return db, nil
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"awesome-trade/src/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试SQLite连接与连接池设置
+func TestOpenSQLite(t *testing.T) {
+	cfg := config.DatabaseConfig{
+		Driver:       DriverSQLite,
+		Path:         filepath.Join(t.TempDir(), "test.db"),
+		MaxOpenConns: 4,
+		MaxIdleConns: 2,
+	}
+
+	db, err := Open(cfg)
+	require.NoError(t, err)
+	defer Close(db)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	assert.Equal(t, 4, sqlDB.Stats().MaxOpenConnections)
+
+	var fk int
+	require.NoError(t, db.Raw("PRAGMA foreign_keys").Scan(&fk).Error)
+	assert.Equal(t, 1, fk)
+}
+
+// 测试连接失败时按次数重试并返回错误
+func TestOpenRetries(t *testing.T) {
+	cfg := config.DatabaseConfig{
+		Driver:         DriverPostgres,
+		Host:           "127.0.0.1",
+		Port:           1,
+		User:           "postgres",
+		DBName:         "awesome_trade",
+		SSLMode:        "disable",
+		ConnectRetries: 2,
+		RetryInterval:  0,
+	}
+
+	start := time.Now()
+	_, err := Open(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "after 3 attempts")
+	assert.Less(t, time.Since(start), 10*time.Second)
+
+	_, err = Open(config.DatabaseConfig{Driver: "oracle"})
+	assert.Error(t, err)
+}
+
+// 测试DSN构建
+func TestDSN(t *testing.T) {
+	dsn := DSN(config.DatabaseConfig{
+		Host:     "db",
+		Port:     5432,
+		User:     "trade",
+		Password: "secret",
+		DBName:   "awesome_trade",
+		SSLMode:  "require",
+	})
+	assert.Equal(t, "host='db' port=5432 user='trade' password='secret' dbname='awesome_trade' sslmode='require'", dsn)
+
+	dsn = DSN(config.DatabaseConfig{
+		Host:     "db",
+		Port:     5432,
+		User:     "trade",
+		Password: `p a's\\s`,
+		DBName:   "awesome_trade",
+		SSLMode:  "disable",
+	})
+	assert.Equal(t, `host='db' port=5432 user='trade' password='p a\'s\\\\s' dbname='awesome_trade' sslmode='disable'`, dsn)
+}
+
+// 测试SQLite路径已有查询参数时追加连接参数
+func TestSQLitePath(t *testing.T) {
+	assert.Equal(t, "file::memory:?_foreign_keys=on&_busy_timeout=5000", sqlitePath(""))
+	assert.Equal(t, "data.db?_foreign_keys=on&_busy_timeout=5000", sqlitePath("data.db"))
+	assert.Equal(t, "file:data.db?mode=ro&_foreign_keys=on&_busy_timeout=5000", sqlitePath("file:data.db?mode=ro"))
+}
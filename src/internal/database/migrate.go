@@ -0,0 +1,424 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrationFS 按方言分目录嵌入的SQL迁移文件，命名与golang-migrate一致：{version}_{name}.{up|down}.sql
+//
+//go:embed migrations
+var migrationFS embed.FS
+
+// 迁移错误
+var (
+	ErrNoChange        = errors.New("no migration to apply")
+	ErrDirty           = errors.New("database is dirty, fix it manually and run force")
+	ErrLocked          = errors.New("migration lock is held by another instance")
+	ErrVersionNotFound = errors.New("migration version not found")
+)
+
+// 迁移元数据表
+const (
+	migrationsTable = "schema_migrations"
+	lockTable       = "schema_migrations_lock"
+)
+
+// advisoryLockID PostgreSQL迁移咨询锁的键
+const advisoryLockID int64 = 0x61775f6d6967
+
+// defaultLockTimeout 等待迁移锁的默认时长
+const defaultLockTimeout = 15 * time.Second
+
+// defaultStaleLockAge 锁表中的锁超过该时长视为持有者已崩溃，可被其他实例接管
+const defaultStaleLockAge = 10 * time.Minute
+
+// migrationFile 迁移文件名格式
+var migrationFile = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration 单个版本的迁移脚本
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migrator 版本化数据库迁移执行器
+type Migrator struct {
+	db          *gorm.DB
+	dialect     string
+	migrations  []Migration
+	LockTimeout time.Duration
+	// StaleLockAge 锁表中的锁超过该时长视为过期，仅用于非PostgreSQL方言
+	StaleLockAge time.Duration
+}
+
+// NewMigrator 根据数据库方言加载内置迁移脚本
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	dialect := db.Dialector.Name()
+	migrations, err := LoadMigrations(migrationFS, path.Join("migrations", dialect))
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:           db,
+		dialect:      dialect,
+		migrations:   migrations,
+		LockTimeout:  defaultLockTimeout,
+		StaleLockAge: defaultStaleLockAge,
+	}, nil
+}
+
+// LoadMigrations 读取目录中的迁移脚本并按版本排序，每个版本必须同时包含up和down
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		m := migrationFile.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+		version, err := strconv.ParseUint(m[1], 10, 32)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version: %s", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[uint(version)]
+		if !ok {
+			mig = &Migration{Version: uint(version), Name: m[2]}
+			byVersion[uint(version)] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(content)
+		} else {
+			mig.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down scripts", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Migrations 返回已加载的迁移列表
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Latest 返回最新的迁移版本，没有迁移时为0
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version 返回当前数据库版本及是否处于脏状态，未执行过迁移时版本为0
+func (m *Migrator) Version(ctx context.Context) (uint, bool, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return 0, false, err
+	}
+	return m.version(ctx)
+}
+
+// Up 应用全部待执行的迁移
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.Steps(ctx, len(m.migrations))
+}
+
+// Down 回滚全部已应用的迁移
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.Steps(ctx, -len(m.migrations))
+}
+
+// Steps 正数时向上应用n个迁移，负数时回滚n个迁移
+func (m *Migrator) Steps(ctx context.Context, n int) error {
+	return m.run(ctx, func(current uint) (uint, error) {
+		idx := m.indexOf(current)
+		target := idx + n
+		if target < -1 {
+			target = -1
+		}
+		if target >= len(m.migrations) {
+			target = len(m.migrations) - 1
+		}
+		if target == -1 {
+			return 0, nil
+		}
+		return m.migrations[target].Version, nil
+	})
+}
+
+// Goto 迁移到指定版本，版本为0表示回滚全部迁移
+func (m *Migrator) Goto(ctx context.Context, version uint) error {
+	if version != 0 && m.indexOf(version) == -1 {
+		return fmt.Errorf("%w: %d", ErrVersionNotFound, version)
+	}
+	return m.run(ctx, func(uint) (uint, error) {
+		return version, nil
+	})
+}
+
+// Force 将版本记录设置为指定值并清除脏状态，不执行任何迁移脚本。版本为-1时清空版本记录。
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	if version < -1 {
+		return fmt.Errorf("%w: %d", ErrVersionNotFound, version)
+	}
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := m.ensureTable(ctx); err != nil {
+		return err
+	}
+	if version == -1 {
+		return m.setVersion(m.db.WithContext(ctx), 0, false)
+	}
+	return m.setVersion(m.db.WithContext(ctx), uint(version), false)
+}
+
+// Unlock 强制释放锁表中的迁移锁，用于持有锁的进程崩溃后人工恢复。
+// PostgreSQL的咨询锁随会话结束自动释放，无需处理
+func (m *Migrator) Unlock(ctx context.Context) error {
+	if m.dialect == DriverPostgres {
+		return nil
+	}
+	db := m.db.WithContext(ctx)
+	if err := m.ensureLockTable(db); err != nil {
+		return err
+	}
+	return db.Exec("DELETE FROM " + lockTable).Error
+}
+
+// run 加锁后从当前版本逐个迁移到plan给出的目标版本
+func (m *Migrator) run(ctx context.Context, plan func(current uint) (uint, error)) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := m.ensureTable(ctx); err != nil {
+		return err
+	}
+	current, dirty, err := m.version(ctx)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w (version %d)", ErrDirty, current)
+	}
+	if current != 0 && m.indexOf(current) == -1 {
+		return fmt.Errorf("%w: database is at unknown version %d", ErrVersionNotFound, current)
+	}
+
+	target, err := plan(current)
+	if err != nil {
+		return err
+	}
+	if target == current {
+		return ErrNoChange
+	}
+
+	for current != target {
+		idx := m.indexOf(current)
+		if target > current {
+			next := m.migrations[idx+1]
+			if err := m.apply(ctx, next.Version, next.Up, next.Version); err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", next.Version, next.Name, err)
+			}
+			current = next.Version
+		} else {
+			mig := m.migrations[idx]
+			var prev uint
+			if idx > 0 {
+				prev = m.migrations[idx-1].Version
+			}
+			if err := m.apply(ctx, mig.Version, mig.Down, prev); err != nil {
+				return fmt.Errorf("migration %d_%s down: %w", mig.Version, mig.Name, err)
+			}
+			current = prev
+		}
+	}
+	return nil
+}
+
+// apply 先将版本标记为脏，在事务中执行脚本并写入结果版本。
+// 脚本失败时事务回滚，版本保持脏状态，需人工确认后使用force修复。
+func (m *Migrator) apply(ctx context.Context, dirtyVersion uint, script string, result uint) error {
+	db := m.db.WithContext(ctx)
+	if err := m.setVersion(db, dirtyVersion, true); err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(script).Error; err != nil {
+			return err
+		}
+		return m.setVersion(tx, result, false)
+	})
+}
+
+// indexOf 返回版本在迁移列表中的下标，版本0或不存在时返回-1
+func (m *Migrator) indexOf(version uint) int {
+	for i, mig := range m.migrations {
+		if mig.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// ensureTable 创建版本记录表，结构与golang-migrate兼容
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	return m.db.WithContext(ctx).Exec(
+		"CREATE TABLE IF NOT EXISTS " + migrationsTable + " (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)",
+	).Error
+}
+
+// version 读取当前版本记录
+func (m *Migrator) version(ctx context.Context) (uint, bool, error) {
+	var rows []struct {
+		Version uint
+		Dirty   bool
+	}
+	err := m.db.WithContext(ctx).Table(migrationsTable).Select("version, dirty").Limit(1).Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return 0, false, err
+	}
+	return rows[0].Version, rows[0].Dirty, nil
+}
+
+// setVersion 覆盖版本记录，版本0且非脏时表示未应用任何迁移
+func (m *Migrator) setVersion(db *gorm.DB, version uint, dirty bool) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM " + migrationsTable).Error; err != nil {
+			return err
+		}
+		if version == 0 && !dirty {
+			return nil
+		}
+		return tx.Exec("INSERT INTO "+migrationsTable+" (version, dirty) VALUES (?, ?)", version, dirty).Error
+	})
+}
+
+// lock 获取迁移锁，防止多个实例同时迁移。
+// PostgreSQL使用会话级咨询锁，其他数据库使用锁表中的唯一行。
+func (m *Migrator) lock(ctx context.Context) (func(), error) {
+	ctx, cancel := context.WithTimeout(ctx, m.LockTimeout)
+	defer cancel()
+
+	if m.dialect == DriverPostgres {
+		return m.advisoryLock(ctx)
+	}
+	return m.tableLock(ctx)
+}
+
+// advisoryLock 在专用连接上获取PostgreSQL咨询锁，释放时使用同一连接
+func (m *Migrator) advisoryLock(ctx context.Context) (func(), error) {
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = retryLock(ctx, func() (bool, error) {
+		var ok bool
+		err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", advisoryLockID).Scan(&ok)
+		return ok, err
+	})
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return func() {
+		_, _ = conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", advisoryLockID)
+		_ = conn.Close()
+	}, nil
+}
+
+// tableLock 通过插入锁表的唯一行获取锁。持有者崩溃后遗留的锁超过StaleLockAge时先删除再获取
+func (m *Migrator) tableLock(ctx context.Context) (func(), error) {
+	db := m.db.WithContext(ctx)
+	if err := m.ensureLockTable(db); err != nil {
+		return nil, err
+	}
+
+	err := retryLock(ctx, func() (bool, error) {
+		now := time.Now().UTC()
+		if m.StaleLockAge > 0 {
+			if err := db.Exec("DELETE FROM "+lockTable+" WHERE locked_at < ?", now.Add(-m.StaleLockAge)).Error; err != nil {
+				return false, err
+			}
+		}
+		res := db.Exec("INSERT INTO "+lockTable+" (id, locked_at) SELECT 1, ? WHERE NOT EXISTS (SELECT 1 FROM "+lockTable+")", now)
+		return res.RowsAffected == 1, res.Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		m.db.Exec("DELETE FROM " + lockTable)
+	}, nil
+}
+
+// ensureLockTable 创建锁表
+func (m *Migrator) ensureLockTable(db *gorm.DB) error {
+	return db.Exec("CREATE TABLE IF NOT EXISTS " + lockTable + " (id INTEGER NOT NULL PRIMARY KEY, locked_at TIMESTAMP NOT NULL)").Error
+}
+
+// retryLock 轮询获取锁直至成功或超时
+func retryLock(ctx context.Context, try func() (bool, error)) error {
+	for {
+		ok, err := try()
+		if err != nil && ctx.Err() == nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ErrLocked
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
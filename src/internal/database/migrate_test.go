@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"awesome-trade/src/internal/config"
+	"awesome-trade/src/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// 测试全部迁移在SQLite上向上应用再全部回滚
+func TestMigrateUpDown(t *testing.T) {
+	db, err := Open(config.DatabaseConfig{Driver: DriverSQLite, Path: ":memory:"})
+	require.NoError(t, err)
+	defer Close(db)
+
+	ctx := context.Background()
+	m, err := NewMigrator(db)
+	require.NoError(t, err)
+	require.NotEmpty(t, m.Migrations())
+
+	version, dirty, err := m.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), version)
+	assert.False(t, dirty)
+
+	require.NoError(t, m.Up(ctx))
+	assert.ErrorIs(t, m.Up(ctx), ErrNoChange)
+
+	version, dirty, err = m.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, m.Latest(), version)
+	assert.False(t, dirty)
+
+	// 迁移结构需覆盖模型的全部字段
+	models := []interface{}{
+		&model.User{}, &model.RefreshToken{}, &model.APIKey{},
+		&model.Permission{}, &model.Role{}, &model.UserRole{},
+		&model.TwoFactor{}, &model.RecoveryCode{},
+		&model.Order{}, &model.Account{}, &model.LedgerEntry{}, &model.LedgerPosting{},
+		&model.Transfer{}, &model.TransferAudit{}, &model.Market{}, &model.Kline{}, &model.RiskLimit{},
+		&model.FeeTier{}, &model.FeeOverride{}, &model.FeePromotion{}, &model.UserFeeTier{}, &model.Fill{},
+		&model.OrderGroup{}, &model.CancelDeadline{}, &model.DepositAddress{},
+		&model.SettlementOffset{}, &model.SettlementFailure{},
+	}
+	for _, mdl := range models {
+		stmt := &gorm.Statement{DB: db}
+		require.NoError(t, stmt.Parse(mdl))
+		require.True(t, db.Migrator().HasTable(stmt.Schema.Table), stmt.Schema.Table)
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			assert.True(t, db.Migrator().HasColumn(mdl, field.DBName), "%s.%s", stmt.Schema.Table, field.DBName)
+		}
+	}
+	assert.True(t, db.Migrator().HasTable("role_permissions"))
+
+	user := &model.User{Username: "alice", Email: "alice@example.com", Password: "x", IsActive: true}
+	require.NoError(t, db.Create(user).Error)
+
+	require.NoError(t, m.Down(ctx))
+	version, _, err = m.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), version)
+	for _, mdl := range models {
+		assert.False(t, db.Migrator().HasTable(mdl))
+	}
+	assert.ErrorIs(t, m.Down(ctx), ErrNoChange)
+}
+
+// 测试按步数、按版本迁移以及脏状态和强制设置版本
+func TestMigrateStepsGotoForce(t *testing.T) {
+	db, err := Open(config.DatabaseConfig{Driver: DriverSQLite, Path: ":memory:"})
+	require.NoError(t, err)
+	defer Close(db)
+
+	ctx := context.Background()
+	m, err := NewMigrator(db)
+	require.NoError(t, err)
+	migrations := m.Migrations()
+	require.GreaterOrEqual(t, len(migrations), 2)
+
+	require.NoError(t, m.Steps(ctx, 1))
+	version, _, _ := m.Version(ctx)
+	assert.Equal(t, migrations[0].Version, version)
+
+	require.NoError(t, m.Goto(ctx, m.Latest()))
+	require.NoError(t, m.Steps(ctx, -1))
+	version, _, _ = m.Version(ctx)
+	assert.Equal(t, migrations[len(migrations)-2].Version, version)
+
+	assert.ErrorIs(t, m.Goto(ctx, 9999), ErrVersionNotFound)
+
+	// 模拟迁移中途失败留下的脏状态
+	require.NoError(t, m.setVersion(db, m.Latest(), true))
+	_, dirty, _ := m.Version(ctx)
+	assert.True(t, dirty)
+	assert.ErrorIs(t, m.Up(ctx), ErrDirty)
+
+	require.NoError(t, m.Force(ctx, int(migrations[len(migrations)-2].Version)))
+	require.NoError(t, m.Up(ctx))
+	version, dirty, _ = m.Version(ctx)
+	assert.Equal(t, m.Latest(), version)
+	assert.False(t, dirty)
+
+	require.NoError(t, m.Goto(ctx, 0))
+	version, _, _ = m.Version(ctx)
+	assert.Equal(t, uint(0), version)
+}
+
+// 测试锁被其他实例持有时迁移失败
+func TestMigrateLocked(t *testing.T) {
+	db, err := Open(config.DatabaseConfig{Driver: DriverSQLite, Path: ":memory:"})
+	require.NoError(t, err)
+	defer Close(db)
+
+	ctx := context.Background()
+	holder, err := NewMigrator(db)
+	require.NoError(t, err)
+	unlock, err := holder.lock(ctx)
+	require.NoError(t, err)
+
+	m, err := NewMigrator(db)
+	require.NoError(t, err)
+	m.LockTimeout = 200 * time.Millisecond
+	assert.ErrorIs(t, m.Up(ctx), ErrLocked)
+
+	unlock()
+	require.NoError(t, m.Up(ctx))
+}
+
+// 测试崩溃进程遗留的锁过期后可被接管，以及手动释放锁
+func TestMigrateStaleLock(t *testing.T) {
+	db, err := Open(config.DatabaseConfig{Driver: DriverSQLite, Path: ":memory:"})
+	require.NoError(t, err)
+	defer Close(db)
+
+	ctx := context.Background()
+	m, err := NewMigrator(db)
+	require.NoError(t, err)
+	m.LockTimeout = 200 * time.Millisecond
+	require.NoError(t, m.ensureLockTable(db))
+
+	require.NoError(t, db.Exec("INSERT INTO "+lockTable+" (id, locked_at) VALUES (1, ?)", time.Now().UTC().Add(-time.Hour)).Error)
+	require.NoError(t, m.Steps(ctx, 1))
+
+	require.NoError(t, db.Exec("INSERT INTO "+lockTable+" (id, locked_at) VALUES (1, ?)", time.Now().UTC()).Error)
+	assert.ErrorIs(t, m.Force(ctx, 1), ErrLocked)
+	require.NoError(t, m.Unlock(ctx))
+	require.NoError(t, m.Force(ctx, 1))
+	require.NoError(t, m.Up(ctx))
+}
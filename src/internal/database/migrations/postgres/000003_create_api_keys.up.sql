@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id               BIGSERIAL PRIMARY KEY,
+    created_at       TIMESTAMPTZ,
+    updated_at       TIMESTAMPTZ,
+    deleted_at       TIMESTAMPTZ,
+    user_id          BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    label            VARCHAR(64),
+    access_key       VARCHAR(64) NOT NULL,
+    secret_encrypted TEXT NOT NULL,
+    scopes           TEXT NOT NULL,
+    ip_allowlist     TEXT,
+    last_used_at     TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_access_key ON api_keys (access_key);
+CREATE INDEX IF NOT EXISTS idx_api_keys_deleted_at ON api_keys (deleted_at);
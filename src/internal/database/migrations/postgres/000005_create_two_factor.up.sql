@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS two_factors (
+    id               BIGSERIAL PRIMARY KEY,
+    created_at       TIMESTAMPTZ,
+    updated_at       TIMESTAMPTZ,
+    deleted_at       TIMESTAMPTZ,
+    user_id          BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    secret_encrypted TEXT NOT NULL,
+    enabled          BOOLEAN DEFAULT FALSE,
+    confirmed_at     TIMESTAMPTZ,
+    last_used_step   BIGINT NOT NULL DEFAULT 0,
+    last_verified_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_two_factors_user_id ON two_factors (user_id);
+CREATE INDEX IF NOT EXISTS idx_two_factors_deleted_at ON two_factors (deleted_at);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id         BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    user_id    BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash  VARCHAR(64) NOT NULL,
+    used_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes (user_id);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_deleted_at ON recovery_codes (deleted_at);
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS accounts (
+    id         BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    user_id    BIGINT NOT NULL,
+    kind       VARCHAR(16) NOT NULL,
+    asset      VARCHAR(16) NOT NULL,
+    available  NUMERIC(36,18) NOT NULL DEFAULT 0,
+    held       NUMERIC(36,18) NOT NULL DEFAULT 0,
+    version    BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_owner_asset ON accounts (user_id, kind, asset);
+CREATE INDEX IF NOT EXISTS idx_accounts_deleted_at ON accounts (deleted_at);
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id         BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    type       VARCHAR(16) NOT NULL,
+    reference  VARCHAR(128) NOT NULL,
+    memo       VARCHAR(255)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_entries_reference ON ledger_entries (type, reference);
+
+CREATE TABLE IF NOT EXISTS ledger_postings (
+    id         BIGSERIAL PRIMARY KEY,
+    entry_id   BIGINT NOT NULL REFERENCES ledger_entries (id),
+    account_id BIGINT NOT NULL REFERENCES accounts (id),
+    asset      VARCHAR(16) NOT NULL,
+    bucket     VARCHAR(16) NOT NULL,
+    amount     NUMERIC(36,18) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_entry_id ON ledger_postings (entry_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_account_id ON ledger_postings (account_id);
+
+ALTER TABLE orders ADD COLUMN held_amount NUMERIC(36,18) NOT NULL DEFAULT 0;
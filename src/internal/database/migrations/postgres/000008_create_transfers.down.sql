@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS transfer_audits;
+DROP TABLE IF EXISTS transfers;
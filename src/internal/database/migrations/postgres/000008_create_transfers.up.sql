@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS transfers (
+    id         BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    user_id    BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    type       VARCHAR(16) NOT NULL,
+    asset      VARCHAR(16) NOT NULL,
+    amount     NUMERIC(36,18) NOT NULL,
+    address    VARCHAR(128),
+    tx_hash    VARCHAR(128),
+    status     VARCHAR(16) NOT NULL,
+    reason     VARCHAR(255),
+    version    BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transfers_tx_hash ON transfers (type, asset, tx_hash);
+CREATE INDEX IF NOT EXISTS idx_transfers_user_id ON transfers (user_id);
+CREATE INDEX IF NOT EXISTS idx_transfers_type_status ON transfers (type, status);
+CREATE INDEX IF NOT EXISTS idx_transfers_deleted_at ON transfers (deleted_at);
+
+CREATE TABLE IF NOT EXISTS transfer_audits (
+    id          BIGSERIAL PRIMARY KEY,
+    created_at  TIMESTAMPTZ,
+    transfer_id BIGINT NOT NULL REFERENCES transfers (id) ON DELETE CASCADE,
+    from_status VARCHAR(16) NOT NULL,
+    to_status   VARCHAR(16) NOT NULL,
+    actor_id    BIGINT NOT NULL,
+    reason      VARCHAR(255)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfer_audits_transfer_id ON transfer_audits (transfer_id);
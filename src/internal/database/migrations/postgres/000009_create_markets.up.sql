@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS markets (
+    id             BIGSERIAL PRIMARY KEY,
+    created_at     TIMESTAMPTZ,
+    updated_at     TIMESTAMPTZ,
+    deleted_at     TIMESTAMPTZ,
+    symbol         VARCHAR(32) NOT NULL,
+    base_asset     VARCHAR(16) NOT NULL,
+    quote_asset    VARCHAR(16) NOT NULL,
+    tick_size      NUMERIC(36,18) NOT NULL,
+    step_size      NUMERIC(36,18) NOT NULL,
+    min_notional   NUMERIC(36,18) NOT NULL,
+    maker_fee_rate NUMERIC(10,6) NOT NULL,
+    taker_fee_rate NUMERIC(10,6) NOT NULL,
+    status         VARCHAR(16) NOT NULL,
+    version        BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_markets_symbol ON markets (symbol);
+CREATE INDEX IF NOT EXISTS idx_markets_deleted_at ON markets (deleted_at);
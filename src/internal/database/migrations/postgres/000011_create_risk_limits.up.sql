@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN tier VARCHAR(16) NOT NULL DEFAULT 'standard';
+
+CREATE TABLE IF NOT EXISTS risk_limits (
+    id                 BIGSERIAL PRIMARY KEY,
+    created_at         TIMESTAMPTZ,
+    updated_at         TIMESTAMPTZ,
+    tier               VARCHAR(16) NOT NULL,
+    symbol             VARCHAR(32) NOT NULL,
+    max_order_quantity NUMERIC(36,18) NOT NULL,
+    max_order_notional NUMERIC(36,18) NOT NULL,
+    max_open_orders    BIGINT NOT NULL,
+    price_band         NUMERIC(10,6) NOT NULL,
+    daily_loss_limit   NUMERIC(36,18) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_risk_limits_tier_symbol ON risk_limits (tier, symbol);
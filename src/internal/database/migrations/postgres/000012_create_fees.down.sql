@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS fills;
+DROP TABLE IF EXISTS user_fee_tiers;
+DROP TABLE IF EXISTS fee_promotions;
+DROP TABLE IF EXISTS fee_overrides;
+DROP TABLE IF EXISTS fee_tiers;
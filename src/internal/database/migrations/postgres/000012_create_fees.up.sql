@@ -0,0 +1,62 @@
+CREATE TABLE IF NOT EXISTS fee_tiers (
+    id             BIGSERIAL PRIMARY KEY,
+    created_at     TIMESTAMPTZ,
+    updated_at     TIMESTAMPTZ,
+    level          BIGINT NOT NULL,
+    min_volume     NUMERIC(36,18) NOT NULL,
+    maker_fee_rate NUMERIC(10,6) NOT NULL,
+    taker_fee_rate NUMERIC(10,6) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_fee_tiers_level ON fee_tiers (level);
+
+CREATE TABLE IF NOT EXISTS fee_overrides (
+    id             BIGSERIAL PRIMARY KEY,
+    created_at     TIMESTAMPTZ,
+    updated_at     TIMESTAMPTZ,
+    symbol         VARCHAR(32) NOT NULL,
+    level          BIGINT NOT NULL,
+    maker_fee_rate NUMERIC(10,6) NOT NULL,
+    taker_fee_rate NUMERIC(10,6) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_fee_overrides_symbol_level ON fee_overrides (symbol, level);
+
+CREATE TABLE IF NOT EXISTS fee_promotions (
+    id         BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    symbol     VARCHAR(32) NOT NULL,
+    starts_at  TIMESTAMPTZ NOT NULL,
+    ends_at    TIMESTAMPTZ NOT NULL,
+    memo       VARCHAR(255)
+);
+
+CREATE INDEX IF NOT EXISTS idx_fee_promotions_starts_at ON fee_promotions (starts_at);
+CREATE INDEX IF NOT EXISTS idx_fee_promotions_ends_at ON fee_promotions (ends_at);
+
+CREATE TABLE IF NOT EXISTS user_fee_tiers (
+    user_id     BIGINT PRIMARY KEY,
+    level       BIGINT NOT NULL,
+    volume      NUMERIC(36,18) NOT NULL,
+    computed_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS fills (
+    id             BIGSERIAL PRIMARY KEY,
+    created_at     TIMESTAMPTZ,
+    user_id        BIGINT NOT NULL,
+    order_id       BIGINT NOT NULL,
+    symbol         VARCHAR(32) NOT NULL,
+    trade_id       BIGINT NOT NULL,
+    side           VARCHAR(8) NOT NULL,
+    liquidity      VARCHAR(8) NOT NULL,
+    price          NUMERIC(36,18) NOT NULL,
+    quantity       NUMERIC(36,18) NOT NULL,
+    quote_quantity NUMERIC(36,18) NOT NULL,
+    fee            NUMERIC(36,18) NOT NULL,
+    fee_asset      VARCHAR(16) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_fills_order_trade ON fills (order_id, trade_id);
+CREATE INDEX IF NOT EXISTS idx_fills_user_id ON fills (user_id);
+CREATE INDEX IF NOT EXISTS idx_fills_created_at ON fills (created_at);
@@ -0,0 +1,9 @@
+ALTER TABLE orders ALTER COLUMN type TYPE VARCHAR(20);
+ALTER TABLE orders ADD COLUMN stop_price NUMERIC(36,18) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN trailing_delta NUMERIC(36,18) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN trigger_price_type VARCHAR(8) NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN parent_order_id BIGINT;
+ALTER TABLE orders ADD COLUMN child_order_id BIGINT;
+ALTER TABLE orders ADD COLUMN triggered_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_orders_parent_order_id ON orders (parent_order_id);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS order_groups (
+    id              BIGSERIAL PRIMARY KEY,
+    created_at      TIMESTAMPTZ,
+    updated_at      TIMESTAMPTZ,
+    user_id         BIGINT NOT NULL,
+    client_group_id VARCHAR(64) NOT NULL,
+    symbol          VARCHAR(32) NOT NULL,
+    type            VARCHAR(16) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_order_groups_user_client_group_id ON order_groups (user_id, client_group_id);
+
+ALTER TABLE orders ADD COLUMN group_id BIGINT;
+ALTER TABLE orders ADD COLUMN group_role VARCHAR(16) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_orders_group_id ON orders (group_id);
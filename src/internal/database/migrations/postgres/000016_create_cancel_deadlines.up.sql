@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS cancel_deadlines (
+    user_id  BIGINT PRIMARY KEY,
+    deadline TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_cancel_deadlines_deadline ON cancel_deadlines (deadline);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS deposit_addresses (
+    id         BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    user_id    BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    asset      VARCHAR(16) NOT NULL,
+    address    VARCHAR(128) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_deposit_addresses_user_asset ON deposit_addresses (user_id, asset);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_deposit_addresses_address ON deposit_addresses (asset, address);
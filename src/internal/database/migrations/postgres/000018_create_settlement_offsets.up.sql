@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS settlement_offsets (
+    symbol VARCHAR(32) PRIMARY KEY,
+    seq    BIGINT NOT NULL
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_settlement_failures_symbol_seq;
+
+DROP TABLE IF EXISTS settlement_failures;
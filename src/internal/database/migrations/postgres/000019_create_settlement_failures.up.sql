@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS settlement_failures (
+    id         BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    symbol     VARCHAR(32) NOT NULL,
+    seq        BIGINT NOT NULL,
+    command    TEXT NOT NULL,
+    result     TEXT NOT NULL,
+    error      TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_settlement_failures_symbol_seq ON settlement_failures (symbol, seq);
@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS permissions (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at  DATETIME,
+    updated_at  DATETIME,
+    deleted_at  DATETIME,
+    code        VARCHAR(64) NOT NULL,
+    description VARCHAR(255)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_permissions_code ON permissions (code);
+CREATE INDEX IF NOT EXISTS idx_permissions_deleted_at ON permissions (deleted_at);
+
+CREATE TABLE IF NOT EXISTS roles (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at  DATETIME,
+    updated_at  DATETIME,
+    deleted_at  DATETIME,
+    name        VARCHAR(64) NOT NULL,
+    description VARCHAR(255)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_roles_name ON roles (name);
+CREATE INDEX IF NOT EXISTS idx_roles_deleted_at ON roles (deleted_at);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id       INTEGER NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    permission_id INTEGER NOT NULL REFERENCES permissions (id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id    INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role_id    INTEGER NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    created_at DATETIME,
+    PRIMARY KEY (user_id, role_id)
+);
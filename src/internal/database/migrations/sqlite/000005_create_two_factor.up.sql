@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS two_factors (
+    id               INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at       DATETIME,
+    updated_at       DATETIME,
+    deleted_at       DATETIME,
+    user_id          INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    secret_encrypted TEXT NOT NULL,
+    enabled          BOOLEAN DEFAULT FALSE,
+    confirmed_at     DATETIME,
+    last_used_step   INTEGER NOT NULL DEFAULT 0,
+    last_verified_at DATETIME
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_two_factors_user_id ON two_factors (user_id);
+CREATE INDEX IF NOT EXISTS idx_two_factors_deleted_at ON two_factors (deleted_at);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME,
+    user_id    INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash  VARCHAR(64) NOT NULL,
+    used_at    DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes (user_id);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_deleted_at ON recovery_codes (deleted_at);
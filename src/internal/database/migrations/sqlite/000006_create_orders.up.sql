@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS orders (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at      DATETIME,
+    updated_at      DATETIME,
+    deleted_at      DATETIME,
+    user_id         INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    client_order_id VARCHAR(64) NOT NULL,
+    symbol          VARCHAR(32) NOT NULL,
+    side            VARCHAR(8) NOT NULL,
+    type            VARCHAR(16) NOT NULL,
+    time_in_force   VARCHAR(16) NOT NULL,
+    price           NUMERIC(36,18) NOT NULL,
+    quantity        NUMERIC(36,18) NOT NULL,
+    filled_quantity NUMERIC(36,18) NOT NULL DEFAULT 0,
+    status          VARCHAR(20) NOT NULL,
+    version         INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_user_client_order_id ON orders (user_id, client_order_id);
+CREATE INDEX IF NOT EXISTS idx_orders_user_status ON orders (user_id, status);
+CREATE INDEX IF NOT EXISTS idx_orders_symbol ON orders (symbol);
+CREATE INDEX IF NOT EXISTS idx_orders_deleted_at ON orders (deleted_at);
@@ -0,0 +1,4 @@
+ALTER TABLE orders DROP COLUMN held_amount;
+DROP TABLE IF EXISTS ledger_postings;
+DROP TABLE IF EXISTS ledger_entries;
+DROP TABLE IF EXISTS accounts;
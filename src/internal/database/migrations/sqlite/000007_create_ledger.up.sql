@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS accounts (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME,
+    user_id    INTEGER NOT NULL,
+    kind       VARCHAR(16) NOT NULL,
+    asset      VARCHAR(16) NOT NULL,
+    available  NUMERIC(36,18) NOT NULL DEFAULT 0,
+    held       NUMERIC(36,18) NOT NULL DEFAULT 0,
+    version    INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_owner_asset ON accounts (user_id, kind, asset);
+CREATE INDEX IF NOT EXISTS idx_accounts_deleted_at ON accounts (deleted_at);
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME,
+    type       VARCHAR(16) NOT NULL,
+    reference  VARCHAR(128) NOT NULL,
+    memo       VARCHAR(255)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_entries_reference ON ledger_entries (type, reference);
+
+CREATE TABLE IF NOT EXISTS ledger_postings (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    entry_id   INTEGER NOT NULL REFERENCES ledger_entries (id),
+    account_id INTEGER NOT NULL REFERENCES accounts (id),
+    asset      VARCHAR(16) NOT NULL,
+    bucket     VARCHAR(16) NOT NULL,
+    amount     NUMERIC(36,18) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_entry_id ON ledger_postings (entry_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_account_id ON ledger_postings (account_id);
+
+ALTER TABLE orders ADD COLUMN held_amount NUMERIC(36,18) NOT NULL DEFAULT 0;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS klines (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    symbol        VARCHAR(32) NOT NULL,
+    interval      VARCHAR(8) NOT NULL,
+    open_time     DATETIME NOT NULL,
+    open          NUMERIC(36,18) NOT NULL,
+    high          NUMERIC(36,18) NOT NULL,
+    low           NUMERIC(36,18) NOT NULL,
+    close         NUMERIC(36,18) NOT NULL,
+    volume        NUMERIC(36,18) NOT NULL,
+    quote_volume  NUMERIC(36,18) NOT NULL,
+    trades        INTEGER NOT NULL,
+    last_trade_id INTEGER NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_klines_symbol_interval_open_time ON klines (symbol, interval, open_time);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS risk_limits;
+
+ALTER TABLE users DROP COLUMN tier;
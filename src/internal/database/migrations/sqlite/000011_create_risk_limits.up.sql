@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN tier VARCHAR(16) NOT NULL DEFAULT 'standard';
+
+CREATE TABLE IF NOT EXISTS risk_limits (
+    id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at         DATETIME,
+    updated_at         DATETIME,
+    tier               VARCHAR(16) NOT NULL,
+    symbol             VARCHAR(32) NOT NULL,
+    max_order_quantity NUMERIC(36,18) NOT NULL,
+    max_order_notional NUMERIC(36,18) NOT NULL,
+    max_open_orders    INTEGER NOT NULL,
+    price_band         NUMERIC(10,6) NOT NULL,
+    daily_loss_limit   NUMERIC(36,18) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_risk_limits_tier_symbol ON risk_limits (tier, symbol);
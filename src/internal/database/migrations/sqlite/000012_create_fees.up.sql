@@ -0,0 +1,62 @@
+CREATE TABLE IF NOT EXISTS fee_tiers (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at     DATETIME,
+    updated_at     DATETIME,
+    level          INTEGER NOT NULL,
+    min_volume     NUMERIC(36,18) NOT NULL,
+    maker_fee_rate NUMERIC(10,6) NOT NULL,
+    taker_fee_rate NUMERIC(10,6) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_fee_tiers_level ON fee_tiers (level);
+
+CREATE TABLE IF NOT EXISTS fee_overrides (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at     DATETIME,
+    updated_at     DATETIME,
+    symbol         VARCHAR(32) NOT NULL,
+    level          INTEGER NOT NULL,
+    maker_fee_rate NUMERIC(10,6) NOT NULL,
+    taker_fee_rate NUMERIC(10,6) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_fee_overrides_symbol_level ON fee_overrides (symbol, level);
+
+CREATE TABLE IF NOT EXISTS fee_promotions (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME,
+    symbol     VARCHAR(32) NOT NULL,
+    starts_at  DATETIME NOT NULL,
+    ends_at    DATETIME NOT NULL,
+    memo       VARCHAR(255)
+);
+
+CREATE INDEX IF NOT EXISTS idx_fee_promotions_starts_at ON fee_promotions (starts_at);
+CREATE INDEX IF NOT EXISTS idx_fee_promotions_ends_at ON fee_promotions (ends_at);
+
+CREATE TABLE IF NOT EXISTS user_fee_tiers (
+    user_id     INTEGER PRIMARY KEY,
+    level       INTEGER NOT NULL,
+    volume      NUMERIC(36,18) NOT NULL,
+    computed_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS fills (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at     DATETIME,
+    user_id        INTEGER NOT NULL,
+    order_id       INTEGER NOT NULL,
+    symbol         VARCHAR(32) NOT NULL,
+    trade_id       INTEGER NOT NULL,
+    side           VARCHAR(8) NOT NULL,
+    liquidity      VARCHAR(8) NOT NULL,
+    price          NUMERIC(36,18) NOT NULL,
+    quantity       NUMERIC(36,18) NOT NULL,
+    quote_quantity NUMERIC(36,18) NOT NULL,
+    fee            NUMERIC(36,18) NOT NULL,
+    fee_asset      VARCHAR(16) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_fills_order_trade ON fills (order_id, trade_id);
+CREATE INDEX IF NOT EXISTS idx_fills_user_id ON fills (user_id);
+CREATE INDEX IF NOT EXISTS idx_fills_created_at ON fills (created_at);
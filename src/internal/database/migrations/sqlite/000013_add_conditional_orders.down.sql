@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_orders_parent_order_id;
+
+ALTER TABLE orders DROP COLUMN triggered_at;
+ALTER TABLE orders DROP COLUMN child_order_id;
+ALTER TABLE orders DROP COLUMN parent_order_id;
+ALTER TABLE orders DROP COLUMN trigger_price_type;
+ALTER TABLE orders DROP COLUMN trailing_delta;
+ALTER TABLE orders DROP COLUMN stop_price;
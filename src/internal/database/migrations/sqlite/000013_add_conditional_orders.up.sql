@@ -0,0 +1,8 @@
+ALTER TABLE orders ADD COLUMN stop_price NUMERIC(36,18) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN trailing_delta NUMERIC(36,18) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN trigger_price_type VARCHAR(8) NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN parent_order_id INTEGER;
+ALTER TABLE orders ADD COLUMN child_order_id INTEGER;
+ALTER TABLE orders ADD COLUMN triggered_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_orders_parent_order_id ON orders (parent_order_id);
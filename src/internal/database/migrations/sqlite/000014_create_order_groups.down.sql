@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_orders_group_id;
+
+ALTER TABLE orders DROP COLUMN group_role;
+ALTER TABLE orders DROP COLUMN group_id;
+
+DROP TABLE IF EXISTS order_groups;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS order_groups (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at      DATETIME,
+    updated_at      DATETIME,
+    user_id         INTEGER NOT NULL,
+    client_group_id VARCHAR(64) NOT NULL,
+    symbol          VARCHAR(32) NOT NULL,
+    type            VARCHAR(16) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_order_groups_user_client_group_id ON order_groups (user_id, client_group_id);
+
+ALTER TABLE orders ADD COLUMN group_id INTEGER;
+ALTER TABLE orders ADD COLUMN group_role VARCHAR(16) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_orders_group_id ON orders (group_id);
@@ -0,0 +1,2 @@
+ALTER TABLE two_factors DROP COLUMN locked_until;
+ALTER TABLE two_factors DROP COLUMN failed_attempts;
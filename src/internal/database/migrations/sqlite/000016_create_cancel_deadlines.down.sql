@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_cancel_deadlines_deadline;
+
+DROP TABLE IF EXISTS cancel_deadlines;
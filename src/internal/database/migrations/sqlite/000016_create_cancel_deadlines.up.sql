@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS cancel_deadlines (
+    user_id  INTEGER PRIMARY KEY,
+    deadline DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_cancel_deadlines_deadline ON cancel_deadlines (deadline);
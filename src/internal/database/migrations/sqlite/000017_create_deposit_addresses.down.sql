@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_deposit_addresses_address;
+DROP INDEX IF EXISTS idx_deposit_addresses_user_asset;
+
+DROP TABLE IF EXISTS deposit_addresses;
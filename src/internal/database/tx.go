@@ -0,0 +1,187 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/gorm"
+)
+
+// 事务重试参数
+const (
+	defaultTxRetries = 3
+	txRetryBaseDelay = 10 * time.Millisecond
+)
+
+// txKey 上下文中保存事务状态的键
+type txKey struct{}
+
+// txScope 一层事务（最外层事务或嵌套保存点）及其提交、回滚钩子
+type txScope struct {
+	tx            *gorm.DB
+	depth         int
+	afterCommit   []func()
+	afterRollback []func()
+}
+
+// TxManager 事务管理器，将事务保存在context中，使同一调用链上的仓储自动加入该事务
+type TxManager struct {
+	db         *gorm.DB
+	MaxRetries int
+}
+
+// NewTxManager 创建事务管理器
+func NewTxManager(db *gorm.DB) *TxManager {
+	return &TxManager{
+		db:         db,
+		MaxRetries: defaultTxRetries,
+	}
+}
+
+// Do 在事务中执行fn，fn须使用传入的ctx访问仓储。
+// 上下文中已有事务时创建保存点，fn出错只回滚到该保存点；
+// 最外层事务遇到序列化失败或死锁时整体重试，fn因此应当可重复执行。
+func (m *TxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.DoWithOptions(ctx, nil, fn)
+}
+
+// DoWithOptions 与Do相同，可指定隔离级别等事务选项，嵌套调用时选项被忽略
+func (m *TxManager) DoWithOptions(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	if parent, ok := ctx.Value(txKey{}).(*txScope); ok {
+		return nested(ctx, parent, fn)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := m.run(ctx, opts, fn)
+		if err == nil || !IsRetryable(err) || attempt >= m.MaxRetries {
+			return err
+		}
+
+		delay := txRetryBaseDelay << attempt
+		delay += time.Duration(rand.Int63n(int64(delay)))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// run 开启最外层事务执行一次fn，并在提交或回滚后执行对应钩子
+func (m *TxManager) run(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) (err error) {
+	tx := m.db.WithContext(ctx).Begin(opts)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	scope := &txScope{tx: tx}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			runHooks(scope.afterRollback)
+			panic(p)
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, scope)); err != nil {
+		tx.Rollback()
+		runHooks(scope.afterRollback)
+		return err
+	}
+	if err = tx.Commit().Error; err != nil {
+		runHooks(scope.afterRollback)
+		return err
+	}
+	runHooks(scope.afterCommit)
+	return nil
+}
+
+// nested 在已有事务中通过保存点执行fn，成功时钩子并入上层，失败时回滚到保存点
+func nested(ctx context.Context, parent *txScope, fn func(ctx context.Context) error) (err error) {
+	scope := &txScope{tx: parent.tx, depth: parent.depth + 1}
+	name := fmt.Sprintf("sp_%d", scope.depth)
+	if err := parent.tx.SavePoint(name).Error; err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			parent.tx.RollbackTo(name)
+			runHooks(scope.afterRollback)
+			panic(p)
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, scope)); err != nil {
+		if rbErr := parent.tx.RollbackTo(name).Error; rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		runHooks(scope.afterRollback)
+		return err
+	}
+	parent.afterCommit = append(parent.afterCommit, scope.afterCommit...)
+	parent.afterRollback = append(parent.afterRollback, scope.afterRollback...)
+	return nil
+}
+
+// TxFromContext 返回上下文中的当前事务
+func TxFromContext(ctx context.Context) (*gorm.DB, bool) {
+	scope, ok := ctx.Value(txKey{}).(*txScope)
+	if !ok {
+		return nil, false
+	}
+	return scope.tx, true
+}
+
+// Conn 返回上下文中的事务，没有事务时返回绑定上下文的db
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// AfterCommit 注册在最外层事务提交后执行的钩子，用于发布事件等副作用。
+// 所在保存点或事务回滚时钩子被丢弃；上下文中没有事务时立即执行。
+func AfterCommit(ctx context.Context, fn func()) {
+	scope, ok := ctx.Value(txKey{}).(*txScope)
+	if !ok {
+		fn()
+		return
+	}
+	scope.afterCommit = append(scope.afterCommit, fn)
+}
+
+// AfterRollback 注册在所在保存点或事务回滚后执行的钩子；上下文中没有事务时忽略
+func AfterRollback(ctx context.Context, fn func()) {
+	if scope, ok := ctx.Value(txKey{}).(*txScope); ok {
+		scope.afterRollback = append(scope.afterRollback, fn)
+	}
+}
+
+// IsRetryable 判断错误是否为可通过重试事务解决的并发冲突：
+// PostgreSQL序列化失败（40001）和死锁（40P01），以及SQLite的数据库忙
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "40001" || pgErr.Code == "40P01"
+	}
+	var liteErr sqlite3.Error
+	if errors.As(err, &liteErr) {
+		return liteErr.Code == sqlite3.ErrBusy || liteErr.Code == sqlite3.ErrLocked
+	}
+	return false
+}
+
+// runHooks 依次执行钩子
+func runHooks(hooks []func()) {
+	for _, hook := range hooks {
+		hook()
+	}
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"awesome-trade/src/internal/config"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// entry 事务测试用模型
+type entry struct {
+	ID   uint
+	Name string
+}
+
+func setupTxManager(t *testing.T) (*gorm.DB, *TxManager) {
+	db, err := Open(config.DatabaseConfig{Driver: DriverSQLite, Path: ":memory:"})
+	require.NoError(t, err)
+	t.Cleanup(func() { Close(db) })
+	require.NoError(t, db.AutoMigrate(&entry{}))
+	return db, NewTxManager(db)
+}
+
+func insert(ctx context.Context, db *gorm.DB, name string) error {
+	return Conn(ctx, db).Create(&entry{Name: name}).Error
+}
+
+func names(t *testing.T, db *gorm.DB) []string {
+	var out []string
+	require.NoError(t, db.Model(&entry{}).Order("id").Pluck("name", &out).Error)
+	return out
+}
+
+// 测试提交、回滚以及钩子执行时机
+func TestTxManagerCommitRollback(t *testing.T) {
+	db, m := setupTxManager(t)
+	ctx := context.Background()
+
+	var events []string
+	require.NoError(t, m.Do(ctx, func(ctx context.Context) error {
+		_, ok := TxFromContext(ctx)
+		assert.True(t, ok)
+		AfterCommit(ctx, func() { events = append(events, "committed a") })
+		AfterRollback(ctx, func() { events = append(events, "rolled back a") })
+		assert.Empty(t, events)
+		return insert(ctx, db, "a")
+	}))
+	assert.Equal(t, []string{"committed a"}, events)
+
+	boom := errors.New("boom")
+	events = nil
+	err := m.Do(ctx, func(ctx context.Context) error {
+		AfterCommit(ctx, func() { events = append(events, "committed b") })
+		AfterRollback(ctx, func() { events = append(events, "rolled back b") })
+		if err := insert(ctx, db, "b"); err != nil {
+			return err
+		}
+		return boom
+	})
+	assert.ErrorIs(t, err, boom)
+	assert.Equal(t, []string{"rolled back b"}, events)
+	assert.Equal(t, []string{"a"}, names(t, db))
+
+	assert.Panics(t, func() {
+		_ = m.Do(ctx, func(ctx context.Context) error {
+			_ = insert(ctx, db, "c")
+			panic("boom")
+		})
+	})
+	assert.Equal(t, []string{"a"}, names(t, db))
+
+	// 没有事务时钩子立即执行
+	fired := false
+	AfterCommit(ctx, func() { fired = true })
+	assert.True(t, fired)
+}
+
+// 测试嵌套调用使用保存点，内层失败只回滚内层
+func TestTxManagerNestedSavepoint(t *testing.T) {
+	db, m := setupTxManager(t)
+	ctx := context.Background()
+
+	var events []string
+	require.NoError(t, m.Do(ctx, func(ctx context.Context) error {
+		if err := insert(ctx, db, "outer"); err != nil {
+			return err
+		}
+
+		err := m.Do(ctx, func(ctx context.Context) error {
+			AfterCommit(ctx, func() { events = append(events, "inner failed committed") })
+			AfterRollback(ctx, func() { events = append(events, "inner failed rolled back") })
+			if err := insert(ctx, db, "inner-failed"); err != nil {
+				return err
+			}
+			return errors.New("inner")
+		})
+		assert.Error(t, err)
+
+		return m.Do(ctx, func(ctx context.Context) error {
+			AfterCommit(ctx, func() { events = append(events, "inner ok committed") })
+			return insert(ctx, db, "inner-ok")
+		})
+	}))
+
+	assert.Equal(t, []string{"outer", "inner-ok"}, names(t, db))
+	assert.Equal(t, []string{"inner failed rolled back", "inner ok committed"}, events)
+}
+
+// 测试遇到可重试错误时整体重试事务
+func TestTxManagerRetry(t *testing.T) {
+	db, m := setupTxManager(t)
+	ctx := context.Background()
+
+	attempts := 0
+	require.NoError(t, m.Do(ctx, func(ctx context.Context) error {
+		attempts++
+		if err := insert(ctx, db, "x"); err != nil {
+			return err
+		}
+		if attempts < 3 {
+			return sqlite3.Error{Code: sqlite3.ErrBusy}
+		}
+		return nil
+	}))
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, []string{"x"}, names(t, db))
+
+	attempts = 0
+	m.MaxRetries = 1
+	err := m.Do(ctx, func(ctx context.Context) error {
+		attempts++
+		return sqlite3.Error{Code: sqlite3.ErrBusy}
+	})
+	assert.True(t, IsRetryable(err))
+	assert.Equal(t, 2, attempts)
+
+	assert.False(t, IsRetryable(errors.New("other")))
+}
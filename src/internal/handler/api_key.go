@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"errors"
+
+	"awesome-trade/src/internal/middleware"
+	"awesome-trade/src/internal/service"
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateAPIKeyRequest 创建API Key请求
+type CreateAPIKeyRequest struct {
+	Label       string   `json:"label" binding:"max=64"`
+	Scopes      []string `json:"scopes" binding:"required,min=1,dive,oneof=read trade withdraw"`
+	IPAllowlist []string `json:"ip_allowlist" binding:"omitempty,dive,required"`
+}
+
+// APIKeyHandler API Key处理器
+type APIKeyHandler struct {
+	keys *service.APIKeyService
+}
+
+// NewAPIKeyHandler 创建API Key处理器实例
+func NewAPIKeyHandler(keys *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		keys: keys,
+	}
+}
+
+// Create 创建API Key，密钥仅在本次响应中返回
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	key, err := h.keys.Create(c.Request.Context(), middleware.GetUserID(c), service.CreateAPIKeyInput{
+		Label:       req.Label,
+		Scopes:      req.Scopes,
+		IPAllowlist: req.IPAllowlist,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidScope) || errors.Is(err, service.ErrInvalidIPAllowlist) {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+		utils.InternalServerError(c, "Failed to create api key")
+		return
+	}
+
+	utils.Success(c, key)
+}
+
+// List 获取当前用户的API Key列表
+func (h *APIKeyHandler) List(c *gin.Context) {
+	keys, err := h.keys.List(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		utils.InternalServerError(c, "Failed to list api keys")
+		return
+	}
+
+	utils.Success(c, keys)
+}
+
+// Delete 删除API Key
+func (h *APIKeyHandler) Delete(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.keys.Delete(c.Request.Context(), middleware.GetUserID(c), id); err != nil {
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.InternalServerError(c, "Failed to delete api key")
+		return
+	}
+
+	utils.Success(c, nil)
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"awesome-trade/src/internal/middleware"
+	"awesome-trade/src/internal/service"
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRequest 注册请求
+type RegisterRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=20"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// LoginRequest 登录请求
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// TwoFactorLoginRequest 两步验证登录请求
+type TwoFactorLoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// RefreshRequest 刷新令牌请求
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LogoutRequest 登出请求
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// AuthHandler 认证处理器
+type AuthHandler struct {
+	auth *service.AuthService
+}
+
+// NewAuthHandler 创建认证处理器实例
+func NewAuthHandler(auth *service.AuthService) *AuthHandler {
+	return &AuthHandler{
+		auth: auth,
+	}
+}
+
+// Register 用户注册
+func (h *AuthHandler) Register(c *gin.Context) {
+	var req RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	user, err := h.auth.Register(c.Request.Context(), req.Username, req.Email, req.Password)
+	if err != nil {
+		if errors.Is(err, service.ErrUserExists) {
+			utils.Conflict(c, err.Error())
+			return
+		}
+		utils.InternalServerError(c, "Failed to register user")
+		return
+	}
+
+	utils.Success(c, user)
+}
+
+// Login 用户登录
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	result, err := h.auth.Login(c.Request.Context(), req.Username, req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCredentials), errors.Is(err, service.ErrUserInactive):
+			utils.Unauthorized(c, err.Error())
+		case errors.Is(err, service.ErrTwoFactorLocked):
+			utils.Fail(c, http.StatusTooManyRequests, http.StatusTooManyRequests, err.Error())
+		default:
+			utils.InternalServerError(c, "Failed to login")
+		}
+		return
+	}
+
+	utils.Success(c, result)
+}
+
+// LoginTwoFactor 登录第二步，校验两步验证码或恢复码
+func (h *AuthHandler) LoginTwoFactor(c *gin.Context) {
+	var req TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	result, err := h.auth.LoginWithTwoFactor(c.Request.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidToken),
+			errors.Is(err, service.ErrTokenRevoked),
+			errors.Is(err, service.ErrInvalidTwoFactorCode),
+			errors.Is(err, service.ErrTwoFactorNotEnrolled),
+			errors.Is(err, service.ErrUserInactive):
+			utils.Unauthorized(c, err.Error())
+		default:
+			utils.InternalServerError(c, "Failed to login")
+		}
+		return
+	}
+
+	utils.Success(c, result)
+}
+
+// Refresh 使用刷新令牌换取新的令牌对
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	tokens, err := h.auth.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidRefreshToken),
+			errors.Is(err, service.ErrRefreshTokenReused),
+			errors.Is(err, service.ErrUserInactive):
+			utils.Unauthorized(c, err.Error())
+		default:
+			utils.InternalServerError(c, "Failed to refresh token")
+		}
+		return
+	}
+
+	utils.Success(c, tokens)
+}
+
+// Logout 用户登出，吊销当前访问令牌及可选的刷新令牌
+func (h *AuthHandler) Logout(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		utils.Unauthorized(c, "Invalid token")
+		return
+	}
+
+	var req LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.BadRequest(c, "Invalid request data: "+err.Error())
+			return
+		}
+	}
+
+	if err := h.auth.Logout(c.Request.Context(), claims, req.RefreshToken); err != nil {
+		utils.InternalServerError(c, "Failed to logout")
+		return
+	}
+	utils.Success(c, nil)
+}
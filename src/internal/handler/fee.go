@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"awesome-trade/src/internal/middleware"
+	"awesome-trade/src/internal/service"
+	"awesome-trade/src/pkg/decimal"
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FeeSummaryRequest 查询手续费等级参数，指定交易对时同时返回该交易对上的费率
+type FeeSummaryRequest struct {
+	Symbol string `form:"symbol"`
+}
+
+// SetFeeTierRequest 设置手续费等级请求
+type SetFeeTierRequest struct {
+	Level        int             `json:"level" binding:"gte=0"`
+	MinVolume    decimal.Decimal `json:"min_volume" binding:"gte=0"`
+	MakerFeeRate decimal.Decimal `json:"maker_fee_rate"`
+	TakerFeeRate decimal.Decimal `json:"taker_fee_rate" binding:"gte=0"`
+}
+
+// DeleteFeeTierRequest 删除手续费等级参数
+type DeleteFeeTierRequest struct {
+	Level *int `form:"level" binding:"required"`
+}
+
+// SetFeeOverrideRequest 设置交易对费率请求
+type SetFeeOverrideRequest struct {
+	Symbol       string          `json:"symbol" binding:"required,max=32"`
+	Level        int             `json:"level" binding:"gte=0"`
+	MakerFeeRate decimal.Decimal `json:"maker_fee_rate"`
+	TakerFeeRate decimal.Decimal `json:"taker_fee_rate" binding:"gte=0"`
+}
+
+// DeleteFeeOverrideRequest 删除交易对费率参数
+type DeleteFeeOverrideRequest struct {
+	Symbol string `form:"symbol" binding:"required"`
+	Level  *int   `form:"level" binding:"required"`
+}
+
+// CreateFeePromotionRequest 创建免费活动请求，Symbol为空表示全部交易对
+type CreateFeePromotionRequest struct {
+	Symbol   string    `json:"symbol" binding:"max=32"`
+	StartsAt time.Time `json:"starts_at" binding:"required"`
+	EndsAt   time.Time `json:"ends_at" binding:"required"`
+	Memo     string    `json:"memo" binding:"max=255"`
+}
+
+// FeeHandler 手续费处理器
+type FeeHandler struct {
+	fees *service.FeeService
+}
+
+// NewFeeHandler 创建手续费处理器实例
+func NewFeeHandler(fees *service.FeeService) *FeeHandler {
+	return &FeeHandler{
+		fees: fees,
+	}
+}
+
+// Summary 获取当前用户的手续费等级和近30天成交额
+func (h *FeeHandler) Summary(c *gin.Context) {
+	var req FeeSummaryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	summary, err := h.fees.Summary(c.Request.Context(), middleware.GetUserID(c), req.Symbol)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, summary)
+}
+
+// ListTiers 获取全部手续费等级
+func (h *FeeHandler) ListTiers(c *gin.Context) {
+	tiers, err := h.fees.ListTiers(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, tiers)
+}
+
+// SetTier 设置手续费等级
+func (h *FeeHandler) SetTier(c *gin.Context) {
+	var req SetFeeTierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	tier, err := h.fees.SetTier(c.Request.Context(), service.FeeTierInput{
+		Level:        req.Level,
+		MinVolume:    req.MinVolume,
+		MakerFeeRate: req.MakerFeeRate,
+		TakerFeeRate: req.TakerFeeRate,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, tier)
+}
+
+// DeleteTier 删除手续费等级
+func (h *FeeHandler) DeleteTier(c *gin.Context) {
+	var req DeleteFeeTierRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	if err := h.fees.DeleteTier(c.Request.Context(), *req.Level); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, nil)
+}
+
+// ListOverrides 获取全部交易对费率
+func (h *FeeHandler) ListOverrides(c *gin.Context) {
+	overrides, err := h.fees.ListOverrides(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, overrides)
+}
+
+// SetOverride 设置交易对在某个等级上的费率
+func (h *FeeHandler) SetOverride(c *gin.Context) {
+	var req SetFeeOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	override, err := h.fees.SetOverride(c.Request.Context(), service.FeeOverrideInput{
+		Symbol:       req.Symbol,
+		Level:        req.Level,
+		MakerFeeRate: req.MakerFeeRate,
+		TakerFeeRate: req.TakerFeeRate,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, override)
+}
+
+// DeleteOverride 删除交易对在某个等级上的费率
+func (h *FeeHandler) DeleteOverride(c *gin.Context) {
+	var req DeleteFeeOverrideRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	if err := h.fees.DeleteOverride(c.Request.Context(), req.Symbol, *req.Level); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, nil)
+}
+
+// ListPromotions 获取全部免费活动
+func (h *FeeHandler) ListPromotions(c *gin.Context) {
+	promotions, err := h.fees.ListPromotions(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, promotions)
+}
+
+// CreatePromotion 创建免费活动
+func (h *FeeHandler) CreatePromotion(c *gin.Context) {
+	var req CreateFeePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	promotion, err := h.fees.CreatePromotion(c.Request.Context(), service.FeePromotionInput{
+		Symbol:   req.Symbol,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+		Memo:     req.Memo,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, promotion)
+}
+
+// DeletePromotion 删除免费活动
+func (h *FeeHandler) DeletePromotion(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.fees.DeletePromotion(c.Request.Context(), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, nil)
+}
+
+// Recompute 立即按近30天成交额重新计算全部用户的手续费等级
+func (h *FeeHandler) Recompute(c *gin.Context) {
+	users, err := h.fees.Recompute(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, gin.H{"users": users})
+}
+
+// handleError 将服务层错误映射为HTTP响应
+func (h *FeeHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrMarketNotFound),
+		errors.Is(err, service.ErrFeeTierNotFound),
+		errors.Is(err, service.ErrFeeOverrideNotFound),
+		errors.Is(err, service.ErrFeePromotionNotFound):
+		utils.NotFound(c, err.Error())
+	case errors.Is(err, service.ErrInvalidFeeTier),
+		errors.Is(err, service.ErrInvalidFeeRate),
+		errors.Is(err, service.ErrInvalidFeePromotion):
+		utils.BadRequest(c, err.Error())
+	default:
+		utils.InternalServerError(c, "Internal server error")
+	}
+}
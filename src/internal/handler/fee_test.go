@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试按等级收取挂单和吃单手续费、按成交额重新计算等级、交易对费率覆盖等级费率和免费活动
+func TestFees(t *testing.T) {
+	r := setupOrderRouter(t)
+
+	place := func(user string, body gin.H) {
+		t.Helper()
+		w, _ := doJSONAs(r, user, "POST", "/orders", body)
+		require.Equal(t, 200, w.Code, w.Body.String())
+	}
+	put := func(path string, body gin.H) {
+		t.Helper()
+		w, _ := doJSON(r, "PUT", path, body)
+		require.Equal(t, 200, w.Code, w.Body.String())
+	}
+	put("/fees/tiers", gin.H{"level": 0, "min_volume": "0", "maker_fee_rate": "0.001", "taker_fee_rate": "0.002"})
+	put("/fees/tiers", gin.H{"level": 1, "min_volume": "5000", "maker_fee_rate": "-0.0001", "taker_fee_rate": "0.001"})
+
+	// 0级：bob挂单卖出收取0.1%计价资产，alice吃单买入收取0.2%基础资产
+	place("bob", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "limit", "price": "30000", "quantity": "0.2"})
+	place("alice", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "30000", "quantity": "0.2"})
+	assert.Equal(t, [2]string{"10.1996", "0"}, balances(t, r, "alice")["BTC"])
+	assert.Equal(t, [2]string{"105994", "0"}, balances(t, r, "bob")["USDT"])
+
+	// 成交额6000达到1级门槛
+	w, resp := doJSON(r, "GET", "/fees", nil)
+	require.Equal(t, 200, w.Code)
+	summary := resp["data"].(map[string]interface{})
+	assert.Equal(t, float64(0), summary["level"])
+	assert.Equal(t, float64(1), summary["next_tier"].(map[string]interface{})["level"])
+
+	w, resp = doJSON(r, "POST", "/fees/recompute", nil)
+	require.Equal(t, 200, w.Code, w.Body.String())
+	assert.Equal(t, float64(2), resp["data"].(map[string]interface{})["users"])
+	w, resp = doJSON(r, "GET", "/fees?symbol=btc_usdt", nil)
+	require.Equal(t, 200, w.Code)
+	summary = resp["data"].(map[string]interface{})
+	assert.Equal(t, float64(1), summary["level"])
+	assert.Equal(t, "6000", summary["volume"])
+	assert.Equal(t, "USDT", summary["volume_asset"])
+	assert.Nil(t, summary["next_tier"])
+	assert.Equal(t, "-0.0001", summary["rates"].(map[string]interface{})["maker_fee_rate"])
+
+	// 免费活动期间不收取手续费
+	w, _ = doJSON(r, "POST", "/fees/promotions", gin.H{
+		"symbol": "eth_usdt", "starts_at": time.Now().Add(-time.Hour), "ends_at": time.Now().Add(time.Hour), "memo": "launch",
+	})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	place("alice", gin.H{"symbol": "ETH_USDT", "side": "sell", "type": "limit", "price": "100", "quantity": "1"})
+	place("bob", gin.H{"symbol": "ETH_USDT", "side": "buy", "type": "limit", "price": "100", "quantity": "1"})
+	assert.Equal(t, [2]string{"11", "0"}, balances(t, r, "bob")["ETH"])
+	w, resp = doJSONAs(r, "bob", "GET", "/fees?symbol=ETH_USDT", nil)
+	require.Equal(t, 200, w.Code)
+	assert.Equal(t, true, resp["data"].(map[string]interface{})["rates"].(map[string]interface{})["promotion"])
+
+	// 交易对费率覆盖1级费率，挂单费率为负时返佣
+	put("/fees/overrides", gin.H{"symbol": "btc_usdt", "level": 1, "maker_fee_rate": "-0.0002", "taker_fee_rate": "0.0005"})
+	place("alice", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "limit", "price": "30000", "quantity": "0.1"})
+	place("bob", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "30000", "quantity": "0.1"})
+	assert.Equal(t, map[string][2]string{
+		"BTC":  {"10.0996", "0"},
+		"ETH":  {"9", "0"},
+		"USDT": {"97100.6", "0"},
+	}, balances(t, r, "alice"))
+	assert.Equal(t, map[string][2]string{
+		"BTC":  {"9.89995", "0"},
+		"ETH":  {"11", "0"},
+		"USDT": {"102894", "0"},
+	}, balances(t, r, "bob"))
+
+	// 返佣按计价资产USDT的6位小数向零舍入：1.23457 * 0.0002 = 0.000246914
+	place("alice", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "limit", "price": "1234.57", "quantity": "0.001"})
+	place("bob", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "1234.57", "quantity": "0.001"})
+	assert.Equal(t, [2]string{"97101.834816", "0"}, balances(t, r, "alice")["USDT"])
+
+	w, resp = doJSON(r, "GET", "/reconcile", nil)
+	require.Equal(t, 200, w.Code)
+	assert.Equal(t, true, resp["data"].(map[string]interface{})["balanced"])
+
+	// 参数校验
+	w, _ = doJSON(r, "PUT", "/fees/tiers", gin.H{"level": 2, "min_volume": "1", "taker_fee_rate": "-0.001"})
+	assert.Equal(t, 400, w.Code)
+	w, _ = doJSON(r, "PUT", "/fees/tiers", gin.H{"level": 2, "min_volume": "1", "taker_fee_rate": "0.0000001"})
+	assert.Equal(t, 400, w.Code)
+	w, _ = doJSON(r, "PUT", "/fees/overrides", gin.H{"symbol": "SOL_USDT", "level": 1, "taker_fee_rate": "0.001"})
+	assert.Equal(t, 404, w.Code)
+	w, _ = doJSON(r, "POST", "/fees/promotions", gin.H{"starts_at": time.Now(), "ends_at": time.Now().Add(-time.Hour)})
+	assert.Equal(t, 400, w.Code)
+	w, _ = doJSON(r, "DELETE", "/fees/tiers?level=7", nil)
+	assert.Equal(t, 404, w.Code)
+}
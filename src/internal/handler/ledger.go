@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"awesome-trade/src/internal/middleware"
+	"awesome-trade/src/internal/service"
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReconcileResponse 对账结果
+type ReconcileResponse struct {
+	Balanced   bool               `json:"balanced"`
+	Mismatches []service.Mismatch `json:"mismatches"`
+}
+
+// LedgerHandler 账户余额与对账处理器
+type LedgerHandler struct {
+	ledger *service.LedgerService
+}
+
+// NewLedgerHandler 创建账本处理器实例
+func NewLedgerHandler(ledger *service.LedgerService) *LedgerHandler {
+	return &LedgerHandler{
+		ledger: ledger,
+	}
+}
+
+// Balances 获取当前用户各资产的可用和冻结余额
+func (h *LedgerHandler) Balances(c *gin.Context) {
+	accounts, err := h.ledger.Balances(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		utils.InternalServerError(c, "Failed to list balances")
+		return
+	}
+
+	utils.Success(c, accounts)
+}
+
+// Reconcile 校验全部账户的余额缓存与分录汇总是否一致
+func (h *LedgerHandler) Reconcile(c *gin.Context) {
+	mismatches, err := h.ledger.Reconcile(c.Request.Context())
+	if err != nil {
+		utils.InternalServerError(c, "Failed to reconcile ledger")
+		return
+	}
+
+	utils.Success(c, ReconcileResponse{
+		Balanced:   len(mismatches) == 0,
+		Mismatches: mismatches,
+	})
+}
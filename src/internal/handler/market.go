@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"errors"
+
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/internal/service"
+	"awesome-trade/src/pkg/decimal"
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MarketRulesRequest 交易对交易规则，数值使用字符串传递以保证精度
+type MarketRulesRequest struct {
+	TickSize     decimal.Decimal `json:"tick_size" binding:"gt=0"`
+	StepSize     decimal.Decimal `json:"step_size" binding:"gt=0"`
+	MinNotional  decimal.Decimal `json:"min_notional" binding:"gte=0"`
+	MakerFeeRate decimal.Decimal `json:"maker_fee_rate"`
+	TakerFeeRate decimal.Decimal `json:"taker_fee_rate"`
+}
+
+// CreateMarketRequest 上架交易对请求
+type CreateMarketRequest struct {
+	BaseAsset  string `json:"base_asset" binding:"required,max=16"`
+	QuoteAsset string `json:"quote_asset" binding:"required,max=16"`
+	Status     string `json:"status" binding:"omitempty,oneof=pre_open trading"`
+	MarketRulesRequest
+}
+
+// SetMarketStatusRequest 变更交易对状态请求，CancelOrders仅对暂停生效，下架总是撤销全部挂单
+type SetMarketStatusRequest struct {
+	Status       string `json:"status" binding:"required,oneof=trading halted delisted"`
+	CancelOrders bool   `json:"cancel_orders"`
+}
+
+// ListMarketsRequest 交易对查询参数
+type ListMarketsRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=pre_open trading halted delisted"`
+}
+
+// MarketStatusResponse 变更交易对状态的结果
+type MarketStatusResponse struct {
+	Market         *model.Market `json:"market"`
+	CanceledOrders int           `json:"canceled_orders"`
+}
+
+// MarketHandler 交易对处理器
+type MarketHandler struct {
+	markets *service.MarketService
+}
+
+// NewMarketHandler 创建交易对处理器实例
+func NewMarketHandler(markets *service.MarketService) *MarketHandler {
+	return &MarketHandler{
+		markets: markets,
+	}
+}
+
+// List 获取交易对列表，可按状态过滤
+func (h *MarketHandler) List(c *gin.Context) {
+	var req ListMarketsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	markets, err := h.markets.List(c.Request.Context(), req.Status)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, markets)
+}
+
+// Get 获取交易对详情
+func (h *MarketHandler) Get(c *gin.Context) {
+	market, err := h.markets.Get(c.Request.Context(), c.Param("symbol"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, market)
+}
+
+// Create 上架交易对
+func (h *MarketHandler) Create(c *gin.Context) {
+	var req CreateMarketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	in := req.MarketRulesRequest.input()
+	in.BaseAsset = req.BaseAsset
+	in.QuoteAsset = req.QuoteAsset
+	in.Status = req.Status
+	market, err := h.markets.Create(c.Request.Context(), in)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, market)
+}
+
+// Update 修改交易对的交易规则
+func (h *MarketHandler) Update(c *gin.Context) {
+	var req MarketRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	market, err := h.markets.Update(c.Request.Context(), c.Param("symbol"), req.input())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, market)
+}
+
+// SetStatus 开放、暂停或下架交易对
+func (h *MarketHandler) SetStatus(c *gin.Context) {
+	var req SetMarketStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	market, canceled, err := h.markets.SetStatus(c.Request.Context(), c.Param("symbol"), req.Status, req.CancelOrders)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, MarketStatusResponse{
+		Market:         market,
+		CanceledOrders: canceled,
+	})
+}
+
+// input 转换为服务层参数
+func (r MarketRulesRequest) input() service.MarketInput {
+	return service.MarketInput{
+		TickSize:     r.TickSize,
+		StepSize:     r.StepSize,
+		MinNotional:  r.MinNotional,
+		MakerFeeRate: r.MakerFeeRate,
+		TakerFeeRate: r.TakerFeeRate,
+	}
+}
+
+// handleError 将服务层错误映射为HTTP响应
+func (h *MarketHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrMarketNotFound):
+		utils.NotFound(c, err.Error())
+	case errors.Is(err, service.ErrMarketExists),
+		errors.Is(err, service.ErrInvalidMarketStatus),
+		errors.Is(err, repository.ErrStaleObject):
+		utils.Conflict(c, err.Error())
+	case errors.Is(err, service.ErrInvalidAsset),
+		errors.Is(err, service.ErrInvalidSymbol),
+		errors.Is(err, service.ErrInvalidMarketRule),
+		errors.Is(err, service.ErrInvalidFeeRate):
+		utils.BadRequest(c, err.Error())
+	default:
+		utils.InternalServerError(c, "Internal server error")
+	}
+}
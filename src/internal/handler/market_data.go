@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"awesome-trade/src/internal/service"
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DepthRequest 深度查询参数
+type DepthRequest struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+// RecentTradesRequest 最近成交查询参数
+type RecentTradesRequest struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+// KlinesRequest K线查询参数，EndTime为毫秒时间戳，只返回开盘时间早于EndTime的K线
+type KlinesRequest struct {
+	Interval string `form:"interval" binding:"required,oneof=1m 5m 1h 1d"`
+	EndTime  int64  `form:"end_time" binding:"omitempty,min=0"`
+	Limit    int    `form:"limit" binding:"omitempty,min=1,max=1000"`
+}
+
+// MarketDataHandler 行情处理器
+type MarketDataHandler struct {
+	marketData *service.MarketDataService
+}
+
+// NewMarketDataHandler 创建行情处理器实例
+func NewMarketDataHandler(marketData *service.MarketDataService) *MarketDataHandler {
+	return &MarketDataHandler{
+		marketData: marketData,
+	}
+}
+
+// Ticker 获取24小时滚动行情
+func (h *MarketDataHandler) Ticker(c *gin.Context) {
+	ticker, err := h.marketData.Ticker(c.Request.Context(), c.Param("symbol"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, ticker)
+}
+
+// Depth 获取订单簿深度
+func (h *MarketDataHandler) Depth(c *gin.Context) {
+	var req DepthRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 20
+	}
+
+	depth, err := h.marketData.Depth(c.Request.Context(), c.Param("symbol"), req.Limit)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, depth)
+}
+
+// Trades 获取最近成交
+func (h *MarketDataHandler) Trades(c *gin.Context) {
+	var req RecentTradesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 50
+	}
+
+	trades, err := h.marketData.Trades(c.Request.Context(), c.Param("symbol"), req.Limit)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, trades)
+}
+
+// Klines 获取K线
+func (h *MarketDataHandler) Klines(c *gin.Context) {
+	var req KlinesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 500
+	}
+	var end time.Time
+	if req.EndTime > 0 {
+		end = time.UnixMilli(req.EndTime).UTC()
+	}
+
+	klines, err := h.marketData.Klines(c.Request.Context(), c.Param("symbol"), req.Interval, end, req.Limit)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, klines)
+}
+
+// handleError 将服务层错误映射为HTTP响应
+func (h *MarketDataHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrMarketNotFound):
+		utils.Fail(c, http.StatusNotFound, utils.CodeMarketNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidInterval):
+		utils.BadRequest(c, err.Error())
+	default:
+		utils.InternalServerError(c, "Internal server error")
+	}
+}
@@ -0,0 +1,379 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"awesome-trade/src/internal/middleware"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/internal/service"
+	"awesome-trade/src/pkg/decimal"
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PlaceOrderRequest 下单请求，价格和数量使用字符串传递以保证精度
+type PlaceOrderRequest struct {
+	Symbol        string          `json:"symbol" binding:"required,min=3,max=32"`
+	Side          string          `json:"side" binding:"required,oneof=buy sell"`
+	Type          string          `json:"type" binding:"required,oneof=limit market stop stop_limit take_profit take_profit_limit trailing_stop"`
+	TimeInForce   string          `json:"time_in_force" binding:"omitempty,oneof=gtc ioc fok post_only"`
+	Price         decimal.Decimal `json:"price" binding:"gte=0"`
+	Quantity      decimal.Decimal `json:"quantity" binding:"required,gt=0"`
+	ClientOrderID string          `json:"client_order_id" binding:"omitempty,max=64,printascii"`
+
+	StopPrice        decimal.Decimal `json:"stop_price" binding:"gte=0"`
+	TrailingDelta    decimal.Decimal `json:"trailing_delta" binding:"gte=0"`
+	TriggerPriceType string          `json:"trigger_price_type" binding:"omitempty,oneof=last mark"`
+}
+
+// BatchOrdersRequest 批量下单撤单请求，先按顺序撤销cancel中的订单，再按顺序提交place中的订单
+type BatchOrdersRequest struct {
+	Cancel []uint              `json:"cancel" binding:"dive,gt=0"`
+	Place  []PlaceOrderRequest `json:"place" binding:"dive"`
+}
+
+// BatchOrderResult 批量操作中单个订单的结果，Code和Message与单独下单或撤单时的响应相同
+type BatchOrderResult struct {
+	ID      uint         `json:"id,omitempty"`
+	Code    int          `json:"code"`
+	Message string       `json:"message"`
+	Order   *model.Order `json:"order,omitempty"`
+}
+
+// BatchOrdersResponse 批量下单撤单响应，结果与请求中的订单一一对应
+type BatchOrdersResponse struct {
+	Cancel []BatchOrderResult `json:"cancel"`
+	Place  []BatchOrderResult `json:"place"`
+}
+
+// CancelAllOrdersRequest 撤销全部挂单请求，symbol和side为空时不限
+type CancelAllOrdersRequest struct {
+	Symbol string `json:"symbol" binding:"omitempty,max=32"`
+	Side   string `json:"side" binding:"omitempty,oneof=buy sell"`
+}
+
+// CancelAfterRequest 撤单倒计时请求，timeout为秒数，0表示关闭
+type CancelAfterRequest struct {
+	Timeout *int `json:"timeout" binding:"required,gte=0"`
+}
+
+// ListOpenOrdersRequest 当前挂单查询参数
+type ListOpenOrdersRequest struct {
+	Symbol string `form:"symbol" binding:"omitempty,max=32"`
+}
+
+// ListOrderHistoryRequest 历史订单查询参数
+type ListOrderHistoryRequest struct {
+	Symbol string `form:"symbol" binding:"omitempty,max=32"`
+	Cursor uint   `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// OrderHandler 订单处理器
+type OrderHandler struct {
+	orders *service.OrderService
+}
+
+// NewOrderHandler 创建订单处理器实例
+func NewOrderHandler(orders *service.OrderService) *OrderHandler {
+	return &OrderHandler{
+		orders: orders,
+	}
+}
+
+// Place 下单，重复提交相同的客户端订单ID返回已有订单
+func (h *OrderHandler) Place(c *gin.Context) {
+	var req PlaceOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	order, err := h.orders.Place(c.Request.Context(), middleware.GetUserID(c), req.input())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, order)
+}
+
+// Batch 批量撤单和下单，单个订单失败不影响其他订单，每个订单分别返回结果
+func (h *OrderHandler) Batch(c *gin.Context) {
+	var req BatchOrdersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	in := service.BatchInput{Cancel: req.Cancel}
+	for _, p := range req.Place {
+		in.Place = append(in.Place, p.input())
+	}
+	canceled, placed, err := h.orders.Batch(c.Request.Context(), middleware.GetUserID(c), in)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	resp := BatchOrdersResponse{
+		Cancel: make([]BatchOrderResult, len(canceled)),
+		Place:  make([]BatchOrderResult, len(placed)),
+	}
+	for i, r := range canceled {
+		resp.Cancel[i] = batchOrderResult(r)
+		resp.Cancel[i].ID = req.Cancel[i]
+	}
+	for i, r := range placed {
+		resp.Place[i] = batchOrderResult(r)
+	}
+	utils.Success(c, resp)
+}
+
+// CancelAll 撤销当前用户的全部挂单，可按交易对和方向过滤
+func (h *OrderHandler) CancelAll(c *gin.Context) {
+	var req CancelAllOrdersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	canceled, err := h.orders.CancelAll(c.Request.Context(), middleware.GetUserID(c), req.Symbol, req.Side)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, gin.H{"canceled_orders": canceled})
+}
+
+// CancelAfter 设置、刷新或关闭撤单倒计时，到期未刷新时撤销当前用户的全部挂单
+func (h *OrderHandler) CancelAfter(c *gin.Context) {
+	var req CancelAfterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	deadline, err := h.orders.CancelAfter(c.Request.Context(), middleware.GetUserID(c), time.Duration(*req.Timeout)*time.Second)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, gin.H{"timeout": *req.Timeout, "cancel_time": deadline})
+}
+
+// Cancel 撤单
+func (h *OrderHandler) Cancel(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.orders.Cancel(c.Request.Context(), middleware.GetUserID(c), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, order)
+}
+
+// AdminGet 管理员获取任意用户的订单详情
+func (h *OrderHandler) AdminGet(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.orders.GetAny(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, order)
+}
+
+// AdminListOpen 管理员获取指定用户的当前挂单
+func (h *OrderHandler) AdminListOpen(c *gin.Context) {
+	userID, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var req ListOpenOrdersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	orders, err := h.orders.ListOpen(c.Request.Context(), userID, req.Symbol)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, orders)
+}
+
+// AdminCancel 管理员撤销任意用户的挂单
+func (h *OrderHandler) AdminCancel(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.orders.CancelAny(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, order)
+}
+
+// Get 获取订单详情
+func (h *OrderHandler) Get(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.orders.Get(c.Request.Context(), middleware.GetUserID(c), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, order)
+}
+
+// ListOpen 获取当前挂单
+func (h *OrderHandler) ListOpen(c *gin.Context) {
+	var req ListOpenOrdersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	orders, err := h.orders.ListOpen(c.Request.Context(), middleware.GetUserID(c), req.Symbol)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, orders)
+}
+
+// ListHistory 获取历史订单，使用cursor翻页
+func (h *OrderHandler) ListHistory(c *gin.Context) {
+	var req ListOrderHistoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	orders, next, err := h.orders.ListHistory(c.Request.Context(), middleware.GetUserID(c), repository.OrderHistoryQuery{
+		Symbol: req.Symbol,
+		Before: req.Cursor,
+		Limit:  req.Limit,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, utils.CursorData{
+		List:       orders,
+		NextCursor: next,
+	})
+}
+
+// input 转换为服务层的下单参数
+func (r PlaceOrderRequest) input() service.PlaceOrderInput {
+	return service.PlaceOrderInput{
+		Symbol:        r.Symbol,
+		Side:          r.Side,
+		Type:          r.Type,
+		TimeInForce:   r.TimeInForce,
+		Price:         r.Price,
+		Quantity:      r.Quantity,
+		ClientOrderID: r.ClientOrderID,
+
+		StopPrice:        r.StopPrice,
+		TrailingDelta:    r.TrailingDelta,
+		TriggerPriceType: r.TriggerPriceType,
+	}
+}
+
+// batchOrderResult 将批量操作中单个订单的结果转换为响应
+func batchOrderResult(r service.BatchResult) BatchOrderResult {
+	if r.Err != nil {
+		_, code, message := orderError(r.Err)
+		return BatchOrderResult{Code: code, Message: message}
+	}
+	return BatchOrderResult{Code: 0, Message: "success", Order: r.Order}
+}
+
+// handleError 将服务层错误映射为HTTP响应
+func (h *OrderHandler) handleError(c *gin.Context, err error) {
+	handleOrderError(c, err)
+}
+
+// handleOrderError 将下单和撤单的服务层错误映射为HTTP响应
+func handleOrderError(c *gin.Context, err error) {
+	status, code, message := orderError(err)
+	utils.Fail(c, status, code, message)
+}
+
+// orderError 返回下单和撤单的服务层错误对应的HTTP状态码、业务错误码和错误信息，
+// 没有业务错误码的错误以HTTP状态码作为错误码
+func orderError(err error) (status, code int, message string) {
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound):
+		return http.StatusNotFound, http.StatusNotFound, err.Error()
+	case errors.Is(err, service.ErrOrderNotOpen), errors.Is(err, service.ErrClientOrderIDConflict):
+		return http.StatusConflict, http.StatusConflict, err.Error()
+	case errors.Is(err, service.ErrInsufficientBalance):
+		return http.StatusUnprocessableEntity, http.StatusUnprocessableEntity, err.Error()
+	case errors.Is(err, service.ErrMarketNotFound):
+		return http.StatusBadRequest, utils.CodeMarketNotFound, err.Error()
+	case errors.Is(err, service.ErrMarketNotTrading):
+		return http.StatusUnprocessableEntity, utils.CodeMarketNotTrading, err.Error()
+	case errors.Is(err, service.ErrPriceTickViolation):
+		return http.StatusBadRequest, utils.CodePriceTick, err.Error()
+	case errors.Is(err, service.ErrQuantityStepViolation):
+		return http.StatusBadRequest, utils.CodeQuantityStep, err.Error()
+	case errors.Is(err, service.ErrMinNotionalViolation):
+		return http.StatusBadRequest, utils.CodeMinNotional, err.Error()
+	case errors.Is(err, service.ErrRiskMaxQuantity):
+		return http.StatusUnprocessableEntity, utils.CodeRiskMaxQuantity, err.Error()
+	case errors.Is(err, service.ErrRiskMaxNotional):
+		return http.StatusUnprocessableEntity, utils.CodeRiskMaxNotional, err.Error()
+	case errors.Is(err, service.ErrRiskMaxOpenOrders):
+		return http.StatusUnprocessableEntity, utils.CodeRiskMaxOpenOrders, err.Error()
+	case errors.Is(err, service.ErrRiskPriceBand):
+		return http.StatusUnprocessableEntity, utils.CodeRiskPriceBand, err.Error()
+	case errors.Is(err, service.ErrRiskDailyLoss):
+		return http.StatusUnprocessableEntity, utils.CodeRiskDailyLoss, err.Error()
+	case errors.Is(err, service.ErrSettlementParked):
+		return http.StatusInternalServerError, utils.CodeSettlementParked, err.Error()
+	case errors.Is(err, service.ErrInvalidSymbol),
+		errors.Is(err, service.ErrInvalidPrice),
+		errors.Is(err, service.ErrInvalidQuantity),
+		errors.Is(err, service.ErrInvalidStopPrice),
+		errors.Is(err, service.ErrInvalidTrailingDelta),
+		errors.Is(err, service.ErrReservedClientOrderID),
+		errors.Is(err, service.ErrInvalidTimeInForce),
+		errors.Is(err, service.ErrInvalidBatchSize),
+		errors.Is(err, service.ErrInvalidCancelAfter):
+		return http.StatusBadRequest, http.StatusBadRequest, err.Error()
+	default:
+		return http.StatusInternalServerError, http.StatusInternalServerError, "Internal server error"
+	}
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试批量撤单下单的逐项结果、按交易对和方向撤销全部挂单，以及撤单倒计时到期后撤销全部挂单
+func TestBatchAndCancelAll(t *testing.T) {
+	r := setupOrderRouter(t)
+
+	openOrders := func(user string) []interface{} {
+		t.Helper()
+		w, resp := doJSONAs(r, user, "GET", "/orders/open", nil)
+		require.Equal(t, 200, w.Code)
+		return resp["data"].([]interface{})
+	}
+	results := func(resp map[string]interface{}, key string) []map[string]interface{} {
+		var out []map[string]interface{}
+		for _, item := range resp["data"].(map[string]interface{})[key].([]interface{}) {
+			out = append(out, item.(map[string]interface{}))
+		}
+		return out
+	}
+
+	// 单个订单失败不影响其他订单，结果的错误码与单独下单时相同
+	w, resp := doJSON(r, "POST", "/orders/batch", gin.H{"place": []gin.H{
+		{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "29000", "quantity": "0.1"},
+		{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "29000.001", "quantity": "0.1"},
+		{"symbol": "ETH_USDT", "side": "sell", "type": "limit", "price": "2000", "quantity": "1"},
+	}})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	placed := results(resp, "place")
+	require.Len(t, placed, 3)
+	assert.Equal(t, float64(0), placed[0]["code"])
+	assert.Equal(t, float64(utils.CodePriceTick), placed[1]["code"])
+	assert.Nil(t, placed[1]["order"])
+	assert.Equal(t, float64(0), placed[2]["code"])
+	assert.Len(t, results(resp, "cancel"), 0)
+
+	// 先撤单再下单
+	id := placed[0]["order"].(map[string]interface{})["id"]
+	w, resp = doJSON(r, "POST", "/orders/batch", gin.H{
+		"cancel": []interface{}{id, id, 999},
+		"place":  []gin.H{{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "29100", "quantity": "0.1"}},
+	})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	canceled := results(resp, "cancel")
+	require.Len(t, canceled, 3)
+	assert.Equal(t, id, canceled[0]["id"])
+	assert.Equal(t, "canceled", canceled[0]["order"].(map[string]interface{})["status"])
+	assert.Equal(t, float64(409), canceled[1]["code"])
+	assert.Equal(t, float64(404), canceled[2]["code"])
+	assert.Equal(t, "29100", results(resp, "place")[0]["order"].(map[string]interface{})["price"])
+
+	for _, body := range []gin.H{
+		{},
+		{"cancel": []int{1, 2, 3}, "place": []gin.H{
+			{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "29000", "quantity": "0.1"},
+			{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "29000", "quantity": "0.1"},
+			{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "29000", "quantity": "0.1"},
+		}},
+		{"place": []gin.H{{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "29000"}}},
+	} {
+		w, _ = doJSON(r, "POST", "/orders/batch", body)
+		assert.Equal(t, 400, w.Code, body)
+	}
+
+	// 按交易对和方向撤销，条件单一并撤销
+	doJSON(r, "POST", "/orders", gin.H{"symbol": "ETH_USDT", "side": "buy", "type": "limit", "price": "1900", "quantity": "1"})
+	doJSON(r, "POST", "/orders", gin.H{"symbol": "ETH_USDT", "side": "buy", "type": "stop", "stop_price": "2100", "quantity": "1"})
+	require.Len(t, openOrders("alice"), 4)
+	w, resp = doJSON(r, "POST", "/orders/cancel-all", gin.H{"symbol": "eth_usdt", "side": "buy"})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	assert.Equal(t, float64(2), resp["data"].(map[string]interface{})["canceled_orders"])
+	require.Len(t, openOrders("alice"), 2)
+	w, resp = doJSON(r, "POST", "/orders/cancel-all", gin.H{})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	assert.Equal(t, float64(2), resp["data"].(map[string]interface{})["canceled_orders"])
+	assert.Len(t, openOrders("alice"), 0)
+	w, _ = doJSON(r, "POST", "/orders/cancel-all", gin.H{"side": "long"})
+	assert.Equal(t, 400, w.Code)
+
+	// 撤单倒计时：关闭后不撤单，到期未刷新时只撤销本用户的挂单
+	for _, body := range []gin.H{{}, {"timeout": -1}, {"timeout": 601}} {
+		w, _ = doJSON(r, "POST", "/orders/cancel-after", body)
+		assert.Equal(t, 400, w.Code, body)
+	}
+	doJSON(r, "POST", "/orders", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "29000", "quantity": "0.1"})
+	doJSONAs(r, "bob", "POST", "/orders", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "29000", "quantity": "0.1"})
+	w, resp = doJSON(r, "POST", "/orders/cancel-after", gin.H{"timeout": 1})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	assert.NotNil(t, resp["data"].(map[string]interface{})["cancel_time"])
+	w, resp = doJSON(r, "POST", "/orders/cancel-after", gin.H{"timeout": 0})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	assert.Nil(t, resp["data"].(map[string]interface{})["cancel_time"])
+	time.Sleep(1500 * time.Millisecond)
+	require.Len(t, openOrders("alice"), 1)
+
+	w, _ = doJSON(r, "POST", "/orders/cancel-after", gin.H{"timeout": 1})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	require.Eventually(t, func() bool { return len(openOrders("alice")) == 0 }, 3*time.Second, 20*time.Millisecond)
+	assert.Len(t, openOrders("bob"), 1)
+
+	w, resp = doJSON(r, "GET", "/reconcile", nil)
+	require.Equal(t, 200, w.Code)
+	assert.Equal(t, true, resp["data"].(map[string]interface{})["balanced"])
+}
+
+// 测试撤单倒计时保存在数据库中：服务停止期间到期的倒计时在重启后撤单，其他实例设置的倒计时由定期检查撤单
+func TestCancelAfterRestart(t *testing.T) {
+	db, dir := setupOrderDB(t), t.TempDir()
+	r, stop := startOrderRouter(t, db, dir)
+	createTestMarkets(t, r)
+
+	openOrders := func(r *gin.Engine, user string) int {
+		t.Helper()
+		w, resp := doJSONAs(r, user, "GET", "/orders/open", nil)
+		require.Equal(t, 200, w.Code)
+		return len(resp["data"].([]interface{}))
+	}
+	place := func(r *gin.Engine, user string) {
+		t.Helper()
+		w, _ := doJSONAs(r, user, "POST", "/orders", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "29000", "quantity": "0.1"})
+		require.Equal(t, 200, w.Code, w.Body.String())
+	}
+
+	place(r, "alice")
+	place(r, "bob")
+	w, _ := doJSON(r, "POST", "/orders/cancel-after", gin.H{"timeout": 1})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	w, _ = doJSONAs(r, "bob", "POST", "/orders/cancel-after", gin.H{"timeout": 600})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	stop()
+	time.Sleep(1200 * time.Millisecond)
+
+	// 停止期间到期的倒计时在重启后立即撤单，未到期的倒计时保留
+	r, _ = startOrderRouter(t, db, dir)
+	require.Eventually(t, func() bool { return openOrders(r, "alice") == 0 }, 2*time.Second, 20*time.Millisecond)
+	assert.Equal(t, 1, openOrders(r, "bob"))
+
+	// 其他实例设置的倒计时由定期检查在到期后撤单
+	place(r, "alice")
+	require.NoError(t, db.Create(&model.CancelDeadline{UserID: 1, Deadline: time.Now().UTC().Add(300 * time.Millisecond)}).Error)
+	require.Eventually(t, func() bool { return openOrders(r, "alice") == 0 }, 2*time.Second, 20*time.Millisecond)
+	assert.Equal(t, 1, openOrders(r, "bob"))
+	require.Eventually(t, func() bool {
+		var count int64
+		require.NoError(t, db.Model(&model.CancelDeadline{}).Count(&count).Error)
+		return count == 1
+	}, 2*time.Second, 20*time.Millisecond)
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"errors"
+
+	"awesome-trade/src/internal/middleware"
+	"awesome-trade/src/internal/service"
+	"awesome-trade/src/pkg/decimal"
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrderGroupLegRequest 订单组内的订单，参数与下单请求相同，交易对取订单组的交易对
+type OrderGroupLegRequest struct {
+	Side          string          `json:"side" binding:"required,oneof=buy sell"`
+	Type          string          `json:"type" binding:"required,oneof=limit market stop stop_limit take_profit take_profit_limit trailing_stop"`
+	TimeInForce   string          `json:"time_in_force" binding:"omitempty,oneof=gtc ioc fok post_only"`
+	Price         decimal.Decimal `json:"price" binding:"gte=0"`
+	Quantity      decimal.Decimal `json:"quantity" binding:"gte=0"`
+	ClientOrderID string          `json:"client_order_id" binding:"omitempty,max=64,printascii"`
+
+	StopPrice        decimal.Decimal `json:"stop_price" binding:"gte=0"`
+	TrailingDelta    decimal.Decimal `json:"trailing_delta" binding:"gte=0"`
+	TriggerPriceType string          `json:"trigger_price_type" binding:"omitempty,oneof=last mark"`
+}
+
+// CreateOrderGroupRequest 创建订单组请求，括号单须提供入场单，止盈止损订单的数量可省略
+type CreateOrderGroupRequest struct {
+	Type          string                `json:"type" binding:"required,oneof=oco bracket"`
+	Symbol        string                `json:"symbol" binding:"required,min=3,max=32"`
+	ClientGroupID string                `json:"client_group_id" binding:"omitempty,max=64,printascii"`
+	Entry         *OrderGroupLegRequest `json:"entry"`
+	TakeProfit    OrderGroupLegRequest  `json:"take_profit"`
+	StopLoss      OrderGroupLegRequest  `json:"stop_loss"`
+}
+
+// OrderGroupHandler 订单组处理器
+type OrderGroupHandler struct {
+	groups *service.OrderGroupService
+}
+
+// NewOrderGroupHandler 创建订单组处理器实例
+func NewOrderGroupHandler(groups *service.OrderGroupService) *OrderGroupHandler {
+	return &OrderGroupHandler{
+		groups: groups,
+	}
+}
+
+// Create 创建订单组，重复提交相同的客户端订单组ID返回已有订单组
+func (h *OrderGroupHandler) Create(c *gin.Context) {
+	var req CreateOrderGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	in := service.OrderGroupInput{
+		Type:          req.Type,
+		Symbol:        req.Symbol,
+		ClientGroupID: req.ClientGroupID,
+		TakeProfit:    req.TakeProfit.input(),
+		StopLoss:      req.StopLoss.input(),
+	}
+	if req.Entry != nil {
+		entry := req.Entry.input()
+		in.Entry = &entry
+	}
+	group, err := h.groups.Create(c.Request.Context(), middleware.GetUserID(c), in)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, group)
+}
+
+// Get 获取订单组及组内订单
+func (h *OrderGroupHandler) Get(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	group, err := h.groups.Get(c.Request.Context(), middleware.GetUserID(c), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, group)
+}
+
+// ListOpen 获取仍有未结束订单的订单组
+func (h *OrderGroupHandler) ListOpen(c *gin.Context) {
+	var req ListOpenOrdersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	groups, err := h.groups.ListOpen(c.Request.Context(), middleware.GetUserID(c), req.Symbol)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, groups)
+}
+
+// Cancel 撤销订单组内全部未结束的订单
+func (h *OrderGroupHandler) Cancel(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	group, err := h.groups.Cancel(c.Request.Context(), middleware.GetUserID(c), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, group)
+}
+
+// handleError 将服务层错误映射为HTTP响应
+func (h *OrderGroupHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrOrderGroupNotFound):
+		utils.NotFound(c, err.Error())
+	case errors.Is(err, service.ErrClientGroupIDConflict):
+		utils.Conflict(c, err.Error())
+	case errors.Is(err, service.ErrInvalidOrderGroup):
+		utils.BadRequest(c, err.Error())
+	default:
+		handleOrderError(c, err)
+	}
+}
+
+// input 转换为服务层的下单参数
+func (r OrderGroupLegRequest) input() service.PlaceOrderInput {
+	return service.PlaceOrderInput{
+		Side:          r.Side,
+		Type:          r.Type,
+		TimeInForce:   r.TimeInForce,
+		Price:         r.Price,
+		Quantity:      r.Quantity,
+		ClientOrderID: r.ClientOrderID,
+
+		StopPrice:        r.StopPrice,
+		TrailingDelta:    r.TrailingDelta,
+		TriggerPriceType: r.TriggerPriceType,
+	}
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试二选一订单组的部分成交调整数量、成交后撤销另一订单、止损触发后撤销限价单，
+// 以及括号单入场单成交后止盈止损订单按成交数量生效、按方向撤销全部挂单时跳过、撤销任一订单撤销整个订单组
+func TestOrderGroups(t *testing.T) {
+	r := setupOrderRouter(t)
+
+	place := func(user string, body gin.H) {
+		t.Helper()
+		w, _ := doJSONAs(r, user, "POST", "/orders", body)
+		require.Equal(t, 200, w.Code, w.Body.String())
+	}
+	create := func(body gin.H) map[string]interface{} {
+		t.Helper()
+		w, resp := doJSON(r, "POST", "/order-groups", body)
+		require.Equal(t, 200, w.Code, w.Body.String())
+		return resp["data"].(map[string]interface{})
+	}
+	legs := func(id interface{}) map[string]map[string]interface{} {
+		t.Helper()
+		w, resp := doJSON(r, "GET", fmt.Sprintf("/order-groups/%v", id), nil)
+		require.Equal(t, 200, w.Code, w.Body.String())
+		out := make(map[string]map[string]interface{})
+		for _, o := range resp["data"].(map[string]interface{})["orders"].([]interface{}) {
+			leg := o.(map[string]interface{})
+			out[leg["group_role"].(string)] = leg
+		}
+		return out
+	}
+
+	for _, body := range []gin.H{
+		{"type": "oco", "symbol": "BTC_USDT",
+			"take_profit": gin.H{"side": "sell", "type": "limit", "price": "31000", "quantity": "1"},
+			"stop_loss":   gin.H{"side": "sell", "type": "stop", "stop_price": "29000", "quantity": "0.5"}},
+		{"type": "oco", "symbol": "BTC_USDT",
+			"take_profit": gin.H{"side": "sell", "type": "stop", "stop_price": "31000", "quantity": "1"},
+			"stop_loss":   gin.H{"side": "sell", "type": "stop", "stop_price": "29000", "quantity": "1"}},
+		{"type": "bracket", "symbol": "BTC_USDT",
+			"take_profit": gin.H{"side": "sell", "type": "take_profit", "stop_price": "31000", "quantity": "1"},
+			"stop_loss":   gin.H{"side": "sell", "type": "stop", "stop_price": "29000", "quantity": "1"}},
+		{"type": "bracket", "symbol": "BTC_USDT",
+			"entry":       gin.H{"side": "buy", "type": "limit", "price": "30000", "quantity": "1"},
+			"take_profit": gin.H{"side": "buy", "type": "take_profit", "stop_price": "31000"},
+			"stop_loss":   gin.H{"side": "buy", "type": "stop", "stop_price": "29000"}},
+	} {
+		w, _ := doJSON(r, "POST", "/order-groups", body)
+		assert.Equal(t, 400, w.Code, body)
+	}
+
+	// 成交价30000
+	place("bob", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "limit", "price": "30000", "quantity": "0.1"})
+	place("alice", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "30000", "quantity": "0.1"})
+
+	// 二选一：限价止盈单冻结资金，止损单等待触发；相同客户端订单组ID返回同一订单组
+	oco := gin.H{"type": "oco", "symbol": "btc_usdt", "client_group_id": "oco-1",
+		"take_profit": gin.H{"side": "sell", "type": "limit", "price": "31000", "quantity": "1"},
+		"stop_loss":   gin.H{"side": "sell", "type": "stop", "stop_price": "29000", "quantity": "1"}}
+	group := create(oco)
+	assert.Equal(t, "BTC_USDT", group["symbol"])
+	assert.Len(t, group["orders"], 2)
+	assert.Equal(t, group["id"], create(oco)["id"])
+	oco["symbol"] = "ETH_USDT"
+	w, _ := doJSON(r, "POST", "/order-groups", oco)
+	assert.Equal(t, 409, w.Code)
+	assert.Equal(t, [2]string{"9.1", "1"}, balances(t, r, "alice")["BTC"])
+
+	// 限价单部分成交后止损单数量等于剩余数量，全部成交后止损单被撤销
+	place("bob", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "31000", "quantity": "0.4"})
+	got := legs(group["id"])
+	assert.Equal(t, "partially_filled", got["take_profit"]["status"])
+	assert.Equal(t, "0.6", got["stop_loss"]["quantity"])
+	assert.Equal(t, "untriggered", got["stop_loss"]["status"])
+	place("bob", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "31000", "quantity": "0.6"})
+	got = legs(group["id"])
+	assert.Equal(t, "filled", got["take_profit"]["status"])
+	assert.Equal(t, "canceled", got["stop_loss"]["status"])
+	w, _ = doJSON(r, "DELETE", fmt.Sprintf("/order-groups/%v", group["id"]), nil)
+	assert.Equal(t, 409, w.Code)
+	w, resp := doJSON(r, "GET", "/order-groups/open", nil)
+	require.Equal(t, 200, w.Code)
+	assert.Len(t, resp["data"], 0)
+
+	// 止损单触发时先撤销限价单，再以市价卖给bob在28000的买单
+	group = create(gin.H{"type": "oco", "symbol": "BTC_USDT",
+		"take_profit": gin.H{"side": "sell", "type": "limit", "price": "32000", "quantity": "0.5"},
+		"stop_loss":   gin.H{"side": "sell", "type": "stop", "stop_price": "29000", "quantity": "0.5"}})
+	place("bob", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "28000", "quantity": "0.5"})
+	place("bob", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "28500", "quantity": "0.01"})
+	place("alice", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "limit", "price": "28500", "quantity": "0.01"})
+	require.Eventually(t, func() bool { return legs(group["id"])["stop_loss"]["child_order_id"] != nil }, 2*time.Second, 10*time.Millisecond)
+	got = legs(group["id"])
+	assert.Equal(t, "canceled", got["take_profit"]["status"])
+	assert.Equal(t, "triggered", got["stop_loss"]["status"])
+	w, resp = doJSON(r, "GET", fmt.Sprintf("/orders/%v", got["stop_loss"]["child_order_id"]), nil)
+	require.Equal(t, 200, w.Code)
+	assert.Equal(t, "0.5", resp["data"].(map[string]interface{})["quantity"])
+	require.Eventually(t, func() bool {
+		_, resp := doJSON(r, "GET", fmt.Sprintf("/orders/%v", got["stop_loss"]["child_order_id"]), nil)
+		return resp["data"].(map[string]interface{})["status"] == "filled"
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// 括号单：止盈止损订单数量默认取入场单数量，入场单有成交后按成交数量生效
+	group = create(gin.H{"type": "bracket", "symbol": "BTC_USDT",
+		"entry":       gin.H{"side": "buy", "type": "limit", "price": "28000", "quantity": "0.2"},
+		"take_profit": gin.H{"side": "sell", "type": "take_profit", "stop_price": "35000"},
+		"stop_loss":   gin.H{"side": "sell", "type": "stop", "stop_price": "25000"}})
+	got = legs(group["id"])
+	assert.Equal(t, "new", got["entry"]["status"])
+	assert.Equal(t, "pending", got["take_profit"]["status"])
+	assert.Equal(t, "0.2", got["stop_loss"]["quantity"])
+	place("bob", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "limit", "price": "28000", "quantity": "0.1"})
+	got = legs(group["id"])
+	assert.Equal(t, "partially_filled", got["entry"]["status"])
+	assert.Equal(t, "untriggered", got["take_profit"]["status"])
+	assert.Equal(t, "0.1", got["take_profit"]["quantity"])
+	assert.Equal(t, "0.1", got["stop_loss"]["quantity"])
+
+	w, resp = doJSON(r, "GET", "/order-groups/open?symbol=btc_usdt", nil)
+	require.Equal(t, 200, w.Code)
+	assert.Len(t, resp["data"], 1)
+
+	// 按方向撤销全部挂单时，组内还有另一方向订单的括号单整体跳过
+	for _, side := range []string{"sell", "buy"} {
+		w, resp = doJSON(r, "POST", "/orders/cancel-all", gin.H{"side": side})
+		require.Equal(t, 200, w.Code, w.Body.String())
+		assert.Equal(t, float64(0), resp["data"].(map[string]interface{})["canceled_orders"], side)
+	}
+	got = legs(group["id"])
+	assert.Equal(t, "partially_filled", got["entry"]["status"])
+	assert.Equal(t, "untriggered", got["take_profit"]["status"])
+	assert.Equal(t, "untriggered", got["stop_loss"]["status"])
+
+	// 撤销组内任一订单撤销整个订单组
+	w, _ = doJSON(r, "DELETE", fmt.Sprintf("/orders/%v", got["stop_loss"]["id"]), nil)
+	require.Equal(t, 200, w.Code, w.Body.String())
+	got = legs(group["id"])
+	for _, role := range []string{"entry", "take_profit", "stop_loss"} {
+		assert.Equal(t, "canceled", got[role]["status"], role)
+	}
+	w, resp = doJSON(r, "GET", "/orders/open", nil)
+	require.Equal(t, 200, w.Code)
+	assert.Len(t, resp["data"], 0)
+
+	w, resp = doJSON(r, "GET", "/reconcile", nil)
+	require.Equal(t, 200, w.Code)
+	assert.Equal(t, true, resp["data"].(map[string]interface{})["balanced"])
+}
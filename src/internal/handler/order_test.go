@@ -0,0 +1,723 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"awesome-trade/src/internal/config"
+	"awesome-trade/src/internal/database"
+	"awesome-trade/src/internal/middleware"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/internal/sequencer"
+	"awesome-trade/src/internal/service"
+	"awesome-trade/src/internal/stream"
+	"awesome-trade/src/pkg/decimal"
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// 设置订单测试路由，X-User请求头模拟已认证用户
+func setupOrderRouter(t *testing.T) *gin.Engine {
+	r, _ := startOrderRouter(t, setupOrderDB(t), t.TempDir())
+	createTestMarkets(t, r)
+	return r
+}
+
+// setupOrderDB 创建订单测试数据库，alice和bob各有BTC、ETH和USDT余额
+func setupOrderDB(t *testing.T) *gorm.DB {
+	db, err := database.Open(config.DatabaseConfig{Driver: database.DriverSQLite, Path: ":memory:"})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.User{}, &model.Order{}, &model.Account{}, &model.LedgerEntry{}, &model.LedgerPosting{}, &model.Market{}, &model.Kline{}, &model.RiskLimit{},
+		&model.FeeTier{}, &model.FeeOverride{}, &model.FeePromotion{}, &model.UserFeeTier{}, &model.Fill{}, &model.OrderGroup{}, &model.CancelDeadline{}, &model.SettlementOffset{},
+		&model.SettlementFailure{}))
+	ledger := service.NewLedgerService(database.NewTxManager(db), repository.NewLedgerRepository(db), testScales)
+	for _, name := range []string{"alice", "bob"} {
+		user := &model.User{Username: name, Email: name + "@example.com", Password: "x"}
+		require.NoError(t, db.Create(user).Error)
+		for asset, amount := range map[string]string{"BTC": "10", "ETH": "10", "USDT": "100000"} {
+			_, err := ledger.Deposit(context.Background(), user.ID, asset, decimal.RequireFromString(amount), fmt.Sprintf("test:%s:%s", name, asset))
+			require.NoError(t, err)
+		}
+	}
+	return db
+}
+
+// testScales 订单测试使用的资产小数位数
+var testScales = decimal.NewScales(map[string]int32{"BTC": 8, "ETH": 8, "USDT": 6})
+
+// createTestMarkets 上架BTC_USDT和ETH_USDT交易对
+func createTestMarkets(t *testing.T, r *gin.Engine) {
+	for _, base := range []string{"BTC", "ETH"} {
+		w, _ := doJSON(r, "POST", "/markets", gin.H{"base_asset": base, "quote_asset": "USDT",
+			"tick_size": "0.01", "step_size": "0.001", "min_notional": "1", "status": "trading"})
+		require.Equal(t, 200, w.Code, w.Body.String())
+	}
+}
+
+// startOrderRouter 在已有数据库和撮合日志目录上创建服务并加载条件单，模拟一次服务启动，返回停止该实例的函数
+func startOrderRouter(t *testing.T, db *gorm.DB, dir string) (*gin.Engine, func()) {
+	gin.SetMode(gin.TestMode)
+
+	txManager := database.NewTxManager(db)
+	ledger := service.NewLedgerService(txManager, repository.NewLedgerRepository(db), testScales)
+	engine := sequencer.NewManager(sequencer.Options{Dir: dir})
+	markets := repository.NewMarketRepository(db)
+	marketDataService := service.NewMarketDataService(markets, repository.NewKlineRepository(db), engine, config.MarketDataConfig{})
+	riskService := service.NewRiskService(repository.NewRiskLimitRepository(db), repository.NewUserRepository(db), markets,
+		repository.NewOrderRepository(db), repository.NewLedgerRepository(db), marketDataService, config.RiskConfig{LossAsset: "USDT"})
+	feeService := service.NewFeeService(txManager, repository.NewFeeRepository(db), repository.NewFillRepository(db), markets,
+		marketDataService, config.FeeConfig{VolumeAsset: "USDT"})
+	orderService := service.NewOrderService(txManager, repository.NewOrderRepository(db), markets, ledger, riskService, feeService, marketDataService, engine, config.OrderConfig{MaxBatchSize: 5})
+	orderGroupService := service.NewOrderGroupService(txManager, repository.NewOrderGroupRepository(db), orderService)
+	marketService := service.NewMarketService(txManager, markets, orderService)
+	hub := stream.NewHub(64, 256)
+	service.NewStreamService(hub, repository.NewOrderRepository(db), ledger, marketDataService, engine)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			cancel()
+			engine.Close()
+		})
+	}
+	t.Cleanup(stop)
+	require.NoError(t, engine.Start())
+	require.NoError(t, orderService.LoadTriggers(context.Background()))
+	go orderService.RunTriggers(ctx)
+	require.NoError(t, orderService.LoadCancelAfter(context.Background()))
+	go orderService.RunCancelAfter(ctx, 100*time.Millisecond)
+	h := NewOrderHandler(orderService)
+	lh := NewLedgerHandler(ledger)
+	mh := NewMarketHandler(marketService)
+	mdh := NewMarketDataHandler(marketDataService)
+	sh := NewStreamHandler(hub, nil, config.StreamConfig{})
+	rh := NewRiskHandler(riskService)
+	fh := NewFeeHandler(feeService)
+	gh := NewOrderGroupHandler(orderGroupService)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		var id uint = 1
+		if c.GetHeader("X-User") == "bob" {
+			id = 2
+		}
+		c.Set(middleware.ContextUserID, id)
+	})
+	orders := r.Group("/orders")
+	orders.POST("", h.Place)
+	orders.POST("/batch", h.Batch)
+	orders.POST("/cancel-all", h.CancelAll)
+	orders.POST("/cancel-after", h.CancelAfter)
+	orders.GET("/open", h.ListOpen)
+	orders.GET("/history", h.ListHistory)
+	orders.GET("/:id", h.Get)
+	orders.DELETE("/:id", h.Cancel)
+	groups := r.Group("/order-groups")
+	groups.POST("", gh.Create)
+	groups.GET("/open", gh.ListOpen)
+	groups.GET("/:id", gh.Get)
+	groups.DELETE("/:id", gh.Cancel)
+	r.GET("/admin/orders/:id", h.AdminGet)
+	r.GET("/admin/users/:id/orders/open", h.AdminListOpen)
+	r.DELETE("/admin/orders/:id", h.AdminCancel)
+	r.GET("/balances", lh.Balances)
+	r.GET("/reconcile", lh.Reconcile)
+	r.GET("/markets/:symbol", mh.Get)
+	r.POST("/markets", mh.Create)
+	r.POST("/markets/:symbol/status", mh.SetStatus)
+	r.GET("/market/:symbol/ticker", mdh.Ticker)
+	r.GET("/market/:symbol/depth", mdh.Depth)
+	r.GET("/market/:symbol/trades", mdh.Trades)
+	r.GET("/market/:symbol/klines", mdh.Klines)
+	r.GET("/ws", sh.WebSocket)
+	r.GET("/stream", sh.SSE)
+	r.GET("/risk/limits", rh.ListLimits)
+	r.PUT("/risk/limits", rh.SetLimit)
+	r.DELETE("/risk/limits", rh.DeleteLimit)
+	r.PUT("/users/:id/tier", rh.SetUserTier)
+	r.GET("/fees", fh.Summary)
+	r.PUT("/fees/tiers", fh.SetTier)
+	r.DELETE("/fees/tiers", fh.DeleteTier)
+	r.PUT("/fees/overrides", fh.SetOverride)
+	r.POST("/fees/promotions", fh.CreatePromotion)
+	r.POST("/fees/recompute", fh.Recompute)
+	return r, stop
+}
+
+// 测试下单、幂等、查询与撤单
+func TestOrderLifecycle(t *testing.T) {
+	r := setupOrderRouter(t)
+
+	place := gin.H{
+		"symbol":          "btc_usdt",
+		"side":            "buy",
+		"type":            "limit",
+		"price":           "30000.5",
+		"quantity":        "0.125",
+		"client_order_id": "my-order-1",
+	}
+	w, resp := doJSON(r, "POST", "/orders", place)
+	require.Equal(t, 200, w.Code, w.Body.String())
+	data := resp["data"].(map[string]interface{})
+	assert.Equal(t, "BTC_USDT", data["symbol"])
+	assert.Equal(t, "gtc", data["time_in_force"])
+	assert.Equal(t, "new", data["status"])
+	assert.Equal(t, "30000.5", data["price"])
+	assert.Equal(t, "0", data["filled_quantity"])
+	id := data["id"]
+
+	// 相同客户端订单ID重复提交返回同一订单
+	w, resp = doJSON(r, "POST", "/orders", place)
+	require.Equal(t, 200, w.Code)
+	assert.Equal(t, id, resp["data"].(map[string]interface{})["id"])
+
+	// 客户端订单ID相同但参数不同
+	place["quantity"] = "1"
+	w, _ = doJSON(r, "POST", "/orders", place)
+	assert.Equal(t, 409, w.Code)
+
+	w, resp = doJSON(r, "GET", "/orders/open?symbol=BTC_USDT", nil)
+	require.Equal(t, 200, w.Code)
+	assert.Len(t, resp["data"], 1)
+
+	// 其他用户无法查看或撤销
+	path := fmt.Sprintf("/orders/%v", id)
+	for _, method := range []string{"GET", "DELETE"} {
+		w, _ = doJSONAs(r, "bob", method, path, nil)
+		assert.Equal(t, 404, w.Code)
+	}
+
+	w, _ = doJSON(r, "DELETE", path, nil)
+	require.Equal(t, 200, w.Code)
+	w, _ = doJSON(r, "DELETE", path, nil)
+	assert.Equal(t, 409, w.Code)
+
+	w, resp = doJSON(r, "GET", "/orders/history", nil)
+	require.Equal(t, 200, w.Code)
+	history := resp["data"].(map[string]interface{})
+	assert.Len(t, history["list"], 1)
+	assert.Equal(t, float64(0), history["next_cursor"])
+
+	w, resp = doJSON(r, "GET", "/orders/open", nil)
+	require.Equal(t, 200, w.Code)
+	assert.Len(t, resp["data"], 0)
+}
+
+// 测试管理员查看和撤销任意用户的订单
+func TestAdminOrders(t *testing.T) {
+	r := setupOrderRouter(t)
+
+	w, resp := doJSON(r, "POST", "/orders", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "29000", "quantity": "0.1"})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	path := fmt.Sprintf("/admin/orders/%v", resp["data"].(map[string]interface{})["id"])
+
+	w, resp = doJSONAs(r, "bob", "GET", path, nil)
+	require.Equal(t, 200, w.Code)
+	assert.Equal(t, float64(1), resp["data"].(map[string]interface{})["user_id"])
+	w, resp = doJSONAs(r, "bob", "GET", "/admin/users/1/orders/open?symbol=btc_usdt", nil)
+	require.Equal(t, 200, w.Code)
+	assert.Len(t, resp["data"], 1)
+
+	w, resp = doJSONAs(r, "bob", "DELETE", path, nil)
+	require.Equal(t, 200, w.Code, w.Body.String())
+	assert.Equal(t, "canceled", resp["data"].(map[string]interface{})["status"])
+	w, _ = doJSONAs(r, "bob", "DELETE", path, nil)
+	assert.Equal(t, 409, w.Code)
+	w, _ = doJSONAs(r, "bob", "GET", "/admin/orders/999", nil)
+	assert.Equal(t, 404, w.Code)
+	assert.Equal(t, [2]string{"100000", "0"}, balances(t, r, "alice")["USDT"])
+}
+
+// 测试下单参数校验和订单归属
+func TestOrderValidation(t *testing.T) {
+	r := setupOrderRouter(t)
+
+	cases := []gin.H{
+		{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "quantity": "1"},
+		{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "-1", "quantity": "1"},
+		{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "1", "quantity": "0"},
+		{"symbol": "BTC_USDT", "side": "buy", "type": "market", "price": "1", "quantity": "1"},
+		{"symbol": "BTC_USDT", "side": "buy", "type": "market", "quantity": "1", "time_in_force": "gtc"},
+		{"symbol": "BTC_USDT", "side": "hold", "type": "limit", "price": "1", "quantity": "1"},
+		{"symbol": "BTC_USDT", "side": "buy", "type": "stop", "price": "1", "quantity": "1"},
+		{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "1.2.3", "quantity": "1"},
+		{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "1", "quantity": "1e30"},
+	}
+	for _, body := range cases {
+		w, _ := doJSON(r, "POST", "/orders", body)
+		assert.Equal(t, 400, w.Code, "%v", body)
+	}
+
+	// Decimal字段的binding标签在绑定时生效
+	w, resp := doJSON(r, "POST", "/orders", cases[1])
+	require.Equal(t, 400, w.Code)
+	assert.Contains(t, resp["message"], "'gte' tag")
+
+	w, resp = doJSON(r, "POST", "/orders", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "market", "quantity": "2"})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	data := resp["data"].(map[string]interface{})
+	assert.Equal(t, "ioc", data["time_in_force"])
+	assert.NotEmpty(t, data["client_order_id"])
+
+	w, _ = doJSON(r, "GET", fmt.Sprintf("/orders/%v", data["id"]), nil)
+	assert.Equal(t, 200, w.Code)
+
+	w, _ = doJSON(r, "GET", "/orders/999", nil)
+	assert.Equal(t, 404, w.Code)
+}
+
+// 测试订单经定序器撮合后更新成交数量和状态
+func TestOrderMatching(t *testing.T) {
+	r := setupOrderRouter(t)
+
+	w, resp := doJSON(r, "POST", "/orders", gin.H{"symbol": "ETH_USDT", "side": "sell", "type": "limit", "price": "2000", "quantity": "1"})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	sell := resp["data"].(map[string]interface{})
+	assert.Equal(t, "new", sell["status"])
+
+	w, resp = doJSONAs(r, "bob", "POST", "/orders", gin.H{"symbol": "ETH_USDT", "side": "buy", "type": "limit", "price": "2001", "quantity": "0.4"})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	data := resp["data"].(map[string]interface{})
+	assert.Equal(t, "filled", data["status"])
+	assert.Equal(t, "0.4", data["filled_quantity"])
+
+	w, resp = doJSON(r, "GET", fmt.Sprintf("/orders/%v", sell["id"]), nil)
+	require.Equal(t, 200, w.Code)
+	data = resp["data"].(map[string]interface{})
+	assert.Equal(t, "partially_filled", data["status"])
+	assert.Equal(t, "0.4", data["filled_quantity"])
+
+	// bob以2001的限价买入，按2000成交，差价随成交解冻
+	assert.Equal(t, map[string][2]string{
+		"BTC":  {"10", "0"},
+		"ETH":  {"10.4", "0"},
+		"USDT": {"99200", "0"},
+	}, balances(t, r, "bob"))
+	assert.Equal(t, map[string][2]string{
+		"BTC":  {"10", "0"},
+		"ETH":  {"9", "0.6"},
+		"USDT": {"100800", "0"},
+	}, balances(t, r, "alice"))
+
+	// 市价单冻结全部可用资金，吃掉剩余挂单后未成交部分撤销
+	w, resp = doJSONAs(r, "bob", "POST", "/orders", gin.H{"symbol": "ETH_USDT", "side": "buy", "type": "market", "quantity": "1"})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	data = resp["data"].(map[string]interface{})
+	assert.Equal(t, "canceled", data["status"])
+	assert.Equal(t, "0.6", data["filled_quantity"])
+
+	w, resp = doJSON(r, "GET", fmt.Sprintf("/orders/%v", sell["id"]), nil)
+	require.Equal(t, 200, w.Code)
+	assert.Equal(t, "filled", resp["data"].(map[string]interface{})["status"])
+
+	// alice卖出1 ETH收入2000 USDT，bob市价买单未用完的冻结资金已解冻
+	assert.Equal(t, map[string][2]string{
+		"BTC":  {"10", "0"},
+		"ETH":  {"9", "0"},
+		"USDT": {"102000", "0"},
+	}, balances(t, r, "alice"))
+	assert.Equal(t, map[string][2]string{
+		"BTC":  {"10", "0"},
+		"ETH":  {"11", "0"},
+		"USDT": {"98000", "0"},
+	}, balances(t, r, "bob"))
+
+	// 市价买单的成交额不超过冻结的可用资金：98000 USDT在30000只够买入3.266（按数量步长取整）
+	doJSON(r, "POST", "/orders", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "limit", "price": "30000", "quantity": "4"})
+	w, resp = doJSONAs(r, "bob", "POST", "/orders", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "market", "quantity": "4"})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	data = resp["data"].(map[string]interface{})
+	assert.Equal(t, "canceled", data["status"])
+	assert.Equal(t, "3.266", data["filled_quantity"])
+	assert.Equal(t, map[string][2]string{
+		"BTC":  {"13.266", "0"},
+		"ETH":  {"11", "0"},
+		"USDT": {"20", "0"},
+	}, balances(t, r, "bob"))
+	w, resp = doJSONAs(r, "bob", "POST", "/orders", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "market", "quantity": "1"})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	assert.Equal(t, "0", resp["data"].(map[string]interface{})["filled_quantity"])
+	assert.Equal(t, [2]string{"20", "0"}, balances(t, r, "bob")["USDT"])
+
+	w, resp = doJSON(r, "GET", "/reconcile", nil)
+	require.Equal(t, 200, w.Code)
+	assert.Equal(t, true, resp["data"].(map[string]interface{})["balanced"])
+
+	// 精度超出引擎支持范围
+	w, _ = doJSON(r, "POST", "/orders", gin.H{"symbol": "ETH_USDT", "side": "buy", "type": "limit", "price": "1.123456789", "quantity": "1"})
+	assert.Equal(t, 400, w.Code)
+	w, _ = doJSON(r, "POST", "/orders", gin.H{"symbol": "ETH/USDT", "side": "buy", "type": "limit", "price": "1", "quantity": "1"})
+	assert.Equal(t, 400, w.Code)
+}
+
+// 测试余额不足时拒绝下单，撤单解冻资金
+func TestOrderHoldAndRelease(t *testing.T) {
+	r := setupOrderRouter(t)
+
+	w, _ := doJSON(r, "POST", "/orders", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "50000", "quantity": "3"})
+	assert.Equal(t, 422, w.Code)
+	w, _ = doJSON(r, "POST", "/orders", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "limit", "price": "50000", "quantity": "11"})
+	assert.Equal(t, 422, w.Code)
+
+	w, resp := doJSON(r, "POST", "/orders", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "40000", "quantity": "2"})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	data := resp["data"].(map[string]interface{})
+	assert.Equal(t, "80000", data["held_amount"])
+	assert.Equal(t, [2]string{"20000", "80000"}, balances(t, r, "alice")["USDT"])
+
+	w, resp = doJSON(r, "DELETE", fmt.Sprintf("/orders/%v", data["id"]), nil)
+	require.Equal(t, 200, w.Code)
+	assert.Equal(t, "0", resp["data"].(map[string]interface{})["held_amount"])
+	assert.Equal(t, [2]string{"100000", "0"}, balances(t, r, "alice")["USDT"])
+
+	w, resp = doJSON(r, "GET", "/reconcile", nil)
+	require.Equal(t, 200, w.Code)
+	assert.Equal(t, true, resp["data"].(map[string]interface{})["balanced"])
+}
+
+// 测试交易对规则校验、错误码以及暂停和下架对挂单的处理
+func TestMarketRulesAndHalt(t *testing.T) {
+	r := setupOrderRouter(t)
+
+	cases := []struct {
+		body gin.H
+		code int
+	}{
+		{gin.H{"symbol": "SOL_USDT", "side": "buy", "type": "limit", "price": "10", "quantity": "1"}, utils.CodeMarketNotFound},
+		{gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "100.005", "quantity": "1"}, utils.CodePriceTick},
+		{gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "100", "quantity": "0.0015"}, utils.CodeQuantityStep},
+		{gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "market", "quantity": "0.0001"}, utils.CodeQuantityStep},
+		{gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "0.5", "quantity": "1"}, utils.CodeMinNotional},
+	}
+	for _, tc := range cases {
+		w, resp := doJSON(r, "POST", "/orders", tc.body)
+		assert.Equal(t, 400, w.Code, "%v", tc.body)
+		assert.Equal(t, float64(tc.code), resp["code"], "%v", tc.body)
+	}
+
+	// 暂停但保留挂单：拒绝新订单，挂单冻结在订单簿中，用户仍可撤单
+	w, resp := doJSON(r, "POST", "/orders", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "40000", "quantity": "1"})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	first := resp["data"].(map[string]interface{})["id"]
+	w, resp = doJSON(r, "POST", "/orders", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "39000", "quantity": "1"})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	second := resp["data"].(map[string]interface{})["id"]
+
+	w, resp = doJSON(r, "POST", "/markets/btc_usdt/status", gin.H{"status": "halted"})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	assert.Equal(t, float64(0), resp["data"].(map[string]interface{})["canceled_orders"])
+	w, resp = doJSONAs(r, "bob", "POST", "/orders", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "limit", "price": "40000", "quantity": "1"})
+	assert.Equal(t, 422, w.Code)
+	assert.Equal(t, float64(utils.CodeMarketNotTrading), resp["code"])
+	w, _ = doJSON(r, "DELETE", fmt.Sprintf("/orders/%v", first), nil)
+	require.Equal(t, 200, w.Code)
+
+	// 下架撤销剩余挂单并解冻资金，下架后不可恢复
+	w, _ = doJSON(r, "POST", "/markets/BTC_USDT/status", gin.H{"status": "trading"})
+	require.Equal(t, 200, w.Code)
+	w, resp = doJSON(r, "POST", "/markets/BTC_USDT/status", gin.H{"status": "delisted"})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	assert.Equal(t, float64(1), resp["data"].(map[string]interface{})["canceled_orders"])
+	w, resp = doJSON(r, "GET", fmt.Sprintf("/orders/%v", second), nil)
+	require.Equal(t, 200, w.Code)
+	assert.Equal(t, "canceled", resp["data"].(map[string]interface{})["status"])
+	assert.Equal(t, [2]string{"100000", "0"}, balances(t, r, "alice")["USDT"])
+	w, _ = doJSON(r, "POST", "/markets/BTC_USDT/status", gin.H{"status": "trading"})
+	assert.Equal(t, 409, w.Code)
+
+	// 重复上架与非法规则
+	w, _ = doJSON(r, "POST", "/markets", gin.H{"base_asset": "eth", "quote_asset": "usdt", "tick_size": "0.01", "step_size": "0.001"})
+	assert.Equal(t, 409, w.Code)
+	w, _ = doJSON(r, "POST", "/markets", gin.H{"base_asset": "SOL", "quote_asset": "USDT", "tick_size": "0", "step_size": "0.001"})
+	assert.Equal(t, 400, w.Code)
+	w, resp = doJSON(r, "POST", "/markets", gin.H{"base_asset": "sol", "quote_asset": "usdt", "tick_size": "0.001", "step_size": "0.01", "taker_fee_rate": "0.001"})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	data := resp["data"].(map[string]interface{})
+	assert.Equal(t, "SOL_USDT", data["symbol"])
+	assert.Equal(t, "pre_open", data["status"])
+}
+
+// 测试行情接口由撮合成交驱动，深度快照携带定序器序号
+func TestMarketDataEndpoints(t *testing.T) {
+	r := setupOrderRouter(t)
+
+	for _, o := range []struct {
+		user string
+		body gin.H
+	}{
+		{"bob", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "limit", "price": "40100", "quantity": "1"}},
+		{"bob", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "limit", "price": "40000", "quantity": "0.5"}},
+		{"alice", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "39000", "quantity": "1"}},
+		{"alice", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "40100", "quantity": "0.6"}},
+	} {
+		w, _ := doJSONAs(r, o.user, "POST", "/orders", o.body)
+		require.Equal(t, 200, w.Code, w.Body.String())
+	}
+
+	w, resp := doJSON(r, "GET", "/market/btc_usdt/depth?limit=5", nil)
+	require.Equal(t, 200, w.Code, w.Body.String())
+	depth := resp["data"].(map[string]interface{})
+	assert.Equal(t, float64(4), depth["seq"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"price": "39000", "quantity": "1"}}, depth["bids"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"price": "40100", "quantity": "0.9"}}, depth["asks"])
+
+	w, resp = doJSON(r, "GET", "/market/BTC_USDT/trades", nil)
+	require.Equal(t, 200, w.Code, w.Body.String())
+	trades := resp["data"].([]interface{})
+	require.Len(t, trades, 2)
+	assert.Equal(t, "40100", trades[0].(map[string]interface{})["price"])
+	assert.Equal(t, "buy", trades[0].(map[string]interface{})["side"])
+
+	w, resp = doJSON(r, "GET", "/market/BTC_USDT/ticker", nil)
+	require.Equal(t, 200, w.Code, w.Body.String())
+	ticker := resp["data"].(map[string]interface{})
+	assert.Equal(t, "40100", ticker["last_price"])
+	assert.Equal(t, "0.6", ticker["volume"])
+	assert.Equal(t, "39000", ticker["best_bid"])
+	assert.Equal(t, "40100", ticker["best_ask"])
+
+	w, resp = doJSON(r, "GET", "/market/BTC_USDT/klines?interval=1m", nil)
+	require.Equal(t, 200, w.Code, w.Body.String())
+	klines := resp["data"].([]interface{})
+	require.NotEmpty(t, klines)
+	assert.Equal(t, "40000", klines[0].(map[string]interface{})["open"])
+
+	w, _ = doJSON(r, "GET", "/market/BTC_USDT/klines?interval=2m", nil)
+	assert.Equal(t, 400, w.Code)
+	w, resp = doJSON(r, "GET", "/market/SOL_USDT/ticker", nil)
+	assert.Equal(t, 404, w.Code)
+	assert.Equal(t, float64(utils.CodeMarketNotFound), resp["code"])
+}
+
+// 测试条件单的校验、撤销、跟踪止损触发价的移动，以及成交价穿过触发价后提交子订单
+func TestConditionalOrders(t *testing.T) {
+	r := setupOrderRouter(t)
+
+	place := func(user string, body gin.H) map[string]interface{} {
+		t.Helper()
+		w, resp := doJSONAs(r, user, "POST", "/orders", body)
+		require.Equal(t, 200, w.Code, w.Body.String())
+		return resp["data"].(map[string]interface{})
+	}
+	get := func(id interface{}) map[string]interface{} {
+		t.Helper()
+		w, resp := doJSON(r, "GET", fmt.Sprintf("/orders/%v", id), nil)
+		require.Equal(t, 200, w.Code, w.Body.String())
+		return resp["data"].(map[string]interface{})
+	}
+
+	for _, body := range []gin.H{
+		{"symbol": "BTC_USDT", "side": "sell", "type": "stop", "quantity": "0.1"},
+		{"symbol": "BTC_USDT", "side": "sell", "type": "stop", "stop_price": "29000.001", "quantity": "0.1"},
+		{"symbol": "BTC_USDT", "side": "sell", "type": "trailing_stop", "stop_price": "29000", "trailing_delta": "100", "quantity": "0.1"},
+		{"symbol": "BTC_USDT", "side": "sell", "type": "limit", "price": "30000", "stop_price": "29000", "quantity": "0.1"},
+		{"symbol": "BTC_USDT", "side": "sell", "type": "stop_limit", "stop_price": "29000", "quantity": "0.1"},
+		{"symbol": "BTC_USDT", "side": "sell", "type": "stop", "stop_price": "29000", "quantity": "0.1", "client_order_id": "trigger-1"},
+	} {
+		w, _ := doJSON(r, "POST", "/orders", body)
+		assert.Equal(t, 400, w.Code, body)
+	}
+
+	// 成交价30000
+	place("bob", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "limit", "price": "30000", "quantity": "0.1"})
+	place("alice", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "30000", "quantity": "0.1"})
+
+	// 条件单等待触发时不冻结资金，可以撤销
+	stop := place("alice", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "stop", "stop_price": "29000", "quantity": "0.1"})
+	assert.Equal(t, "untriggered", stop["status"])
+	assert.Equal(t, "ioc", stop["time_in_force"])
+	assert.Equal(t, "last", stop["trigger_price_type"])
+	assert.Equal(t, "0", stop["held_amount"])
+	tp := place("alice", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "take_profit_limit", "stop_price": "31000", "price": "31000", "quantity": "0.1"})
+	w, resp := doJSON(r, "DELETE", fmt.Sprintf("/orders/%v", tp["id"]), nil)
+	require.Equal(t, 200, w.Code, w.Body.String())
+	assert.Equal(t, "canceled", resp["data"].(map[string]interface{})["status"])
+
+	// 跟踪止损卖单的触发价为最新成交价减回撤价差
+	trailing := place("alice", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "trailing_stop", "trailing_delta": "1000", "quantity": "0.1", "trigger_price_type": "mark"})
+	require.Eventually(t, func() bool { return get(trailing["id"])["stop_price"] == "29000" }, 2*time.Second, 10*time.Millisecond)
+
+	w, resp = doJSON(r, "GET", "/orders/open", nil)
+	require.Equal(t, 200, w.Code)
+	assert.Len(t, resp["data"], 2)
+	assert.Equal(t, [2]string{"10.1", "0"}, balances(t, r, "alice")["BTC"])
+
+	// 成交价跌至28500，两个条件单触发并以市价卖给bob在28000的买单
+	place("bob", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "28000", "quantity": "0.3"})
+	place("bob", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "28500", "quantity": "0.01"})
+	place("alice", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "limit", "price": "28500", "quantity": "0.01"})
+
+	for _, id := range []interface{}{stop["id"], trailing["id"]} {
+		require.Eventually(t, func() bool { return get(id)["child_order_id"] != nil }, 2*time.Second, 10*time.Millisecond)
+		parent := get(id)
+		assert.Equal(t, "triggered", parent["status"])
+		assert.NotNil(t, parent["triggered_at"])
+		require.Eventually(t, func() bool { return get(parent["child_order_id"])["status"] == "filled" }, 2*time.Second, 10*time.Millisecond)
+		child := get(parent["child_order_id"])
+		assert.Equal(t, "market", child["type"])
+		assert.Equal(t, id, child["parent_order_id"])
+		assert.Equal(t, fmt.Sprintf("trigger-%v", id), child["client_order_id"])
+	}
+	assert.Equal(t, [2]string{"9.89", "0"}, balances(t, r, "alice")["BTC"])
+
+	w, resp = doJSON(r, "GET", "/orders/history", nil)
+	require.Equal(t, 200, w.Code)
+	assert.Len(t, resp["data"].(map[string]interface{})["list"], 7)
+}
+
+// 测试重启后从数据库恢复等待触发的条件单，为已触发但尚未提交子订单的条件单补交子订单，
+// 并提交已落库但未送达定序器的子订单
+func TestConditionalOrdersRestart(t *testing.T) {
+	db, dir := setupOrderDB(t), t.TempDir()
+	r, stop := startOrderRouter(t, db, dir)
+	createTestMarkets(t, r)
+
+	place := func(user string, body gin.H) map[string]interface{} {
+		t.Helper()
+		w, resp := doJSONAs(r, user, "POST", "/orders", body)
+		require.Equal(t, 200, w.Code, w.Body.String())
+		return resp["data"].(map[string]interface{})
+	}
+	get := func(id interface{}) map[string]interface{} {
+		t.Helper()
+		w, resp := doJSON(r, "GET", fmt.Sprintf("/orders/%v", id), nil)
+		require.Equal(t, 200, w.Code, w.Body.String())
+		return resp["data"].(map[string]interface{})
+	}
+
+	// 成交价30000，bob在28000挂买单
+	place("bob", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "limit", "price": "30000", "quantity": "0.1"})
+	place("alice", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "30000", "quantity": "0.1"})
+	place("bob", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "28000", "quantity": "0.3"})
+	untriggered := place("alice", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "stop", "stop_price": "29000", "quantity": "0.1"})
+	crashed := place("alice", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "stop", "stop_price": "29500", "quantity": "0.1"})
+	unsubmitted := place("alice", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "stop", "stop_price": "29800", "quantity": "0.1"})
+	stop()
+
+	// 模拟条件单已标记为触发、子订单提交前进程崩溃
+	for _, id := range []interface{}{crashed["id"], unsubmitted["id"]} {
+		require.NoError(t, db.Model(&model.Order{}).Where("id = ?", uint(id.(float64))).
+			Updates(map[string]interface{}{"status": model.OrderStatusTriggered, "triggered_at": time.Now()}).Error)
+	}
+	assert.Equal(t, "untriggered", get(untriggered["id"])["status"])
+
+	// 模拟子订单已落库并冻结资金、提交给定序器前进程崩溃
+	var parent model.Order
+	require.NoError(t, db.First(&parent, uint(unsubmitted["id"].(float64))).Error)
+	child := &model.Order{UserID: parent.UserID, ClientOrderID: fmt.Sprintf("trigger-%d", parent.ID), Symbol: parent.Symbol,
+		Side: parent.Side, Type: parent.ExecType(), TimeInForce: parent.TimeInForce, Price: parent.Price, Quantity: parent.Quantity,
+		FilledQuantity: decimal.Zero, HeldAmount: parent.Quantity, Status: model.OrderStatusNew, ParentOrderID: &parent.ID}
+	require.NoError(t, db.Create(child).Error)
+	ledger := service.NewLedgerService(database.NewTxManager(db), repository.NewLedgerRepository(db), testScales)
+	_, err := ledger.Hold(context.Background(), parent.UserID, "BTC", parent.Quantity, fmt.Sprintf("order:%d", child.ID))
+	require.NoError(t, err)
+
+	// 重启后补交子订单，以28000成交后恢复的条件单随之触发
+	r, _ = startOrderRouter(t, db, dir)
+	for _, id := range []interface{}{crashed["id"], unsubmitted["id"], untriggered["id"]} {
+		require.Eventually(t, func() bool { return get(id)["child_order_id"] != nil }, 2*time.Second, 10*time.Millisecond)
+		parent := get(id)
+		assert.Equal(t, "triggered", parent["status"])
+		require.Eventually(t, func() bool { return get(parent["child_order_id"])["status"] == "filled" }, 2*time.Second, 10*time.Millisecond)
+	}
+	assert.Equal(t, float64(child.ID), get(unsubmitted["id"])["child_order_id"])
+	assert.Equal(t, [2]string{"9.8", "0"}, balances(t, r, "alice")["BTC"])
+
+	w, resp := doJSON(r, "GET", "/reconcile", nil)
+	require.Equal(t, 200, w.Code)
+	assert.Equal(t, true, resp["data"].(map[string]interface{})["balanced"])
+}
+
+// 测试结算永久失败时搁置事件：等待的请求返回错误，冻结资金保留到人工处理，交易对继续结算后续事件
+func TestSettlementParked(t *testing.T) {
+	db := setupOrderDB(t)
+	r, _ := startOrderRouter(t, db, t.TempDir())
+	createTestMarkets(t, r)
+
+	w, resp := doJSON(r, "POST", "/orders", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "limit", "price": "30000", "quantity": "1"})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	maker := resp["data"].(map[string]interface{})
+	// 模拟挂单记录缺失，成交无法结算
+	require.NoError(t, db.Delete(&model.Order{}, uint(maker["id"].(float64))).Error)
+
+	w, resp = doJSONAs(r, "bob", "POST", "/orders", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "30000", "quantity": "1", "client_order_id": "parked"})
+	assert.Equal(t, 500, w.Code)
+	assert.Equal(t, float64(utils.CodeSettlementParked), resp["code"])
+
+	var failures []model.SettlementFailure
+	require.NoError(t, db.Find(&failures).Error)
+	require.Len(t, failures, 1)
+	assert.Equal(t, "BTC_USDT", failures[0].Symbol)
+	assert.Contains(t, failures[0].Error, "settlement record not found")
+
+	// 成交未记账，双方的冻结资金保留
+	assert.Equal(t, [2]string{"9", "1"}, balances(t, r, "alice")["BTC"])
+	assert.Equal(t, [2]string{"70000", "30000"}, balances(t, r, "bob")["USDT"])
+
+	// 订单已在引擎中成交，撤单不解冻资金
+	var taker model.Order
+	require.NoError(t, db.Where("client_order_id = ?", "parked").First(&taker).Error)
+	assert.Equal(t, model.OrderStatusNew, taker.Status)
+	w, resp = doJSONAs(r, "bob", "DELETE", fmt.Sprintf("/orders/%d", taker.ID), nil)
+	assert.Equal(t, 500, w.Code)
+	assert.Equal(t, float64(utils.CodeSettlementParked), resp["code"])
+	assert.Equal(t, [2]string{"70000", "30000"}, balances(t, r, "bob")["USDT"])
+
+	// 后续事件照常结算
+	w, _ = doJSON(r, "POST", "/orders", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "limit", "price": "31000", "quantity": "0.5"})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	w, resp = doJSONAs(r, "bob", "POST", "/orders", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "31000", "quantity": "0.5"})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	assert.Equal(t, "filled", resp["data"].(map[string]interface{})["status"])
+	assert.Equal(t, [2]string{"10.5", "0"}, balances(t, r, "bob")["BTC"])
+}
+
+// doJSONAs 以指定测试用户的身份发送JSON请求
+func doJSONAs(r *gin.Engine, user, method, path string, body interface{}) (*httptest.ResponseRecorder, map[string]interface{}) {
+	var buf bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&buf).Encode(body)
+	}
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User", user)
+	r.ServeHTTP(w, req)
+
+	var resp map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+// balances 查询用户各资产的可用和冻结余额
+func balances(t *testing.T, r *gin.Engine, user string) map[string][2]string {
+	w, _ := doJSONAs(r, user, "GET", "/balances", nil)
+	require.Equal(t, 200, w.Code)
+
+	var resp struct {
+		Data []struct {
+			Asset     string `json:"asset"`
+			Available string `json:"available"`
+			Held      string `json:"held"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	out := make(map[string][2]string)
+	for _, a := range resp.Data {
+		out[a.Asset] = [2]string{a.Available, a.Held}
+	}
+	return out
+}
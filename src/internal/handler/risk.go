@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"errors"
+
+	"awesome-trade/src/internal/service"
+	"awesome-trade/src/pkg/decimal"
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetRiskLimitRequest 设置风控限额请求，Symbol为空表示该等级在所有交易对上的默认限额，数值为0表示不限制
+type SetRiskLimitRequest struct {
+	Tier             string          `json:"tier" binding:"required,max=16"`
+	Symbol           string          `json:"symbol" binding:"max=32"`
+	MaxOrderQuantity decimal.Decimal `json:"max_order_quantity" binding:"gte=0"`
+	MaxOrderNotional decimal.Decimal `json:"max_order_notional" binding:"gte=0"`
+	MaxOpenOrders    int             `json:"max_open_orders" binding:"gte=0"`
+	PriceBand        decimal.Decimal `json:"price_band" binding:"gte=0,lte=1"`
+	DailyLossLimit   decimal.Decimal `json:"daily_loss_limit" binding:"gte=0"`
+}
+
+// DeleteRiskLimitRequest 删除风控限额参数
+type DeleteRiskLimitRequest struct {
+	Tier   string `form:"tier" binding:"required"`
+	Symbol string `form:"symbol"`
+}
+
+// SetUserTierRequest 修改用户风控等级请求
+type SetUserTierRequest struct {
+	Tier string `json:"tier" binding:"required"`
+}
+
+// RiskHandler 风控限额管理处理器
+type RiskHandler struct {
+	risk *service.RiskService
+}
+
+// NewRiskHandler 创建风控限额管理处理器实例
+func NewRiskHandler(risk *service.RiskService) *RiskHandler {
+	return &RiskHandler{
+		risk: risk,
+	}
+}
+
+// ListLimits 获取全部风控限额
+func (h *RiskHandler) ListLimits(c *gin.Context) {
+	limits, err := h.risk.ListLimits(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, limits)
+}
+
+// SetLimit 设置风控限额，立即生效
+func (h *RiskHandler) SetLimit(c *gin.Context) {
+	var req SetRiskLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	limit, err := h.risk.SetLimit(c.Request.Context(), service.RiskLimitInput{
+		Tier:             req.Tier,
+		Symbol:           req.Symbol,
+		MaxOrderQuantity: req.MaxOrderQuantity,
+		MaxOrderNotional: req.MaxOrderNotional,
+		MaxOpenOrders:    req.MaxOpenOrders,
+		PriceBand:        req.PriceBand,
+		DailyLossLimit:   req.DailyLossLimit,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, limit)
+}
+
+// DeleteLimit 删除风控限额，立即生效
+func (h *RiskHandler) DeleteLimit(c *gin.Context) {
+	var req DeleteRiskLimitRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	if err := h.risk.DeleteLimit(c.Request.Context(), req.Tier, req.Symbol); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, nil)
+}
+
+// SetUserTier 修改用户的风控等级
+func (h *RiskHandler) SetUserTier(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	var req SetUserTierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	if err := h.risk.SetUserTier(c.Request.Context(), id, req.Tier); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, nil)
+}
+
+// handleError 将服务层错误映射为HTTP响应
+func (h *RiskHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound),
+		errors.Is(err, service.ErrMarketNotFound),
+		errors.Is(err, service.ErrRiskLimitNotFound):
+		utils.NotFound(c, err.Error())
+	case errors.Is(err, service.ErrInvalidUserTier), errors.Is(err, service.ErrInvalidRiskLimit):
+		utils.BadRequest(c, err.Error())
+	default:
+		utils.InternalServerError(c, "Internal server error")
+	}
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"testing"
+
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试风控限额的各项拒绝原因、交易对限额覆盖等级默认限额，以及修改后立即生效
+func TestRiskLimits(t *testing.T) {
+	r := setupOrderRouter(t)
+
+	reject := func(user string, body gin.H, code int) {
+		t.Helper()
+		w, resp := doJSONAs(r, user, "POST", "/orders", body)
+		assert.Equal(t, 422, w.Code, "%v", body)
+		assert.Equal(t, float64(code), resp["code"], "%v", body)
+	}
+	place := func(user string, body gin.H) {
+		t.Helper()
+		w, _ := doJSONAs(r, user, "POST", "/orders", body)
+		require.Equal(t, 200, w.Code, w.Body.String())
+	}
+	setLimit := func(body gin.H) {
+		t.Helper()
+		w, _ := doJSON(r, "PUT", "/risk/limits", body)
+		require.Equal(t, 200, w.Code, w.Body.String())
+	}
+
+	// 等级默认限额：单笔数量
+	setLimit(gin.H{"tier": "standard", "max_order_quantity": "2"})
+	reject("alice", gin.H{"symbol": "ETH_USDT", "side": "buy", "type": "limit", "price": "100", "quantity": "3"}, utils.CodeRiskMaxQuantity)
+
+	// 交易对限额覆盖默认限额：单笔金额、挂单数
+	setLimit(gin.H{"tier": "standard", "symbol": "btc_usdt", "max_order_notional": "50000", "max_open_orders": 1})
+	reject("alice", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "30000", "quantity": "2"}, utils.CodeRiskMaxNotional)
+	place("alice", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "39000", "quantity": "0.4"})
+	reject("alice", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "30000", "quantity": "0.1"}, utils.CodeRiskMaxOpenOrders)
+	reject("alice", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "time_in_force": "post_only", "price": "30000", "quantity": "0.1"}, utils.CodeRiskMaxOpenOrders)
+
+	// 其他等级不受standard的限额约束
+	w, _ := doJSON(r, "PUT", "/users/2/tier", gin.H{"tier": "vip"})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	place("bob", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "limit", "price": "39000", "quantity": "0.4"})
+	place("bob", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "30000", "quantity": "0.4"})
+	place("alice", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "limit", "price": "30000", "quantity": "0.4"})
+
+	// 最新成交价30000，价格偏离上限10%
+	setLimit(gin.H{"tier": "standard", "symbol": "BTC_USDT", "price_band": "0.1"})
+	reject("alice", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "33000.01", "quantity": "0.1"}, utils.CodeRiskPriceBand)
+	place("alice", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "27000", "quantity": "0.1"})
+
+	// alice当日以39000买入、30000卖出0.4，亏损3600
+	setLimit(gin.H{"tier": "standard", "symbol": "BTC_USDT", "daily_loss_limit": "3600"})
+	reject("alice", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "market", "quantity": "0.1"}, utils.CodeRiskDailyLoss)
+	setLimit(gin.H{"tier": "standard", "symbol": "BTC_USDT", "daily_loss_limit": "5000"})
+	place("alice", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "29000", "quantity": "0.1"})
+
+	// 当日亏损按用户在全部交易对上合计：alice在ETH_USDT以600买入、100卖出2，合计亏损4600
+	place("bob", gin.H{"symbol": "ETH_USDT", "side": "sell", "type": "limit", "price": "600", "quantity": "2"})
+	place("alice", gin.H{"symbol": "ETH_USDT", "side": "buy", "type": "limit", "price": "600", "quantity": "2"})
+	place("bob", gin.H{"symbol": "ETH_USDT", "side": "buy", "type": "limit", "price": "100", "quantity": "2"})
+	place("alice", gin.H{"symbol": "ETH_USDT", "side": "sell", "type": "limit", "price": "100", "quantity": "2"})
+	setLimit(gin.H{"tier": "standard", "symbol": "BTC_USDT", "daily_loss_limit": "4500"})
+	reject("alice", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "29000", "quantity": "0.1"}, utils.CodeRiskDailyLoss)
+	setLimit(gin.H{"tier": "standard", "symbol": "BTC_USDT", "daily_loss_limit": "5000"})
+	place("alice", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "29000", "quantity": "0.1"})
+
+	w, resp := doJSON(r, "GET", "/risk/limits", nil)
+	require.Equal(t, 200, w.Code)
+	assert.Len(t, resp["data"], 2)
+
+	// 删除交易对限额后回落到等级默认限额
+	w, _ = doJSON(r, "DELETE", "/risk/limits?tier=standard&symbol=BTC_USDT", nil)
+	require.Equal(t, 200, w.Code, w.Body.String())
+	reject("alice", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "29000", "quantity": "2.5"}, utils.CodeRiskMaxQuantity)
+	place("alice", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "20000", "quantity": "2"})
+
+	w, _ = doJSON(r, "DELETE", "/risk/limits?tier=standard&symbol=BTC_USDT", nil)
+	assert.Equal(t, 404, w.Code)
+	w, _ = doJSON(r, "PUT", "/risk/limits", gin.H{"tier": "standard", "symbol": "SOL_USDT"})
+	assert.Equal(t, 404, w.Code)
+	w, _ = doJSON(r, "PUT", "/risk/limits", gin.H{"tier": "Bad Tier"})
+	assert.Equal(t, 400, w.Code)
+	w, _ = doJSON(r, "PUT", "/risk/limits", gin.H{"tier": "standard", "price_band": "-0.1"})
+	assert.Equal(t, 400, w.Code)
+	w, _ = doJSON(r, "PUT", "/users/99/tier", gin.H{"tier": "vip"})
+	assert.Equal(t, 404, w.Code)
+}
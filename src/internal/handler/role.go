@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"errors"
+
+	"awesome-trade/src/internal/service"
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AssignRoleRequest 分配角色请求
+type AssignRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// RoleHandler 角色管理处理器
+type RoleHandler struct {
+	rbac *service.RBACService
+}
+
+// NewRoleHandler 创建角色管理处理器实例
+func NewRoleHandler(rbac *service.RBACService) *RoleHandler {
+	return &RoleHandler{
+		rbac: rbac,
+	}
+}
+
+// ListRoles 获取全部角色及权限
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.rbac.ListRoles(c.Request.Context())
+	if err != nil {
+		utils.InternalServerError(c, "Failed to list roles")
+		return
+	}
+
+	utils.Success(c, roles)
+}
+
+// UserRoles 获取用户的角色
+func (h *RoleHandler) UserRoles(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	roles, err := h.rbac.UserRoles(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, roles)
+}
+
+// AssignRole 为用户分配角色
+func (h *RoleHandler) AssignRole(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	var req AssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	if err := h.rbac.AssignRole(c.Request.Context(), id, req.Role); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, nil)
+}
+
+// RevokeRole 撤销用户的角色
+func (h *RoleHandler) RevokeRole(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.rbac.RevokeRole(c.Request.Context(), id, c.Param("role")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, nil)
+}
+
+// handleError 将服务层错误映射为HTTP响应
+func (h *RoleHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrRoleNotFound):
+		utils.NotFound(c, err.Error())
+	default:
+		utils.InternalServerError(c, "Internal server error")
+	}
+}
@@ -0,0 +1,310 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"awesome-trade/src/internal/config"
+	"awesome-trade/src/internal/middleware"
+	"awesome-trade/src/internal/service"
+	"awesome-trade/src/internal/stream"
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket客户端操作
+const (
+	StreamOpSubscribe   = "subscribe"
+	StreamOpUnsubscribe = "unsubscribe"
+	StreamOpAuth        = "auth"
+	StreamOpPing        = "ping"
+	StreamOpPong        = "pong"
+	StreamOpError       = "error"
+)
+
+const (
+	// streamWriteWait 单条消息的写超时
+	streamWriteWait = 10 * time.Second
+	// streamMaxMessageSize 客户端消息的大小上限
+	streamMaxMessageSize = 4096
+)
+
+// 推送连接错误
+var (
+	errUnknownOp          = errors.New("unknown op")
+	errAlreadyAuthorized  = errors.New("connection is already authenticated")
+	errInvalidStreamToken = errors.New("invalid token")
+)
+
+// StreamRequest 客户端发送的操作，ID原样带回应答
+type StreamRequest struct {
+	ID       uint64   `json:"id"`
+	Op       string   `json:"op"`
+	Channels []string `json:"channels,omitempty"`
+	Token    string   `json:"token,omitempty"`
+}
+
+// StreamReply 对客户端操作的应答
+type StreamReply struct {
+	ID       uint64   `json:"id"`
+	Op       string   `json:"op"`
+	Channels []string `json:"channels,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// SSERequest SSE订阅参数，Channels为逗号分隔的公开频道。
+// LastEventID用于无法设置请求头的首次连接，Last-Event-ID请求头优先。
+type SSERequest struct {
+	Channels    string `form:"channels" binding:"required"`
+	LastEventID string `form:"last_event_id"`
+}
+
+// sseEventReset 无法续传时发送的事件，客户端应重新拉取REST快照
+const sseEventReset = "reset"
+
+// streamUpgrader 握手不依赖Cookie，认证通过请求头或auth操作携带令牌，因此接受任意来源
+var streamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(*http.Request) bool { return true },
+}
+
+// StreamHandler WebSocket推送处理器
+type StreamHandler struct {
+	hub  *stream.Hub
+	auth *service.AuthService
+	cfg  config.StreamConfig
+}
+
+// NewStreamHandler 创建WebSocket推送处理器实例
+func NewStreamHandler(hub *stream.Hub, auth *service.AuthService, cfg config.StreamConfig) *StreamHandler {
+	if cfg.PingInterval <= 0 {
+		cfg.PingInterval = 20
+	}
+	if cfg.PongTimeout <= 0 {
+		cfg.PongTimeout = 3 * cfg.PingInterval
+	}
+	return &StreamHandler{
+		hub:  hub,
+		auth: auth,
+		cfg:  cfg,
+	}
+}
+
+// WebSocket 升级为WebSocket连接并处理订阅。握手时已认证的连接可直接订阅私有频道，
+// 匿名连接可先发送auth操作携带访问令牌。服务端定期发送ping，超时未收到pong或任何消息时断开。
+func (h *StreamHandler) WebSocket(c *gin.Context) {
+	conn, err := streamUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// 握手失败时Upgrade已写入错误响应
+		return
+	}
+
+	sub := h.hub.NewSubscriber(middleware.GetUserID(c), h.cfg.MaxSubscriptions)
+	quit := make(chan struct{})
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		h.writeLoop(conn, sub, quit)
+	}()
+
+	h.readLoop(conn, sub)
+	close(quit)
+	<-written
+	h.hub.Remove(sub)
+}
+
+// readLoop 读取并处理客户端操作，连接出错或超时时返回
+func (h *StreamHandler) readLoop(conn *websocket.Conn, sub *stream.Subscriber) {
+	timeout := time.Duration(h.cfg.PongTimeout) * time.Second
+	conn.SetReadLimit(streamMaxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(timeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(timeout))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(timeout))
+
+		var req StreamRequest
+		reply := StreamReply{Op: StreamOpError, Error: "invalid message"}
+		if err := json.Unmarshal(data, &req); err == nil {
+			reply = h.handle(sub, req)
+		}
+		if !h.hub.Send(sub, reply) {
+			return
+		}
+	}
+}
+
+// handle 执行一个客户端操作并返回应答。一次订阅中任一频道名无效时全部不订阅。
+func (h *StreamHandler) handle(sub *stream.Subscriber, req StreamRequest) StreamReply {
+	reply := StreamReply{ID: req.ID, Op: req.Op, Channels: req.Channels}
+	fail := func(err error) StreamReply {
+		return StreamReply{ID: req.ID, Op: StreamOpError, Channels: req.Channels, Error: err.Error()}
+	}
+
+	switch req.Op {
+	case StreamOpPing:
+		return StreamReply{ID: req.ID, Op: StreamOpPong}
+	case StreamOpAuth:
+		if sub.UserID != 0 {
+			return fail(errAlreadyAuthorized)
+		}
+		claims, err := h.auth.ParseAccessToken(context.Background(), req.Token)
+		if err != nil {
+			return fail(errInvalidStreamToken)
+		}
+		sub.UserID = claims.UserID
+		return StreamReply{ID: req.ID, Op: req.Op}
+	case StreamOpSubscribe, StreamOpUnsubscribe:
+		topics := make([]stream.Topic, len(req.Channels))
+		reply.Channels = make([]string, len(req.Channels))
+		for i, name := range req.Channels {
+			topic, err := stream.ParseChannel(name, sub.UserID)
+			if err != nil {
+				return fail(err)
+			}
+			topics[i] = topic
+			reply.Channels[i] = topic.Channel
+		}
+		for _, topic := range topics {
+			if req.Op == StreamOpUnsubscribe {
+				h.hub.Unsubscribe(sub, topic)
+				continue
+			}
+			if err := h.hub.Subscribe(sub, topic); err != nil {
+				return fail(err)
+			}
+		}
+		return reply
+	default:
+		return fail(errUnknownOp)
+	}
+}
+
+// writeLoop 发送推送消息和心跳。订阅者因发送缓冲满被关闭时以1008关闭连接，quit关闭时返回。
+func (h *StreamHandler) writeLoop(conn *websocket.Conn, sub *stream.Subscriber, quit <-chan struct{}) {
+	ticker := time.NewTicker(time.Duration(h.cfg.PingInterval) * time.Second)
+	defer ticker.Stop()
+	defer conn.Close()
+
+	for {
+		select {
+		case ev := <-sub.C():
+			_ = conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+			if err := conn.WriteMessage(websocket.TextMessage, ev.Data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteWait)); err != nil {
+				return
+			}
+		case <-sub.Done():
+			log.Printf("Dropping slow stream consumer: remote=%s", conn.RemoteAddr())
+			msg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "slow consumer")
+			_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(streamWriteWait))
+			return
+		case <-quit:
+			return
+		}
+	}
+}
+
+// SSE 以Server-Sent Events推送公开频道，供无法使用WebSocket的客户端使用。每条事件的id为 <纪元>-<序号> 格式的续传ID，
+// event为频道名，data与WebSocket推送消息相同。重连时按Last-Event-ID从重放缓冲补发错过的消息，
+// 服务重启后纪元改变或消息已不在重放缓冲中时无法补发，先发送reset事件。客户端断开（请求上下文取消）或因发送缓冲满被断开时结束响应。
+func (h *StreamHandler) SSE(c *gin.Context) {
+	var req SSERequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+	var lastID stream.EventID
+	if header := c.GetHeader("Last-Event-ID"); header != "" || req.LastEventID != "" {
+		if header == "" {
+			header = req.LastEventID
+		}
+		id, err := stream.ParseEventID(header)
+		if err != nil {
+			utils.BadRequest(c, "Invalid Last-Event-ID")
+			return
+		}
+		lastID = id
+	}
+	var topics []stream.Topic
+	for _, name := range strings.Split(req.Channels, ",") {
+		topic, err := stream.ParseChannel(name, 0)
+		if err != nil {
+			utils.BadRequest(c, name+": "+err.Error())
+			return
+		}
+		topics = append(topics, topic)
+	}
+
+	sub := h.hub.NewSubscriber(0, h.cfg.MaxSubscriptions)
+	defer h.hub.Remove(sub)
+	replay, err := h.hub.Resume(sub, topics, lastID)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	c.Header("Content-Type", sse.ContentType)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	if replay.Gap {
+		reset := sse.Event{Id: replay.LastID.String(), Event: sseEventReset, Data: gin.H{"last_event_id": lastID.String()}}
+		if err := sse.Encode(c.Writer, reset); err != nil {
+			return
+		}
+	}
+	for _, ev := range replay.Events {
+		if err := h.writeSSE(c.Writer, ev); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(time.Duration(h.cfg.PingInterval) * time.Second)
+	defer ticker.Stop()
+	ctx := c.Request.Context()
+	for {
+		select {
+		case ev := <-sub.C():
+			if err := h.writeSSE(c.Writer, ev); err != nil {
+				return
+			}
+		case <-ticker.C:
+			// 注释行作为心跳，防止代理因空闲断开连接
+			if _, err := c.Writer.WriteString(": ping\n\n"); err != nil {
+				return
+			}
+		case <-sub.Done():
+			log.Printf("Dropping slow SSE consumer: remote=%s", c.ClientIP())
+			return
+		case <-ctx.Done():
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+// writeSSE 写入一条推送消息事件，id为带纪元的续传ID
+func (h *StreamHandler) writeSSE(w gin.ResponseWriter, ev stream.Event) error {
+	return sse.Encode(w, sse.Event{Id: h.hub.EventID(ev).String(), Event: ev.Channel, Data: string(ev.Data)})
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"awesome-trade/src/internal/stream"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dialStream 以指定测试用户的身份建立WebSocket连接
+func dialStream(t *testing.T, srv *httptest.Server, user string) *websocket.Conn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"X-User": {user}})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readStream 读取下一条推送消息或应答
+func readStream(t *testing.T, conn *websocket.Conn, v interface{}) {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, conn.ReadJSON(v))
+}
+
+// 测试订阅公开和私有频道，深度增量序号与REST快照一致
+func TestStreamSubscribe(t *testing.T) {
+	r := setupOrderRouter(t)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+	conn := dialStream(t, srv, "bob")
+
+	var reply StreamReply
+	require.NoError(t, conn.WriteJSON(StreamRequest{ID: 1, Op: StreamOpSubscribe, Channels: []string{"depth:btc_usdt", "trades:BTC_USDT", "orders", "fills"}}))
+	readStream(t, conn, &reply)
+	assert.Equal(t, StreamReply{ID: 1, Op: StreamOpSubscribe, Channels: []string{"depth:BTC_USDT", "trades:BTC_USDT", "orders", "fills"}}, reply)
+	require.NoError(t, conn.WriteJSON(StreamRequest{ID: 2, Op: StreamOpSubscribe, Channels: []string{"klines:BTC_USDT:2m"}}))
+	readStream(t, conn, &reply)
+	assert.Equal(t, StreamOpError, reply.Op)
+	assert.Equal(t, stream.ErrInvalidChannel.Error(), reply.Error)
+	require.NoError(t, conn.WriteJSON(StreamRequest{ID: 3, Op: StreamOpPing}))
+	reply = StreamReply{}
+	readStream(t, conn, &reply)
+	assert.Equal(t, StreamReply{ID: 3, Op: StreamOpPong}, reply)
+
+	w, _ := doJSON(r, "POST", "/orders", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "39000", "quantity": "1"})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	w, _ = doJSONAs(r, "bob", "POST", "/orders", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "limit", "price": "39000", "quantity": "0.4"})
+	require.Equal(t, 200, w.Code, w.Body.String())
+
+	var msgs []map[string]interface{}
+	for i := 0; i < 5; i++ {
+		var msg map[string]interface{}
+		readStream(t, conn, &msg)
+		msgs = append(msgs, msg)
+	}
+	channels := make([]string, len(msgs))
+	for i, msg := range msgs {
+		channels[i] = msg["channel"].(string)
+	}
+	assert.Equal(t, []string{"depth:BTC_USDT", "depth:BTC_USDT", "trades:BTC_USDT", "orders", "fills"}, channels)
+
+	// 深度增量的序号为定序器序号，prev_seq衔接上一条增量
+	assert.Equal(t, float64(1), msgs[0]["seq"])
+	assert.Equal(t, float64(2), msgs[1]["seq"])
+	assert.Equal(t, float64(1), msgs[1]["prev_seq"])
+	update := msgs[1]["data"].(map[string]interface{})
+	assert.Equal(t, []interface{}{map[string]interface{}{"price": "39000", "quantity": "0.6"}}, update["bids"])
+	order := msgs[3]["data"].(map[string]interface{})
+	assert.Equal(t, "filled", order["status"])
+	fill := msgs[4]["data"].(map[string]interface{})
+	assert.Equal(t, "taker", fill["liquidity"])
+	assert.Equal(t, "sell", fill["side"])
+	assert.Equal(t, "0.4", fill["quantity"])
+
+	_, resp := doJSON(r, "GET", "/market/BTC_USDT/depth", nil)
+	assert.Equal(t, msgs[1]["seq"], resp["data"].(map[string]interface{})["seq"])
+}
+
+// readSSE 读取SSE响应中的前n个事件，返回各事件的字段
+func readSSE(t *testing.T, body *bufio.Reader, n int) []map[string]string {
+	t.Helper()
+	var events []map[string]string
+	event := map[string]string{}
+	for len(events) < n {
+		line, err := body.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		if line == "" {
+			if len(event) > 0 {
+				events = append(events, event)
+				event = map[string]string{}
+			}
+			continue
+		}
+		if field, value, ok := strings.Cut(line, ":"); ok && field != "" {
+			event[field] = value
+		}
+	}
+	return events
+}
+
+// 测试SSE按Last-Event-ID补发错过的消息，服务重启前的ID或无法续传时先发送reset事件
+func TestStreamSSEResume(t *testing.T) {
+	r := setupOrderRouter(t)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	w, _ := doJSON(r, "POST", "/orders", gin.H{"symbol": "BTC_USDT", "side": "buy", "type": "limit", "price": "39000", "quantity": "1"})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	w, _ = doJSONAs(r, "bob", "POST", "/orders", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "limit", "price": "39000", "quantity": "0.4"})
+	require.Equal(t, 200, w.Code, w.Body.String())
+
+	open := func(lastEventID string) *bufio.Reader {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		t.Cleanup(cancel)
+		req, err := http.NewRequestWithContext(ctx, "GET", srv.URL+"/stream?channels=depth:btc_usdt,trades:BTC_USDT", nil)
+		require.NoError(t, err)
+		req.Header.Set("Last-Event-ID", lastEventID)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		require.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+		return bufio.NewReader(resp.Body)
+	}
+
+	// 服务重启前的ID纪元不同，无法续传，reset事件带有当前纪元的最新ID
+	events := readSSE(t, open("1"), 1)
+	assert.Equal(t, "reset", events[0]["event"])
+	last, err := stream.ParseEventID(events[0]["id"])
+	require.NoError(t, err)
+	require.NotEmpty(t, last.Epoch)
+	assert.Equal(t, uint64(7), last.Seq)
+	id := func(seq uint64) string { return stream.EventID{Epoch: last.Epoch, Seq: seq}.String() }
+
+	// 公开消息依次为 depth(1) depth(2) trades(2) 以及未订阅的K线，从第一条之后续传
+	events = readSSE(t, open(id(1)), 2)
+	assert.Equal(t, "depth:BTC_USDT", events[0]["event"])
+	assert.Equal(t, id(2), events[0]["id"])
+	assert.Contains(t, events[0]["data"], `"seq":2,"prev_seq":1`)
+	assert.Equal(t, "trades:BTC_USDT", events[1]["event"])
+	assert.Equal(t, id(3), events[1]["id"])
+
+	body := open(id(100))
+	events = readSSE(t, body, 1)
+	assert.Equal(t, "reset", events[0]["event"])
+	assert.Equal(t, id(7), events[0]["id"])
+
+	// 订阅后的新消息实时推送
+	w, _ = doJSONAs(r, "bob", "POST", "/orders", gin.H{"symbol": "BTC_USDT", "side": "sell", "type": "limit", "price": "40000", "quantity": "1"})
+	require.Equal(t, 200, w.Code, w.Body.String())
+	events = readSSE(t, body, 1)
+	assert.Equal(t, "depth:BTC_USDT", events[0]["event"])
+	assert.Equal(t, id(8), events[0]["id"])
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/stream?channels=orders", nil))
+	assert.Equal(t, 400, w.Code)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/stream?channels=depth:BTC_USDT&last_event_id=abc", nil))
+	assert.Equal(t, 400, w.Code)
+}
@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"errors"
+
+	"awesome-trade/src/internal/middleware"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/internal/service"
+	"awesome-trade/src/pkg/decimal"
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DepositRequest 充值申报请求，金额使用字符串传递以保证精度
+type DepositRequest struct {
+	Asset  string          `json:"asset" binding:"required,max=16"`
+	Amount decimal.Decimal `json:"amount" binding:"required,gt=0"`
+	TxHash string          `json:"tx_hash" binding:"required,max=128"`
+}
+
+// DepositAddressRequest 充值地址查询参数
+type DepositAddressRequest struct {
+	Asset string `form:"asset" binding:"required,max=16"`
+}
+
+// WithdrawalRequest 提现申请请求
+type WithdrawalRequest struct {
+	Asset   string          `json:"asset" binding:"required,max=16"`
+	Amount  decimal.Decimal `json:"amount" binding:"required,gt=0"`
+	Address string          `json:"address" binding:"required,max=128"`
+}
+
+// RejectTransferRequest 拒绝转账请求
+type RejectTransferRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// ListTransfersRequest 转账查询参数
+type ListTransfersRequest struct {
+	Type   string `form:"type" binding:"omitempty,oneof=deposit withdrawal"`
+	Status string `form:"status" binding:"omitempty,oneof=pending reviewing approved broadcasting confirmed rejected failed"`
+	Cursor uint   `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// TransferHandler 充值提现处理器
+type TransferHandler struct {
+	transfers *service.TransferService
+}
+
+// NewTransferHandler 创建充值提现处理器实例
+func NewTransferHandler(transfers *service.TransferService) *TransferHandler {
+	return &TransferHandler{
+		transfers: transfers,
+	}
+}
+
+// DepositAddress 获取当前用户在该资产上的充值地址，首次获取时生成
+func (h *TransferHandler) DepositAddress(c *gin.Context) {
+	var req DepositAddressRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	address, err := h.transfers.DepositAddress(c.Request.Context(), middleware.GetUserID(c), req.Asset)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, address)
+}
+
+// Deposit 申报转入充值地址的链上交易，确认后按链上金额入账
+func (h *TransferHandler) Deposit(c *gin.Context) {
+	var req DepositRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	transfer, err := h.transfers.RequestDeposit(c.Request.Context(), middleware.GetUserID(c), service.DepositInput{
+		Asset:  req.Asset,
+		Amount: req.Amount,
+		TxHash: req.TxHash,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, transfer)
+}
+
+// Withdraw 申请提现，超过免审批额度时等待管理员审批
+func (h *TransferHandler) Withdraw(c *gin.Context) {
+	var req WithdrawalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	transfer, err := h.transfers.RequestWithdrawal(c.Request.Context(), middleware.GetUserID(c), service.WithdrawalInput{
+		Asset:   req.Asset,
+		Amount:  req.Amount,
+		Address: req.Address,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, transfer)
+}
+
+// List 获取当前用户的充值提现记录，使用cursor翻页
+func (h *TransferHandler) List(c *gin.Context) {
+	h.search(c, middleware.GetUserID(c))
+}
+
+// Get 获取当前用户的转账详情
+func (h *TransferHandler) Get(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	transfer, err := h.transfers.Get(c.Request.Context(), middleware.GetUserID(c), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, transfer)
+}
+
+// AdminList 管理员按类型和状态查询全部转账
+func (h *TransferHandler) AdminList(c *gin.Context) {
+	h.search(c, 0)
+}
+
+// AdminGet 管理员获取转账详情及审计记录
+func (h *TransferHandler) AdminGet(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	transfer, err := h.transfers.GetWithAudits(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, transfer)
+}
+
+// Approve 管理员批准提现并广播
+func (h *TransferHandler) Approve(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	transfer, err := h.transfers.Approve(c.Request.Context(), middleware.GetUserID(c), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, transfer)
+}
+
+// Reject 管理员拒绝转账，提现资金解冻
+func (h *TransferHandler) Reject(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	var req RejectTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	transfer, err := h.transfers.Reject(c.Request.Context(), middleware.GetUserID(c), id, req.Reason)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, transfer)
+}
+
+// Sync 管理员立即同步转账的链上状态
+func (h *TransferHandler) Sync(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	transfer, err := h.transfers.Sync(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, transfer)
+}
+
+// search 分页查询转账，userID为0时查询全部用户
+func (h *TransferHandler) search(c *gin.Context, userID uint) {
+	var req ListTransfersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	transfers, next, err := h.transfers.Search(c.Request.Context(), repository.TransferQuery{
+		UserID: userID,
+		Type:   req.Type,
+		Status: req.Status,
+		Before: req.Cursor,
+		Limit:  req.Limit,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, utils.CursorData{
+		List:       transfers,
+		NextCursor: next,
+	})
+}
+
+// handleError 将服务层错误映射为HTTP响应
+func (h *TransferHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrTransferNotFound):
+		utils.NotFound(c, err.Error())
+	case errors.Is(err, service.ErrInvalidTransition),
+		errors.Is(err, service.ErrTransferInProgress),
+		errors.Is(err, service.ErrDepositTxConflict):
+		utils.Conflict(c, err.Error())
+	case errors.Is(err, service.ErrInsufficientBalance),
+		errors.Is(err, service.ErrNoDepositAddress):
+		utils.UnprocessableEntity(c, err.Error())
+	case errors.Is(err, service.ErrInvalidAsset),
+		errors.Is(err, service.ErrInvalidAmount),
+		errors.Is(err, service.ErrInvalidAddress),
+		errors.Is(err, service.ErrInvalidTxHash):
+		utils.BadRequest(c, err.Error())
+	default:
+		utils.InternalServerError(c, "Internal server error")
+	}
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"awesome-trade/src/internal/middleware"
+	"awesome-trade/src/internal/service"
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TwoFactorCodeRequest 两步验证码请求
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorHandler 两步验证处理器
+type TwoFactorHandler struct {
+	twoFactor *service.TwoFactorService
+}
+
+// NewTwoFactorHandler 创建两步验证处理器实例
+func NewTwoFactorHandler(twoFactor *service.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactor: twoFactor,
+	}
+}
+
+// Status 查询两步验证状态
+func (h *TwoFactorHandler) Status(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := middleware.GetUserID(c)
+
+	enabled, err := h.twoFactor.IsEnabled(ctx, userID)
+	if err != nil {
+		utils.InternalServerError(c, "Failed to load two-factor status")
+		return
+	}
+	remaining := int64(0)
+	if enabled {
+		if remaining, err = h.twoFactor.RemainingRecoveryCodes(ctx, userID); err != nil {
+			utils.InternalServerError(c, "Failed to load two-factor status")
+			return
+		}
+	}
+
+	utils.Success(c, gin.H{
+		"enabled":                  enabled,
+		"remaining_recovery_codes": remaining,
+	})
+}
+
+// Enroll 生成TOTP密钥和otpauth URI
+func (h *TwoFactorHandler) Enroll(c *gin.Context) {
+	enrollment, err := h.twoFactor.Enroll(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, enrollment)
+}
+
+// Confirm 使用第一个验证码确认启用，返回恢复码
+func (h *TwoFactorHandler) Confirm(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	codes, err := h.twoFactor.Confirm(c.Request.Context(), middleware.GetUserID(c), req.Code)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"recovery_codes": codes,
+	})
+}
+
+// Verify 敏感操作前的二次验证，刷新验证新鲜度
+func (h *TwoFactorHandler) Verify(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	if err := h.twoFactor.Verify(c.Request.Context(), middleware.GetUserID(c), req.Code); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, nil)
+}
+
+// Disable 关闭两步验证
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	if err := h.twoFactor.Disable(c.Request.Context(), middleware.GetUserID(c), req.Code); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, nil)
+}
+
+// handleError 将服务层错误映射为HTTP响应
+func (h *TwoFactorHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrTwoFactorAlreadyEnabled):
+		utils.Conflict(c, err.Error())
+	case errors.Is(err, service.ErrTwoFactorNotEnrolled), errors.Is(err, service.ErrInvalidTwoFactorCode):
+		utils.BadRequest(c, err.Error())
+	case errors.Is(err, service.ErrTwoFactorLocked):
+		utils.Fail(c, http.StatusTooManyRequests, http.StatusTooManyRequests, err.Error())
+	case errors.Is(err, service.ErrUserNotFound):
+		utils.NotFound(c, err.Error())
+	default:
+		utils.InternalServerError(c, "Internal server error")
+	}
+}
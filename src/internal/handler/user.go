@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/internal/service"
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListUsersRequest 用户列表查询参数
+type ListUsersRequest struct {
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+	IsActive *bool  `form:"is_active"`
+	SortBy   string `form:"sort_by" binding:"omitempty,oneof=id username email created_at updated_at"`
+	Order    string `form:"order" binding:"omitempty,oneof=asc desc"`
+}
+
+// CreateUserRequest 创建用户请求
+type CreateUserRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=20"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=6"`
+	IsActive *bool  `json:"is_active"`
+}
+
+// UpdateUserRequest 更新用户请求
+type UpdateUserRequest struct {
+	Username *string `json:"username" binding:"omitempty,min=3,max=20"`
+	Email    *string `json:"email" binding:"omitempty,email"`
+	Password *string `json:"password" binding:"omitempty,min=6"`
+	IsActive *bool   `json:"is_active"`
+}
+
+// UserHandler 用户处理器
+type UserHandler struct {
+	users *service.UserService
+}
+
+// NewUserHandler 创建用户处理器实例
+func NewUserHandler(users *service.UserService) *UserHandler {
+	return &UserHandler{
+		users: users,
+	}
+}
+
+// List 获取用户列表
+func (h *UserHandler) List(c *gin.Context) {
+	var req ListUsersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	query := repository.UserQuery{
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		IsActive: req.IsActive,
+		SortBy:   req.SortBy,
+		Order:    req.Order,
+	}
+	users, total, err := h.users.List(c.Request.Context(), query)
+	if err != nil {
+		utils.InternalServerError(c, "Failed to list users")
+		return
+	}
+
+	page, pageSize := utils.NormalizePage(req.Page, req.PageSize)
+	utils.Success(c, utils.PageData{
+		List:     users,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+// Create 创建用户
+func (h *UserHandler) Create(c *gin.Context) {
+	var req CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	user, err := h.users.Create(c.Request.Context(), service.CreateUserInput{
+		Username: req.Username,
+		Email:    req.Email,
+		Password: req.Password,
+		IsActive: req.IsActive,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, user)
+}
+
+// Get 获取用户详情
+func (h *UserHandler) Get(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.users.Get(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, user)
+}
+
+// Update 更新用户
+func (h *UserHandler) Update(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request data: "+err.Error())
+		return
+	}
+
+	user, err := h.users.Update(c.Request.Context(), id, service.UpdateUserInput{
+		Username: req.Username,
+		Email:    req.Email,
+		Password: req.Password,
+		IsActive: req.IsActive,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, user)
+}
+
+// Delete 删除用户（软删除）
+func (h *UserHandler) Delete(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.users.Delete(c.Request.Context(), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.Success(c, nil)
+}
+
+// handleError 将服务层错误映射为HTTP响应
+func (h *UserHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		utils.NotFound(c, err.Error())
+	case errors.Is(err, service.ErrUserExists):
+		utils.Conflict(c, err.Error())
+	default:
+		utils.InternalServerError(c, "Internal server error")
+	}
+}
+
+// parseID 解析路径中的ID参数，失败时直接写入400响应
+func parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		utils.BadRequest(c, "Invalid id")
+		return 0, false
+	}
+	return uint(id), true
+}
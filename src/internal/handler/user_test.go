@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"awesome-trade/src/internal/config"
+	"awesome-trade/src/internal/database"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 设置用户测试路由
+func setupUserRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	db, err := database.Open(config.DatabaseConfig{Driver: database.DriverSQLite, Path: ":memory:"})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.User{}))
+
+	h := NewUserHandler(service.NewUserService(database.NewTxManager(db), repository.NewUserRepository(db)))
+
+	r := gin.New()
+	users := r.Group("/users")
+	users.GET("/", h.List)
+	users.POST("/", h.Create)
+	users.GET("/:id", h.Get)
+	users.PUT("/:id", h.Update)
+	users.DELETE("/:id", h.Delete)
+	return r
+}
+
+func doJSON(r *gin.Engine, method, path string, body interface{}) (*httptest.ResponseRecorder, map[string]interface{}) {
+	var buf bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&buf).Encode(body)
+	}
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	var resp map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+// 测试用户CRUD完整流程
+func TestUserCRUD(t *testing.T) {
+	r := setupUserRouter(t)
+
+	w, resp := doJSON(r, "POST", "/users/", gin.H{
+		"username": "alice",
+		"email":    "alice@example.com",
+		"password": "password123",
+	})
+	assert.Equal(t, 200, w.Code)
+	data := resp["data"].(map[string]interface{})
+	assert.Equal(t, "alice", data["username"])
+	assert.NotContains(t, w.Body.String(), "password123")
+
+	// 重复用户名
+	w, _ = doJSON(r, "POST", "/users/", gin.H{
+		"username": "alice",
+		"email":    "other@example.com",
+		"password": "password123",
+	})
+	assert.Equal(t, 409, w.Code)
+
+	w, resp = doJSON(r, "PUT", "/users/1", gin.H{"is_active": false})
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, false, resp["data"].(map[string]interface{})["is_active"])
+
+	w, _ = doJSON(r, "DELETE", "/users/1", nil)
+	assert.Equal(t, 200, w.Code)
+
+	w, _ = doJSON(r, "GET", "/users/1", nil)
+	assert.Equal(t, 404, w.Code)
+}
+
+// 测试用户列表分页、过滤和排序
+func TestUserList(t *testing.T) {
+	r := setupUserRouter(t)
+
+	for _, name := range []string{"carol", "alice", "bob"} {
+		w, _ := doJSON(r, "POST", "/users/", gin.H{
+			"username": name,
+			"email":    name + "@example.com",
+			"password": "password123",
+		})
+		assert.Equal(t, 200, w.Code)
+	}
+	doJSON(r, "PUT", "/users/2", gin.H{"is_active": false})
+
+	w, resp := doJSON(r, "GET", "/users/?is_active=true&sort_by=username&order=desc&page_size=1", nil)
+	assert.Equal(t, 200, w.Code)
+	data := resp["data"].(map[string]interface{})
+	assert.Equal(t, float64(2), data["total"])
+	list := data["list"].([]interface{})
+	assert.Len(t, list, 1)
+	assert.Equal(t, "carol", list[0].(map[string]interface{})["username"])
+
+	w, _ = doJSON(r, "GET", "/users/?sort_by=password", nil)
+	assert.Equal(t, 400, w.Code)
+}
@@ -0,0 +1,15 @@
+package handler
+
+import (
+	"awesome-trade/src/pkg/decimal"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// 使请求结构体中Decimal字段的binding标签（如 required、gt=0）按数值校验
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		decimal.RegisterValidation(v)
+	}
+}
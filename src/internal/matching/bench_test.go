@@ -0,0 +1,73 @@
+package matching
+
+import (
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+)
+
+const (
+	// minOrdersPerSecond 单核吞吐量下限
+	minOrdersPerSecond = 100000
+	// throughputEnv 设置该环境变量时才运行吞吐量测试，耗时受机器负载影响，不在常规测试中检查
+	throughputEnv = "MATCHING_THROUGHPUT"
+)
+
+// generateOrders 预先生成订单流，避免基准测试计入随机数开销
+func generateOrders(n int) []Order {
+	rng := rand.New(rand.NewSource(1))
+	orders := make([]Order, n)
+	for i := range orders {
+		orders[i] = *randomOrder(rng, uint64(i+1))
+	}
+	return orders
+}
+
+// 基准测试，以orders/s报告吞吐量
+func BenchmarkSubmit(b *testing.B) {
+	orders := generateOrders(b.N)
+	book := NewOrderBook("BTC_USDT")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		book.Submit(&orders[i])
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "orders/s")
+}
+
+// 撤单基准测试
+func BenchmarkSubmitCancel(b *testing.B) {
+	orders := generateOrders(b.N)
+	book := NewOrderBook("BTC_USDT")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		o := &orders[i]
+		book.Submit(o)
+		if i%2 == 1 {
+			book.Cancel(orders[i-1].ID)
+		}
+	}
+}
+
+// 测试单核吞吐量不低于每秒10万笔，须设置MATCHING_THROUGHPUT环境变量
+func TestThroughput(t *testing.T) {
+	if testing.Short() || os.Getenv(throughputEnv) == "" {
+		t.Skipf("skipping throughput test, set %s=1 to run it", throughputEnv)
+	}
+
+	const n = 500000
+	orders := generateOrders(n)
+	book := NewOrderBook("BTC_USDT")
+
+	start := time.Now()
+	for i := range orders {
+		book.Submit(&orders[i])
+	}
+	rate := float64(n) / time.Since(start).Seconds()
+	t.Logf("%.0f orders/s", rate)
+	if rate < minOrdersPerSecond {
+		t.Fatalf("throughput %.0f orders/s is below %d", rate, minOrdersPerSecond)
+	}
+}
@@ -0,0 +1,344 @@
+package matching
+
+import "math/bits"
+
+// OrderBook 单个交易对的订单簿
+type OrderBook struct {
+	Symbol   string
+	bids     *levelList
+	asks     *levelList
+	orders   map[uint64]*Order
+	tradeSeq uint64
+}
+
+// NewOrderBook 创建订单簿
+func NewOrderBook(symbol string) *OrderBook {
+	return &OrderBook{
+		Symbol: symbol,
+		bids:   newLevelList(true),
+		asks:   newLevelList(false),
+		orders: make(map[uint64]*Order),
+	}
+}
+
+// Submit 提交订单并撮合。未完全成交的GTC和只做挂单的限价单进入订单簿，其余剩余部分撤销。
+func (b *OrderBook) Submit(o *Order) *Result {
+	res := &Result{Symbol: b.Symbol, OrderID: o.ID, UserID: o.UserID}
+	if reason := b.validate(o); reason != ReasonNone {
+		return b.reject(res, o, reason)
+	}
+
+	o.Remaining = o.Quantity
+	opposite := b.side(o.Side.Opposite())
+	if o.TimeInForce == PostOnly {
+		if best := opposite.front(); best != nil && crosses(o, best.price) {
+			return b.reject(res, o, ReasonPostOnlyWouldTake)
+		}
+	}
+	if o.TimeInForce == FOK && !b.fillable(o, opposite) {
+		res.Status = StatusCanceled
+		res.Reason = ReasonFillOrKill
+		res.Remaining = o.Quantity
+		return res
+	}
+
+	funds := o.Funds
+	b.match(o, opposite, res, &funds)
+
+	switch {
+	case o.Remaining == 0:
+		res.Status = StatusFilled
+	case o.Type == Limit && (o.TimeInForce == GTC || o.TimeInForce == PostOnly):
+		b.rest(o, res)
+		if o.Remaining < o.Quantity {
+			res.Status = StatusPartiallyFilled
+		} else {
+			res.Status = StatusNew
+		}
+	default:
+		res.Status = StatusCanceled
+		switch {
+		case len(res.Trades) > 0:
+		case o.Funds > 0 && opposite.front() != nil:
+			res.Reason = ReasonInsufficientFunds
+		default:
+			res.Reason = ReasonNoLiquidity
+		}
+	}
+	res.Filled = o.Quantity - o.Remaining
+	res.Remaining = o.Remaining
+	return res
+}
+
+// Cancel 撤销订单簿中的订单
+func (b *OrderBook) Cancel(id uint64) *Result {
+	res := &Result{Symbol: b.Symbol, OrderID: id}
+	o, ok := b.orders[id]
+	if !ok {
+		res.Status = StatusRejected
+		res.Reason = ReasonUnknownOrder
+		return res
+	}
+	res.UserID = o.UserID
+
+	levels := b.side(o.Side)
+	level := o.level
+	level.unlink(o)
+	level.total -= o.Remaining
+	res.addDelta(o.Side, level.price, level.total)
+	if level.head == nil {
+		levels.remove(level)
+	}
+	delete(b.orders, id)
+
+	res.Status = StatusCanceled
+	res.Filled = o.Quantity - o.Remaining
+	res.Remaining = o.Remaining
+	return res
+}
+
+// Order 查询挂单，返回的订单只读
+func (b *OrderBook) Order(id uint64) (*Order, bool) {
+	o, ok := b.orders[id]
+	return o, ok
+}
+
+// Len 返回挂单数量
+func (b *OrderBook) Len() int {
+	return len(b.orders)
+}
+
+// BestBid 返回最高买价，没有买单时ok为false
+func (b *OrderBook) BestBid() (price int64, ok bool) {
+	if l := b.bids.front(); l != nil {
+		return l.price, true
+	}
+	return 0, false
+}
+
+// BestAsk 返回最低卖价，没有卖单时ok为false
+func (b *OrderBook) BestAsk() (price int64, ok bool) {
+	if l := b.asks.front(); l != nil {
+		return l.price, true
+	}
+	return 0, false
+}
+
+// Depth 返回买卖双方前limit个价位，limit不大于0时返回全部价位
+func (b *OrderBook) Depth(limit int) (bids, asks []Level) {
+	return collect(b.bids, limit), collect(b.asks, limit)
+}
+
+// Orders 按价格优先、时间优先的顺序返回某一方向的全部挂单副本
+func (b *OrderBook) Orders(side Side) []Order {
+	levels := b.side(side)
+	out := make([]Order, 0, len(b.orders))
+	for l := levels.front(); l != nil; l = l.next[0] {
+		for o := l.head; o != nil; o = o.next {
+			c := *o
+			c.prev, c.next, c.level = nil, nil, nil
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// TradeSeq 返回最近一笔成交的编号
+func (b *OrderBook) TradeSeq() uint64 {
+	return b.tradeSeq
+}
+
+// validate 校验订单参数
+func (b *OrderBook) validate(o *Order) Reason {
+	if o.Quantity <= 0 {
+		return ReasonInvalidQuantity
+	}
+	if o.Side != Buy && o.Side != Sell {
+		return ReasonInvalidOrderType
+	}
+	switch o.Type {
+	case Limit:
+		if o.Price <= 0 {
+			return ReasonInvalidPrice
+		}
+		if o.TimeInForce < GTC || o.TimeInForce > PostOnly {
+			return ReasonInvalidOrderType
+		}
+	case Market:
+		if o.TimeInForce != IOC && o.TimeInForce != FOK {
+			return ReasonInvalidOrderType
+		}
+		if o.Funds < 0 || o.Funds > 0 && (o.Side != Buy || o.Unit <= 0 || o.Lot < 0) {
+			return ReasonInvalidQuantity
+		}
+	default:
+		return ReasonInvalidOrderType
+	}
+	if _, ok := b.orders[o.ID]; ok {
+		return ReasonDuplicateOrder
+	}
+	return ReasonNone
+}
+
+// reject 拒绝订单
+func (b *OrderBook) reject(res *Result, o *Order, reason Reason) *Result {
+	res.Status = StatusRejected
+	res.Reason = reason
+	res.Remaining = o.Quantity
+	return res
+}
+
+// side 返回指定方向的价位表
+func (b *OrderBook) side(s Side) *levelList {
+	if s == Buy {
+		return b.bids
+	}
+	return b.asks
+}
+
+// fillable 判断对手盘在可接受价格内的数量是否足以全部成交，限定资金的市价买单按逐笔成交额计算
+func (b *OrderBook) fillable(o *Order, opposite *levelList) bool {
+	need := o.Remaining
+	funds := o.Funds
+	for l := opposite.front(); l != nil && crosses(o, l.price); l = l.next[0] {
+		if o.Funds == 0 {
+			need -= l.total
+		} else {
+			for maker := l.head; maker != nil && need > 0; maker = maker.next {
+				qty := affordable(o, l.price, min64(need, maker.Remaining), &funds)
+				if qty == 0 {
+					return false
+				}
+				need -= qty
+			}
+		}
+		if need <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// match 与对手盘按价格优先、时间优先撮合，funds为限定资金的市价买单的剩余资金
+func (b *OrderBook) match(o *Order, opposite *levelList, res *Result, funds *int64) {
+	makerSide := o.Side.Opposite()
+	exhausted := false
+	for o.Remaining > 0 && !exhausted {
+		level := opposite.front()
+		if level == nil || !crosses(o, level.price) {
+			return
+		}
+
+		total := level.total
+		for o.Remaining > 0 && level.head != nil {
+			maker := level.head
+			qty := affordable(o, level.price, min64(o.Remaining, maker.Remaining), funds)
+			if qty == 0 {
+				exhausted = true
+				break
+			}
+			o.Remaining -= qty
+			maker.Remaining -= qty
+			level.total -= qty
+
+			b.tradeSeq++
+			res.Trades = append(res.Trades, Trade{
+				ID:             b.tradeSeq,
+				Price:          level.price,
+				Quantity:       qty,
+				TakerOrderID:   o.ID,
+				MakerOrderID:   maker.ID,
+				TakerUserID:    o.UserID,
+				MakerUserID:    maker.UserID,
+				TakerSide:      o.Side,
+				MakerRemaining: maker.Remaining,
+			})
+
+			if maker.Remaining == 0 {
+				level.unlink(maker)
+				delete(b.orders, maker.ID)
+			}
+		}
+
+		if level.total == total {
+			return
+		}
+		res.addDelta(makerSide, level.price, level.total)
+		if level.head == nil {
+			opposite.remove(level)
+		}
+	}
+}
+
+// rest 将剩余部分挂入订单簿
+func (b *OrderBook) rest(o *Order, res *Result) {
+	levels := b.side(o.Side)
+	level := levels.get(o.Price)
+	if level == nil {
+		level = levels.insert(o.Price)
+	}
+	level.push(o)
+	b.orders[o.ID] = o
+	res.addDelta(o.Side, level.price, level.total)
+}
+
+// affordable 返回订单在price价位最多可成交的数量并扣除成交额。不限资金的订单返回qty；
+// 限定资金的市价买单按剩余资金向下取整到Lot，资金不足一个Lot时返回0
+func affordable(o *Order, price, qty int64, funds *int64) int64 {
+	if o.Funds == 0 {
+		return qty
+	}
+	// 剩余资金可买入的数量为funds*Unit/price，用128位中间结果避免溢出，商超出int64时不受限制
+	if hi, lo := bits.Mul64(uint64(*funds), uint64(o.Unit)); hi < uint64(price) {
+		max, _ := bits.Div64(hi, lo, uint64(price))
+		if lot := uint64(o.Lot); lot > 1 {
+			max -= max % lot
+		}
+		if max < uint64(qty) {
+			qty = int64(max)
+		}
+	}
+	if qty == 0 {
+		return 0
+	}
+	hi, lo := bits.Mul64(uint64(price), uint64(qty))
+	cost, rem := bits.Div64(hi, lo, uint64(o.Unit))
+	if rem > 0 {
+		cost++
+	}
+	*funds -= int64(cost)
+	return qty
+}
+
+// min64 返回较小值
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// crosses 判断订单是否可以与该价位成交
+func crosses(o *Order, price int64) bool {
+	switch {
+	case o.Type == Market:
+		return true
+	case o.Side == Buy:
+		return price <= o.Price
+	default:
+		return price >= o.Price
+	}
+}
+
+// collect 收集前limit个价位
+func collect(levels *levelList, limit int) []Level {
+	n := levels.len()
+	if limit > 0 && limit < n {
+		n = limit
+	}
+	out := make([]Level, 0, n)
+	for l := levels.front(); l != nil && len(out) < n; l = l.next[0] {
+		out = append(out, Level{Price: l.price, Quantity: l.total, Orders: l.count})
+	}
+	return out
+}
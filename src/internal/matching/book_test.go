@@ -0,0 +1,288 @@
+package matching
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// limit 构造GTC限价单
+func limit(id uint64, side Side, price, qty int64) *Order {
+	return &Order{ID: id, UserID: id, Side: side, Type: Limit, TimeInForce: GTC, Price: price, Quantity: qty}
+}
+
+// 测试价格优先、时间优先撮合及部分成交
+func TestPriceTimePriority(t *testing.T) {
+	book := NewOrderBook("BTC_USDT")
+
+	for _, o := range []*Order{
+		limit(1, Sell, 101, 5),
+		limit(2, Sell, 100, 3),
+		limit(3, Sell, 100, 4),
+		limit(4, Sell, 102, 1),
+	} {
+		res := book.Submit(o)
+		require.Equal(t, StatusNew, res.Status)
+	}
+	price, _ := book.BestAsk()
+	assert.Equal(t, int64(100), price)
+
+	res := book.Submit(limit(5, Buy, 101, 10))
+	assert.Equal(t, StatusFilled, res.Status)
+	assert.Equal(t, int64(10), res.Filled)
+	assert.Equal(t, []Trade{
+		{ID: 1, Price: 100, Quantity: 3, TakerOrderID: 5, MakerOrderID: 2, TakerUserID: 5, MakerUserID: 2, TakerSide: Buy, MakerRemaining: 0},
+		{ID: 2, Price: 100, Quantity: 4, TakerOrderID: 5, MakerOrderID: 3, TakerUserID: 5, MakerUserID: 3, TakerSide: Buy, MakerRemaining: 0},
+		{ID: 3, Price: 101, Quantity: 3, TakerOrderID: 5, MakerOrderID: 1, TakerUserID: 5, MakerUserID: 1, TakerSide: Buy, MakerRemaining: 2},
+	}, res.Trades)
+	assert.Equal(t, []BookDelta{
+		{Side: Sell, Price: 100, Quantity: 0},
+		{Side: Sell, Price: 101, Quantity: 2},
+	}, res.Deltas)
+
+	// 剩余部分挂单
+	res = book.Submit(limit(6, Buy, 101, 5))
+	assert.Equal(t, StatusPartiallyFilled, res.Status)
+	assert.Equal(t, int64(2), res.Filled)
+	assert.Equal(t, int64(3), res.Remaining)
+	assert.Equal(t, []BookDelta{
+		{Side: Sell, Price: 101, Quantity: 0},
+		{Side: Buy, Price: 101, Quantity: 3},
+	}, res.Deltas)
+
+	bids, asks := book.Depth(10)
+	assert.Equal(t, []Level{{Price: 101, Quantity: 3, Orders: 1}}, bids)
+	assert.Equal(t, []Level{{Price: 102, Quantity: 1, Orders: 1}}, asks)
+	assert.Equal(t, 2, book.Len())
+	assert.Equal(t, uint64(4), book.TradeSeq())
+}
+
+// 测试市价单和IOC单的剩余部分撤销
+func TestMarketAndIOC(t *testing.T) {
+	book := NewOrderBook("BTC_USDT")
+	book.Submit(limit(1, Buy, 99, 2))
+	book.Submit(limit(2, Buy, 98, 2))
+
+	res := book.Submit(&Order{ID: 3, Side: Sell, Type: Market, TimeInForce: IOC, Quantity: 5})
+	assert.Equal(t, StatusCanceled, res.Status)
+	assert.Equal(t, int64(4), res.Filled)
+	assert.Equal(t, int64(1), res.Remaining)
+	require.Len(t, res.Trades, 2)
+	assert.Equal(t, int64(99), res.Trades[0].Price)
+	assert.Equal(t, int64(98), res.Trades[1].Price)
+	assert.Equal(t, 0, book.Len())
+
+	res = book.Submit(&Order{ID: 4, Side: Buy, Type: Market, TimeInForce: IOC, Quantity: 1})
+	assert.Equal(t, StatusCanceled, res.Status)
+	assert.Equal(t, ReasonNoLiquidity, res.Reason)
+
+	book.Submit(limit(5, Sell, 105, 2))
+	res = book.Submit(&Order{ID: 6, Side: Buy, Type: Limit, TimeInForce: IOC, Price: 104, Quantity: 1})
+	assert.Equal(t, StatusCanceled, res.Status)
+	assert.Empty(t, res.Trades)
+	assert.Equal(t, 1, book.Len())
+}
+
+// 测试限定资金的市价买单按剩余资金和最小单位限制成交数量，资金不足时停止撮合
+func TestMarketBuyFunds(t *testing.T) {
+	book := NewOrderBook("BTC_USDT")
+	book.Submit(limit(1, Sell, 100, 20))
+	book.Submit(limit(2, Sell, 120, 50))
+
+	// 价格和数量均放大10倍：成交额为价格乘数量除以10，资金300可买入100价位的20和120价位的8（按Lot为2取整）
+	market := func(id uint64, tif TimeInForce, qty, funds int64) *Order {
+		return &Order{ID: id, Side: Buy, Type: Market, TimeInForce: tif, Quantity: qty, Funds: funds, Unit: 10, Lot: 2}
+	}
+	res := book.Submit(market(3, FOK, 40, 300))
+	assert.Equal(t, StatusCanceled, res.Status)
+	assert.Equal(t, ReasonFillOrKill, res.Reason)
+	assert.Empty(t, res.Trades)
+
+	res = book.Submit(market(4, IOC, 40, 300))
+	assert.Equal(t, StatusCanceled, res.Status)
+	assert.Equal(t, ReasonNone, res.Reason)
+	assert.Equal(t, int64(28), res.Filled)
+	require.Len(t, res.Trades, 2)
+	assert.Equal(t, int64(8), res.Trades[1].Quantity)
+	assert.Equal(t, []BookDelta{
+		{Side: Sell, Price: 100, Quantity: 0},
+		{Side: Sell, Price: 120, Quantity: 42},
+	}, res.Deltas)
+
+	res = book.Submit(market(5, IOC, 10, 23))
+	assert.Equal(t, StatusCanceled, res.Status)
+	assert.Equal(t, ReasonInsufficientFunds, res.Reason)
+	assert.Empty(t, res.Deltas)
+
+	res = book.Submit(market(6, FOK, 10, 120))
+	assert.Equal(t, StatusFilled, res.Status)
+	assert.Equal(t, int64(32), book.Orders(Sell)[0].Remaining)
+
+	res = book.Submit(&Order{ID: 7, Side: Sell, Type: Market, TimeInForce: IOC, Quantity: 1, Funds: 10, Unit: 10})
+	assert.Equal(t, ReasonInvalidQuantity, res.Reason)
+}
+
+// 测试FOK单全部成交或整单撤销
+func TestFillOrKill(t *testing.T) {
+	book := NewOrderBook("BTC_USDT")
+	book.Submit(limit(1, Sell, 100, 2))
+	book.Submit(limit(2, Sell, 101, 2))
+
+	res := book.Submit(&Order{ID: 3, Side: Buy, Type: Limit, TimeInForce: FOK, Price: 100, Quantity: 3})
+	assert.Equal(t, StatusCanceled, res.Status)
+	assert.Equal(t, ReasonFillOrKill, res.Reason)
+	assert.Empty(t, res.Trades)
+	assert.Empty(t, res.Deltas)
+	assert.Equal(t, 2, book.Len())
+
+	res = book.Submit(&Order{ID: 4, Side: Buy, Type: Market, TimeInForce: FOK, Quantity: 4})
+	assert.Equal(t, StatusFilled, res.Status)
+	assert.Len(t, res.Trades, 2)
+	assert.Equal(t, 0, book.Len())
+}
+
+// 测试只做挂单
+func TestPostOnly(t *testing.T) {
+	book := NewOrderBook("BTC_USDT")
+	book.Submit(limit(1, Sell, 100, 2))
+
+	res := book.Submit(&Order{ID: 2, Side: Buy, Type: Limit, TimeInForce: PostOnly, Price: 100, Quantity: 1})
+	assert.Equal(t, StatusRejected, res.Status)
+	assert.Equal(t, ReasonPostOnlyWouldTake, res.Reason)
+
+	res = book.Submit(&Order{ID: 3, Side: Buy, Type: Limit, TimeInForce: PostOnly, Price: 99, Quantity: 1})
+	assert.Equal(t, StatusNew, res.Status)
+	price, ok := book.BestBid()
+	assert.True(t, ok)
+	assert.Equal(t, int64(99), price)
+}
+
+// 测试撤单和参数校验
+func TestCancelAndValidation(t *testing.T) {
+	book := NewOrderBook("BTC_USDT")
+	book.Submit(limit(1, Buy, 100, 2))
+	book.Submit(limit(2, Buy, 100, 3))
+
+	res := book.Cancel(1)
+	assert.Equal(t, StatusCanceled, res.Status)
+	assert.Equal(t, int64(2), res.Remaining)
+	assert.Equal(t, []BookDelta{{Side: Buy, Price: 100, Quantity: 3}}, res.Deltas)
+
+	res = book.Cancel(2)
+	assert.Equal(t, []BookDelta{{Side: Buy, Price: 100, Quantity: 0}}, res.Deltas)
+	_, ok := book.BestBid()
+	assert.False(t, ok)
+
+	assert.Equal(t, ReasonUnknownOrder, book.Cancel(2).Reason)
+
+	book.Submit(limit(3, Buy, 100, 1))
+	cases := map[Reason]*Order{
+		ReasonInvalidQuantity:  limit(10, Buy, 100, 0),
+		ReasonInvalidPrice:     limit(11, Buy, 0, 1),
+		ReasonInvalidOrderType: {ID: 12, Side: Buy, Type: Market, TimeInForce: GTC, Quantity: 1},
+		ReasonDuplicateOrder:   limit(3, Buy, 100, 1),
+	}
+	for reason, o := range cases {
+		res := book.Submit(o)
+		assert.Equal(t, StatusRejected, res.Status)
+		assert.Equal(t, reason, res.Reason)
+	}
+	assert.Equal(t, 1, book.Len())
+}
+
+// 测试随机订单流下订单簿的不变量：买卖不交叉、价位总量与订单一致、成交量守恒
+func TestRandomOrderFlowInvariants(t *testing.T) {
+	book := NewOrderBook("BTC_USDT")
+	rng := rand.New(rand.NewSource(42))
+	var open []uint64
+
+	for i := uint64(1); i <= 20000; i++ {
+		if len(open) > 0 && rng.Intn(4) == 0 {
+			idx := rng.Intn(len(open))
+			book.Cancel(open[idx])
+			open = append(open[:idx], open[idx+1:]...)
+			continue
+		}
+
+		o := randomOrder(rng, i)
+		res := book.Submit(o)
+		var traded int64
+		for _, tr := range res.Trades {
+			traded += tr.Quantity
+		}
+		require.Equal(t, res.Filled, traded)
+		require.Equal(t, o.Quantity, res.Filled+res.Remaining)
+		if res.Status == StatusNew || res.Status == StatusPartiallyFilled {
+			open = append(open, o.ID)
+		}
+
+		if bid, ok := book.BestBid(); ok {
+			if ask, ok := book.BestAsk(); ok {
+				require.Less(t, bid, ask)
+			}
+		}
+	}
+
+	// 校验价位汇总与挂单一致
+	for _, side := range []Side{Buy, Sell} {
+		var sum int64
+		for _, o := range book.Orders(side) {
+			sum += o.Remaining
+		}
+		var levels int64
+		for l := book.side(side).front(); l != nil; l = l.next[0] {
+			levels += l.total
+		}
+		assert.Equal(t, sum, levels)
+	}
+}
+
+// randomOrder 在中间价附近生成随机订单
+func randomOrder(rng *rand.Rand, id uint64) *Order {
+	o := &Order{
+		ID:          id,
+		UserID:      uint64(rng.Intn(100) + 1),
+		Side:        Side(rng.Intn(2) + 1),
+		Type:        Limit,
+		TimeInForce: GTC,
+		Price:       10000 + int64(rng.Intn(200)) - 100,
+		Quantity:    int64(rng.Intn(100) + 1),
+	}
+	switch rng.Intn(10) {
+	case 0:
+		o.Type, o.TimeInForce, o.Price = Market, IOC, 0
+	case 1:
+		o.TimeInForce = IOC
+	case 2:
+		o.TimeInForce = FOK
+	case 3:
+		o.TimeInForce = PostOnly
+	}
+	return o
+}
+
+// 测试从快照恢复的订单簿与原订单簿撮合结果一致
+func TestSnapshotRestore(t *testing.T) {
+	book := NewOrderBook("BTC_USDT")
+	rng := rand.New(rand.NewSource(7))
+	for i := uint64(1); i <= 2000; i++ {
+		book.Submit(randomOrder(rng, i))
+	}
+
+	restored := RestoreOrderBook(book.Snapshot())
+	assert.Equal(t, book.Len(), restored.Len())
+	assert.Equal(t, book.TradeSeq(), restored.TradeSeq())
+	bids, asks := book.Depth(0)
+	rbids, rasks := restored.Depth(0)
+	assert.Equal(t, bids, rbids)
+	assert.Equal(t, asks, rasks)
+
+	for i := uint64(2001); i <= 4000; i++ {
+		o := randomOrder(rng, i)
+		c := *o
+		require.Equal(t, book.Submit(o), restored.Submit(&c))
+	}
+	assert.Equal(t, book.Snapshot(), restored.Snapshot())
+}
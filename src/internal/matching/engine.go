@@ -0,0 +1,45 @@
+package matching
+
+import "sort"
+
+// Engine 撮合引擎，按交易对管理订单簿
+type Engine struct {
+	books map[string]*OrderBook
+}
+
+// NewEngine 创建撮合引擎
+func NewEngine() *Engine {
+	return &Engine{
+		books: make(map[string]*OrderBook),
+	}
+}
+
+// Book 返回交易对的订单簿，不存在时创建
+func (e *Engine) Book(symbol string) *OrderBook {
+	book, ok := e.books[symbol]
+	if !ok {
+		book = NewOrderBook(symbol)
+		e.books[symbol] = book
+	}
+	return book
+}
+
+// Symbols 返回已有订单簿的交易对
+func (e *Engine) Symbols() []string {
+	symbols := make([]string, 0, len(e.books))
+	for symbol := range e.books {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// Submit 向交易对的订单簿提交订单
+func (e *Engine) Submit(symbol string, o *Order) *Result {
+	return e.Book(symbol).Submit(o)
+}
+
+// Cancel 撤销交易对订单簿中的订单
+func (e *Engine) Cancel(symbol string, id uint64) *Result {
+	return e.Book(symbol).Cancel(id)
+}
@@ -0,0 +1,199 @@
+// Package matching 内存撮合引擎，每个交易对一个按价格优先、时间优先撮合的订单簿。
+// 价格和数量均为按交易对精度缩放后的整数，引擎本身不是并发安全的，须由单一协程驱动。
+package matching
+
+// Side 买卖方向
+type Side uint8
+
+// 买卖方向取值
+const (
+	Buy Side = iota + 1
+	Sell
+)
+
+// String 返回方向名称
+func (s Side) String() string {
+	switch s {
+	case Buy:
+		return "buy"
+	case Sell:
+		return "sell"
+	default:
+		return "unknown"
+	}
+}
+
+// Opposite 返回对手方向
+func (s Side) Opposite() Side {
+	if s == Buy {
+		return Sell
+	}
+	return Buy
+}
+
+// OrderType 订单类型
+type OrderType uint8
+
+// 订单类型取值
+const (
+	Limit OrderType = iota + 1
+	Market
+)
+
+// TimeInForce 订单有效方式
+type TimeInForce uint8
+
+// 订单有效方式取值
+const (
+	GTC      TimeInForce = iota + 1 // 未成交部分挂单直至撤销
+	IOC                             // 立即成交，剩余撤销
+	FOK                             // 全部立即成交，否则整单撤销
+	PostOnly                        // 只做挂单，会立即成交时拒绝
+)
+
+// Status 订单处理后的状态
+type Status uint8
+
+// 订单状态取值
+const (
+	StatusNew Status = iota + 1
+	StatusPartiallyFilled
+	StatusFilled
+	StatusCanceled
+	StatusRejected
+)
+
+// String 返回状态名称，与订单模型的状态值一致
+func (s Status) String() string {
+	switch s {
+	case StatusNew:
+		return "new"
+	case StatusPartiallyFilled:
+		return "partially_filled"
+	case StatusFilled:
+		return "filled"
+	case StatusCanceled:
+		return "canceled"
+	case StatusRejected:
+		return "rejected"
+	default:
+		return "unknown"
+	}
+}
+
+// Reason 拒绝或撤销原因
+type Reason uint8
+
+// 拒绝或撤销原因取值
+const (
+	ReasonNone Reason = iota
+	ReasonInvalidQuantity
+	ReasonInvalidPrice
+	ReasonInvalidOrderType
+	ReasonDuplicateOrder
+	ReasonPostOnlyWouldTake
+	ReasonFillOrKill
+	ReasonNoLiquidity
+	ReasonUnknownOrder
+	ReasonInsufficientFunds
+)
+
+// String 返回原因代码
+func (r Reason) String() string {
+	switch r {
+	case ReasonNone:
+		return ""
+	case ReasonInvalidQuantity:
+		return "invalid_quantity"
+	case ReasonInvalidPrice:
+		return "invalid_price"
+	case ReasonInvalidOrderType:
+		return "invalid_order_type"
+	case ReasonDuplicateOrder:
+		return "duplicate_order"
+	case ReasonPostOnlyWouldTake:
+		return "post_only_would_take"
+	case ReasonFillOrKill:
+		return "fill_or_kill"
+	case ReasonNoLiquidity:
+		return "no_liquidity"
+	case ReasonUnknownOrder:
+		return "unknown_order"
+	case ReasonInsufficientFunds:
+		return "insufficient_funds"
+	default:
+		return "unknown"
+	}
+}
+
+// Order 引擎内的订单。挂单后由订单簿持有，调用方不得再修改。
+type Order struct {
+	ID          uint64      `json:"id"`
+	UserID      uint64      `json:"user_id"`
+	Side        Side        `json:"side"`
+	Type        OrderType   `json:"type"`
+	TimeInForce TimeInForce `json:"time_in_force"`
+	Price       int64       `json:"price"`
+	Quantity    int64       `json:"quantity"`
+	Remaining   int64       `json:"remaining"`
+
+	// Funds 市价买单最多花费的计价资产，与价格同单位，0表示不限。成交额为价格乘数量除以Unit并向上取整，
+	// 受Funds限制时成交数量按Lot向下取整，剩余资金不足时停止撮合
+	Funds int64 `json:"funds,omitempty"`
+	Unit  int64 `json:"unit,omitempty"`
+	Lot   int64 `json:"lot,omitempty"`
+
+	// 所在价位队列中的前后订单
+	prev, next *Order
+	level      *priceLevel
+}
+
+// Trade 一笔成交，成交价为挂单方价格
+type Trade struct {
+	ID             uint64
+	Price          int64
+	Quantity       int64
+	TakerOrderID   uint64
+	MakerOrderID   uint64
+	TakerUserID    uint64
+	MakerUserID    uint64
+	TakerSide      Side
+	MakerRemaining int64
+}
+
+// BookDelta 价位变化，Quantity为该价位变化后的挂单总量，0表示价位被移除
+type BookDelta struct {
+	Side     Side
+	Price    int64
+	Quantity int64
+}
+
+// Result 一次下单或撤单的处理结果
+type Result struct {
+	Symbol    string
+	OrderID   uint64
+	UserID    uint64 // 订单所属用户，撤销未知订单时为0
+	Seq       uint64 // 定序器为命令分配的序号，由定序器填写
+	Status    Status
+	Reason    Reason
+	Filled    int64
+	Remaining int64
+	Trades    []Trade
+	Deltas    []BookDelta
+}
+
+// addDelta 记录价位变化，同一价位连续变化时只保留最终数量
+func (r *Result) addDelta(side Side, price, quantity int64) {
+	if n := len(r.Deltas); n > 0 && r.Deltas[n-1].Side == side && r.Deltas[n-1].Price == price {
+		r.Deltas[n-1].Quantity = quantity
+		return
+	}
+	r.Deltas = append(r.Deltas, BookDelta{Side: side, Price: price, Quantity: quantity})
+}
+
+// Level 深度中的一个价位
+type Level struct {
+	Price    int64 `json:"price"`
+	Quantity int64 `json:"quantity"`
+	Orders   int   `json:"orders"`
+}
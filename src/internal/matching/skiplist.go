@@ -0,0 +1,154 @@
+package matching
+
+// maxHeight 跳表最大层数，p=1/4时可支撑数百万个价位
+const maxHeight = 12
+
+// priceLevel 一个价位及其按时间排序的订单队列，同时作为跳表节点
+type priceLevel struct {
+	price int64
+	total int64
+	count int
+	head  *Order
+	tail  *Order
+	next  []*priceLevel
+}
+
+// push 将订单追加到队尾
+func (l *priceLevel) push(o *Order) {
+	o.level = l
+	o.prev = l.tail
+	o.next = nil
+	if l.tail != nil {
+		l.tail.next = o
+	} else {
+		l.head = o
+	}
+	l.tail = o
+	l.total += o.Remaining
+	l.count++
+}
+
+// unlink 将订单移出队列，不调整总量
+func (l *priceLevel) unlink(o *Order) {
+	if o.prev != nil {
+		o.prev.next = o.next
+	} else {
+		l.head = o.next
+	}
+	if o.next != nil {
+		o.next.prev = o.prev
+	} else {
+		l.tail = o.prev
+	}
+	o.prev, o.next, o.level = nil, nil, nil
+	l.count--
+}
+
+// levelList 按价格排序的价位跳表，买方价格从高到低，卖方从低到高
+type levelList struct {
+	head    priceLevel
+	height  int
+	desc    bool
+	seed    uint64
+	byPrice map[int64]*priceLevel
+}
+
+// newLevelList 创建价位跳表，随机层数使用固定种子以保证结构可复现
+func newLevelList(desc bool) *levelList {
+	return &levelList{
+		head:    priceLevel{next: make([]*priceLevel, maxHeight)},
+		height:  1,
+		desc:    desc,
+		seed:    0x9E3779B97F4A7C15,
+		byPrice: make(map[int64]*priceLevel),
+	}
+}
+
+// before 判断价格a是否排在b之前
+func (s *levelList) before(a, b int64) bool {
+	if s.desc {
+		return a > b
+	}
+	return a < b
+}
+
+// front 返回最优价位
+func (s *levelList) front() *priceLevel {
+	return s.head.next[0]
+}
+
+// len 返回价位数量
+func (s *levelList) len() int {
+	return len(s.byPrice)
+}
+
+// get 按价格查找价位
+func (s *levelList) get(price int64) *priceLevel {
+	return s.byPrice[price]
+}
+
+// insert 插入新价位，调用方须确认该价位不存在
+func (s *levelList) insert(price int64) *priceLevel {
+	var update [maxHeight]*priceLevel
+	x := &s.head
+	for i := s.height - 1; i >= 0; i-- {
+		for x.next[i] != nil && s.before(x.next[i].price, price) {
+			x = x.next[i]
+		}
+		update[i] = x
+	}
+
+	h := s.randomHeight()
+	if h > s.height {
+		for i := s.height; i < h; i++ {
+			update[i] = &s.head
+		}
+		s.height = h
+	}
+
+	level := &priceLevel{price: price, next: make([]*priceLevel, h)}
+	for i := 0; i < h; i++ {
+		level.next[i] = update[i].next[i]
+		update[i].next[i] = level
+	}
+	s.byPrice[price] = level
+	return level
+}
+
+// remove 删除价位，删除最优价位时无需查找前驱
+func (s *levelList) remove(level *priceLevel) {
+	if s.head.next[0] == level {
+		for i := range level.next {
+			s.head.next[i] = level.next[i]
+		}
+	} else {
+		x := &s.head
+		for i := s.height - 1; i >= 0; i-- {
+			for x.next[i] != nil && s.before(x.next[i].price, level.price) {
+				x = x.next[i]
+			}
+			if i < len(level.next) && x.next[i] == level {
+				x.next[i] = level.next[i]
+			}
+		}
+	}
+	for s.height > 1 && s.head.next[s.height-1] == nil {
+		s.height--
+	}
+	delete(s.byPrice, level.price)
+}
+
+// randomHeight 按p=1/4生成节点层数（xorshift64*）
+func (s *levelList) randomHeight() int {
+	h := 1
+	for h < maxHeight {
+		s.seed ^= s.seed >> 12
+		s.seed ^= s.seed << 25
+		s.seed ^= s.seed >> 27
+		if (s.seed*2685821657736338717)>>62 != 0 {
+			break
+		}
+		h++
+	}
+	return h
+}
@@ -0,0 +1,38 @@
+package matching
+
+// Snapshot 订单簿快照，挂单按价格优先、时间优先的顺序排列
+type Snapshot struct {
+	Symbol   string  `json:"symbol"`
+	TradeSeq uint64  `json:"trade_seq"`
+	Bids     []Order `json:"bids"`
+	Asks     []Order `json:"asks"`
+}
+
+// Snapshot 生成订单簿快照
+func (b *OrderBook) Snapshot() *Snapshot {
+	return &Snapshot{
+		Symbol:   b.Symbol,
+		TradeSeq: b.tradeSeq,
+		Bids:     b.Orders(Buy),
+		Asks:     b.Orders(Sell),
+	}
+}
+
+// RestoreOrderBook 从快照恢复订单簿，恢复后的撮合结果与生成快照时的订单簿一致
+func RestoreOrderBook(snap *Snapshot) *OrderBook {
+	b := NewOrderBook(snap.Symbol)
+	b.tradeSeq = snap.TradeSeq
+	for _, orders := range [][]Order{snap.Bids, snap.Asks} {
+		for i := range orders {
+			o := orders[i]
+			levels := b.side(o.Side)
+			level := levels.get(o.Price)
+			if level == nil {
+				level = levels.insert(o.Price)
+			}
+			level.push(&o)
+			b.orders[o.ID] = &o
+		}
+	}
+	return b
+}
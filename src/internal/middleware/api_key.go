@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/service"
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// API Key签名请求头
+const (
+	HeaderAPIKey       = "X-API-KEY"
+	HeaderAPITimestamp = "X-API-TIMESTAMP"
+	HeaderAPISignature = "X-API-SIGNATURE"
+	HeaderRecvWindow   = "X-API-RECV-WINDOW"
+)
+
+// ContextAPIKey API Key在上下文中的键
+const ContextAPIKey = "api_key"
+
+// maxSignedBodySize 参与签名的请求体大小上限
+const maxSignedBodySize = 1 << 20
+
+// APIKeyAuth API Key签名认证中间件。
+// 签名为 HMAC-SHA256(secret, timestamp + "\n" + recvWindow + "\n" + METHOD + "\n" + path?query + "\n" + body) 的十六进制值，
+// recvWindow为X-API-RECV-WINDOW请求头的值，未携带时为空，见service.SignaturePayload。
+func APIKeyAuth(keys *service.APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authenticateAPIKey(c, keys) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// Authenticate 组合认证中间件：携带X-API-KEY头时走API Key签名认证，否则走JWT认证
+func Authenticate(auth *service.AuthService, keys *service.APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ok bool
+		if c.GetHeader(HeaderAPIKey) != "" {
+			ok = authenticateAPIKey(c, keys)
+		} else {
+			ok = authenticateJWT(c, auth)
+		}
+		if !ok {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// OptionalAuthenticate 可选认证中间件：携带Authorization或X-API-KEY头时按Authenticate认证，认证失败返回401；
+// 未携带时作为匿名请求继续处理，GetUserID返回0
+func OptionalAuthenticate(auth *service.AuthService, keys *service.APIKeyService) gin.HandlerFunc {
+	required := Authenticate(auth, keys)
+	return func(c *gin.Context) {
+		if c.GetHeader(HeaderAPIKey) == "" && c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		required(c)
+	}
+}
+
+// RequireScope 要求API Key具备指定权限范围，交互式登录（JWT）不受限制
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString(ContextAuthMethod) == AuthMethodAPIKey {
+			key := GetAPIKey(c)
+			if key == nil || !key.HasScope(scope) {
+				utils.Forbidden(c, "API key lacks required scope: "+scope)
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// GetAPIKey 获取当前请求使用的API Key
+func GetAPIKey(c *gin.Context) *model.APIKey {
+	if v, ok := c.Get(ContextAPIKey); ok {
+		if key, ok := v.(*model.APIKey); ok {
+			return key
+		}
+	}
+	return nil
+}
+
+// authenticateAPIKey 校验API Key签名并写入上下文，失败时写入响应并返回false
+func authenticateAPIKey(c *gin.Context, keys *service.APIKeyService) bool {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxSignedBodySize+1))
+	if err != nil || len(body) > maxSignedBodySize {
+		utils.BadRequest(c, "Invalid request body")
+		return false
+	}
+	// 恢复请求体，供后续处理器绑定
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	key, err := keys.Verify(c.Request.Context(), service.SignedRequest{
+		AccessKey:  c.GetHeader(HeaderAPIKey),
+		Timestamp:  c.GetHeader(HeaderAPITimestamp),
+		Signature:  c.GetHeader(HeaderAPISignature),
+		RecvWindow: c.GetHeader(HeaderRecvWindow),
+		Method:     c.Request.Method,
+		Path:       c.Request.URL.RequestURI(),
+		Body:       body,
+		ClientIP:   c.ClientIP(),
+	})
+	if err != nil {
+		log.Printf("API key auth failed: request_id=%s ip=%s err=%v", c.GetString("request_id"), c.ClientIP(), err)
+		switch {
+		case errors.Is(err, service.ErrIPNotAllowed):
+			utils.Forbidden(c, err.Error())
+		case errors.Is(err, service.ErrInvalidAPIKey),
+			errors.Is(err, service.ErrInvalidSignature),
+			errors.Is(err, service.ErrTimestampOutOfRange),
+			errors.Is(err, service.ErrRequestReplayed),
+			errors.Is(err, service.ErrUserInactive):
+			utils.Unauthorized(c, err.Error())
+		default:
+			utils.InternalServerError(c, "Failed to verify api key")
+		}
+		return false
+	}
+
+	c.Set(ContextUserID, key.UserID)
+	c.Set(ContextUsername, key.User.Username)
+	c.Set(ContextAPIKey, key)
+	c.Set(ContextAuthMethod, AuthMethodAPIKey)
+	return true
+}
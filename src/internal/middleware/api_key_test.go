@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"awesome-trade/src/internal/config"
+	"awesome-trade/src/internal/database"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/internal/service"
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 创建API Key测试路由，返回路由和一个具备read、trade权限的Key
+func setupAPIKeyRouter(t *testing.T, allowlist []string) (*gin.Engine, *service.CreatedAPIKey) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := database.Open(config.DatabaseConfig{Driver: database.DriverSQLite, Path: ":memory:"})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.User{}, &model.APIKey{}))
+
+	user := &model.User{Username: "bot", Email: "bot@example.com", Password: "x", IsActive: true}
+	require.NoError(t, db.Create(user).Error)
+
+	keys := service.NewAPIKeyService(repository.NewAPIKeyRepository(db), utils.NewCipher("test-key"), config.APIKeyConfig{
+		RecvWindow:    5000,
+		MaxRecvWindow: 60000,
+	})
+	key, err := keys.Create(context.Background(), user.ID, service.CreateAPIKeyInput{
+		Scopes:      []string{model.ScopeRead, model.ScopeTrade},
+		IPAllowlist: allowlist,
+	})
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.Use(APIKeyAuth(keys))
+	r.POST("/orders", RequireScope(model.ScopeTrade), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.JSON(200, gin.H{"user_id": GetUserID(c), "body": string(body)})
+	})
+	r.POST("/withdraw", RequireScope(model.ScopeWithdraw), func(c *gin.Context) {
+		c.JSON(200, gin.H{})
+	})
+	return r, key
+}
+
+// 构造签名请求
+func signedRequest(key *service.CreatedAPIKey, method, path, body string, ts time.Time) *http.Request {
+	timestamp := strconv.FormatInt(ts.UnixMilli(), 10)
+	req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set(HeaderAPIKey, key.AccessKey)
+	req.Header.Set(HeaderAPITimestamp, timestamp)
+	req.Header.Set(HeaderAPISignature, service.SignRequest(key.Secret, service.SignaturePayload(timestamp, "", method, path, []byte(body))))
+	req.RemoteAddr = "10.0.0.1:12345"
+	return req
+}
+
+// 测试API Key签名认证
+func TestAPIKeyAuth(t *testing.T) {
+	r, key := setupAPIKeyRouter(t, nil)
+
+	// 有效签名，请求体可被后续处理器读取
+	now := time.Now()
+	req := signedRequest(key, "POST", "/orders?symbol=BTCUSDT", `{"qty":"1"}`, now)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), `"body":"{\"qty\":\"1\"}"`)
+
+	// 重放同一请求
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, signedRequest(key, "POST", "/orders?symbol=BTCUSDT", `{"qty":"1"}`, now))
+	assert.Equal(t, 401, w.Code)
+
+	// 篡改请求体
+	req = signedRequest(key, "POST", "/orders", `{"qty":"1"}`, time.Now())
+	req.Body = io.NopCloser(bytes.NewBufferString(`{"qty":"100"}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 401, w.Code)
+
+	// 超出接收窗口
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, signedRequest(key, "POST", "/orders", `{}`, time.Now().Add(-10*time.Second)))
+	assert.Equal(t, 401, w.Code)
+
+	// 接收窗口参与签名：重放时放大窗口导致签名不匹配
+	old := time.Now().Add(-10 * time.Second)
+	req = signedRequest(key, "POST", "/orders", `{}`, old)
+	req.Header.Set(HeaderRecvWindow, "60000")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 401, w.Code)
+
+	timestamp := strconv.FormatInt(old.UnixMilli(), 10)
+	req = signedRequest(key, "POST", "/orders", `{}`, old)
+	req.Header.Set(HeaderRecvWindow, "60000")
+	req.Header.Set(HeaderAPISignature, service.SignRequest(key.Secret, service.SignaturePayload(timestamp, "60000", "POST", "/orders", []byte(`{}`))))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	// 缺少权限范围
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, signedRequest(key, "POST", "/withdraw", `{}`, time.Now()))
+	assert.Equal(t, 403, w.Code)
+
+	// 字段以分隔符隔开，移动字段边界改变待签名内容
+	assert.NotEqual(t, service.SignaturePayload("1700000000000", "5000", "GET", "/orders", []byte("?a=1")),
+		service.SignaturePayload("17000000000005", "000", "GET", "/orders?a=1", nil))
+	assert.Equal(t, "1700000000000\n\nGET\n/orders\n{}", service.SignaturePayload("1700000000000", "", "get", "/orders", []byte("{}")))
+}
+
+// 测试IP白名单
+func TestAPIKeyIPAllowlist(t *testing.T) {
+	r, key := setupAPIKeyRouter(t, []string{"192.168.0.0/16"})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, signedRequest(key, "POST", "/orders", `{}`, time.Now()))
+	assert.Equal(t, 403, w.Code)
+
+	req := signedRequest(key, "POST", "/orders", `{}`, time.Now())
+	req.RemoteAddr = "192.168.1.10:12345"
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"errors"
+	"strings"
+
+	"awesome-trade/src/internal/service"
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 上下文键
+const (
+	ContextUserID     = "user_id"
+	ContextUsername   = "username"
+	ContextClaims     = "token_claims"
+	ContextAuthMethod = "auth_method"
+)
+
+// 认证方式
+const (
+	AuthMethodJWT    = "jwt"
+	AuthMethodAPIKey = "api_key"
+)
+
+// JWTAuth JWT认证中间件，校验签名、有效期、吊销状态以及用户是否仍然有效
+func JWTAuth(auth *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authenticateJWT(c, auth) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// authenticateJWT 校验Bearer令牌并写入上下文，失败时写入401响应并返回false
+func authenticateJWT(c *gin.Context, auth *service.AuthService) bool {
+	// 获取Authorization头
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		utils.Unauthorized(c, "Authorization header is required")
+		return false
+	}
+
+	// 检查Bearer token格式
+	tokenParts := strings.SplitN(authHeader, " ", 2)
+	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
+		utils.Unauthorized(c, "Invalid authorization header format")
+		return false
+	}
+
+	claims, err := auth.ParseAccessToken(c.Request.Context(), tokenParts[1])
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrTokenRevoked):
+			utils.Unauthorized(c, "Token has been revoked")
+		case errors.Is(err, service.ErrUserInactive):
+			utils.Unauthorized(c, "User is inactive")
+		case errors.Is(err, service.ErrInvalidToken):
+			utils.Unauthorized(c, "Invalid token")
+		default:
+			utils.InternalServerError(c, "Failed to verify token")
+		}
+		return false
+	}
+
+	// 将用户信息存储到上下文中
+	c.Set(ContextUserID, claims.UserID)
+	c.Set(ContextUsername, claims.Username)
+	c.Set(ContextClaims, claims)
+	c.Set(ContextAuthMethod, AuthMethodJWT)
+	return true
+}
+
+// GetUserID 获取当前认证用户ID，未认证时返回0
+func GetUserID(c *gin.Context) uint {
+	if v, ok := c.Get(ContextUserID); ok {
+		if id, ok := v.(uint); ok {
+			return id
+		}
+	}
+	return 0
+}
+
+// GetClaims 获取当前请求的令牌声明
+func GetClaims(c *gin.Context) *service.Claims {
+	if v, ok := c.Get(ContextClaims); ok {
+		if claims, ok := v.(*service.Claims); ok {
+			return claims
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"awesome-trade/src/internal/service"
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// contextPermissions 当前请求已加载的权限集合
+const contextPermissions = "permissions"
+
+// RequirePermission 要求当前用户拥有全部指定权限，需放在认证中间件之后
+func RequirePermission(rbac *service.RBACService, codes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, code := range codes {
+			ok, err := HasPermission(c, rbac, code)
+			if err != nil {
+				utils.InternalServerError(c, "Failed to load permissions")
+				c.Abort()
+				return
+			}
+			if !ok {
+				utils.Forbidden(c, "Permission denied: "+code)
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// HasPermission 判断当前用户是否拥有指定权限。
+// 权限集合在每个请求内只加载一次，处理器可多次调用而不会重复查询数据库。
+func HasPermission(c *gin.Context, rbac *service.RBACService, code string) (bool, error) {
+	perms, err := loadPermissions(c, rbac)
+	if err != nil {
+		return false, err
+	}
+	return perms[code], nil
+}
+
+// loadPermissions 获取并缓存当前请求用户的权限集合
+func loadPermissions(c *gin.Context, rbac *service.RBACService) (map[string]bool, error) {
+	if v, ok := c.Get(contextPermissions); ok {
+		return v.(map[string]bool), nil
+	}
+
+	userID := GetUserID(c)
+	if userID == 0 {
+		return map[string]bool{}, nil
+	}
+	perms, err := rbac.Permissions(c.Request.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+	c.Set(contextPermissions, perms)
+	return perms, nil
+}
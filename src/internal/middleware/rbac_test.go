@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"awesome-trade/src/internal/config"
+	"awesome-trade/src/internal/database"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// 测试权限校验及请求内缓存
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := database.Open(config.DatabaseConfig{Driver: database.DriverSQLite, Path: ":memory:"})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.User{}, &model.Permission{}, &model.Role{}, &model.UserRole{}))
+
+	ctx := context.Background()
+	rbac := service.NewRBACService(database.NewTxManager(db), repository.NewRoleRepository(db), repository.NewUserRepository(db))
+	require.NoError(t, rbac.Seed(ctx))
+	require.NoError(t, rbac.Seed(ctx))
+
+	support := &model.User{Username: "support", Email: "s@example.com", Password: "x", IsActive: true}
+	trader := &model.User{Username: "trader", Email: "t@example.com", Password: "x", IsActive: true}
+	require.NoError(t, db.Create(support).Error)
+	require.NoError(t, db.Create(trader).Error)
+	require.NoError(t, rbac.AssignRole(ctx, support.ID, model.RoleSupport))
+	require.NoError(t, rbac.AssignRole(ctx, support.ID, model.RoleSupport))
+
+	// 统计查询次数
+	queries := 0
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:count", func(*gorm.DB) {
+		queries++
+	}))
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if c.GetHeader("X-User") == "support" {
+			c.Set(ContextUserID, support.ID)
+		} else {
+			c.Set(ContextUserID, trader.ID)
+		}
+	})
+	r.GET("/orders", RequirePermission(rbac, model.PermOrdersReadAny), func(c *gin.Context) {
+		canRead, _ := HasPermission(c, rbac, model.PermUsersRead)
+		canCancel, _ := HasPermission(c, rbac, model.PermOrdersCancelAny)
+		c.JSON(200, gin.H{"users_read": canRead, "cancel_any": canCancel})
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/orders", nil)
+	req.Header.Set("X-User", "support")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.JSONEq(t, `{"users_read":true,"cancel_any":false}`, w.Body.String())
+	assert.Equal(t, 1, queries)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/orders", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 403, w.Code)
+}
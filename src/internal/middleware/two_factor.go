@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"awesome-trade/src/internal/service"
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderTwoFactorCode 敏感操作随请求提交两步验证码的请求头
+const HeaderTwoFactorCode = "X-2FA-CODE"
+
+// RequireFresh2FA 要求用户已启用两步验证，且在新鲜度窗口内完成过验证。
+// 也可以通过X-2FA-CODE请求头随本次请求提交验证码。需放在认证中间件之后。
+func RequireFresh2FA(twoFactor *service.TwoFactorService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		userID := GetUserID(c)
+
+		if code := c.GetHeader(HeaderTwoFactorCode); code != "" {
+			if err := twoFactor.Verify(ctx, userID, code); err != nil {
+				abortTwoFactor(c, err)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		fresh, err := twoFactor.VerifiedRecently(ctx, userID)
+		if err != nil {
+			abortTwoFactor(c, err)
+			return
+		}
+		if !fresh {
+			utils.Forbidden(c, "Fresh two-factor verification required")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// abortTwoFactor 将两步验证错误映射为HTTP响应并中止请求
+func abortTwoFactor(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrTwoFactorNotEnrolled), errors.Is(err, service.ErrTwoFactorRequired):
+		utils.Forbidden(c, service.ErrTwoFactorRequired.Error())
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		utils.Forbidden(c, err.Error())
+	case errors.Is(err, service.ErrTwoFactorLocked):
+		utils.Fail(c, http.StatusTooManyRequests, http.StatusTooManyRequests, err.Error())
+	default:
+		utils.InternalServerError(c, "Failed to verify two-factor code")
+	}
+	c.Abort()
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"net"
+	"strings"
+	"time"
+)
+
+// API Key权限范围
+const (
+	ScopeRead     = "read"
+	ScopeTrade    = "trade"
+	ScopeWithdraw = "withdraw"
+)
+
+// APIKey 程序化交易使用的API Key，密钥使用服务端密钥加密保存
+type APIKey struct {
+	BaseModel
+	UserID          uint       `gorm:"index;not null" json:"user_id"`
+	User            User       `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Label           string     `gorm:"size:64" json:"label"`
+	AccessKey       string     `gorm:"size:64;uniqueIndex;not null" json:"api_key"`
+	SecretEncrypted string     `gorm:"not null" json:"-"`
+	Scopes          string     `gorm:"not null" json:"scopes"`
+	IPAllowlist     string     `json:"ip_allowlist"`
+	LastUsedAt      *time.Time `json:"last_used_at"`
+}
+
+// ScopeList 返回权限范围列表
+func (k *APIKey) ScopeList() []string {
+	return splitList(k.Scopes)
+}
+
+// HasScope 判断是否拥有指定权限范围
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsIP 判断客户端IP是否在白名单内，未配置白名单时放行所有IP
+func (k *APIKey) AllowsIP(ip string) bool {
+	entries := splitList(k.IPAllowlist)
+	if len(entries) == 0 {
+		return true
+	}
+
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry, "/") {
+			if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(addr) {
+				return true
+			}
+			continue
+		}
+		if allowed := net.ParseIP(entry); allowed != nil && allowed.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList 拆分逗号分隔的列表，忽略空白项
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
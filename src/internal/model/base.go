@@ -21,4 +21,7 @@ type User struct {
 	Email    string `gorm:"uniqueIndex;not null" json:"email"`
 	Password string `gorm:"not null" json:"-"`
 	IsActive bool   `gorm:"default:true" json:"is_active"`
+	Tier     string `gorm:"size:16;not null;default:standard" json:"tier"` // 风控等级，决定适用的风控限额
+	// TokenVersion 令牌版本，修改密码时递增，之前签发的访问令牌和刷新令牌随之失效
+	TokenVersion uint `gorm:"not null;default:0" json:"-"`
 }
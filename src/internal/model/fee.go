@@ -0,0 +1,77 @@
+package model
+
+import (
+	"time"
+
+	"awesome-trade/src/pkg/decimal"
+)
+
+// 成交中的流动性角色
+const (
+	LiquidityMaker = "maker"
+	LiquidityTaker = "taker"
+)
+
+// FeeTier 手续费等级，用户按近30天成交额落入MinVolume不超过成交额的最高等级。
+// 没有等级记录的用户为0级；某等级未配置时使用交易对自身的费率。
+type FeeTier struct {
+	ID           uint            `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+	Level        int             `gorm:"not null;uniqueIndex" json:"level"`
+	MinVolume    decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"min_volume"`
+	MakerFeeRate decimal.Decimal `gorm:"type:numeric(10,6);not null" json:"maker_fee_rate"`
+	TakerFeeRate decimal.Decimal `gorm:"type:numeric(10,6);not null" json:"taker_fee_rate"`
+}
+
+// FeeOverride 某个手续费等级在某个交易对上的费率，优先于等级的默认费率
+type FeeOverride struct {
+	ID           uint            `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+	Symbol       string          `gorm:"size:32;not null;uniqueIndex:idx_fee_overrides_symbol_level,priority:1" json:"symbol"`
+	Level        int             `gorm:"not null;uniqueIndex:idx_fee_overrides_symbol_level,priority:2" json:"level"`
+	MakerFeeRate decimal.Decimal `gorm:"type:numeric(10,6);not null" json:"maker_fee_rate"`
+	TakerFeeRate decimal.Decimal `gorm:"type:numeric(10,6);not null" json:"taker_fee_rate"`
+}
+
+// FeePromotion 免手续费活动，[StartsAt, EndsAt)内的成交不收取也不返还手续费。Symbol为空表示全部交易对。
+type FeePromotion struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Symbol    string    `gorm:"size:32;not null" json:"symbol"`
+	StartsAt  time.Time `gorm:"not null;index" json:"starts_at"`
+	EndsAt    time.Time `gorm:"not null;index" json:"ends_at"`
+	Memo      string    `gorm:"size:255" json:"memo"`
+}
+
+// Active 判断活动在t时刻是否对交易对生效
+func (p *FeePromotion) Active(symbol string, t time.Time) bool {
+	return (p.Symbol == "" || p.Symbol == symbol) && !t.Before(p.StartsAt) && t.Before(p.EndsAt)
+}
+
+// UserFeeTier 用户的手续费等级，由每日任务按近30天成交额重新计算。Volume以计量资产计。
+type UserFeeTier struct {
+	UserID     uint            `gorm:"primarykey;autoIncrement:false" json:"user_id"`
+	Level      int             `gorm:"not null" json:"level"`
+	Volume     decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"volume"`
+	ComputedAt time.Time       `gorm:"not null" json:"computed_at"`
+}
+
+// Fill 订单的一笔成交，每笔撮合成交为买卖双方各记一条。
+// Fee为按成交时费率收取的手续费，以收到的资产计（买方为基础资产，卖方为计价资产），为负表示返佣。
+type Fill struct {
+	ID            uint            `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time       `gorm:"index:idx_fills_created_at" json:"created_at"`
+	UserID        uint            `gorm:"not null;index" json:"user_id"`
+	OrderID       uint            `gorm:"not null;uniqueIndex:idx_fills_order_trade,priority:1" json:"order_id"`
+	Symbol        string          `gorm:"size:32;not null" json:"symbol"`
+	TradeID       uint64          `gorm:"not null;uniqueIndex:idx_fills_order_trade,priority:2" json:"trade_id"`
+	Side          string          `gorm:"size:8;not null" json:"side"`
+	Liquidity     string          `gorm:"size:8;not null" json:"liquidity"`
+	Price         decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"price"`
+	Quantity      decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"quantity"`
+	QuoteQuantity decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"quote_quantity"`
+	Fee           decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"fee"`
+	FeeAsset      string          `gorm:"size:16;not null" json:"fee_asset"`
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"awesome-trade/src/pkg/decimal"
+)
+
+// K线周期
+const (
+	KlineInterval1m = "1m"
+	KlineInterval5m = "5m"
+	KlineInterval1h = "1h"
+	KlineInterval1d = "1d"
+)
+
+// KlineIntervals 支持的K线周期及其时长，周期按UTC对齐
+var KlineIntervals = map[string]time.Duration{
+	KlineInterval1m: time.Minute,
+	KlineInterval5m: 5 * time.Minute,
+	KlineInterval1h: time.Hour,
+	KlineInterval1d: 24 * time.Hour,
+}
+
+// Kline 一个周期内的成交汇总（K线）。LastTradeID为已计入的最后一笔成交编号，
+// 用于在重放撮合事件时跳过已汇总的成交。
+type Kline struct {
+	ID          uint            `gorm:"primarykey" json:"-"`
+	Symbol      string          `gorm:"size:32;not null;uniqueIndex:idx_klines_symbol_interval_open_time,priority:1" json:"symbol"`
+	Interval    string          `gorm:"size:8;not null;uniqueIndex:idx_klines_symbol_interval_open_time,priority:2" json:"interval"`
+	OpenTime    time.Time       `gorm:"not null;uniqueIndex:idx_klines_symbol_interval_open_time,priority:3" json:"open_time"`
+	Open        decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"open"`
+	High        decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"high"`
+	Low         decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"low"`
+	Close       decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"close"`
+	Volume      decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"volume"`
+	QuoteVolume decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"quote_volume"`
+	Trades      int             `gorm:"not null" json:"trades"`
+	LastTradeID uint64          `gorm:"not null" json:"-"`
+}
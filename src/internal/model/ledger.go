@@ -0,0 +1,75 @@
+package model
+
+import (
+	"time"
+
+	"awesome-trade/src/pkg/decimal"
+)
+
+// 账户类型
+const (
+	AccountKindSpot     = "spot"     // 用户现货账户
+	AccountKindExternal = "external" // 系统外部清算账户，充值和提现的对手方，余额为负表示平台对用户的负债
+	AccountKindRevenue  = "revenue"  // 系统手续费收入账户，收取的手续费记入、返佣从中支出
+)
+
+// SystemUserID 系统账户的用户ID
+const SystemUserID uint = 0
+
+// 余额分类
+const (
+	BucketAvailable = "available"
+	BucketHeld      = "held"
+)
+
+// 分录类型
+const (
+	EntryTypeDeposit    = "deposit"
+	EntryTypeWithdrawal = "withdrawal"
+	EntryTypeHold       = "hold"
+	EntryTypeRelease    = "release"
+	EntryTypeTrade      = "trade"
+)
+
+// Account 账户，每个用户、账户类型和资产唯一。
+// Available和Held是分录的缓存汇总，只能通过记账更新，可用对账校验。
+type Account struct {
+	BaseModel
+	UserID    uint            `gorm:"not null;uniqueIndex:idx_accounts_owner_asset,priority:1" json:"user_id"`
+	Kind      string          `gorm:"size:16;not null;uniqueIndex:idx_accounts_owner_asset,priority:2" json:"kind"`
+	Asset     string          `gorm:"size:16;not null;uniqueIndex:idx_accounts_owner_asset,priority:3" json:"asset"`
+	Available decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"available"`
+	Held      decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"held"`
+	Version   uint            `gorm:"not null;default:0" json:"-"`
+}
+
+// IsSystem 判断是否为系统账户，系统账户余额允许为负
+func (a *Account) IsSystem() bool {
+	return a.UserID == SystemUserID
+}
+
+// Total 返回可用与冻结余额之和
+func (a *Account) Total() decimal.Decimal {
+	return a.Available.Add(a.Held)
+}
+
+// LedgerEntry 记账分录，创建后不可修改。同一类型和业务引用只记账一次，保证重复处理幂等。
+type LedgerEntry struct {
+	ID        uint            `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Type      string          `gorm:"size:16;not null;uniqueIndex:idx_ledger_entries_reference,priority:1" json:"type"`
+	Reference string          `gorm:"size:128;not null;uniqueIndex:idx_ledger_entries_reference,priority:2" json:"reference"`
+	Memo      string          `gorm:"size:255" json:"memo"`
+	Postings  []LedgerPosting `gorm:"foreignKey:EntryID" json:"postings,omitempty"`
+}
+
+// LedgerPosting 分录中的一笔借贷，Amount为正表示增加余额、为负表示减少余额。
+// 同一分录中每种资产的Amount之和为零。
+type LedgerPosting struct {
+	ID        uint            `gorm:"primarykey" json:"id"`
+	EntryID   uint            `gorm:"not null;index" json:"entry_id"`
+	AccountID uint            `gorm:"not null;index" json:"account_id"`
+	Asset     string          `gorm:"size:16;not null" json:"asset"`
+	Bucket    string          `gorm:"size:16;not null" json:"bucket"`
+	Amount    decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"amount"`
+}
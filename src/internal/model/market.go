@@ -0,0 +1,51 @@
+package model
+
+import (
+	"awesome-trade/src/pkg/decimal"
+)
+
+// 交易对状态
+const (
+	MarketStatusPreOpen  = "pre_open" // 已上架，尚未开放交易
+	MarketStatusTrading  = "trading"  // 正常交易
+	MarketStatusHalted   = "halted"   // 暂停交易，挂单冻结或已撤销
+	MarketStatusDelisted = "delisted" // 已下架，挂单全部撤销
+)
+
+// marketTransitions 交易对允许的状态迁移，下架后不可恢复
+var marketTransitions = map[string][]string{
+	MarketStatusPreOpen: {MarketStatusTrading, MarketStatusHalted, MarketStatusDelisted},
+	MarketStatusTrading: {MarketStatusHalted, MarketStatusDelisted},
+	MarketStatusHalted:  {MarketStatusTrading, MarketStatusDelisted},
+}
+
+// Market 交易对及其交易规则。价格须为TickSize的整数倍，数量须为StepSize的整数倍，
+// 限价单的价格乘数量不得低于MinNotional。
+type Market struct {
+	BaseModel
+	Symbol       string          `gorm:"size:32;not null;uniqueIndex" json:"symbol"`
+	BaseAsset    string          `gorm:"size:16;not null" json:"base_asset"`
+	QuoteAsset   string          `gorm:"size:16;not null" json:"quote_asset"`
+	TickSize     decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"tick_size"`
+	StepSize     decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"step_size"`
+	MinNotional  decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"min_notional"`
+	MakerFeeRate decimal.Decimal `gorm:"type:numeric(10,6);not null" json:"maker_fee_rate"`
+	TakerFeeRate decimal.Decimal `gorm:"type:numeric(10,6);not null" json:"taker_fee_rate"`
+	Status       string          `gorm:"size:16;not null" json:"status"`
+	Version      uint            `gorm:"not null;default:0" json:"-"`
+}
+
+// IsTrading 判断交易对是否接受新订单
+func (m *Market) IsTrading() bool {
+	return m.Status == MarketStatusTrading
+}
+
+// CanTransition 判断交易对能否从当前状态迁移到to
+func (m *Market) CanTransition(to string) bool {
+	for _, s := range marketTransitions[m.Status] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package model
+
+import (
+	"time"
+
+	"awesome-trade/src/pkg/decimal"
+)
+
+// 订单方向
+const (
+	SideBuy  = "buy"
+	SideSell = "sell"
+)
+
+// 订单类型
+const (
+	OrderTypeLimit           = "limit"
+	OrderTypeMarket          = "market"
+	OrderTypeStop            = "stop"              // 止损单，触发后以市价成交
+	OrderTypeStopLimit       = "stop_limit"        // 止损限价单，触发后以限价委托
+	OrderTypeTakeProfit      = "take_profit"       // 止盈单，触发后以市价成交
+	OrderTypeTakeProfitLimit = "take_profit_limit" // 止盈限价单，触发后以限价委托
+	OrderTypeTrailingStop    = "trailing_stop"     // 跟踪止损单，触发价随行情有利方向移动，触发后以市价成交
+)
+
+// ConditionalOrderTypes 条件单类型及其触发后提交的子订单类型
+var ConditionalOrderTypes = map[string]string{
+	OrderTypeStop:            OrderTypeMarket,
+	OrderTypeStopLimit:       OrderTypeLimit,
+	OrderTypeTakeProfit:      OrderTypeMarket,
+	OrderTypeTakeProfitLimit: OrderTypeLimit,
+	OrderTypeTrailingStop:    OrderTypeMarket,
+}
+
+// 条件单的触发价格来源
+const (
+	TriggerPriceLast = "last" // 最新成交价
+	TriggerPriceMark = "mark" // 标记价格，即限制在买一和卖一之间的最新成交价
+)
+
+// 订单有效方式
+const (
+	TimeInForceGTC      = "gtc"       // 成交为止
+	TimeInForceIOC      = "ioc"       // 立即成交，剩余撤销
+	TimeInForceFOK      = "fok"       // 全部成交，否则撤销
+	TimeInForcePostOnly = "post_only" // 只做挂单，会立即成交时拒绝
+)
+
+// 订单状态
+const (
+	OrderStatusPending         = "pending"     // 括号单的止盈止损订单等待入场单成交
+	OrderStatusUntriggered     = "untriggered" // 条件单等待触发
+	OrderStatusTriggered       = "triggered"   // 条件单已触发并提交了子订单
+	OrderStatusNew             = "new"
+	OrderStatusPartiallyFilled = "partially_filled"
+	OrderStatusFilled          = "filled"
+	OrderStatusCanceled        = "canceled"
+	OrderStatusRejected        = "rejected"
+)
+
+// OpenOrderStatuses 仍在订单簿中或等待生效、触发，可以撤销的订单状态
+var OpenOrderStatuses = []string{OrderStatusPending, OrderStatusUntriggered, OrderStatusNew, OrderStatusPartiallyFilled}
+
+// Order 委托订单，同一用户的客户端订单ID唯一，用于幂等下单。
+// HeldAmount为该订单仍冻结的资金：买单为计价资产，卖单为基础资产，订单结束时解冻。
+// 条件单不进入订单簿也不冻结资金，触发后以自身参数提交一个子订单，两者通过ParentOrderID和ChildOrderID关联。
+// 跟踪止损单的StopPrice为当前触发价，随行情移动。订单组内的订单通过GroupID关联，GroupRole为其在组内的角色。
+type Order struct {
+	BaseModel
+	UserID         uint            `gorm:"not null;uniqueIndex:idx_orders_user_client_order_id,priority:1;index:idx_orders_user_status,priority:1" json:"user_id"`
+	User           User            `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	ClientOrderID  string          `gorm:"size:64;not null;uniqueIndex:idx_orders_user_client_order_id,priority:2" json:"client_order_id"`
+	Symbol         string          `gorm:"size:32;not null;index" json:"symbol"`
+	Side           string          `gorm:"size:8;not null" json:"side"`
+	Type           string          `gorm:"size:20;not null" json:"type"`
+	TimeInForce    string          `gorm:"size:16;not null" json:"time_in_force"`
+	Price          decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"price"`
+	Quantity       decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"quantity"`
+	FilledQuantity decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"filled_quantity"`
+	HeldAmount     decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"held_amount"`
+	Status         string          `gorm:"size:20;not null;index:idx_orders_user_status,priority:2" json:"status"`
+	Version        uint            `gorm:"not null;default:0" json:"-"`
+
+	StopPrice        decimal.Decimal `gorm:"type:numeric(36,18);not null;default:0" json:"stop_price"`
+	TrailingDelta    decimal.Decimal `gorm:"type:numeric(36,18);not null;default:0" json:"trailing_delta"`
+	TriggerPriceType string          `gorm:"size:8;not null;default:''" json:"trigger_price_type,omitempty"`
+	ParentOrderID    *uint           `gorm:"index" json:"parent_order_id,omitempty"`
+	ChildOrderID     *uint           `json:"child_order_id,omitempty"`
+	TriggeredAt      *time.Time      `json:"triggered_at,omitempty"`
+
+	GroupID   *uint  `gorm:"index" json:"group_id,omitempty"`
+	GroupRole string `gorm:"size:16;not null;default:''" json:"group_role,omitempty"`
+}
+
+// IsOpen 判断订单是否仍可成交、触发或撤销
+func (o *Order) IsOpen() bool {
+	switch o.Status {
+	case OrderStatusPending, OrderStatusUntriggered, OrderStatusNew, OrderStatusPartiallyFilled:
+		return true
+	}
+	return false
+}
+
+// OnBook 判断订单是否在订单簿中，即已提交给撮合引擎且尚未结束
+func (o *Order) OnBook() bool {
+	return o.Status == OrderStatusNew || o.Status == OrderStatusPartiallyFilled
+}
+
+// IsConditional 判断是否为条件单
+func (o *Order) IsConditional() bool {
+	_, ok := ConditionalOrderTypes[o.Type]
+	return ok
+}
+
+// ExecType 返回订单进入撮合引擎时的类型，条件单为触发后子订单的类型
+func (o *Order) ExecType() string {
+	if child, ok := ConditionalOrderTypes[o.Type]; ok {
+		return child
+	}
+	return o.Type
+}
+
+// HoldAsset 返回订单冻结的资产，买单冻结计价资产，卖单冻结基础资产
+func (o *Order) HoldAsset(base, quote string) string {
+	if o.Side == SideBuy {
+		return quote
+	}
+	return base
+}
+
+// RemainingQuantity 返回未成交数量
+func (o *Order) RemainingQuantity() decimal.Decimal {
+	return o.Quantity.Sub(o.FilledQuantity)
+}
+
+// CancelDeadline 用户的撤单倒计时，到期未刷新时撤销该用户的全部挂单。
+// 保存在数据库中，服务重启后重新设置定时器，各实例也会定期处理已到期的倒计时。
+type CancelDeadline struct {
+	UserID   uint      `gorm:"primarykey;autoIncrement:false" json:"user_id"`
+	Deadline time.Time `gorm:"not null;index" json:"deadline"`
+}
+
+// SettlementOffset 交易对已结算的最大定序序号，与结算结果在同一事务中写入。
+// 重启时定序器从日志重放其后的命令，日志和快照保留到结算追上为止。
+type SettlementOffset struct {
+	Symbol string `gorm:"primarykey;size:32" json:"symbol"`
+	Seq    uint64 `gorm:"not null" json:"seq"`
+}
+
+// SettlementFailure 无法结算而被搁置的撮合事件。Command和Result为事件的JSON，事件涉及的订单保持结算前的状态，
+// 冻结的资金不解冻也不转移，由运维人员核对后处理
+type SettlementFailure struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Symbol    string    `gorm:"size:32;not null;uniqueIndex:idx_settlement_failures_symbol_seq,priority:1" json:"symbol"`
+	Seq       uint64    `gorm:"not null;uniqueIndex:idx_settlement_failures_symbol_seq,priority:2" json:"seq"`
+	Command   string    `gorm:"type:text;not null" json:"command"`
+	Result    string    `gorm:"type:text;not null" json:"result"`
+	Error     string    `gorm:"type:text;not null" json:"error"`
+}
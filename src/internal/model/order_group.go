@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+)
+
+// 订单组类型
+const (
+	OrderGroupOCO     = "oco"     // 二选一：止盈和止损两个订单，一个成交或撤销时撤销另一个
+	OrderGroupBracket = "bracket" // 括号单：入场单成交后止盈和止损订单按成交数量生效，二者互为二选一
+)
+
+// 订单在组内的角色
+const (
+	GroupRoleEntry      = "entry"
+	GroupRoleTakeProfit = "take_profit"
+	GroupRoleStopLoss   = "stop_loss"
+)
+
+// OrderGroup 关联的一组订单，同一用户的客户端订单组ID唯一，用于幂等创建。
+// 组内最多一个订单在订单簿中（二选一的限价止盈单或括号单的入场单），其余为条件单；
+// 订单簿中的订单成交或撤销时，在同一个定序步骤中调整或撤销组内其他订单。
+type OrderGroup struct {
+	ID            uint      `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	UserID        uint      `gorm:"not null;uniqueIndex:idx_order_groups_user_client_group_id,priority:1" json:"user_id"`
+	ClientGroupID string    `gorm:"size:64;not null;uniqueIndex:idx_order_groups_user_client_group_id,priority:2" json:"client_group_id"`
+	Symbol        string    `gorm:"size:32;not null" json:"symbol"`
+	Type          string    `gorm:"size:16;not null" json:"type"`
+	Orders        []Order   `gorm:"foreignKey:GroupID" json:"orders"`
+}
+
+// IsOpen 判断组内是否仍有未结束的订单
+func (g *OrderGroup) IsOpen() bool {
+	for i := range g.Orders {
+		if g.Orders[i].IsOpen() {
+			return true
+		}
+	}
+	return false
+}
+
+// Leg 返回组内指定角色的订单，不存在时返回nil
+func (g *OrderGroup) Leg(role string) *Order {
+	for i := range g.Orders {
+		if g.Orders[i].GroupRole == role {
+			return &g.Orders[i]
+		}
+	}
+	return nil
+}
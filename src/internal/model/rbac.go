@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+)
+
+// 权限代码，格式为 资源:操作
+const (
+	PermUsersRead          = "users:read"
+	PermUsersWrite         = "users:write"
+	PermRolesAssign        = "roles:assign"
+	PermOrdersReadAny      = "orders:read_any"
+	PermOrdersCancelAny    = "orders:cancel_any"
+	PermWithdrawalsApprove = "withdrawals:approve"
+	PermMarketsManage      = "markets:manage"
+	PermLedgerRead         = "ledger:read"
+	PermRiskManage         = "risk:manage"
+	PermFeesManage         = "fees:manage"
+)
+
+// 内置角色
+const (
+	RoleAdmin   = "admin"
+	RoleSupport = "support"
+	RoleTrader  = "trader"
+)
+
+// Permission 权限
+type Permission struct {
+	BaseModel
+	Code        string `gorm:"size:64;uniqueIndex;not null" json:"code"`
+	Description string `gorm:"size:255" json:"description"`
+}
+
+// Role 角色
+type Role struct {
+	BaseModel
+	Name        string       `gorm:"size:64;uniqueIndex;not null" json:"name"`
+	Description string       `gorm:"size:255" json:"description"`
+	Permissions []Permission `gorm:"many2many:role_permissions" json:"permissions,omitempty"`
+}
+
+// UserRole 用户与角色的关联
+type UserRole struct {
+	UserID    uint      `gorm:"primaryKey" json:"user_id"`
+	RoleID    uint      `gorm:"primaryKey" json:"role_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+)
+
+// RefreshToken 刷新令牌，仅保存令牌的SHA-256哈希
+type RefreshToken struct {
+	BaseModel
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	User      User       `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	FamilyID  string     `gorm:"size:64;index;not null" json:"family_id"`
+	TokenHash string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	// TokenVersion 签发时用户的令牌版本，与用户当前版本不一致时令牌失效
+	TokenVersion uint `gorm:"not null;default:0" json:"-"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"awesome-trade/src/pkg/decimal"
+)
+
+// UserTierStandard 新用户的默认风控等级
+const UserTierStandard = "standard"
+
+// RiskLimit 某个用户等级在某个交易对上的风控限额，Symbol为空表示该等级在所有交易对上的默认限额。
+// 数值为0表示不限制。PriceBand为限价单价格偏离最新成交价的最大比例，如0.1表示±10%；
+// DailyLossLimit以risk.loss_asset配置的资产计，为用户当日（UTC）在全部交易对上按最新成交价估值的合计亏损上限。
+type RiskLimit struct {
+	ID               uint            `gorm:"primarykey" json:"id"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+	Tier             string          `gorm:"size:16;not null;uniqueIndex:idx_risk_limits_tier_symbol,priority:1" json:"tier"`
+	Symbol           string          `gorm:"size:32;not null;uniqueIndex:idx_risk_limits_tier_symbol,priority:2" json:"symbol"`
+	MaxOrderQuantity decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"max_order_quantity"`
+	MaxOrderNotional decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"max_order_notional"`
+	MaxOpenOrders    int             `gorm:"not null" json:"max_open_orders"`
+	PriceBand        decimal.Decimal `gorm:"type:numeric(10,6);not null" json:"price_band"`
+	DailyLossLimit   decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"daily_loss_limit"`
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"time"
+
+	"awesome-trade/src/pkg/decimal"
+)
+
+// 转账类型
+const (
+	TransferTypeDeposit    = "deposit"
+	TransferTypeWithdrawal = "withdrawal"
+)
+
+// 转账状态
+const (
+	TransferStatusPending      = "pending"      // 已提交，等待处理
+	TransferStatusReviewing    = "reviewing"    // 等待管理员审批
+	TransferStatusApproved     = "approved"     // 已批准，等待广播
+	TransferStatusBroadcasting = "broadcasting" // 已广播，等待链上确认
+	TransferStatusConfirmed    = "confirmed"    // 已确认入账或出账
+	TransferStatusRejected     = "rejected"     // 被拒绝
+	TransferStatusFailed       = "failed"       // 广播或链上交易失败
+)
+
+// transferTransitions 各类型转账允许的状态迁移。
+// 充值在链上确认后直接入账；提现超过审批阈值时须经管理员审批，广播后等待链上确认。
+var transferTransitions = map[string]map[string][]string{
+	TransferTypeDeposit: {
+		TransferStatusPending: {TransferStatusConfirmed, TransferStatusRejected, TransferStatusFailed},
+	},
+	TransferTypeWithdrawal: {
+		TransferStatusPending:      {TransferStatusReviewing, TransferStatusApproved, TransferStatusRejected},
+		TransferStatusReviewing:    {TransferStatusApproved, TransferStatusRejected},
+		TransferStatusApproved:     {TransferStatusBroadcasting, TransferStatusFailed},
+		TransferStatusBroadcasting: {TransferStatusConfirmed, TransferStatusFailed},
+	},
+}
+
+// Transfer 充值或提现申请
+type Transfer struct {
+	BaseModel
+	UserID  uint            `gorm:"not null;index:idx_transfers_user_id" json:"user_id"`
+	User    User            `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Type    string          `gorm:"size:16;not null;uniqueIndex:idx_transfers_tx_hash,priority:1;index:idx_transfers_type_status,priority:1" json:"type"`
+	Asset   string          `gorm:"size:16;not null;uniqueIndex:idx_transfers_tx_hash,priority:2" json:"asset"`
+	Amount  decimal.Decimal `gorm:"type:numeric(36,18);not null" json:"amount"`
+	Address string          `gorm:"size:128" json:"address,omitempty"`
+	TxHash  *string         `gorm:"size:128;uniqueIndex:idx_transfers_tx_hash,priority:3" json:"tx_hash"`
+	Status  string          `gorm:"size:16;not null;index:idx_transfers_type_status,priority:2" json:"status"`
+	Reason  string          `gorm:"size:255" json:"reason,omitempty"`
+	Version uint            `gorm:"not null;default:0" json:"-"`
+
+	Audits []TransferAudit `gorm:"foreignKey:TransferID" json:"audits,omitempty"`
+}
+
+// CanTransition 判断转账能否从当前状态迁移到to
+func (t *Transfer) CanTransition(to string) bool {
+	for _, s := range transferTransitions[t.Type][t.Status] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFinal 判断转账是否已结束
+func (t *Transfer) IsFinal() bool {
+	return len(transferTransitions[t.Type][t.Status]) == 0
+}
+
+// TransferAudit 转账状态迁移审计记录，ActorID为0表示系统操作
+type TransferAudit struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	TransferID uint      `gorm:"not null;index" json:"transfer_id"`
+	FromStatus string    `gorm:"size:16;not null" json:"from_status"`
+	ToStatus   string    `gorm:"size:16;not null" json:"to_status"`
+	ActorID    uint      `gorm:"not null" json:"actor_id"`
+	Reason     string    `gorm:"size:255" json:"reason,omitempty"`
+}
+
+// DepositAddress 用户在某一资产上的充值地址，只有转入该地址的链上金额才会入账
+type DepositAddress struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_deposit_addresses_user_asset,priority:1" json:"user_id"`
+	User      User      `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Asset     string    `gorm:"size:16;not null;uniqueIndex:idx_deposit_addresses_user_asset,priority:2;uniqueIndex:idx_deposit_addresses_address,priority:1" json:"asset"`
+	Address   string    `gorm:"size:128;not null;uniqueIndex:idx_deposit_addresses_address,priority:2" json:"address"`
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+)
+
+// TwoFactor 用户的TOTP两步验证配置，密钥加密保存
+type TwoFactor struct {
+	BaseModel
+	UserID          uint       `gorm:"uniqueIndex;not null" json:"user_id"`
+	User            User       `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	SecretEncrypted string     `gorm:"not null" json:"-"`
+	Enabled         bool       `gorm:"default:false" json:"enabled"`
+	ConfirmedAt     *time.Time `json:"confirmed_at"`
+	LastUsedStep    int64      `json:"-"`
+	LastVerifiedAt  *time.Time `json:"last_verified_at"`
+	FailedAttempts  int        `gorm:"not null;default:0" json:"-"`
+	LockedUntil     *time.Time `json:"locked_until"`
+}
+
+// RecoveryCode 两步验证恢复码，仅保存SHA-256哈希
+type RecoveryCode struct {
+	BaseModel
+	UserID   uint       `gorm:"index;not null" json:"user_id"`
+	User     User       `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	CodeHash string     `gorm:"size:64;not null" json:"-"`
+	UsedAt   *time.Time `json:"used_at"`
+}
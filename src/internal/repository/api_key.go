@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"awesome-trade/src/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository API Key仓储
+type APIKeyRepository struct {
+	*BaseRepository
+}
+
+// NewAPIKeyRepository 创建API Key仓储实例
+func NewAPIKeyRepository(db *gorm.DB) *APIKeyRepository {
+	return &APIKeyRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// Create 保存API Key
+func (r *APIKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	return r.DB(ctx).Create(key).Error
+}
+
+// ListByUser 查询用户的全部API Key
+func (r *APIKeyRepository) ListByUser(ctx context.Context, userID uint) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := r.DB(ctx).Where("user_id = ?", userID).Order("id").Find(&keys).Error
+	return keys, err
+}
+
+// GetByKey 根据公开的Key获取API Key及其所属用户，不存在时返回nil
+func (r *APIKeyRepository) GetByKey(ctx context.Context, key string) (*model.APIKey, error) {
+	var apiKey model.APIKey
+	err := r.DB(ctx).Preload("User").Where("access_key = ?", key).First(&apiKey).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+// Delete 删除用户的API Key，返回是否删除了记录
+func (r *APIKeyRepository) Delete(ctx context.Context, userID, id uint) (bool, error) {
+	res := r.DB(ctx).Where("user_id = ?", userID).Delete(&model.APIKey{}, id)
+	return res.RowsAffected > 0, res.Error
+}
+
+// TouchLastUsed 更新最近使用时间
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id uint, at time.Time) error {
+	return r.DB(ctx).Model(&model.APIKey{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", at).Error
+}
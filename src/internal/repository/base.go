@@ -1,6 +1,10 @@
 package repository
 
 import (
+	"context"
+
+	"awesome-trade/src/internal/database"
+
 	"gorm.io/gorm"
 )
 
@@ -20,3 +24,8 @@ func NewBaseRepository(db *gorm.DB) *BaseRepository {
 func (r *BaseRepository) GetDB() *gorm.DB {
 	return r.db
 }
+
+// DB 获取绑定上下文的数据库连接，上下文中存在事务时自动加入该事务
+func (r *BaseRepository) DB(ctx context.Context) *gorm.DB {
+	return database.Conn(ctx, r.db)
+}
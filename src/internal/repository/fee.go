@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"awesome-trade/src/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FeeRepository 手续费等级、交易对费率、免费活动和用户等级仓储
+type FeeRepository struct {
+	*Repository[model.FeeTier]
+}
+
+// NewFeeRepository 创建手续费仓储实例
+func NewFeeRepository(db *gorm.DB) *FeeRepository {
+	return &FeeRepository{
+		Repository: NewRepository[model.FeeTier](db),
+	}
+}
+
+// ListTiers 按等级查询全部手续费等级
+func (r *FeeRepository) ListTiers(ctx context.Context) ([]model.FeeTier, error) {
+	var tiers []model.FeeTier
+	err := r.DB(ctx).Order("level").Find(&tiers).Error
+	return tiers, err
+}
+
+// UpsertTier 写入手续费等级，同一等级已存在时覆盖
+func (r *FeeRepository) UpsertTier(ctx context.Context, tier *model.FeeTier) error {
+	return r.DB(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "level"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "min_volume", "maker_fee_rate", "taker_fee_rate"}),
+	}).Create(tier).Error
+}
+
+// DeleteTier 删除手续费等级，不存在时返回false
+func (r *FeeRepository) DeleteTier(ctx context.Context, level int) (bool, error) {
+	res := r.DB(ctx).Where("level = ?", level).Delete(&model.FeeTier{})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// ListOverrides 按交易对和等级查询全部交易对费率
+func (r *FeeRepository) ListOverrides(ctx context.Context) ([]model.FeeOverride, error) {
+	var overrides []model.FeeOverride
+	err := r.DB(ctx).Order("symbol, level").Find(&overrides).Error
+	return overrides, err
+}
+
+// UpsertOverride 写入交易对费率，同一交易对和等级已存在时覆盖
+func (r *FeeRepository) UpsertOverride(ctx context.Context, override *model.FeeOverride) error {
+	return r.DB(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "symbol"}, {Name: "level"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "maker_fee_rate", "taker_fee_rate"}),
+	}).Create(override).Error
+}
+
+// DeleteOverride 删除交易对在某个等级上的费率，不存在时返回false
+func (r *FeeRepository) DeleteOverride(ctx context.Context, symbol string, level int) (bool, error) {
+	res := r.DB(ctx).Where("symbol = ? AND level = ?", symbol, level).Delete(&model.FeeOverride{})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// ListPromotions 按开始时间查询在since之后结束的免费活动
+func (r *FeeRepository) ListPromotions(ctx context.Context, since time.Time) ([]model.FeePromotion, error) {
+	var promotions []model.FeePromotion
+	err := r.DB(ctx).Where("ends_at > ?", since).Order("starts_at, id").Find(&promotions).Error
+	return promotions, err
+}
+
+// CreatePromotion 创建免费活动
+func (r *FeeRepository) CreatePromotion(ctx context.Context, promotion *model.FeePromotion) error {
+	return r.DB(ctx).Create(promotion).Error
+}
+
+// DeletePromotion 删除免费活动，不存在时返回false
+func (r *FeeRepository) DeletePromotion(ctx context.Context, id uint) (bool, error) {
+	res := r.DB(ctx).Delete(&model.FeePromotion{}, id)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// GetUserTier 获取用户的手续费等级，尚未计算过时返回nil
+func (r *FeeRepository) GetUserTier(ctx context.Context, userID uint) (*model.UserFeeTier, error) {
+	var tier model.UserFeeTier
+	err := r.DB(ctx).Where("user_id = ?", userID).First(&tier).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tier, nil
+}
+
+// ReplaceUserTiers 以tiers替换全部用户的手续费等级，须在事务中调用
+func (r *FeeRepository) ReplaceUserTiers(ctx context.Context, tiers []model.UserFeeTier) error {
+	if err := r.DB(ctx).Where("1 = 1").Delete(&model.UserFeeTier{}).Error; err != nil {
+		return err
+	}
+	if len(tiers) == 0 {
+		return nil
+	}
+	return r.DB(ctx).CreateInBatches(tiers, 500).Error
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/pkg/decimal"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SymbolVolume 用户在一个交易对上的成交额，以计价资产计
+type SymbolVolume struct {
+	UserID      uint
+	Symbol      string
+	QuoteVolume decimal.Decimal
+}
+
+// FillRepository 成交明细仓储
+type FillRepository struct {
+	*Repository[model.Fill]
+}
+
+// NewFillRepository 创建成交明细仓储实例
+func NewFillRepository(db *gorm.DB) *FillRepository {
+	return &FillRepository{
+		Repository: NewRepository[model.Fill](db),
+	}
+}
+
+// CreateBatch 写入成交明细，同一订单和成交编号已存在时跳过
+func (r *FillRepository) CreateBatch(ctx context.Context, fills []model.Fill) error {
+	if len(fills) == 0 {
+		return nil
+	}
+	return r.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&fills).Error
+}
+
+// SumVolumes 逐行累加since之后的成交明细，返回每个用户在每个交易对上的成交额
+func (r *FillRepository) SumVolumes(ctx context.Context, since time.Time) ([]SymbolVolume, error) {
+	rows, err := r.DB(ctx).Model(&model.Fill{}).
+		Select("user_id", "symbol", "quote_quantity").
+		Where("created_at >= ?", since).
+		Order("id").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type key struct {
+		userID uint
+		symbol string
+	}
+	index := make(map[key]int)
+	var volumes []SymbolVolume
+	for rows.Next() {
+		var f model.Fill
+		if err := rows.Scan(&f.UserID, &f.Symbol, &f.QuoteQuantity); err != nil {
+			return nil, err
+		}
+		k := key{userID: f.UserID, symbol: f.Symbol}
+		i, ok := index[k]
+		if !ok {
+			i = len(volumes)
+			index[k] = i
+			volumes = append(volumes, SymbolVolume{UserID: f.UserID, Symbol: f.Symbol})
+		}
+		volumes[i].QuoteVolume = volumes[i].QuoteVolume.Add(f.QuoteQuantity)
+	}
+	return volumes, rows.Err()
+}
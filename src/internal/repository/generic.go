@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrStaleObject 乐观锁校验失败，记录已被其他事务修改
+var ErrStaleObject = errors.New("record has been modified by another transaction")
+
+// versionField 乐观锁版本字段名
+const versionField = "Version"
+
+// Scope 附加到查询上的过滤条件
+type Scope = func(*gorm.DB) *gorm.DB
+
+// Where 构造等同于 db.Where 的过滤条件
+func Where(query interface{}, args ...interface{}) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(query, args...)
+	}
+}
+
+// PageQuery 偏移分页参数，SortBy须为模型的数据库列名，否则按主键排序
+type PageQuery struct {
+	Page     int
+	PageSize int
+	SortBy   string
+	Order    string
+}
+
+// CursorQuery 基于主键的游标分页参数，After为上一页最后一条记录的主键，0表示从头开始
+type CursorQuery struct {
+	After uint
+	Limit int
+	Desc  bool
+}
+
+// Repository 通用类型化仓储，提供按主键的增删改查和分页查询
+type Repository[T any] struct {
+	*BaseRepository
+}
+
+// NewRepository 创建通用仓储实例
+func NewRepository[T any](db *gorm.DB) *Repository[T] {
+	return &Repository[T]{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// WithDB 返回绑定到指定连接（通常是事务）的仓储副本
+func (r *Repository[T]) WithDB(db *gorm.DB) *Repository[T] {
+	return NewRepository[T](db)
+}
+
+// FindByID 根据主键获取记录，不存在时返回nil
+func (r *Repository[T]) FindByID(ctx context.Context, id uint) (*T, error) {
+	var entity T
+	err := r.DB(ctx).First(&entity, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// List 偏移分页查询，返回当前页记录和满足条件的总数
+func (r *Repository[T]) List(ctx context.Context, q PageQuery, scopes ...Scope) ([]T, int64, error) {
+	sch, err := r.schema()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if err := r.query(ctx, scopes).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	page := q.Page
+	if page < 1 {
+		page = 1
+	}
+	pk := sch.PrioritizedPrimaryField.DBName
+	column := pk
+	if field := sch.LookUpField(q.SortBy); field != nil && field.DBName != "" {
+		column = field.DBName
+	}
+	desc := q.Order == "desc"
+
+	db := r.query(ctx, scopes).Order(orderBy(column, desc))
+	if column != pk {
+		db = db.Order(orderBy(pk, desc))
+	}
+	if q.PageSize > 0 {
+		db = db.Offset((page - 1) * q.PageSize).Limit(q.PageSize)
+	}
+
+	var items []T
+	if err := db.Find(&items).Error; err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// ListByCursor 按主键游标分页查询，返回记录和下一页游标，没有更多数据时游标为0
+func (r *Repository[T]) ListByCursor(ctx context.Context, q CursorQuery, scopes ...Scope) ([]T, uint, error) {
+	sch, err := r.schema()
+	if err != nil {
+		return nil, 0, err
+	}
+	pk := sch.PrioritizedPrimaryField
+
+	db := r.query(ctx, scopes).Order(orderBy(pk.DBName, q.Desc))
+	if q.After > 0 {
+		column := clause.Column{Table: clause.CurrentTable, Name: pk.DBName}
+		if q.Desc {
+			db = db.Where(clause.Lt{Column: column, Value: q.After})
+		} else {
+			db = db.Where(clause.Gt{Column: column, Value: q.After})
+		}
+	}
+	if q.Limit > 0 {
+		db = db.Limit(q.Limit)
+	}
+
+	var items []T
+	if err := db.Find(&items).Error; err != nil {
+		return nil, 0, err
+	}
+	if q.Limit <= 0 || len(items) < q.Limit {
+		return items, 0, nil
+	}
+
+	value, _ := pk.ValueOf(ctx, reflect.ValueOf(&items[len(items)-1]).Elem())
+	next, ok := value.(uint)
+	if !ok {
+		return nil, 0, fmt.Errorf("cursor pagination requires a uint primary key, got %T", value)
+	}
+	return items, next, nil
+}
+
+// Count 统计满足条件的记录数
+func (r *Repository[T]) Count(ctx context.Context, scopes ...Scope) (int64, error) {
+	var total int64
+	err := r.query(ctx, scopes).Count(&total).Error
+	return total, err
+}
+
+// Create 创建记录
+func (r *Repository[T]) Create(ctx context.Context, entity *T) error {
+	return r.DB(ctx).Create(entity).Error
+}
+
+// Update 保存记录的全部字段。模型包含Version字段时启用乐观锁：
+// 仅当数据库中的版本与实体一致时才更新并将版本加一，否则返回ErrStaleObject。
+func (r *Repository[T]) Update(ctx context.Context, entity *T) error {
+	sch, err := r.schema()
+	if err != nil {
+		return err
+	}
+	field := sch.LookUpField(versionField)
+	if field == nil {
+		return r.DB(ctx).Save(entity).Error
+	}
+
+	rv := reflect.ValueOf(entity).Elem()
+	value, _ := field.ValueOf(ctx, rv)
+	current := reflect.ValueOf(value).Convert(reflect.TypeOf(uint64(0))).Uint()
+	if err := field.Set(ctx, rv, current+1); err != nil {
+		return err
+	}
+
+	res := r.DB(ctx).Model(entity).
+		Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: current}).
+		Select("*").Omit(sch.PrioritizedPrimaryField.DBName, "created_at").
+		Updates(entity)
+	if res.Error == nil && res.RowsAffected == 0 {
+		res.Error = ErrStaleObject
+	}
+	if res.Error != nil {
+		_ = field.Set(ctx, rv, current)
+		return res.Error
+	}
+	return nil
+}
+
+// SoftDelete 软删除记录，返回记录是否存在
+func (r *Repository[T]) SoftDelete(ctx context.Context, id uint) (bool, error) {
+	res := r.DB(ctx).Delete(new(T), id)
+	return res.RowsAffected > 0, res.Error
+}
+
+// Restore 恢复已软删除的记录，返回是否有记录被恢复
+func (r *Repository[T]) Restore(ctx context.Context, id uint) (bool, error) {
+	sch, err := r.schema()
+	if err != nil {
+		return false, err
+	}
+	if sch.LookUpField("DeletedAt") == nil {
+		return false, fmt.Errorf("%s does not support soft delete", sch.Name)
+	}
+	res := r.DB(ctx).Unscoped().Model(new(T)).
+		Where(clause.Eq{Column: clause.PrimaryColumn, Value: id}).
+		Where("deleted_at IS NOT NULL").
+		Update("deleted_at", nil)
+	return res.RowsAffected > 0, res.Error
+}
+
+// query 构建带过滤条件的基础查询
+func (r *Repository[T]) query(ctx context.Context, scopes []Scope) *gorm.DB {
+	return r.DB(ctx).Model(new(T)).Scopes(scopes...)
+}
+
+// schema 解析模型结构，结果由GORM缓存
+func (r *Repository[T]) schema() (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: r.GetDB()}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	if stmt.Schema.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("%s has no primary key", stmt.Schema.Name)
+	}
+	return stmt.Schema, nil
+}
+
+// orderBy 构造限定当前表的排序子句
+func orderBy(column string, desc bool) clause.OrderByColumn {
+	return clause.OrderByColumn{
+		Column: clause.Column{Table: clause.CurrentTable, Name: column},
+		Desc:   desc,
+	}
+}
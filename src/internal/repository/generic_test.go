@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"awesome-trade/src/internal/config"
+	"awesome-trade/src/internal/database"
+	"awesome-trade/src/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// widget 带乐观锁版本字段的测试模型
+type widget struct {
+	model.BaseModel
+	Name    string
+	Color   string
+	Version uint
+}
+
+func setupGenericRepo(t *testing.T) (*gorm.DB, *Repository[widget]) {
+	db, err := database.Open(config.DatabaseConfig{Driver: database.DriverSQLite, Path: ":memory:"})
+	require.NoError(t, err)
+	t.Cleanup(func() { database.Close(db) })
+	require.NoError(t, db.AutoMigrate(&widget{}, &model.User{}))
+
+	repo := NewRepository[widget](db)
+	for i := 1; i <= 5; i++ {
+		color := "red"
+		if i%2 == 0 {
+			color = "blue"
+		}
+		require.NoError(t, repo.Create(context.Background(), &widget{Name: fmt.Sprintf("w%d", i), Color: color}))
+	}
+	return db, repo
+}
+
+// 测试偏移分页、游标分页和过滤
+func TestRepositoryList(t *testing.T) {
+	_, repo := setupGenericRepo(t)
+	ctx := context.Background()
+
+	items, total, err := repo.List(ctx, PageQuery{Page: 2, PageSize: 2, SortBy: "name", Order: "desc"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	require.Len(t, items, 2)
+	assert.Equal(t, "w3", items[0].Name)
+	assert.Equal(t, "w2", items[1].Name)
+
+	items, total, err = repo.List(ctx, PageQuery{Page: 1, PageSize: 10, SortBy: "no_such_column"}, Where("color = ?", "red"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Equal(t, "w1", items[0].Name)
+
+	var names []string
+	var cursor uint
+	for {
+		page, next, err := repo.ListByCursor(ctx, CursorQuery{After: cursor, Limit: 2})
+		require.NoError(t, err)
+		for _, w := range page {
+			names = append(names, w.Name)
+		}
+		if next == 0 {
+			break
+		}
+		cursor = next
+	}
+	assert.Equal(t, []string{"w1", "w2", "w3", "w4", "w5"}, names)
+
+	page, next, err := repo.ListByCursor(ctx, CursorQuery{After: 4, Limit: 2, Desc: true})
+	require.NoError(t, err)
+	assert.Equal(t, "w3", page[0].Name)
+	assert.Equal(t, uint(2), next)
+
+	count, err := repo.Count(ctx, Where("color = ?", "blue"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+// 测试乐观锁更新
+func TestRepositoryUpdateOptimisticLock(t *testing.T) {
+	_, repo := setupGenericRepo(t)
+	ctx := context.Background()
+
+	a, err := repo.FindByID(ctx, 1)
+	require.NoError(t, err)
+	b, err := repo.FindByID(ctx, 1)
+	require.NoError(t, err)
+
+	a.Color = "green"
+	require.NoError(t, repo.Update(ctx, a))
+	assert.Equal(t, uint(1), a.Version)
+
+	b.Color = "yellow"
+	assert.ErrorIs(t, repo.Update(ctx, b), ErrStaleObject)
+	assert.Equal(t, uint(0), b.Version)
+
+	got, err := repo.FindByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "green", got.Color)
+	assert.Equal(t, uint(1), got.Version)
+
+	missing, err := repo.FindByID(ctx, 99)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+// 测试软删除与恢复
+func TestRepositorySoftDeleteRestore(t *testing.T) {
+	_, repo := setupGenericRepo(t)
+	ctx := context.Background()
+
+	ok, err := repo.SoftDelete(ctx, 2)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	got, _ := repo.FindByID(ctx, 2)
+	assert.Nil(t, got)
+
+	ok, err = repo.Restore(ctx, 2)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	got, _ = repo.FindByID(ctx, 2)
+	require.NotNil(t, got)
+
+	ok, err = repo.Restore(ctx, 2)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+// 测试WithTx在多个仓储间共享事务，出错时整体回滚
+func TestRepositoriesWithTx(t *testing.T) {
+	db, repo := setupGenericRepo(t)
+	repos := NewRepositories(db)
+	ctx := context.Background()
+
+	boom := errors.New("boom")
+	err := repos.WithTx(ctx, func(tx *Repositories) error {
+		if err := tx.Users.Create(ctx, &model.User{Username: "alice", Email: "a@example.com", Password: "x"}); err != nil {
+			return err
+		}
+		if err := repo.WithDB(tx.DB()).Create(ctx, &widget{Name: "w6"}); err != nil {
+			return err
+		}
+		return boom
+	})
+	assert.ErrorIs(t, err, boom)
+
+	users, _ := repos.Users.Count(ctx)
+	widgets, _ := repo.Count(ctx)
+	assert.Equal(t, int64(0), users)
+	assert.Equal(t, int64(5), widgets)
+
+	require.NoError(t, repos.WithTx(ctx, func(tx *Repositories) error {
+		if err := tx.Users.Create(ctx, &model.User{Username: "alice", Email: "a@example.com", Password: "x"}); err != nil {
+			return err
+		}
+		return repo.WithDB(tx.DB()).Create(ctx, &widget{Name: "w6"})
+	}))
+	users, _ = repos.Users.Count(ctx)
+	widgets, _ = repo.Count(ctx)
+	assert.Equal(t, int64(1), users)
+	assert.Equal(t, int64(6), widgets)
+}
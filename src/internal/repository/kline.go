@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"awesome-trade/src/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// KlineRepository K线仓储
+type KlineRepository struct {
+	*BaseRepository
+}
+
+// NewKlineRepository 创建K线仓储实例
+func NewKlineRepository(db *gorm.DB) *KlineRepository {
+	return &KlineRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// Upsert 写入K线，同一交易对、周期和开盘时间的K线已存在时覆盖
+func (r *KlineRepository) Upsert(ctx context.Context, klines []model.Kline) error {
+	if len(klines) == 0 {
+		return nil
+	}
+	return r.DB(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "symbol"}, {Name: "interval"}, {Name: "open_time"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"open", "high", "low", "close", "volume", "quote_volume", "trades", "last_trade_id",
+		}),
+	}).Create(&klines).Error
+}
+
+// ListBefore 按开盘时间正序返回早于before的最近limit根K线，before为零值时不限制
+func (r *KlineRepository) ListBefore(ctx context.Context, symbol, interval string, before time.Time, limit int) ([]model.Kline, error) {
+	db := r.DB(ctx).Where(&model.Kline{Symbol: symbol, Interval: interval})
+	if !before.IsZero() {
+		db = db.Where("open_time < ?", before)
+	}
+	var klines []model.Kline
+	if err := db.Order("open_time DESC").Limit(limit).Find(&klines).Error; err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(klines)-1; i < j; i, j = i+1, j-1 {
+		klines[i], klines[j] = klines[j], klines[i]
+	}
+	return klines, nil
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/pkg/decimal"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostingTotals 按分录汇总的账户余额
+type PostingTotals struct {
+	Available decimal.Decimal
+	Held      decimal.Decimal
+}
+
+// LedgerRepository 账户与记账分录仓储
+type LedgerRepository struct {
+	*Repository[model.Account]
+}
+
+// NewLedgerRepository 创建账本仓储实例
+func NewLedgerRepository(db *gorm.DB) *LedgerRepository {
+	return &LedgerRepository{
+		Repository: NewRepository[model.Account](db),
+	}
+}
+
+// LockAccount 获取账户并加行锁，账户不存在时以零余额创建，须在事务中调用
+func (r *LedgerRepository) LockAccount(ctx context.Context, userID uint, kind, asset string) (*model.Account, error) {
+	account := &model.Account{
+		UserID:    userID,
+		Kind:      kind,
+		Asset:     asset,
+		Available: decimal.Zero,
+		Held:      decimal.Zero,
+	}
+	if err := r.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(account).Error; err != nil {
+		return nil, err
+	}
+
+	var locked model.Account
+	err := r.DB(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND kind = ? AND asset = ?", userID, kind, asset).
+		First(&locked).Error
+	if err != nil {
+		return nil, err
+	}
+	return &locked, nil
+}
+
+// GetAccount 获取账户，不存在时返回nil
+func (r *LedgerRepository) GetAccount(ctx context.Context, userID uint, kind, asset string) (*model.Account, error) {
+	var account model.Account
+	err := r.DB(ctx).Where("user_id = ? AND kind = ? AND asset = ?", userID, kind, asset).First(&account).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// ListAccounts 查询用户的全部账户
+func (r *LedgerRepository) ListAccounts(ctx context.Context, userID uint) ([]model.Account, error) {
+	var accounts []model.Account
+	err := r.DB(ctx).Where("user_id = ?", userID).Order("kind, asset").Find(&accounts).Error
+	return accounts, err
+}
+
+// ListAllAccounts 查询全部账户
+func (r *LedgerRepository) ListAllAccounts(ctx context.Context) ([]model.Account, error) {
+	var accounts []model.Account
+	err := r.DB(ctx).Order("id").Find(&accounts).Error
+	return accounts, err
+}
+
+// CreateEntry 写入分录及其借贷明细，相同类型和业务引用的分录已存在时不写入并返回false
+func (r *LedgerRepository) CreateEntry(ctx context.Context, entry *model.LedgerEntry) (bool, error) {
+	res := r.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Omit("Postings").Create(entry)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return false, res.Error
+	}
+	for i := range entry.Postings {
+		entry.Postings[i].EntryID = entry.ID
+	}
+	if err := r.DB(ctx).Create(&entry.Postings).Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetEntry 根据类型和业务引用获取分录及明细，不存在时返回nil
+func (r *LedgerRepository) GetEntry(ctx context.Context, entryType, reference string) (*model.LedgerEntry, error) {
+	var entry model.LedgerEntry
+	err := r.DB(ctx).Preload("Postings").
+		Where("type = ? AND reference = ?", entryType, reference).
+		First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// SumUserPostings 按资产汇总用户现货账户在since之后、指定类型且业务引用以prefix开头的分录中的借贷
+func (r *LedgerRepository) SumUserPostings(ctx context.Context, userID uint, entryType, prefix string, since time.Time) (map[string]decimal.Decimal, error) {
+	rows, err := r.DB(ctx).Table("ledger_postings AS p").
+		Select("p.asset", "p.amount").
+		Joins("JOIN ledger_entries AS e ON e.id = p.entry_id").
+		Joins("JOIN accounts AS a ON a.id = p.account_id").
+		Where("a.user_id = ? AND a.kind = ?", userID, model.AccountKindSpot).
+		Where("e.type = ? AND e.reference LIKE ? AND e.created_at >= ?", entryType, prefix+"%", since).
+		Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sums := make(map[string]decimal.Decimal)
+	for rows.Next() {
+		var asset string
+		var amount decimal.Decimal
+		if err := rows.Scan(&asset, &amount); err != nil {
+			return nil, err
+		}
+		sums[asset] = sums[asset].Add(amount)
+	}
+	return sums, rows.Err()
+}
+
+// SumPostings 逐行累加全部借贷明细，返回每个账户按分录计算的余额
+func (r *LedgerRepository) SumPostings(ctx context.Context) (map[uint]*PostingTotals, error) {
+	rows, err := r.DB(ctx).Model(&model.LedgerPosting{}).
+		Select("account_id", "bucket", "amount").
+		Order("id").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make(map[uint]*PostingTotals)
+	for rows.Next() {
+		var p model.LedgerPosting
+		if err := rows.Scan(&p.AccountID, &p.Bucket, &p.Amount); err != nil {
+			return nil, err
+		}
+		t, ok := totals[p.AccountID]
+		if !ok {
+			t = &PostingTotals{}
+			totals[p.AccountID] = t
+		}
+		if p.Bucket == model.BucketHeld {
+			t.Held = t.Held.Add(p.Amount)
+		} else {
+			t.Available = t.Available.Add(p.Amount)
+		}
+	}
+	return totals, rows.Err()
+}
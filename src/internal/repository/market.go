@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"awesome-trade/src/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MarketRepository 交易对仓储
+type MarketRepository struct {
+	*Repository[model.Market]
+}
+
+// NewMarketRepository 创建交易对仓储实例
+func NewMarketRepository(db *gorm.DB) *MarketRepository {
+	return &MarketRepository{
+		Repository: NewRepository[model.Market](db),
+	}
+}
+
+// CreateIfAbsent 创建交易对，交易对已存在时不写入并返回false
+func (r *MarketRepository) CreateIfAbsent(ctx context.Context, market *model.Market) (bool, error) {
+	res := r.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(market)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// GetBySymbol 根据交易对名称获取交易对，不存在时返回nil
+func (r *MarketRepository) GetBySymbol(ctx context.Context, symbol string) (*model.Market, error) {
+	var market model.Market
+	err := r.DB(ctx).Where("symbol = ?", symbol).First(&market).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &market, nil
+}
+
+// ListAll 按交易对名称查询全部交易对，status为空时不过滤
+func (r *MarketRepository) ListAll(ctx context.Context, status string) ([]model.Market, error) {
+	db := r.DB(ctx)
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	var markets []model.Market
+	err := db.Order("symbol").Find(&markets).Error
+	return markets, err
+}
@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/pkg/decimal"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OrderHistoryQuery 历史订单查询条件，按ID倒序游标分页
+type OrderHistoryQuery struct {
+	Symbol string
+	Before uint
+	Limit  int
+}
+
+// OrderRepository 订单仓储
+type OrderRepository struct {
+	*Repository[model.Order]
+}
+
+// NewOrderRepository 创建订单仓储实例
+func NewOrderRepository(db *gorm.DB) *OrderRepository {
+	return &OrderRepository{
+		Repository: NewRepository[model.Order](db),
+	}
+}
+
+// CreateIfAbsent 创建订单，客户端订单ID已存在时不写入并返回false
+func (r *OrderRepository) CreateIfAbsent(ctx context.Context, order *model.Order) (bool, error) {
+	res := r.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(order)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// GetForUser 获取属于指定用户的订单，不存在时返回nil
+func (r *OrderRepository) GetForUser(ctx context.Context, userID, id uint) (*model.Order, error) {
+	return r.first(r.DB(ctx).Where("id = ? AND user_id = ?", id, userID))
+}
+
+// GetByClientOrderID 根据客户端订单ID获取订单，不存在时返回nil
+func (r *OrderRepository) GetByClientOrderID(ctx context.Context, userID uint, clientOrderID string) (*model.Order, error) {
+	return r.first(r.DB(ctx).Where("user_id = ? AND client_order_id = ?", userID, clientOrderID))
+}
+
+// ListOpen 查询用户的当前挂单，symbol为空时查询全部交易对
+func (r *OrderRepository) ListOpen(ctx context.Context, userID uint, symbol string) ([]model.Order, error) {
+	db := r.DB(ctx).Where("user_id = ? AND status IN ?", userID, model.OpenOrderStatuses)
+	if symbol != "" {
+		db = db.Where("symbol = ?", symbol)
+	}
+	var orders []model.Order
+	err := db.Order("id").Find(&orders).Error
+	return orders, err
+}
+
+// CountOpen 统计用户在交易对上的挂单数
+func (r *OrderRepository) CountOpen(ctx context.Context, userID uint, symbol string) (int64, error) {
+	var count int64
+	err := r.DB(ctx).Model(&model.Order{}).
+		Where("user_id = ? AND symbol = ? AND status IN ?", userID, symbol, model.OpenOrderStatuses).
+		Count(&count).Error
+	return count, err
+}
+
+// ListOpenBySymbol 按ID正序查询交易对上全部用户的挂单
+func (r *OrderRepository) ListOpenBySymbol(ctx context.Context, symbol string) ([]model.Order, error) {
+	var orders []model.Order
+	err := r.DB(ctx).Where("symbol = ? AND status IN ?", symbol, model.OpenOrderStatuses).Order("id").Find(&orders).Error
+	return orders, err
+}
+
+// ListHistory 按ID倒序查询用户已结束的订单，返回下一页游标
+func (r *OrderRepository) ListHistory(ctx context.Context, userID uint, q OrderHistoryQuery) ([]model.Order, uint, error) {
+	scopes := []Scope{Where("user_id = ? AND status NOT IN ?", userID, model.OpenOrderStatuses)}
+	if q.Symbol != "" {
+		scopes = append(scopes, Where("symbol = ?", q.Symbol))
+	}
+	return r.ListByCursor(ctx, CursorQuery{After: q.Before, Limit: q.Limit, Desc: true}, scopes...)
+}
+
+// TransitionStatus 仅当订单处于from中的状态时更新为to，返回是否更新成功
+func (r *OrderRepository) TransitionStatus(ctx context.Context, id uint, from []string, to string) (bool, error) {
+	res := r.DB(ctx).Model(&model.Order{}).
+		Where("id = ? AND status IN ?", id, from).
+		Updates(map[string]interface{}{
+			"status":  to,
+			"version": gorm.Expr("version + 1"),
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+// UpdateExecution 更新挂单的累计成交数量、剩余冻结金额和状态，订单已结束时不更新并返回false
+func (r *OrderRepository) UpdateExecution(ctx context.Context, id uint, filled, held decimal.Decimal, status string) (bool, error) {
+	res := r.DB(ctx).Model(&model.Order{}).
+		Where("id = ? AND status IN ?", id, model.OpenOrderStatuses).
+		Updates(map[string]interface{}{
+			"filled_quantity": filled,
+			"held_amount":     held,
+			"status":          status,
+			"version":         gorm.Expr("version + 1"),
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+// ListPendingTriggers 按ID正序查询等待触发的条件单，以及已触发但尚未记录子订单的条件单
+func (r *OrderRepository) ListPendingTriggers(ctx context.Context) ([]model.Order, error) {
+	var orders []model.Order
+	err := r.DB(ctx).
+		Where("status = ? OR (status = ? AND child_order_id IS NULL)", model.OrderStatusUntriggered, model.OrderStatusTriggered).
+		Order("id").Find(&orders).Error
+	return orders, err
+}
+
+// MarkTriggered 仅当条件单仍在等待触发时更新为已触发并记录触发时间，返回是否更新成功
+func (r *OrderRepository) MarkTriggered(ctx context.Context, id uint, at time.Time) (bool, error) {
+	res := r.DB(ctx).Model(&model.Order{}).
+		Where("id = ? AND status = ?", id, model.OrderStatusUntriggered).
+		Updates(map[string]interface{}{
+			"status":       model.OrderStatusTriggered,
+			"triggered_at": at,
+			"version":      gorm.Expr("version + 1"),
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+// SetChildOrder 记录条件单触发后提交的子订单
+func (r *OrderRepository) SetChildOrder(ctx context.Context, id, childID uint) error {
+	return r.DB(ctx).Model(&model.Order{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"child_order_id": childID,
+			"version":        gorm.Expr("version + 1"),
+		}).Error
+}
+
+// UpdateStopPrice 更新等待触发的条件单的触发价，订单已触发或撤销时不更新并返回false
+func (r *OrderRepository) UpdateStopPrice(ctx context.Context, id uint, price decimal.Decimal) (bool, error) {
+	res := r.DB(ctx).Model(&model.Order{}).
+		Where("id = ? AND status = ?", id, model.OrderStatusUntriggered).
+		Updates(map[string]interface{}{
+			"stop_price": price,
+			"version":    gorm.Expr("version + 1"),
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+// ListByGroup 按ID正序查询订单组内的订单
+func (r *OrderRepository) ListByGroup(ctx context.Context, groupID uint) ([]model.Order, error) {
+	var orders []model.Order
+	err := r.DB(ctx).Where("group_id = ?", groupID).Order("id").Find(&orders).Error
+	return orders, err
+}
+
+// Resize 修改尚未提交给撮合引擎的订单数量，订单已结束或已有子订单时不更新并返回false
+func (r *OrderRepository) Resize(ctx context.Context, id uint, quantity decimal.Decimal) (bool, error) {
+	res := r.DB(ctx).Model(&model.Order{}).
+		Where("id = ? AND (status IN ? OR (status = ? AND child_order_id IS NULL))",
+			id, []string{model.OrderStatusPending, model.OrderStatusUntriggered}, model.OrderStatusTriggered).
+		Updates(map[string]interface{}{
+			"quantity": quantity,
+			"version":  gorm.Expr("version + 1"),
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+// SetCancelDeadline 设置或刷新用户的撤单倒计时到期时间
+func (r *OrderRepository) SetCancelDeadline(ctx context.Context, userID uint, deadline time.Time) error {
+	return r.DB(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"deadline"}),
+	}).Create(&model.CancelDeadline{UserID: userID, Deadline: deadline}).Error
+}
+
+// DeleteCancelDeadline 关闭用户的撤单倒计时
+func (r *OrderRepository) DeleteCancelDeadline(ctx context.Context, userID uint) error {
+	return r.DB(ctx).Where("user_id = ?", userID).Delete(&model.CancelDeadline{}).Error
+}
+
+// FindCancelDeadline 查询用户的撤单倒计时，未设置时返回nil
+func (r *OrderRepository) FindCancelDeadline(ctx context.Context, userID uint) (*model.CancelDeadline, error) {
+	var deadline model.CancelDeadline
+	err := r.DB(ctx).Where("user_id = ?", userID).First(&deadline).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &deadline, nil
+}
+
+// ListCancelDeadlines 按到期时间查询撤单倒计时，before不为零时只返回在此之前到期的倒计时
+func (r *OrderRepository) ListCancelDeadlines(ctx context.Context, before time.Time) ([]model.CancelDeadline, error) {
+	var deadlines []model.CancelDeadline
+	db := r.DB(ctx)
+	if !before.IsZero() {
+		db = db.Where("deadline <= ?", before)
+	}
+	err := db.Order("deadline, user_id").Find(&deadlines).Error
+	return deadlines, err
+}
+
+// DeleteExpiredCancelDeadline 删除用户在before之前到期的撤单倒计时，期间已被刷新的倒计时保留
+func (r *OrderRepository) DeleteExpiredCancelDeadline(ctx context.Context, userID uint, before time.Time) error {
+	return r.DB(ctx).Where("user_id = ? AND deadline <= ?", userID, before).Delete(&model.CancelDeadline{}).Error
+}
+
+// first 查询单条订单，不存在时返回nil
+func (r *OrderRepository) first(db *gorm.DB) (*model.Order, error) {
+	var order model.Order
+	err := db.First(&order).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// GetSettledSeq 查询交易对已结算的最大序号，尚未结算过时返回0
+func (r *OrderRepository) GetSettledSeq(ctx context.Context, symbol string) (uint64, error) {
+	var offset model.SettlementOffset
+	err := r.DB(ctx).Where("symbol = ?", symbol).First(&offset).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	return offset.Seq, err
+}
+
+// SetSettledSeq 保存交易对已结算的最大序号
+func (r *OrderRepository) SetSettledSeq(ctx context.Context, symbol string, seq uint64) error {
+	return r.DB(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "symbol"}},
+		DoUpdates: clause.AssignmentColumns([]string{"seq"}),
+	}).Create(&model.SettlementOffset{Symbol: symbol, Seq: seq}).Error
+}
+
+// CreateSettlementFailure 记录被搁置的撮合事件，同一交易对同一序号只记录一次
+func (r *OrderRepository) CreateSettlementFailure(ctx context.Context, failure *model.SettlementFailure) error {
+	return r.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(failure).Error
+}
+
+// HasSettlementFailure 查询交易对是否有被搁置的撮合事件
+func (r *OrderRepository) HasSettlementFailure(ctx context.Context, symbol string) (bool, error) {
+	var count int64
+	err := r.DB(ctx).Model(&model.SettlementFailure{}).Where("symbol = ?", symbol).Limit(1).Count(&count).Error
+	return count > 0, err
+}
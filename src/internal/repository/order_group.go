@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"awesome-trade/src/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OrderGroupRepository 订单组仓储
+type OrderGroupRepository struct {
+	*Repository[model.OrderGroup]
+}
+
+// NewOrderGroupRepository 创建订单组仓储实例
+func NewOrderGroupRepository(db *gorm.DB) *OrderGroupRepository {
+	return &OrderGroupRepository{
+		Repository: NewRepository[model.OrderGroup](db),
+	}
+}
+
+// CreateIfAbsent 创建订单组（不含组内订单），客户端订单组ID已存在时不写入并返回false
+func (r *OrderGroupRepository) CreateIfAbsent(ctx context.Context, group *model.OrderGroup) (bool, error) {
+	res := r.DB(ctx).Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(group)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// GetForUser 获取属于指定用户的订单组及组内订单，不存在时返回nil
+func (r *OrderGroupRepository) GetForUser(ctx context.Context, userID, id uint) (*model.OrderGroup, error) {
+	return r.first(r.DB(ctx).Where("id = ? AND user_id = ?", id, userID))
+}
+
+// GetByClientGroupID 根据客户端订单组ID获取订单组及组内订单，不存在时返回nil
+func (r *OrderGroupRepository) GetByClientGroupID(ctx context.Context, userID uint, clientGroupID string) (*model.OrderGroup, error) {
+	return r.first(r.DB(ctx).Where("user_id = ? AND client_group_id = ?", userID, clientGroupID))
+}
+
+// ListOpen 查询用户仍有未结束订单的订单组，symbol为空时查询全部交易对
+func (r *OrderGroupRepository) ListOpen(ctx context.Context, userID uint, symbol string) ([]model.OrderGroup, error) {
+	open := r.DB(ctx).Model(&model.Order{}).Select("group_id").
+		Where("group_id IS NOT NULL AND status IN ?", model.OpenOrderStatuses)
+	db := r.DB(ctx).Preload("Orders", orderByID).Where("user_id = ? AND id IN (?)", userID, open)
+	if symbol != "" {
+		db = db.Where("symbol = ?", symbol)
+	}
+	var groups []model.OrderGroup
+	err := db.Order("id").Find(&groups).Error
+	return groups, err
+}
+
+// first 查询单个订单组并加载组内订单，不存在时返回nil
+func (r *OrderGroupRepository) first(db *gorm.DB) (*model.OrderGroup, error) {
+	var group model.OrderGroup
+	err := db.Preload("Orders", orderByID).First(&group).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// orderByID 组内订单按ID正序加载
+func orderByID(db *gorm.DB) *gorm.DB {
+	return db.Order("id")
+}
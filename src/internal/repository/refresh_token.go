@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"awesome-trade/src/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// RefreshTokenRepository 刷新令牌仓储
+type RefreshTokenRepository struct {
+	*BaseRepository
+}
+
+// NewRefreshTokenRepository 创建刷新令牌仓储实例
+func NewRefreshTokenRepository(db *gorm.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// Create 保存刷新令牌
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	return r.DB(ctx).Create(token).Error
+}
+
+// GetByHash 根据令牌哈希获取刷新令牌，不存在时返回nil
+func (r *RefreshTokenRepository) GetByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	err := r.DB(ctx).Where("token_hash = ?", hash).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Rotate 在同一事务中将旧令牌标记为已使用并保存新令牌。
+// 旧令牌已被使用或吊销时返回false，调用方应视为令牌重放。
+func (r *RefreshTokenRepository) Rotate(ctx context.Context, oldID uint, next *model.RefreshToken) (bool, error) {
+	rotated := false
+	err := r.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", oldID).
+			Update("used_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		rotated = true
+		return nil
+	})
+	return rotated, err
+}
+
+// RevokeFamily 吊销同一令牌族中所有尚未吊销的令牌
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	return r.DB(ctx).Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
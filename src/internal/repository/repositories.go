@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+
+	"awesome-trade/src/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// Repositories 汇总全部仓储，便于在启动时统一注入数据库连接
+type Repositories struct {
+	Users         *UserRepository
+	RefreshTokens *RefreshTokenRepository
+	APIKeys       *APIKeyRepository
+	Roles         *RoleRepository
+	TwoFactor     *TwoFactorRepository
+	Orders        *OrderRepository
+	Ledger        *LedgerRepository
+	Transfers     *TransferRepository
+	Markets       *MarketRepository
+	Klines        *KlineRepository
+	RiskLimits    *RiskLimitRepository
+	Fees          *FeeRepository
+	Fills         *FillRepository
+	OrderGroups   *OrderGroupRepository
+
+	db *gorm.DB
+}
+
+// NewRepositories 使用同一个数据库连接创建全部仓储
+func NewRepositories(db *gorm.DB) *Repositories {
+	return &Repositories{
+		Users:         NewUserRepository(db),
+		RefreshTokens: NewRefreshTokenRepository(db),
+		APIKeys:       NewAPIKeyRepository(db),
+		Roles:         NewRoleRepository(db),
+		TwoFactor:     NewTwoFactorRepository(db),
+		Orders:        NewOrderRepository(db),
+		Ledger:        NewLedgerRepository(db),
+		Transfers:     NewTransferRepository(db),
+		Markets:       NewMarketRepository(db),
+		Klines:        NewKlineRepository(db),
+		RiskLimits:    NewRiskLimitRepository(db),
+		Fees:          NewFeeRepository(db),
+		Fills:         NewFillRepository(db),
+		OrderGroups:   NewOrderGroupRepository(db),
+		db:            db,
+	}
+}
+
+// DB 返回仓储使用的数据库连接，在WithTx内即为当前事务，可用于绑定其他仓储
+func (r *Repositories) DB() *gorm.DB {
+	return r.db
+}
+
+// WithTx 在同一个事务中执行fn，传入的仓储全部绑定到该事务；fn返回错误时整体回滚。
+// 上下文中已有事务时作为保存点嵌套执行。
+func (r *Repositories) WithTx(ctx context.Context, fn func(tx *Repositories) error) error {
+	return database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		return fn(NewRepositories(tx))
+	})
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+
+	"awesome-trade/src/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RiskLimitRepository 风控限额仓储
+type RiskLimitRepository struct {
+	*Repository[model.RiskLimit]
+}
+
+// NewRiskLimitRepository 创建风控限额仓储实例
+func NewRiskLimitRepository(db *gorm.DB) *RiskLimitRepository {
+	return &RiskLimitRepository{
+		Repository: NewRepository[model.RiskLimit](db),
+	}
+}
+
+// ListAll 按等级和交易对查询全部风控限额
+func (r *RiskLimitRepository) ListAll(ctx context.Context) ([]model.RiskLimit, error) {
+	var limits []model.RiskLimit
+	err := r.DB(ctx).Order("tier, symbol").Find(&limits).Error
+	return limits, err
+}
+
+// Upsert 写入风控限额，同一等级和交易对的限额已存在时覆盖
+func (r *RiskLimitRepository) Upsert(ctx context.Context, limit *model.RiskLimit) error {
+	return r.DB(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tier"}, {Name: "symbol"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"updated_at", "max_order_quantity", "max_order_notional", "max_open_orders", "price_band", "daily_loss_limit",
+		}),
+	}).Create(limit).Error
+}
+
+// DeleteByKey 删除等级在交易对上的限额，不存在时返回false
+func (r *RiskLimitRepository) DeleteByKey(ctx context.Context, tier, symbol string) (bool, error) {
+	res := r.DB(ctx).Where("tier = ? AND symbol = ?", tier, symbol).Delete(&model.RiskLimit{})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"awesome-trade/src/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RoleRepository 角色与权限仓储
+type RoleRepository struct {
+	*BaseRepository
+}
+
+// NewRoleRepository 创建角色仓储实例
+func NewRoleRepository(db *gorm.DB) *RoleRepository {
+	return &RoleRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// UpsertPermission 按代码创建权限，已存在时返回现有记录
+func (r *RoleRepository) UpsertPermission(ctx context.Context, perm *model.Permission) error {
+	return r.DB(ctx).
+		Where(model.Permission{Code: perm.Code}).
+		Attrs(model.Permission{Description: perm.Description}).
+		FirstOrCreate(perm).Error
+}
+
+// UpsertRole 按名称创建角色，并确保其拥有给定权限
+func (r *RoleRepository) UpsertRole(ctx context.Context, role *model.Role, perms []model.Permission) error {
+	return r.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where(model.Role{Name: role.Name}).
+			Attrs(model.Role{Description: role.Description}).
+			FirstOrCreate(role).Error
+		if err != nil {
+			return err
+		}
+		if len(perms) == 0 {
+			return nil
+		}
+		return tx.Model(role).Association("Permissions").Append(perms)
+	})
+}
+
+// ListRoles 查询全部角色及其权限
+func (r *RoleRepository) ListRoles(ctx context.Context) ([]model.Role, error) {
+	var roles []model.Role
+	err := r.DB(ctx).Preload("Permissions").Order("id").Find(&roles).Error
+	return roles, err
+}
+
+// GetRoleByName 根据名称获取角色，不存在时返回nil
+func (r *RoleRepository) GetRoleByName(ctx context.Context, name string) (*model.Role, error) {
+	var role model.Role
+	err := r.DB(ctx).Where("name = ?", name).First(&role).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// RolesForUser 查询用户拥有的角色
+func (r *RoleRepository) RolesForUser(ctx context.Context, userID uint) ([]model.Role, error) {
+	var roles []model.Role
+	err := r.DB(ctx).
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.id").
+		Find(&roles).Error
+	return roles, err
+}
+
+// PermissionCodesForUser 通过一次查询获取用户经由角色获得的全部权限代码
+func (r *RoleRepository) PermissionCodesForUser(ctx context.Context, userID uint) ([]string, error) {
+	var codes []string
+	err := r.DB(ctx).Model(&model.Permission{}).
+		Distinct("permissions.code").
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id AND roles.deleted_at IS NULL").
+		Joins("JOIN user_roles ON user_roles.role_id = role_permissions.role_id").
+		Where("user_roles.user_id = ?", userID).
+		Pluck("permissions.code", &codes).Error
+	return codes, err
+}
+
+// AssignRole 为用户分配角色，重复分配时忽略
+func (r *RoleRepository) AssignRole(ctx context.Context, userID, roleID uint) error {
+	return r.DB(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.UserRole{UserID: userID, RoleID: roleID}).Error
+}
+
+// RevokeRole 撤销用户的角色
+func (r *RoleRepository) RevokeRole(ctx context.Context, userID, roleID uint) error {
+	return r.DB(ctx).
+		Where("user_id = ? AND role_id = ?", userID, roleID).
+		Delete(&model.UserRole{}).Error
+}
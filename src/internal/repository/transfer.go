@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/pkg/decimal"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TransferQuery 转账查询条件，按ID倒序游标分页，空字段表示不过滤
+type TransferQuery struct {
+	UserID uint
+	Type   string
+	Status string
+	Before uint
+	Limit  int
+}
+
+// TransferRepository 充值提现仓储
+type TransferRepository struct {
+	*Repository[model.Transfer]
+}
+
+// NewTransferRepository 创建充值提现仓储实例
+func NewTransferRepository(db *gorm.DB) *TransferRepository {
+	return &TransferRepository{
+		Repository: NewRepository[model.Transfer](db),
+	}
+}
+
+// CreateIfAbsent 创建转账，同类型同资产的链上交易哈希已存在时不写入并返回false
+func (r *TransferRepository) CreateIfAbsent(ctx context.Context, transfer *model.Transfer) (bool, error) {
+	res := r.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(transfer)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// GetForUser 获取属于指定用户的转账，不存在时返回nil
+func (r *TransferRepository) GetForUser(ctx context.Context, userID, id uint) (*model.Transfer, error) {
+	return r.first(r.DB(ctx).Where("id = ? AND user_id = ?", id, userID))
+}
+
+// GetByTxHash 根据类型、资产和链上交易哈希获取转账，不存在时返回nil
+func (r *TransferRepository) GetByTxHash(ctx context.Context, transferType, asset, txHash string) (*model.Transfer, error) {
+	return r.first(r.DB(ctx).Where("type = ? AND asset = ? AND tx_hash = ?", transferType, asset, txHash))
+}
+
+// GetWithAudits 获取转账及其全部状态迁移记录，不存在时返回nil
+func (r *TransferRepository) GetWithAudits(ctx context.Context, id uint) (*model.Transfer, error) {
+	return r.first(r.DB(ctx).Preload("Audits", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Where("id = ?", id))
+}
+
+// Search 按条件倒序查询转账，返回下一页游标
+func (r *TransferRepository) Search(ctx context.Context, q TransferQuery) ([]model.Transfer, uint, error) {
+	var scopes []Scope
+	if q.UserID != 0 {
+		scopes = append(scopes, Where("user_id = ?", q.UserID))
+	}
+	if q.Type != "" {
+		scopes = append(scopes, Where("type = ?", q.Type))
+	}
+	if q.Status != "" {
+		scopes = append(scopes, Where("status = ?", q.Status))
+	}
+	return r.ListByCursor(ctx, CursorQuery{After: q.Before, Limit: q.Limit, Desc: true}, scopes...)
+}
+
+// ListByStatus 按ID正序查询指定类型和状态的转账
+func (r *TransferRepository) ListByStatus(ctx context.Context, transferType, status string, limit int) ([]model.Transfer, error) {
+	var transfers []model.Transfer
+	err := r.DB(ctx).Where("type = ? AND status = ?", transferType, status).
+		Order("id").Limit(limit).Find(&transfers).Error
+	return transfers, err
+}
+
+// Transition 仅当转账仍处于当前状态时迁移到to，同时保存原因并写入审计记录。
+// 成功时更新transfer的状态和版本；状态已被其他操作改变时返回false。
+func (r *TransferRepository) Transition(ctx context.Context, transfer *model.Transfer, to string, actorID uint, reason string) (bool, error) {
+	from := transfer.Status
+	updates := map[string]interface{}{
+		"status":  to,
+		"version": gorm.Expr("version + 1"),
+	}
+	if reason != "" {
+		updates["reason"] = reason
+	}
+	res := r.DB(ctx).Model(&model.Transfer{}).
+		Where("id = ? AND status = ?", transfer.ID, from).
+		Updates(updates)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return false, res.Error
+	}
+
+	transfer.Status = to
+	transfer.Version++
+	if reason != "" {
+		transfer.Reason = reason
+	}
+	if err := r.AddAudit(ctx, transfer, from, actorID, reason); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// AddAudit 记录转账从from迁移到当前状态，创建转账时from为空
+func (r *TransferRepository) AddAudit(ctx context.Context, transfer *model.Transfer, from string, actorID uint, reason string) error {
+	return r.DB(ctx).Create(&model.TransferAudit{
+		TransferID: transfer.ID,
+		FromStatus: from,
+		ToStatus:   transfer.Status,
+		ActorID:    actorID,
+		Reason:     reason,
+	}).Error
+}
+
+// SetTxHash 保存提现广播后的链上交易哈希
+func (r *TransferRepository) SetTxHash(ctx context.Context, id uint, txHash string) error {
+	return r.DB(ctx).Model(&model.Transfer{}).Where("id = ?", id).Update("tx_hash", txHash).Error
+}
+
+// ClearTxHash 清除转账的链上交易哈希，使该交易可由其他用户重新申报
+func (r *TransferRepository) ClearTxHash(ctx context.Context, id uint) error {
+	return r.DB(ctx).Model(&model.Transfer{}).Where("id = ?", id).Update("tx_hash", nil).Error
+}
+
+// SetAmount 将充值金额更新为链上实际转入的金额
+func (r *TransferRepository) SetAmount(ctx context.Context, id uint, amount decimal.Decimal) error {
+	return r.DB(ctx).Model(&model.Transfer{}).Where("id = ?", id).Update("amount", amount).Error
+}
+
+// GetDepositAddress 获取用户在该资产上的充值地址，不存在时返回nil
+func (r *TransferRepository) GetDepositAddress(ctx context.Context, userID uint, asset string) (*model.DepositAddress, error) {
+	var address model.DepositAddress
+	err := r.DB(ctx).Where("user_id = ? AND asset = ?", userID, asset).First(&address).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// CreateDepositAddress 保存充值地址，用户在该资产上已有地址时不写入
+func (r *TransferRepository) CreateDepositAddress(ctx context.Context, address *model.DepositAddress) error {
+	return r.DB(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "asset"}},
+		DoNothing: true,
+	}).Create(address).Error
+}
+
+// first 查询单条转账，不存在时返回nil
+func (r *TransferRepository) first(db *gorm.DB) (*model.Transfer, error) {
+	var transfer model.Transfer
+	err := db.First(&transfer).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
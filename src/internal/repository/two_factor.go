@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"awesome-trade/src/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// TwoFactorRepository 两步验证仓储
+type TwoFactorRepository struct {
+	*BaseRepository
+}
+
+// NewTwoFactorRepository 创建两步验证仓储实例
+func NewTwoFactorRepository(db *gorm.DB) *TwoFactorRepository {
+	return &TwoFactorRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// GetByUser 获取用户的两步验证配置，不存在时返回nil
+func (r *TwoFactorRepository) GetByUser(ctx context.Context, userID uint) (*model.TwoFactor, error) {
+	var tf model.TwoFactor
+	err := r.DB(ctx).Where("user_id = ?", userID).First(&tf).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tf, nil
+}
+
+// Save 保存两步验证配置
+func (r *TwoFactorRepository) Save(ctx context.Context, tf *model.TwoFactor) error {
+	return r.DB(ctx).Save(tf).Error
+}
+
+// Enable 启用两步验证并替换全部恢复码
+func (r *TwoFactorRepository) Enable(ctx context.Context, tf *model.TwoFactor, codes []model.RecoveryCode) error {
+	return r.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(tf).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", tf.UserID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Delete 删除用户的两步验证配置及恢复码
+func (r *TwoFactorRepository) Delete(ctx context.Context, userID uint) error {
+	return r.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("user_id = ?", userID).Delete(&model.TwoFactor{}).Error
+	})
+}
+
+// AdvanceStep 原子地记录已使用的时间步，时间步未前进（验证码被重放）时返回false
+func (r *TwoFactorRepository) AdvanceStep(ctx context.Context, id uint, step int64, at time.Time) (bool, error) {
+	res := r.DB(ctx).Model(&model.TwoFactor{}).
+		Where("id = ? AND last_used_step < ?", id, step).
+		Updates(map[string]interface{}{"last_used_step": step, "last_verified_at": at})
+	return res.RowsAffected > 0, res.Error
+}
+
+// RecordFailure 原子地累加验证失败次数，达到max次时锁定到lockUntil并清零计数，返回是否因此锁定
+func (r *TwoFactorRepository) RecordFailure(ctx context.Context, id uint, max int, lockUntil time.Time) (bool, error) {
+	locked := false
+	err := r.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.TwoFactor{}).Where("id = ?", id).
+			Update("failed_attempts", gorm.Expr("failed_attempts + 1")).Error
+		if err != nil {
+			return err
+		}
+		res := tx.Model(&model.TwoFactor{}).
+			Where("id = ? AND failed_attempts >= ?", id, max).
+			Updates(map[string]interface{}{"failed_attempts": 0, "locked_until": lockUntil})
+		locked = res.RowsAffected > 0
+		return res.Error
+	})
+	return locked, err
+}
+
+// ResetFailures 验证成功后清零失败次数
+func (r *TwoFactorRepository) ResetFailures(ctx context.Context, id uint) error {
+	return r.DB(ctx).Model(&model.TwoFactor{}).
+		Where("id = ? AND failed_attempts > 0", id).
+		Update("failed_attempts", 0).Error
+}
+
+// UseRecoveryCode 原子地消耗一个未使用的恢复码，不存在或已使用时返回false
+func (r *TwoFactorRepository) UseRecoveryCode(ctx context.Context, userID uint, hash string, at time.Time) (bool, error) {
+	used := false
+	err := r.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.RecoveryCode{}).
+			Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+			Update("used_at", at)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		used = true
+		return tx.Model(&model.TwoFactor{}).Where("user_id = ?", userID).
+			Update("last_verified_at", at).Error
+	})
+	return used, err
+}
+
+// CountUnusedRecoveryCodes 统计剩余可用的恢复码数量
+func (r *TwoFactorRepository) CountUnusedRecoveryCodes(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.DB(ctx).Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"awesome-trade/src/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// UserQuery 用户列表查询条件
+type UserQuery struct {
+	Page     int
+	PageSize int
+	IsActive *bool
+	SortBy   string
+	Order    string
+}
+
+// UserRepository 用户仓储
+type UserRepository struct {
+	*Repository[model.User]
+}
+
+// NewUserRepository 创建用户仓储实例
+func NewUserRepository(db *gorm.DB) *UserRepository {
+	return &UserRepository{
+		Repository: NewRepository[model.User](db),
+	}
+}
+
+// List 分页查询用户列表
+func (r *UserRepository) List(ctx context.Context, q UserQuery) ([]model.User, int64, error) {
+	var scopes []Scope
+	if q.IsActive != nil {
+		scopes = append(scopes, Where("is_active = ?", *q.IsActive))
+	}
+	return r.Repository.List(ctx, PageQuery{
+		Page:     q.Page,
+		PageSize: q.PageSize,
+		SortBy:   q.SortBy,
+		Order:    q.Order,
+	}, scopes...)
+}
+
+// GetByID 根据ID获取用户，不存在时返回nil
+func (r *UserRepository) GetByID(ctx context.Context, id uint) (*model.User, error) {
+	return r.FindByID(ctx, id)
+}
+
+// GetByUsername 根据用户名获取用户，不存在时返回nil
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	var user model.User
+	err := r.DB(ctx).Where("username = ?", username).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ExistsByUsernameOrEmail 检查用户名或邮箱是否已被占用（包含已软删除的记录）
+func (r *UserRepository) ExistsByUsernameOrEmail(ctx context.Context, username, email string, excludeID uint) (bool, error) {
+	var count int64
+	db := r.DB(ctx).Unscoped().Model(&model.User{}).
+		Where("username = ? OR email = ?", username, email)
+	if excludeID != 0 {
+		db = db.Where("id <> ?", excludeID)
+	}
+	if err := db.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// SetTier 修改用户的风控等级，用户不存在时返回false
+func (r *UserRepository) SetTier(ctx context.Context, id uint, tier string) (bool, error) {
+	res := r.DB(ctx).Model(&model.User{}).Where("id = ?", id).Update("tier", tier)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// Delete 软删除用户
+func (r *UserRepository) Delete(ctx context.Context, id uint) error {
+	_, err := r.SoftDelete(ctx, id)
+	return err
+}
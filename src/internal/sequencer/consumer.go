@@ -0,0 +1,209 @@
+package sequencer
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// ErrParked 命令已定序但消费者无法处理，事件已被搁置
+var ErrParked = errors.New("event could not be applied and was parked")
+
+const (
+	// defaultApplyRetries 消费者处理临时失败时默认的最多尝试次数
+	defaultApplyRetries = 10
+	// applyRetryDelay 消费者处理失败后首次重试的等待时间，之后每次翻倍
+	applyRetryDelay = 100 * time.Millisecond
+	// maxApplyRetryDelay 消费者重试等待时间上限
+	maxApplyRetryDelay = 5 * time.Second
+)
+
+// Consumer 有序消费者，在独立协程中按序号顺序处理事件，不阻塞定序协程。
+// 消费者自行保存已处理的序号，重启时从日志重放其后的命令；处理失败时重试同一事件，之后的事件等待。
+// 永久错误或重试次数用尽时搁置该事件，继续处理后续事件
+type Consumer interface {
+	// Applied 返回交易对已处理的最大序号
+	Applied(symbol string) (uint64, error)
+	// Apply 处理一条事件，返回nil时须已保存该事件的序号。重试无法解决的错误用Permanent包装
+	Apply(ev Event) error
+	// Park 持久记录无法处理的事件并保存其序号，返回错误时继续重试该事件
+	Park(ev Event, cause error) error
+}
+
+// permanentError 重试无法解决的处理错误
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent 将错误标记为重试无法解决，消费者返回该错误时事件立即被搁置
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent 判断错误是否被标记为重试无法解决
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// SetConsumer 设置有序消费者，须在Start之前调用。设置后日志和快照保留到消费者处理完成为止
+func (s *Sequencer) SetConsumer(c Consumer) {
+	s.consumer = c
+}
+
+// SubscribeApplied 注册消费者处理成功后的事件订阅者，在消费协程中按序号顺序调用，须在Start之前调用
+func (s *Sequencer) SubscribeApplied(h Handler) {
+	s.appliedHandlers = append(s.appliedHandlers, h)
+}
+
+// Applied 返回消费者已处理的最大序号，没有消费者时返回最近处理的命令序号
+func (s *Sequencer) Applied() uint64 {
+	if s.consumer == nil {
+		return s.seq.Load()
+	}
+	return s.applied.Load()
+}
+
+// Parked 返回本次启动以来被搁置的事件数
+func (s *Sequencer) Parked() int {
+	s.appliedMu.Lock()
+	defer s.appliedMu.Unlock()
+	return len(s.parked)
+}
+
+// WaitApplied 等待消费者处理完调用时已定序的全部命令，没有消费者时立即返回
+func (s *Sequencer) WaitApplied(ctx context.Context) error {
+	return s.waitApplied(ctx, s.seq.Load())
+}
+
+// WaitSeq 等待消费者处理完序号为seq的命令，该命令的事件被搁置时返回ErrParked，没有消费者时立即返回
+func (s *Sequencer) WaitSeq(ctx context.Context, seq uint64) error {
+	if err := s.waitApplied(ctx, seq); err != nil {
+		return err
+	}
+	s.appliedMu.Lock()
+	defer s.appliedMu.Unlock()
+	if _, ok := s.parked[seq]; ok {
+		return ErrParked
+	}
+	return nil
+}
+
+// waitApplied 等待消费者已处理的序号达到target
+func (s *Sequencer) waitApplied(ctx context.Context, target uint64) error {
+	if s.consumer == nil {
+		return nil
+	}
+	for {
+		s.appliedMu.Lock()
+		advanced := s.advanced
+		s.appliedMu.Unlock()
+		if s.applied.Load() >= target {
+			return nil
+		}
+		select {
+		case <-advanced:
+		case <-s.consumed:
+			if s.applied.Load() >= target {
+				return nil
+			}
+			return ErrClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// startConsumer 启动消费协程，没有消费者时不启动
+func (s *Sequencer) startConsumer() {
+	if s.consumer == nil {
+		close(s.consumed)
+		return
+	}
+	s.events = make(chan Event, s.opts.QueueSize)
+	go s.consume()
+}
+
+// stopConsumer 等待消费协程处理完已入队的事件后退出，重试中的事件放弃，重启后从日志重放
+func (s *Sequencer) stopConsumer() {
+	if s.consumer == nil {
+		return
+	}
+	close(s.closing)
+	close(s.events)
+	<-s.consumed
+}
+
+// enqueue 将事件交给消费协程，队列已满时阻塞定序协程，消费协程已退出时丢弃
+func (s *Sequencer) enqueue(ev Event) {
+	select {
+	case s.events <- ev:
+	case <-s.consumed:
+	}
+}
+
+// consume 消费协程主循环
+func (s *Sequencer) consume() {
+	defer close(s.consumed)
+	for ev := range s.events {
+		ok, parked := s.applyEvent(ev)
+		if !ok {
+			return
+		}
+		s.appliedMu.Lock()
+		if parked {
+			s.parked[ev.Command.Seq] = struct{}{}
+		}
+		s.applied.Store(ev.Command.Seq)
+		close(s.advanced)
+		s.advanced = make(chan struct{})
+		s.appliedMu.Unlock()
+		if parked {
+			continue
+		}
+		for _, h := range s.appliedHandlers {
+			h(ev)
+		}
+	}
+}
+
+// applyEvent 调用消费者处理事件，临时失败时按退避间隔重试。永久错误或尝试ApplyRetries次仍失败时搁置事件，
+// 搁置失败时继续重试。处理成功或已搁置时返回true，parked表示事件已被搁置；定序器关闭时返回false
+func (s *Sequencer) applyEvent(ev Event) (ok, parked bool) {
+	delay := applyRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := s.consumer.Apply(ev)
+		if err == nil {
+			return true, false
+		}
+		if IsPermanent(err) || attempt >= s.opts.ApplyRetries {
+			perr := s.consumer.Park(ev, err)
+			if perr == nil {
+				log.Printf("ALERT: sequencer %s parked seq %d after %d attempts: %v", s.symbol, ev.Command.Seq, attempt, err)
+				return true, true
+			}
+			log.Printf("Sequencer %s failed to park seq %d: %v", s.symbol, ev.Command.Seq, perr)
+		}
+		log.Printf("Sequencer %s failed to apply seq %d (attempt %d), retrying in %s: %v", s.symbol, ev.Command.Seq, attempt, delay, err)
+		select {
+		case <-s.closing:
+			return false, false
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxApplyRetryDelay {
+			delay = maxApplyRetryDelay
+		}
+	}
+}
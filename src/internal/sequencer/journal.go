@@ -0,0 +1,225 @@
+package sequencer
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 日志记录头：4字节负载长度 + 4字节CRC32校验和
+const recordHeaderSize = 8
+
+// maxRecordSize 单条记录负载上限，超过时视为损坏
+const maxRecordSize = 1 << 20
+
+// 日志分段和快照文件名格式，数字为分段的起始序号或快照的序号
+const (
+	segmentPrefix  = "journal-"
+	segmentSuffix  = ".log"
+	snapshotPrefix = "snapshot-"
+	snapshotSuffix = ".json"
+)
+
+// journal 预写日志，按快照切分为多个分段文件，只追加写入
+type journal struct {
+	dir   string
+	fsync bool
+	file  *os.File
+}
+
+// segmentName 返回起始序号为seq的分段文件名
+func segmentName(seq uint64) string {
+	return fmt.Sprintf("%s%020d%s", segmentPrefix, seq, segmentSuffix)
+}
+
+// listFiles 列出目录中指定前后缀的文件，按文件名中的序号升序返回序号和路径
+func listFiles(dir, prefix, suffix string) ([]uint64, []string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	var seqs []uint64
+	paths := make(map[uint64]string)
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+		paths[seq] = filepath.Join(dir, name)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	out := make([]string, len(seqs))
+	for i, seq := range seqs {
+		out[i] = paths[seq]
+	}
+	return seqs, out, nil
+}
+
+// replayJournal 按序读取目录中的全部分段，对序号大于after的命令调用fn，返回最后一条命令的序号。
+// 最后一个分段末尾写入不完整的记录视为崩溃时的残留并截断，其余位置的损坏返回ErrCorruptJournal。
+func replayJournal(dir string, after uint64, fn func(Command) error) (uint64, error) {
+	_, paths, err := listFiles(dir, segmentPrefix, segmentSuffix)
+	if err != nil {
+		return 0, err
+	}
+
+	last := after
+	for i, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return 0, err
+		}
+		valid, err := readSegment(f, func(cmd Command) error {
+			if cmd.Seq <= after {
+				return nil
+			}
+			if cmd.Seq != last+1 {
+				return fmt.Errorf("%w: expected seq %d, got %d in %s", ErrCorruptJournal, last+1, cmd.Seq, filepath.Base(path))
+			}
+			last = cmd.Seq
+			return fn(cmd)
+		})
+		f.Close()
+
+		var torn *tornRecordError
+		switch {
+		case errors.As(err, &torn) && i == len(paths)-1:
+			if err := os.Truncate(path, valid); err != nil {
+				return 0, err
+			}
+		case errors.As(err, &torn):
+			return 0, fmt.Errorf("%w: %v in %s", ErrCorruptJournal, torn.err, filepath.Base(path))
+		case err != nil:
+			return 0, err
+		}
+	}
+	return last, nil
+}
+
+// tornRecordError 记录不完整或校验失败
+type tornRecordError struct {
+	err error
+}
+
+func (e *tornRecordError) Error() string {
+	return e.err.Error()
+}
+
+// readSegment 读取分段中的全部记录，返回最后一条完整记录结束处的偏移量
+func readSegment(r io.Reader, fn func(Command) error) (int64, error) {
+	var offset int64
+	var header [recordHeaderSize]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if err == io.EOF {
+				return offset, nil
+			}
+			return offset, &tornRecordError{err: err}
+		}
+		size := binary.BigEndian.Uint32(header[0:4])
+		if size == 0 || size > maxRecordSize {
+			return offset, &tornRecordError{err: fmt.Errorf("invalid record size %d", size)}
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return offset, &tornRecordError{err: err}
+		}
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+			return offset, &tornRecordError{err: errors.New("checksum mismatch")}
+		}
+
+		var cmd Command
+		if err := json.Unmarshal(payload, &cmd); err != nil {
+			return offset, &tornRecordError{err: err}
+		}
+		if err := fn(cmd); err != nil {
+			return offset, err
+		}
+		offset += recordHeaderSize + int64(size)
+	}
+}
+
+// openJournal 打开目录中最新的分段用于追加，没有分段时创建起始序号为next的分段
+func openJournal(dir string, next uint64, fsync bool) (*journal, error) {
+	_, paths, err := listFiles(dir, segmentPrefix, segmentSuffix)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, segmentName(next))
+	if len(paths) > 0 {
+		path = paths[len(paths)-1]
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &journal{dir: dir, fsync: fsync, file: f}, nil
+}
+
+// append 追加一条命令
+func (j *journal) append(cmd *Command) error {
+	payload, err := json.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(payload))
+	copy(buf[recordHeaderSize:], payload)
+
+	if _, err := j.file.Write(buf); err != nil {
+		return err
+	}
+	if j.fsync {
+		return j.file.Sync()
+	}
+	return nil
+}
+
+// rotate 关闭当前分段并从序号next开始新的分段，之前的分段由compact删除
+func (j *journal) rotate(next uint64) error {
+	f, err := os.OpenFile(filepath.Join(j.dir, segmentName(next)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	old := j.file
+	j.file = f
+	if err := old.Close(); err != nil {
+		return err
+	}
+	return syncDir(j.dir)
+}
+
+// close 关闭当前分段
+func (j *journal) close() error {
+	if j.fsync {
+		if err := j.file.Sync(); err != nil {
+			j.file.Close()
+			return err
+		}
+	}
+	return j.file.Close()
+}
+
+// syncDir 刷新目录元数据，保证新建和重命名的文件在宕机后可见
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
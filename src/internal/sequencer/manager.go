@@ -0,0 +1,135 @@
+package sequencer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// symbolPattern 交易对名称同时作为目录名，只允许字母、数字、下划线和连字符
+var symbolPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,31}$`)
+
+// Manager 按交易对管理定序器，每个交易对使用Options.Dir下的同名子目录
+type Manager struct {
+	opts       Options
+	handlers   []Handler
+	consumer   Consumer
+	applied    []Handler
+	mu         sync.Mutex
+	sequencers map[string]*Sequencer
+	closed     bool
+}
+
+// NewManager 创建定序器管理器
+func NewManager(opts Options) *Manager {
+	return &Manager{
+		opts:       opts,
+		sequencers: make(map[string]*Sequencer),
+	}
+}
+
+// Subscribe 注册所有交易对的事件订阅者，须在Start和Get之前调用
+func (m *Manager) Subscribe(h Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers = append(m.handlers, h)
+}
+
+// SetConsumer 设置所有交易对的有序消费者，须在Start和Get之前调用
+func (m *Manager) SetConsumer(c Consumer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.consumer = c
+}
+
+// SubscribeApplied 注册所有交易对的消费者处理成功后的事件订阅者，须在Start和Get之前调用
+func (m *Manager) SubscribeApplied(h Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.applied = append(m.applied, h)
+}
+
+// Start 恢复目录中已有的全部交易对
+func (m *Manager) Start() error {
+	entries, err := os.ReadDir(m.opts.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() && symbolPattern.MatchString(e.Name()) {
+			if _, err := m.Get(e.Name()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Get 返回交易对的定序器，首次访问时从磁盘恢复并启动
+func (m *Manager) Get(symbol string) (*Sequencer, error) {
+	if !symbolPattern.MatchString(symbol) {
+		return nil, ErrInvalidSymbol
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, ErrClosed
+	}
+	if s, ok := m.sequencers[symbol]; ok {
+		return s, nil
+	}
+
+	opts := m.opts
+	opts.Dir = filepath.Join(m.opts.Dir, symbol)
+	s := New(symbol, opts)
+	s.handlers = append(s.handlers, m.handlers...)
+	s.appliedHandlers = append(s.appliedHandlers, m.applied...)
+	s.consumer = m.consumer
+	if err := s.Start(); err != nil {
+		return nil, err
+	}
+	m.sequencers[symbol] = s
+	return s, nil
+}
+
+// Lookup 返回已启动的定序器，不存在时ok为false
+func (m *Manager) Lookup(symbol string) (*Sequencer, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sequencers[symbol]
+	return s, ok
+}
+
+// Symbols 返回已启动的交易对
+func (m *Manager) Symbols() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	symbols := make([]string, 0, len(m.sequencers))
+	for symbol := range m.sequencers {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// Close 关闭全部定序器
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	sequencers := m.sequencers
+	m.sequencers = make(map[string]*Sequencer)
+	m.mu.Unlock()
+
+	var errs []error
+	for _, s := range sequencers {
+		errs = append(errs, s.Close())
+	}
+	return errors.Join(errs...)
+}
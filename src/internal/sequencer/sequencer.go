@@ -0,0 +1,349 @@
+// Package sequencer 交易对的单线程定序器。每个交易对的全部订单命令由一个协程串行处理：
+// 分配单调递增的序号、写入预写日志、驱动撮合引擎并通知订阅者。重启时从最新快照恢复订单簿并重放其后的日志。
+// 有序消费者在独立协程中处理事件并保存已处理的序号，日志和快照保留到消费者处理完成为止。
+package sequencer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"awesome-trade/src/internal/matching"
+)
+
+// 定序器错误
+var (
+	ErrClosed          = errors.New("sequencer is closed")
+	ErrNotStarted      = errors.New("sequencer is not started")
+	ErrInvalidSymbol   = errors.New("invalid symbol")
+	ErrCorruptJournal  = errors.New("journal is corrupt")
+	ErrCorruptSnapshot = errors.New("snapshot is corrupt")
+)
+
+// CommandType 命令类型
+type CommandType uint8
+
+// 命令类型取值
+const (
+	CommandSubmit CommandType = iota + 1
+	CommandCancel
+)
+
+// Command 写入日志的订单命令
+type Command struct {
+	Seq     uint64          `json:"seq"`
+	Time    time.Time       `json:"time"`
+	Type    CommandType     `json:"type"`
+	Order   *matching.Order `json:"order,omitempty"`
+	OrderID uint64          `json:"order_id,omitempty"`
+}
+
+// Event 命令处理完成的事件，Replayed表示该命令是启动恢复时从日志重放的
+type Event struct {
+	Symbol   string
+	Command  Command
+	Result   *matching.Result
+	Replayed bool
+}
+
+// Handler 事件订阅者，在定序协程中按序号顺序同步调用，不得再向定序器提交命令
+type Handler func(Event)
+
+// Options 定序器配置
+type Options struct {
+	Dir              string // 日志和快照目录
+	SnapshotInterval int    // 每处理多少条命令生成一次快照，0表示不生成
+	Fsync            bool   // 每条命令写入日志后是否刷盘
+	QueueSize        int    // 命令队列长度
+	ApplyRetries     int    // 消费者处理临时失败时的最多尝试次数，之后搁置该事件，0表示默认值
+}
+
+// request 提交给定序协程的请求，cmd为nil时为只读请求
+type request struct {
+	cmd   *Command
+	read  func(book *matching.OrderBook, seq uint64)
+	reply chan reply
+}
+
+// reply 请求的处理结果
+type reply struct {
+	result *matching.Result
+	err    error
+}
+
+// Sequencer 单个交易对的定序器
+type Sequencer struct {
+	symbol          string
+	opts            Options
+	handlers        []Handler
+	consumer        Consumer
+	appliedHandlers []Handler
+
+	book        *matching.OrderBook
+	journal     *journal
+	seq         atomic.Uint64
+	snapshotSeq uint64
+	err         error
+	queue       chan request
+	stopped     chan struct{}
+	mu          sync.RWMutex
+	started     bool
+	closed      bool
+
+	events    chan Event
+	applied   atomic.Uint64
+	appliedMu sync.Mutex
+	advanced  chan struct{} // 已处理序号增加时关闭并替换
+	parked    map[uint64]struct{}
+	closing   chan struct{}
+	consumed  chan struct{}
+}
+
+// New 创建定序器，Options.Dir为该交易对独占的目录
+func New(symbol string, opts Options) *Sequencer {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1024
+	}
+	if opts.ApplyRetries <= 0 {
+		opts.ApplyRetries = defaultApplyRetries
+	}
+	return &Sequencer{
+		symbol:  symbol,
+		opts:    opts,
+		book:    matching.NewOrderBook(symbol),
+		queue:   make(chan request, opts.QueueSize),
+		stopped: make(chan struct{}),
+
+		advanced: make(chan struct{}),
+		parked:   make(map[uint64]struct{}),
+		closing:  make(chan struct{}),
+		consumed: make(chan struct{}),
+	}
+}
+
+// Symbol 返回交易对
+func (s *Sequencer) Symbol() string {
+	return s.symbol
+}
+
+// Seq 返回最近处理的命令序号
+func (s *Sequencer) Seq() uint64 {
+	return s.seq.Load()
+}
+
+// Subscribe 注册事件订阅者，须在Start之前调用
+func (s *Sequencer) Subscribe(h Handler) {
+	s.handlers = append(s.handlers, h)
+}
+
+// Start 从快照和日志恢复订单簿，恢复过程中的命令以Replayed事件通知订阅者，然后启动定序协程。
+// 有消费者时从不晚于消费者已处理序号的快照恢复，消费者尚未处理的命令在重放时交给消费者
+func (s *Sequencer) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started || s.closed {
+		return nil
+	}
+
+	if err := os.MkdirAll(s.opts.Dir, 0o755); err != nil {
+		return err
+	}
+	applied := uint64(math.MaxUint64)
+	if s.consumer != nil {
+		n, err := s.consumer.Applied(s.symbol)
+		if err != nil {
+			return err
+		}
+		s.applied.Store(n)
+		applied = n
+	}
+	snap, err := loadSnapshot(s.opts.Dir, applied)
+	if err != nil {
+		return err
+	}
+	if snap != nil {
+		if snap.Book.Symbol != s.symbol {
+			return fmt.Errorf("%w: symbol %q does not match %q", ErrCorruptSnapshot, snap.Book.Symbol, s.symbol)
+		}
+		s.book = matching.RestoreOrderBook(snap.Book)
+		s.snapshotSeq = snap.Seq
+	}
+
+	s.startConsumer()
+	last, err := replayJournal(s.opts.Dir, s.snapshotSeq, func(cmd Command) error {
+		s.dispatch(cmd, s.apply(&cmd), true)
+		return nil
+	})
+	if err == nil && s.consumer != nil && applied > last {
+		// 日志丢失时新命令会重用消费者已处理的序号而被跳过
+		err = fmt.Errorf("%w: consumer applied seq %d is ahead of journal seq %d", ErrCorruptJournal, applied, last)
+	}
+	if err == nil {
+		s.seq.Store(last)
+		s.journal, err = openJournal(s.opts.Dir, last+1, s.opts.Fsync)
+	}
+	if err != nil {
+		s.stopConsumer()
+		return err
+	}
+	s.started = true
+	go s.run()
+	return nil
+}
+
+// Submit 提交订单，返回撮合结果
+func (s *Sequencer) Submit(ctx context.Context, o matching.Order) (*matching.Result, error) {
+	return s.do(ctx, request{cmd: &Command{Type: CommandSubmit, Order: &o}})
+}
+
+// Cancel 撤销订单
+func (s *Sequencer) Cancel(ctx context.Context, id uint64) (*matching.Result, error) {
+	return s.do(ctx, request{cmd: &Command{Type: CommandCancel, OrderID: id}})
+}
+
+// Read 在定序协程中读取订单簿，fn返回前订单簿不会变化，fn中不得保留订单簿的引用
+func (s *Sequencer) Read(ctx context.Context, fn func(book *matching.OrderBook, seq uint64)) error {
+	_, err := s.do(ctx, request{read: fn})
+	return err
+}
+
+// Close 处理完已入队的命令后停止定序协程，等待消费者处理完已定序的事件，然后关闭日志
+func (s *Sequencer) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	started := s.started
+	close(s.queue)
+	s.mu.Unlock()
+
+	if !started {
+		return nil
+	}
+	<-s.stopped
+	s.stopConsumer()
+	return s.journal.close()
+}
+
+// do 将请求放入队列并等待结果。请求一旦入队就会被处理，ctx只控制排队等待。
+func (s *Sequencer) do(ctx context.Context, req request) (*matching.Result, error) {
+	req.reply = make(chan reply, 1)
+
+	s.mu.RLock()
+	switch {
+	case s.closed:
+		s.mu.RUnlock()
+		return nil, ErrClosed
+	case !s.started:
+		s.mu.RUnlock()
+		return nil, ErrNotStarted
+	}
+	select {
+	case s.queue <- req:
+		s.mu.RUnlock()
+	case <-ctx.Done():
+		s.mu.RUnlock()
+		return nil, ctx.Err()
+	}
+
+	r := <-req.reply
+	return r.result, r.err
+}
+
+// run 定序协程主循环
+func (s *Sequencer) run() {
+	defer close(s.stopped)
+	for req := range s.queue {
+		if req.cmd == nil {
+			req.read(s.book, s.seq.Load())
+			req.reply <- reply{}
+			continue
+		}
+		res, err := s.process(req.cmd)
+		req.reply <- reply{result: res, err: err}
+	}
+}
+
+// process 为命令分配序号并写入日志，然后驱动撮合引擎、通知订阅者并按需生成快照
+func (s *Sequencer) process(cmd *Command) (*matching.Result, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	cmd.Seq = s.seq.Load() + 1
+	cmd.Time = time.Now().UTC()
+	if err := s.journal.append(cmd); err != nil {
+		// 写入失败后日志末尾可能残留不完整的记录，继续追加的命令在重放时会丢失，因此停止接受命令
+		s.err = fmt.Errorf("journal append failed: %w", err)
+		log.Printf("Sequencer %s stopped: %v", s.symbol, s.err)
+		return nil, s.err
+	}
+	s.seq.Store(cmd.Seq)
+
+	res := s.apply(cmd)
+	s.dispatch(*cmd, res, false)
+
+	if s.opts.SnapshotInterval > 0 && cmd.Seq-s.snapshotSeq >= uint64(s.opts.SnapshotInterval) {
+		if err := s.snapshot(); err != nil {
+			log.Printf("Sequencer %s snapshot at seq %d failed: %v", s.symbol, cmd.Seq, err)
+		}
+	}
+	return res, nil
+}
+
+// apply 在订单簿上执行命令，结果带上命令的序号
+func (s *Sequencer) apply(cmd *Command) *matching.Result {
+	var res *matching.Result
+	switch cmd.Type {
+	case CommandSubmit:
+		o := *cmd.Order
+		res = s.book.Submit(&o)
+	case CommandCancel:
+		res = s.book.Cancel(cmd.OrderID)
+	default:
+		res = &matching.Result{Symbol: s.symbol, Status: matching.StatusRejected, Reason: matching.ReasonInvalidOrderType}
+	}
+	res.Seq = cmd.Seq
+	return res
+}
+
+// dispatch 通知订阅者，并将消费者尚未处理的事件交给消费协程
+func (s *Sequencer) dispatch(cmd Command, res *matching.Result, replayed bool) {
+	ev := Event{Symbol: s.symbol, Command: cmd, Result: res, Replayed: replayed}
+	for _, h := range s.handlers {
+		h(ev)
+	}
+	if s.consumer != nil && cmd.Seq > s.applied.Load() {
+		s.enqueue(ev)
+	}
+}
+
+// snapshot 写入当前序号的快照并切换到新的日志分段，然后删除消费者不再需要的快照和分段
+func (s *Sequencer) snapshot() error {
+	seq := s.seq.Load()
+	err := writeSnapshot(s.opts.Dir, &snapshot{
+		Seq:  seq,
+		Time: time.Now().UTC(),
+		Book: s.book.Snapshot(),
+	})
+	if err != nil {
+		return err
+	}
+	s.snapshotSeq = seq
+	if err := s.journal.rotate(seq + 1); err != nil {
+		return err
+	}
+	applied := uint64(math.MaxUint64)
+	if s.consumer != nil {
+		applied = s.applied.Load()
+	}
+	return compact(s.opts.Dir, applied)
+}
@@ -0,0 +1,321 @@
+package sequencer
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"awesome-trade/src/internal/matching"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// randomOrder 在中间价附近生成随机限价单
+func randomOrder(rng *rand.Rand, id uint64) matching.Order {
+	return matching.Order{
+		ID:          id,
+		UserID:      uint64(rng.Intn(10) + 1),
+		Side:        matching.Side(rng.Intn(2) + 1),
+		Type:        matching.Limit,
+		TimeInForce: matching.GTC,
+		Price:       1000 + int64(rng.Intn(20)) - 10,
+		Quantity:    int64(rng.Intn(10) + 1),
+	}
+}
+
+// depth 在定序协程中读取完整深度和序号
+func depth(t *testing.T, s *Sequencer) (bids, asks []matching.Level, seq uint64) {
+	require.NoError(t, s.Read(context.Background(), func(book *matching.OrderBook, n uint64) {
+		bids, asks = book.Depth(0)
+		seq = n
+	}))
+	return bids, asks, seq
+}
+
+// 测试崩溃恢复：从快照加载订单簿并重放之后的日志
+func TestRecoverFromSnapshotAndJournal(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{Dir: dir, SnapshotInterval: 50}
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(1))
+
+	s := New("BTC_USDT", opts)
+	require.NoError(t, s.Start())
+	for i := uint64(1); i <= 120; i++ {
+		_, err := s.Submit(ctx, randomOrder(rng, i))
+		require.NoError(t, err)
+		if i%7 == 0 {
+			_, err := s.Cancel(ctx, i-3)
+			require.NoError(t, err)
+		}
+	}
+	bids, asks, seq := depth(t, s)
+	require.NoError(t, s.Close())
+
+	// 只保留最新快照和其后的日志分段
+	snaps, _, err := listFiles(dir, snapshotPrefix, snapshotSuffix)
+	require.NoError(t, err)
+	segments, _, err := listFiles(dir, segmentPrefix, segmentSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{100}, snaps)
+	assert.Equal(t, []uint64{101}, segments)
+
+	var replayed []uint64
+	recovered := New("BTC_USDT", opts)
+	recovered.Subscribe(func(ev Event) {
+		if ev.Replayed {
+			replayed = append(replayed, ev.Command.Seq)
+		}
+	})
+	require.NoError(t, recovered.Start())
+	defer recovered.Close()
+
+	rbids, rasks, rseq := depth(t, recovered)
+	assert.Equal(t, seq, rseq)
+	assert.Equal(t, bids, rbids)
+	assert.Equal(t, asks, rasks)
+	require.Len(t, replayed, int(seq-100))
+	assert.Equal(t, uint64(101), replayed[0])
+
+	// 恢复后序号继续递增
+	res, err := recovered.Submit(ctx, randomOrder(rng, 1000))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1000), res.OrderID)
+	assert.Equal(t, seq+1, recovered.Seq())
+}
+
+// 测试日志末尾写入不完整的记录在恢复时被截断
+func TestTornJournalTail(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(2))
+
+	s := New("ETH_USDT", Options{Dir: dir})
+	require.NoError(t, s.Start())
+	for i := uint64(1); i <= 10; i++ {
+		_, err := s.Submit(ctx, randomOrder(rng, i))
+		require.NoError(t, err)
+	}
+	bids, asks, _ := depth(t, s)
+	require.NoError(t, s.Close())
+
+	path := filepath.Join(dir, segmentName(1))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0, 0, 0, 42, 1, 2, 3})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	recovered := New("ETH_USDT", Options{Dir: dir})
+	require.NoError(t, recovered.Start())
+	rbids, rasks, seq := depth(t, recovered)
+	assert.Equal(t, uint64(10), seq)
+	assert.Equal(t, bids, rbids)
+	assert.Equal(t, asks, rasks)
+
+	_, err = recovered.Submit(ctx, randomOrder(rng, 11))
+	require.NoError(t, err)
+	require.NoError(t, recovered.Close())
+
+	// 截断后追加的命令可以再次恢复
+	info2, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Greater(t, info2.Size(), info.Size())
+	again := New("ETH_USDT", Options{Dir: dir})
+	require.NoError(t, again.Start())
+	defer again.Close()
+	assert.Equal(t, uint64(11), again.Seq())
+}
+
+// 测试并发提交时序号单调递增且事件按序号顺序通知
+func TestConcurrentSubmit(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(Options{Dir: t.TempDir(), SnapshotInterval: 100})
+	var seqs []uint64
+	m.Subscribe(func(ev Event) {
+		seqs = append(seqs, ev.Command.Seq)
+	})
+	s, err := m.Get("BTC_USDT")
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(w)))
+			for i := 0; i < 100; i++ {
+				_, err := s.Submit(ctx, randomOrder(rng, uint64(w*1000+i+1)))
+				assert.NoError(t, err)
+			}
+		}(w)
+	}
+	wg.Wait()
+	require.NoError(t, m.Close())
+
+	require.Len(t, seqs, 800)
+	for i, seq := range seqs {
+		require.Equal(t, uint64(i+1), seq)
+	}
+
+	_, err = s.Submit(ctx, randomOrder(rand.New(rand.NewSource(0)), 1))
+	assert.ErrorIs(t, err, ErrClosed)
+
+	// 重新启动时恢复已有交易对
+	m = NewManager(Options{Dir: m.opts.Dir})
+	require.NoError(t, m.Start())
+	defer m.Close()
+	assert.Equal(t, []string{"BTC_USDT"}, m.Symbols())
+	s, _ = m.Lookup("BTC_USDT")
+	assert.Equal(t, uint64(800), s.Seq())
+
+	_, err = m.Get("../etc")
+	assert.ErrorIs(t, err, ErrInvalidSymbol)
+}
+
+// testConsumer 记录已处理序号的消费者，failFrom不为0时从该序号开始临时失败，broken中的序号永久失败
+type testConsumer struct {
+	mu       sync.Mutex
+	applied  uint64
+	failFrom uint64
+	broken   map[uint64]bool
+	seen     []uint64
+	parked   []uint64
+}
+
+func (c *testConsumer) Applied(symbol string) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.applied, nil
+}
+
+func (c *testConsumer) Apply(ev Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.broken[ev.Command.Seq] {
+		return Permanent(errors.New("order not found"))
+	}
+	if c.failFrom != 0 && ev.Command.Seq >= c.failFrom {
+		return errors.New("settlement unavailable")
+	}
+	c.applied = ev.Command.Seq
+	c.seen = append(c.seen, ev.Command.Seq)
+	return nil
+}
+
+func (c *testConsumer) Park(ev Event, cause error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.applied = ev.Command.Seq
+	c.parked = append(c.parked, ev.Command.Seq)
+	return nil
+}
+
+// 测试消费者落后时保留其尚未处理的日志，重启后从不晚于已处理序号的快照恢复并补发未处理的事件
+func TestConsumerRetention(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{Dir: dir, SnapshotInterval: 10, ApplyRetries: 1000}
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(3))
+
+	consumer := &testConsumer{failFrom: 16}
+	s := New("BTC_USDT", opts)
+	s.SetConsumer(consumer)
+	require.NoError(t, s.Start())
+	for i := uint64(1); i <= 35; i++ {
+		_, err := s.Submit(ctx, randomOrder(rng, i))
+		require.NoError(t, err)
+		if i == 15 {
+			require.NoError(t, s.WaitApplied(ctx))
+		}
+	}
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.WaitApplied(timeout), context.DeadlineExceeded)
+	assert.Equal(t, uint64(15), s.Applied())
+	bids, asks, seq := depth(t, s)
+	require.NoError(t, s.Close())
+
+	// 消费者处理到15，保留快照10及其后的快照和分段
+	snaps, _, err := listFiles(dir, snapshotPrefix, snapshotSuffix)
+	require.NoError(t, err)
+	segments, _, err := listFiles(dir, segmentPrefix, segmentSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{10, 20, 30}, snaps)
+	assert.Equal(t, []uint64{11, 21, 31}, segments)
+
+	consumer.failFrom = 0
+	var published []uint64
+	recovered := New("BTC_USDT", opts)
+	recovered.SetConsumer(consumer)
+	recovered.SubscribeApplied(func(ev Event) {
+		assert.True(t, ev.Replayed)
+		published = append(published, ev.Command.Seq)
+	})
+	require.NoError(t, recovered.Start())
+	require.NoError(t, recovered.WaitApplied(ctx))
+
+	rbids, rasks, rseq := depth(t, recovered)
+	assert.Equal(t, seq, rseq)
+	assert.Equal(t, bids, rbids)
+	assert.Equal(t, asks, rasks)
+	assert.Equal(t, uint64(35), recovered.Applied())
+	require.NoError(t, recovered.Close())
+	require.Len(t, consumer.seen, 35)
+	for i, n := range consumer.seen {
+		require.Equal(t, uint64(i+1), n)
+	}
+	assert.Equal(t, consumer.seen[15:], published)
+}
+
+// 测试永久错误的事件立即搁置、临时错误重试次数用尽后搁置，搁置后继续处理后续事件，等待被搁置命令的调用方收到ErrParked
+func TestConsumerPark(t *testing.T) {
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(4))
+	consumer := &testConsumer{broken: map[uint64]bool{2: true}}
+	s := New("BTC_USDT", Options{Dir: t.TempDir(), ApplyRetries: 2})
+	s.SetConsumer(consumer)
+	var published []uint64
+	s.SubscribeApplied(func(ev Event) {
+		published = append(published, ev.Command.Seq)
+	})
+	require.NoError(t, s.Start())
+
+	seqs := make([]uint64, 0, 4)
+	for i := uint64(1); i <= 4; i++ {
+		if i == 3 {
+			consumer.mu.Lock()
+			consumer.failFrom = 3
+			consumer.mu.Unlock()
+		}
+		res, err := s.Submit(ctx, randomOrder(rng, i))
+		require.NoError(t, err)
+		seqs = append(seqs, res.Seq)
+		if i == 3 {
+			// 第3条命令尝试2次后搁置
+			assert.ErrorIs(t, s.WaitSeq(ctx, res.Seq), ErrParked)
+			consumer.mu.Lock()
+			consumer.failFrom = 0
+			consumer.mu.Unlock()
+		}
+	}
+	assert.Equal(t, []uint64{1, 2, 3, 4}, seqs)
+	assert.NoError(t, s.WaitSeq(ctx, 1))
+	assert.ErrorIs(t, s.WaitSeq(ctx, 2), ErrParked)
+	assert.NoError(t, s.WaitSeq(ctx, 4))
+	assert.Equal(t, uint64(4), s.Applied())
+	assert.Equal(t, 2, s.Parked())
+	require.NoError(t, s.Close())
+
+	assert.Equal(t, []uint64{2, 3}, consumer.parked)
+	assert.Equal(t, []uint64{1, 4}, consumer.seen)
+	assert.Equal(t, []uint64{1, 4}, published)
+}
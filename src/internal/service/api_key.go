@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"awesome-trade/src/internal/config"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/pkg/utils"
+)
+
+// API Key服务错误
+var (
+	ErrAPIKeyNotFound      = errors.New("api key not found")
+	ErrInvalidAPIKey       = errors.New("invalid api key")
+	ErrInvalidSignature    = errors.New("invalid signature")
+	ErrTimestampOutOfRange = errors.New("timestamp outside of recv window")
+	ErrRequestReplayed     = errors.New("request has already been processed")
+	ErrIPNotAllowed        = errors.New("ip address not allowed for this api key")
+	ErrInvalidScope        = errors.New("invalid scope")
+	ErrInvalidIPAllowlist  = errors.New("invalid ip allowlist entry")
+)
+
+// validScopes 允许授予API Key的权限范围
+var validScopes = map[string]bool{
+	model.ScopeRead:     true,
+	model.ScopeTrade:    true,
+	model.ScopeWithdraw: true,
+}
+
+// SignedRequest 待校验的签名请求
+type SignedRequest struct {
+	AccessKey  string
+	Timestamp  string
+	Signature  string
+	RecvWindow string
+	Method     string
+	Path       string
+	Body       []byte
+	ClientIP   string
+}
+
+// CreateAPIKeyInput 创建API Key参数
+type CreateAPIKeyInput struct {
+	Label       string
+	Scopes      []string
+	IPAllowlist []string
+}
+
+// CreatedAPIKey 新建的API Key，Secret只在创建时返回一次
+type CreatedAPIKey struct {
+	*model.APIKey
+	Secret string `json:"secret"`
+}
+
+// APIKeyService API Key服务
+type APIKeyService struct {
+	*BaseService
+	keys          *repository.APIKeyRepository
+	cipher        *utils.Cipher
+	recvWindow    time.Duration
+	maxRecvWindow time.Duration
+	seen          *signatureCache
+}
+
+// NewAPIKeyService 创建API Key服务实例
+func NewAPIKeyService(keys *repository.APIKeyRepository, cipher *utils.Cipher, cfg config.APIKeyConfig) *APIKeyService {
+	return &APIKeyService{
+		BaseService:   NewBaseService(nil),
+		keys:          keys,
+		cipher:        cipher,
+		recvWindow:    time.Duration(cfg.RecvWindow) * time.Millisecond,
+		maxRecvWindow: time.Duration(cfg.MaxRecvWindow) * time.Millisecond,
+		seen:          newSignatureCache(),
+	}
+}
+
+// Create 为用户创建API Key
+func (s *APIKeyService) Create(ctx context.Context, userID uint, in CreateAPIKeyInput) (*CreatedAPIKey, error) {
+	if len(in.Scopes) == 0 {
+		return nil, ErrInvalidScope
+	}
+	for _, scope := range in.Scopes {
+		if !validScopes[scope] {
+			return nil, ErrInvalidScope
+		}
+	}
+	for _, entry := range in.IPAllowlist {
+		if net.ParseIP(entry) == nil {
+			if _, _, err := net.ParseCIDR(entry); err != nil {
+				return nil, ErrInvalidIPAllowlist
+			}
+		}
+	}
+
+	accessKey, err := utils.RandomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := utils.RandomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.cipher.Encrypt(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &model.APIKey{
+		UserID:          userID,
+		Label:           in.Label,
+		AccessKey:       accessKey,
+		SecretEncrypted: encrypted,
+		Scopes:          strings.Join(in.Scopes, ","),
+		IPAllowlist:     strings.Join(in.IPAllowlist, ","),
+	}
+	if err := s.keys.Create(ctx, key); err != nil {
+		return nil, err
+	}
+	return &CreatedAPIKey{APIKey: key, Secret: secret}, nil
+}
+
+// List 查询用户的API Key
+func (s *APIKeyService) List(ctx context.Context, userID uint) ([]model.APIKey, error) {
+	return s.keys.ListByUser(ctx, userID)
+}
+
+// Delete 删除用户的API Key
+func (s *APIKeyService) Delete(ctx context.Context, userID, id uint) error {
+	deleted, err := s.keys.Delete(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Verify 校验签名请求：Key有效、时间戳在接收窗口内、签名正确、未被重放且来源IP在白名单内
+func (s *APIKeyService) Verify(ctx context.Context, req SignedRequest) (*model.APIKey, error) {
+	if req.AccessKey == "" || req.Signature == "" {
+		return nil, ErrInvalidAPIKey
+	}
+
+	window := s.recvWindow
+	if req.RecvWindow != "" {
+		ms, err := strconv.ParseInt(req.RecvWindow, 10, 64)
+		if err != nil || ms <= 0 || time.Duration(ms)*time.Millisecond > s.maxRecvWindow {
+			return nil, ErrTimestampOutOfRange
+		}
+		window = time.Duration(ms) * time.Millisecond
+	}
+
+	ts, err := strconv.ParseInt(req.Timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrTimestampOutOfRange
+	}
+	now := time.Now()
+	sentAt := time.UnixMilli(ts)
+	// 允许客户端时钟略微超前
+	if sentAt.After(now.Add(time.Second)) || now.Sub(sentAt) > window {
+		return nil, ErrTimestampOutOfRange
+	}
+
+	key, err := s.keys.GetByKey(ctx, req.AccessKey)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, ErrInvalidAPIKey
+	}
+	if !key.User.IsActive {
+		return nil, ErrUserInactive
+	}
+
+	secret, err := s.cipher.Decrypt(key.SecretEncrypted)
+	if err != nil {
+		return nil, err
+	}
+	signature := strings.ToLower(req.Signature)
+	expected := SignRequest(secret, SignaturePayload(req.Timestamp, req.RecvWindow, req.Method, req.Path, req.Body))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, ErrInvalidSignature
+	}
+
+	if !key.AllowsIP(req.ClientIP) {
+		return nil, ErrIPNotAllowed
+	}
+
+	// 同一签名在最大接收窗口内只能使用一次。记录保存在本实例内存中，多实例部署时须在负载均衡层按API Key固定实例
+	if !s.seen.add(signature, sentAt.Add(s.maxRecvWindow)) {
+		return nil, ErrRequestReplayed
+	}
+
+	_ = s.keys.TouchLastUsed(ctx, key.ID, now)
+	return key, nil
+}
+
+// SignaturePayload 构造待签名内容：timestamp、recvWindow、METHOD、path(含查询串)和body依次以换行符分隔，
+// 未指定接收窗口时recvWindow为空。接收窗口参与签名，防止重放时放大窗口；字段间的分隔符防止移动字段边界后签名不变
+func SignaturePayload(timestamp, recvWindow, method, path string, body []byte) string {
+	var b strings.Builder
+	for _, field := range []string{timestamp, recvWindow, strings.ToUpper(method), path} {
+		b.WriteString(field)
+		b.WriteByte('\n')
+	}
+	b.Write(body)
+	return b.String()
+}
+
+// SignRequest 使用HMAC-SHA256计算签名，返回十六进制字符串
+func SignRequest(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signatureCache 记录接收窗口内已使用过的签名
+type signatureCache struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+func newSignatureCache() *signatureCache {
+	return &signatureCache{
+		entries: make(map[string]time.Time),
+	}
+}
+
+// add 记录签名，签名已存在时返回false
+func (c *signatureCache) add(signature string, expiresAt time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if exp, ok := c.entries[signature]; ok && now.Before(exp) {
+		return false
+	}
+	if len(c.entries) > 1024 {
+		for sig, exp := range c.entries {
+			if now.After(exp) {
+				delete(c.entries, sig)
+			}
+		}
+	}
+	c.entries[signature] = expiresAt
+	return true
+}
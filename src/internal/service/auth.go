@@ -0,0 +1,391 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"awesome-trade/src/internal/config"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 认证服务错误
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrUserInactive       = errors.New("user is inactive")
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrTokenRevoked       = errors.New("token has been revoked")
+
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected, all sessions in this family are revoked")
+)
+
+// dummyPasswordHash 用户不存在时参与比对的哈希，避免通过响应时间枚举用户名
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+// 令牌用途
+const (
+	tokenPurposeAccess = "access"
+	tokenPurposeMFA    = "mfa"
+)
+
+// mfaTokenExpire 两步验证登录挑战令牌的有效期
+const mfaTokenExpire = 5 * time.Minute
+
+// mfaTokenMaxAttempts 每个登录挑战令牌允许的验证失败次数，达到后吊销令牌，须重新输入密码
+const mfaTokenMaxAttempts = 3
+
+// Claims 访问令牌声明
+type Claims struct {
+	UserID   uint   `json:"uid"`
+	Username string `json:"username"`
+	Purpose  string `json:"pur"`
+	Version  uint   `json:"ver"` // 签发时用户的令牌版本
+	jwt.RegisteredClaims
+}
+
+// TokenPair 登录成功后返回的令牌
+type TokenPair struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token,omitempty"`
+	RefreshExpiresIn int    `json:"refresh_expires_in,omitempty"`
+}
+
+// LoginResult 登录结果。启用两步验证的用户先获得MFAToken，验证通过后才签发令牌
+type LoginResult struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token,omitempty"`
+	*TokenPair
+}
+
+// AuthService 认证服务
+type AuthService struct {
+	*BaseService
+	users         *repository.UserRepository
+	refreshTokens *repository.RefreshTokenRepository
+	userSvc       *UserService
+	twoFactor     *TwoFactorService
+	denylist      TokenDenylist
+	mfaFailures   *attemptCounter
+	secret        []byte
+	expire        time.Duration
+	refreshExpire time.Duration
+}
+
+// NewAuthService 创建认证服务实例
+func NewAuthService(users *repository.UserRepository, refreshTokens *repository.RefreshTokenRepository, userSvc *UserService, twoFactor *TwoFactorService, denylist TokenDenylist, cfg config.JWTConfig) *AuthService {
+	return &AuthService{
+		BaseService:   NewBaseService(nil),
+		users:         users,
+		refreshTokens: refreshTokens,
+		userSvc:       userSvc,
+		twoFactor:     twoFactor,
+		denylist:      denylist,
+		mfaFailures:   newAttemptCounter(),
+		secret:        []byte(cfg.Secret),
+		expire:        time.Duration(cfg.ExpireTime) * time.Second,
+		refreshExpire: time.Duration(cfg.RefreshExpireTime) * time.Second,
+	}
+}
+
+// Register 注册新用户
+func (s *AuthService) Register(ctx context.Context, username, email, password string) (*model.User, error) {
+	return s.userSvc.Create(ctx, CreateUserInput{
+		Username: username,
+		Email:    email,
+		Password: password,
+	})
+}
+
+// Login 校验用户名密码。未启用两步验证时直接签发令牌，
+// 否则返回短期有效的MFAToken，由LoginWithTwoFactor完成第二步
+func (s *AuthService) Login(ctx context.Context, username, password string) (*LoginResult, error) {
+	user, err := s.Authenticate(ctx, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled, err := s.twoFactor.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		mfaToken, err := s.signToken(user, tokenPurposeMFA, mfaTokenExpire)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{MFARequired: true, MFAToken: mfaToken}, nil
+	}
+
+	pair, err := s.IssueTokens(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{TokenPair: pair}, nil
+}
+
+// LoginWithTwoFactor 校验登录挑战令牌和两步验证码（或恢复码）后签发令牌
+func (s *AuthService) LoginWithTwoFactor(ctx context.Context, mfaToken, code string) (*LoginResult, error) {
+	claims, err := s.parseToken(mfaToken, tokenPurposeMFA)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactor.Verify(ctx, claims.UserID, code); err != nil {
+		// 验证码错误次数达到上限或账户已锁定时吊销挑战令牌
+		if errors.Is(err, ErrTwoFactorLocked) ||
+			(errors.Is(err, ErrInvalidTwoFactorCode) && s.mfaFailures.add(claims.ID, claims.ExpiresAt.Time) >= mfaTokenMaxAttempts) {
+			s.denylist.Revoke(claims.ID, claims.ExpiresAt.Time)
+			s.mfaFailures.remove(claims.ID)
+		}
+		return nil, err
+	}
+	// 挑战令牌只能使用一次
+	s.denylist.Revoke(claims.ID, claims.ExpiresAt.Time)
+	s.mfaFailures.remove(claims.ID)
+
+	user, err := s.users.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsActive {
+		return nil, ErrUserInactive
+	}
+
+	pair, err := s.IssueTokens(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{TokenPair: pair}, nil
+}
+
+// IssueTokens 开启新的令牌族，签发访问令牌和刷新令牌
+func (s *AuthService) IssueTokens(ctx context.Context, user *model.User) (*TokenPair, error) {
+	familyID, err := utils.RandomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	refresh, raw, err := s.newRefreshToken(user, familyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refreshTokens.Create(ctx, refresh); err != nil {
+		return nil, err
+	}
+
+	pair, err := s.IssueAccessToken(user)
+	if err != nil {
+		return nil, err
+	}
+	s.attachRefreshToken(pair, raw)
+	return pair, nil
+}
+
+// Refresh 使用刷新令牌换取新的令牌对。刷新令牌只能使用一次，
+// 已使用的令牌再次出现说明令牌可能被盗，此时吊销整个令牌族。
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	stored, err := s.refreshTokens.GetByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil || stored.RevokedAt != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if stored.UsedAt != nil {
+		if err := s.refreshTokens.RevokeFamily(ctx, stored.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.users.GetByID(ctx, stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsActive {
+		if err := s.refreshTokens.RevokeFamily(ctx, stored.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrUserInactive
+	}
+	// 修改密码后之前签发的刷新令牌失效
+	if stored.TokenVersion != user.TokenVersion {
+		if err := s.refreshTokens.RevokeFamily(ctx, stored.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	next, raw, err := s.newRefreshToken(user, stored.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	rotated, err := s.refreshTokens.Rotate(ctx, stored.ID, next)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// 并发请求抢先使用了同一个令牌
+		if err := s.refreshTokens.RevokeFamily(ctx, stored.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	pair, err := s.IssueAccessToken(user)
+	if err != nil {
+		return nil, err
+	}
+	s.attachRefreshToken(pair, raw)
+	return pair, nil
+}
+
+// Authenticate 校验用户名密码
+func (s *AuthService) Authenticate(ctx context.Context, username, password string) (*model.User, error) {
+	user, err := s.users.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if !user.IsActive {
+		return nil, ErrUserInactive
+	}
+	return user, nil
+}
+
+// IssueAccessToken 为用户签发访问令牌
+func (s *AuthService) IssueAccessToken(user *model.User) (*TokenPair, error) {
+	token, err := s.signToken(user, tokenPurposeAccess, s.expire)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(s.expire.Seconds()),
+	}, nil
+}
+
+// ParseAccessToken 校验访问令牌签名、有效期和吊销状态，并校验用户仍然有效：
+// 用户已停用或删除时返回ErrUserInactive，令牌签发后修改过密码时返回ErrTokenRevoked
+func (s *AuthService) ParseAccessToken(ctx context.Context, tokenString string) (*Claims, error) {
+	claims, err := s.parseToken(tokenString, tokenPurposeAccess)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsActive {
+		return nil, ErrUserInactive
+	}
+	if claims.Version != user.TokenVersion {
+		return nil, ErrTokenRevoked
+	}
+	return claims, nil
+}
+
+// signToken 签发指定用途的JWT
+func (s *AuthService) signToken(user *model.User, purpose string, expire time.Duration) (string, error) {
+	jti, err := utils.RandomHex(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := Claims{
+		UserID:   user.ID,
+		Username: user.Username,
+		Purpose:  purpose,
+		Version:  user.TokenVersion,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expire)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+}
+
+// parseToken 校验JWT签名、有效期、用途和吊销状态
+func (s *AuthService) parseToken(tokenString, purpose string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.ID == "" || claims.UserID == 0 || claims.Purpose != purpose {
+		return nil, ErrInvalidToken
+	}
+	if s.denylist.IsRevoked(claims.ID) {
+		return nil, ErrTokenRevoked
+	}
+	return claims, nil
+}
+
+// Logout 吊销访问令牌；若提供了刷新令牌，同时吊销其所在的令牌族
+func (s *AuthService) Logout(ctx context.Context, claims *Claims, refreshToken string) error {
+	s.denylist.Revoke(claims.ID, claims.ExpiresAt.Time)
+
+	if refreshToken == "" {
+		return nil
+	}
+	stored, err := s.refreshTokens.GetByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		return err
+	}
+	if stored == nil || stored.UserID != claims.UserID {
+		return nil
+	}
+	return s.refreshTokens.RevokeFamily(ctx, stored.FamilyID)
+}
+
+// newRefreshToken 生成刷新令牌，返回待保存的记录和明文令牌
+func (s *AuthService) newRefreshToken(user *model.User, familyID string) (*model.RefreshToken, string, error) {
+	raw, err := utils.RandomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+	return &model.RefreshToken{
+		UserID:       user.ID,
+		FamilyID:     familyID,
+		TokenHash:    hashToken(raw),
+		ExpiresAt:    time.Now().Add(s.refreshExpire),
+		TokenVersion: user.TokenVersion,
+	}, raw, nil
+}
+
+// attachRefreshToken 将刷新令牌附加到令牌对
+func (s *AuthService) attachRefreshToken(pair *TokenPair, raw string) {
+	pair.RefreshToken = raw
+	pair.RefreshExpiresIn = int(s.refreshExpire.Seconds())
+}
+
+// hashToken 计算令牌的SHA-256哈希
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"awesome-trade/src/internal/config"
+	"awesome-trade/src/internal/database"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/pkg/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 创建测试用认证服务
+func setupAuthService(t *testing.T, expire int) *AuthService {
+	db, err := database.Open(config.DatabaseConfig{Driver: database.DriverSQLite, Path: ":memory:"})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.User{}, &model.RefreshToken{}, &model.TwoFactor{}, &model.RecoveryCode{}))
+
+	users := repository.NewUserRepository(db)
+	twoFactor := NewTwoFactorService(repository.NewTwoFactorRepository(db), users, utils.NewCipher("test-key"), config.TwoFactorConfig{
+		Issuer:      "Awesome Trade",
+		FreshWindow: 300,
+	})
+	return NewAuthService(users, repository.NewRefreshTokenRepository(db), NewUserService(database.NewTxManager(db), users), twoFactor, NewMemoryDenylist(), config.JWTConfig{
+		Secret:            "test-secret",
+		ExpireTime:        expire,
+		RefreshExpireTime: 86400,
+	})
+}
+
+// 测试注册、登录、校验和登出
+func TestAuthLoginLogout(t *testing.T) {
+	auth := setupAuthService(t, 3600)
+	ctx := context.Background()
+
+	user, err := auth.Register(ctx, "alice", "alice@example.com", "password123")
+	require.NoError(t, err)
+	assert.NotEqual(t, "password123", user.Password)
+
+	_, err = auth.Login(ctx, "alice", "wrong-password")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = auth.Login(ctx, "nobody", "password123")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	tokens, err := auth.Login(ctx, "alice", "password123")
+	require.NoError(t, err)
+	assert.Equal(t, 3600, tokens.ExpiresIn)
+
+	claims, err := auth.ParseAccessToken(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+
+	require.NoError(t, auth.Logout(ctx, claims, tokens.RefreshToken))
+	_, err = auth.ParseAccessToken(ctx, tokens.AccessToken)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+	_, err = auth.Refresh(ctx, tokens.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+// 测试刷新令牌轮换与重放检测
+func TestAuthRefreshRotation(t *testing.T) {
+	auth := setupAuthService(t, 3600)
+	ctx := context.Background()
+
+	_, err := auth.Register(ctx, "carol", "carol@example.com", "password123")
+	require.NoError(t, err)
+	first, err := auth.Login(ctx, "carol", "password123")
+	require.NoError(t, err)
+	require.NotEmpty(t, first.RefreshToken)
+
+	second, err := auth.Refresh(ctx, first.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+	third, err := auth.Refresh(ctx, second.RefreshToken)
+	require.NoError(t, err)
+
+	// 重放已使用的令牌，整个令牌族被吊销
+	_, err = auth.Refresh(ctx, first.RefreshToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	_, err = auth.Refresh(ctx, third.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	// 其他登录会话不受影响
+	other, err := auth.Login(ctx, "carol", "password123")
+	require.NoError(t, err)
+	_, err = auth.Refresh(ctx, other.RefreshToken)
+	assert.NoError(t, err)
+}
+
+// 测试修改密码后之前签发的令牌失效，停用或删除用户后令牌被拒绝
+func TestAuthUserStateInvalidatesTokens(t *testing.T) {
+	auth := setupAuthService(t, 3600)
+	ctx := context.Background()
+
+	user, err := auth.Register(ctx, "dave", "dave@example.com", "password123")
+	require.NoError(t, err)
+	old, err := auth.Login(ctx, "dave", "password123")
+	require.NoError(t, err)
+
+	password := "new-password456"
+	_, err = auth.userSvc.Update(ctx, user.ID, UpdateUserInput{Password: &password})
+	require.NoError(t, err)
+	_, err = auth.ParseAccessToken(ctx, old.AccessToken)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+	_, err = auth.Refresh(ctx, old.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	tokens, err := auth.Login(ctx, "dave", password)
+	require.NoError(t, err)
+	_, err = auth.ParseAccessToken(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+
+	inactive, active := false, true
+	_, err = auth.userSvc.Update(ctx, user.ID, UpdateUserInput{IsActive: &inactive})
+	require.NoError(t, err)
+	_, err = auth.ParseAccessToken(ctx, tokens.AccessToken)
+	assert.ErrorIs(t, err, ErrUserInactive)
+
+	_, err = auth.userSvc.Update(ctx, user.ID, UpdateUserInput{IsActive: &active})
+	require.NoError(t, err)
+	_, err = auth.ParseAccessToken(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	require.NoError(t, auth.userSvc.Delete(ctx, user.ID))
+	_, err = auth.ParseAccessToken(ctx, tokens.AccessToken)
+	assert.ErrorIs(t, err, ErrUserInactive)
+}
+
+// 测试过期、篡改和非HS256签名的令牌被拒绝
+func TestAuthRejectsInvalidTokens(t *testing.T) {
+	auth := setupAuthService(t, -1)
+	ctx := context.Background()
+
+	_, err := auth.Register(ctx, "bob", "bob@example.com", "password123")
+	require.NoError(t, err)
+	tokens, err := auth.Login(ctx, "bob", "password123")
+	require.NoError(t, err)
+
+	_, err = auth.ParseAccessToken(ctx, tokens.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	other := setupAuthService(t, 3600)
+	other.secret = []byte("another-secret")
+	forged, err := other.IssueAccessToken(&model.User{BaseModel: model.BaseModel{ID: 1}, Username: "bob"})
+	require.NoError(t, err)
+	_, err = auth.ParseAccessToken(ctx, forged.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, Claims{
+		UserID: 1,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "x",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	_, err = auth.ParseAccessToken(ctx, none)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
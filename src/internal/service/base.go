@@ -1,11 +1,38 @@
 package service
 
+import (
+	"context"
+	"database/sql"
+
+	"awesome-trade/src/internal/database"
+)
+
 // BaseService 基础服务结构
 type BaseService struct {
-	// 这里可以添加通用的服务依赖，如数据库连接、缓存等
+	tx *database.TxManager
+}
+
+// NewBaseService 创建基础服务实例，不需要事务的服务可传入nil
+func NewBaseService(tx *database.TxManager) *BaseService {
+	return &BaseService{
+		tx: tx,
+	}
+}
+
+// Transaction 在事务中执行fn，fn内使用传入ctx的仓储调用自动加入该事务。
+// 未配置事务管理器时直接执行fn。
+func (s *BaseService) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.tx == nil {
+		return fn(ctx)
+	}
+	return s.tx.Do(ctx, fn)
 }
 
-// NewBaseService 创建基础服务实例
-func NewBaseService() *BaseService {
-	return &BaseService{}
+// ReadSnapshot 在可重复读的只读事务中执行fn，fn内的多次查询看到同一个数据快照。
+// 未配置事务管理器时直接执行fn。
+func (s *BaseService) ReadSnapshot(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.tx == nil {
+		return fn(ctx)
+	}
+	return s.tx.DoWithOptions(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}, fn)
 }
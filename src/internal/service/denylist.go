@@ -0,0 +1,96 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// TokenDenylist 已吊销令牌列表
+type TokenDenylist interface {
+	// Revoke 吊销令牌，记录保留到令牌自然过期
+	Revoke(jti string, expiresAt time.Time)
+	// IsRevoked 判断令牌是否已被吊销
+	IsRevoked(jti string) bool
+}
+
+// MemoryDenylist 基于内存的令牌吊销列表（多实例部署建议使用Redis）
+type MemoryDenylist struct {
+	mu      sync.RWMutex
+	entries map[string]time.Time
+}
+
+// NewMemoryDenylist 创建内存吊销列表实例
+func NewMemoryDenylist() *MemoryDenylist {
+	return &MemoryDenylist{
+		entries: make(map[string]time.Time),
+	}
+}
+
+// Revoke 吊销令牌，并顺带清理已过期的记录
+func (d *MemoryDenylist) Revoke(jti string, expiresAt time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	for id, exp := range d.entries {
+		if now.After(exp) {
+			delete(d.entries, id)
+		}
+	}
+	d.entries[jti] = expiresAt
+}
+
+// IsRevoked 判断令牌是否已被吊销
+func (d *MemoryDenylist) IsRevoked(jti string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	_, ok := d.entries[jti]
+	return ok
+}
+
+// attemptCounter 按令牌ID统计验证失败次数，记录保留到令牌自然过期
+type attemptCounter struct {
+	mu      sync.Mutex
+	entries map[string]*attempts
+}
+
+// attempts 单个令牌的失败次数
+type attempts struct {
+	count     int
+	expiresAt time.Time
+}
+
+// newAttemptCounter 创建失败次数计数器
+func newAttemptCounter() *attemptCounter {
+	return &attemptCounter{
+		entries: make(map[string]*attempts),
+	}
+}
+
+// add 记录一次失败并返回累计次数，并顺带清理已过期的记录
+func (c *attemptCounter) add(jti string, expiresAt time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for id, a := range c.entries {
+		if now.After(a.expiresAt) {
+			delete(c.entries, id)
+		}
+	}
+	a, ok := c.entries[jti]
+	if !ok {
+		a = &attempts{expiresAt: expiresAt}
+		c.entries[jti] = a
+	}
+	a.count++
+	return a.count
+}
+
+// remove 删除令牌的失败记录
+func (c *attemptCounter) remove(jti string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, jti)
+}
@@ -0,0 +1,478 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"awesome-trade/src/internal/config"
+	"awesome-trade/src/internal/database"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/pkg/decimal"
+)
+
+// 手续费管理错误
+var (
+	ErrFeeTierNotFound      = errors.New("fee tier not found")
+	ErrFeeOverrideNotFound  = errors.New("fee override not found")
+	ErrFeePromotionNotFound = errors.New("fee promotion not found")
+	ErrInvalidFeeTier       = errors.New("fee tier level and minimum volume must not be negative")
+	ErrInvalidFeePromotion  = errors.New("fee promotion must end after it starts")
+)
+
+const (
+	// feeVolumeWindow 计算手续费等级的成交额统计窗口
+	feeVolumeWindow = 30 * 24 * time.Hour
+	// volumeScale 统计成交额保留的小数位数
+	volumeScale = engineScale
+)
+
+// FeeTierInput 手续费等级参数
+type FeeTierInput struct {
+	Level        int
+	MinVolume    decimal.Decimal
+	MakerFeeRate decimal.Decimal
+	TakerFeeRate decimal.Decimal
+}
+
+// FeeOverrideInput 交易对费率参数
+type FeeOverrideInput struct {
+	Symbol       string
+	Level        int
+	MakerFeeRate decimal.Decimal
+	TakerFeeRate decimal.Decimal
+}
+
+// FeePromotionInput 免费活动参数，Symbol为空表示全部交易对
+type FeePromotionInput struct {
+	Symbol   string
+	StartsAt time.Time
+	EndsAt   time.Time
+	Memo     string
+}
+
+// FeeRates 用户在交易对上生效的费率，Promotion表示处于免费活动中
+type FeeRates struct {
+	MakerFeeRate decimal.Decimal `json:"maker_fee_rate"`
+	TakerFeeRate decimal.Decimal `json:"taker_fee_rate"`
+	Promotion    bool            `json:"promotion"`
+}
+
+// FeeSummary 用户当前的手续费等级和近30天成交额，Rates仅在指定交易对时返回
+type FeeSummary struct {
+	Level       int             `json:"level"`
+	Volume      decimal.Decimal `json:"volume"`
+	VolumeAsset string          `json:"volume_asset"`
+	ComputedAt  *time.Time      `json:"computed_at"`
+	Tier        *model.FeeTier  `json:"tier"`
+	NextTier    *model.FeeTier  `json:"next_tier"`
+	Symbol      string          `json:"symbol,omitempty"`
+	Rates       *FeeRates       `json:"rates,omitempty"`
+}
+
+// feeKey 交易对费率缓存的键
+type feeKey struct {
+	symbol string
+	level  int
+}
+
+// FeeService 手续费服务。按用户等级计算每笔成交的挂单和吃单手续费，生效顺序为：
+// 免费活动、交易对在该等级上的费率、等级的默认费率、交易对自身的费率。
+// 用户等级由每日任务按近30天成交额重新计算；费率配置缓存在内存中，修改后立即重新加载。
+type FeeService struct {
+	*BaseService
+	fees        *repository.FeeRepository
+	fills       *repository.FillRepository
+	markets     *repository.MarketRepository
+	marketData  *MarketDataService
+	volumeAsset string
+
+	mu         sync.RWMutex
+	tiers      []model.FeeTier
+	overrides  map[feeKey]model.FeeOverride
+	promotions []model.FeePromotion
+}
+
+// NewFeeService 创建手续费服务实例，成交额按计量资产统计，其他计价资产按最新成交价折算
+func NewFeeService(tx *database.TxManager, fees *repository.FeeRepository, fills *repository.FillRepository, markets *repository.MarketRepository, marketData *MarketDataService, cfg config.FeeConfig) *FeeService {
+	return &FeeService{
+		BaseService: NewBaseService(tx),
+		fees:        fees,
+		fills:       fills,
+		markets:     markets,
+		marketData:  marketData,
+		volumeAsset: strings.ToUpper(cfg.VolumeAsset),
+		overrides:   make(map[feeKey]model.FeeOverride),
+	}
+}
+
+// Reload 从数据库重新加载手续费等级、交易对费率和未结束的免费活动
+func (s *FeeService) Reload(ctx context.Context) error {
+	tiers, err := s.fees.ListTiers(ctx)
+	if err != nil {
+		return err
+	}
+	overrides, err := s.fees.ListOverrides(ctx)
+	if err != nil {
+		return err
+	}
+	promotions, err := s.fees.ListPromotions(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	cache := make(map[feeKey]model.FeeOverride, len(overrides))
+	for _, o := range overrides {
+		cache[feeKey{symbol: o.Symbol, level: o.Level}] = o
+	}
+
+	s.mu.Lock()
+	s.tiers = tiers
+	s.overrides = cache
+	s.promotions = promotions
+	s.mu.Unlock()
+	return nil
+}
+
+// Run 每隔interval重新加载费率配置，直到ctx取消
+func (s *FeeService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Reload(ctx); err != nil {
+				log.Printf("Failed to reload fee schedule: %v", err)
+			}
+		}
+	}
+}
+
+// RunDaily 每天UTC的hour点重新计算全部用户的手续费等级，直到ctx取消
+func (s *FeeService) RunDaily(ctx context.Context, hour int) {
+	for {
+		now := time.Now().UTC()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			if n, err := s.Recompute(ctx); err != nil {
+				log.Printf("Failed to recompute fee tiers: %v", err)
+			} else {
+				log.Printf("Recomputed fee tiers for %d users", n)
+			}
+		}
+	}
+}
+
+// Recompute 按近30天成交额重新计算全部用户的手续费等级，返回有成交的用户数。
+// 计价资产不是计量资产的交易对按计价资产对计量资产的最新成交价折算，无法折算时不计入。
+func (s *FeeService) Recompute(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	volumes, err := s.fills.SumVolumes(ctx, now.Add(-feeVolumeWindow))
+	if err != nil {
+		return 0, err
+	}
+	markets, err := s.markets.ListAll(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+	quotes := make(map[string]string, len(markets))
+	for _, m := range markets {
+		quotes[m.Symbol] = m.QuoteAsset
+	}
+
+	factors := map[string]decimal.Decimal{s.volumeAsset: decimal.NewFromInt(1)}
+	totals := make(map[uint]decimal.Decimal)
+	for _, v := range volumes {
+		quote := quotes[v.Symbol]
+		factor, ok := factors[quote]
+		if !ok {
+			if factor, ok, err = s.conversion(ctx, quote, quotes); err != nil {
+				return 0, err
+			}
+			if !ok {
+				log.Printf("Warning: no %s price for %s, its volume is not counted towards fee tiers", s.volumeAsset, quote)
+			}
+			factors[quote] = factor
+		}
+		totals[v.UserID] = totals[v.UserID].Add(v.QuoteVolume.Mul(factor))
+	}
+
+	s.mu.RLock()
+	tiers := s.tiers
+	s.mu.RUnlock()
+	assigned := make([]model.UserFeeTier, 0, len(totals))
+	for userID, volume := range totals {
+		volume = volume.RoundWith(volumeScale, decimal.RoundDown)
+		assigned = append(assigned, model.UserFeeTier{
+			UserID:     userID,
+			Level:      levelFor(tiers, volume),
+			Volume:     volume,
+			ComputedAt: now,
+		})
+	}
+	sort.Slice(assigned, func(i, j int) bool { return assigned[i].UserID < assigned[j].UserID })
+
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		return s.fees.ReplaceUserTiers(ctx, assigned)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(assigned), nil
+}
+
+// Rates 返回用户在交易对上at时刻生效的费率
+func (s *FeeService) Rates(ctx context.Context, userID uint, market *model.Market, at time.Time) (FeeRates, error) {
+	tier, err := s.fees.GetUserTier(ctx, userID)
+	if err != nil {
+		return FeeRates{}, err
+	}
+	level := 0
+	if tier != nil {
+		level = tier.Level
+	}
+	return s.rates(level, market, at), nil
+}
+
+// Record 写入成交明细，重复写入同一笔成交时跳过
+func (s *FeeService) Record(ctx context.Context, fills []model.Fill) error {
+	return s.fills.CreateBatch(ctx, fills)
+}
+
+// Summary 返回用户当前的手续费等级、成交额和下一等级，指定交易对时同时返回该交易对上的费率
+func (s *FeeService) Summary(ctx context.Context, userID uint, symbol string) (*FeeSummary, error) {
+	summary := &FeeSummary{Volume: decimal.Zero, VolumeAsset: s.volumeAsset}
+	tier, err := s.fees.GetUserTier(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tier != nil {
+		summary.Level, summary.Volume = tier.Level, tier.Volume
+		computedAt := tier.ComputedAt
+		summary.ComputedAt = &computedAt
+	}
+
+	s.mu.RLock()
+	for i := range s.tiers {
+		t := s.tiers[i]
+		if t.Level == summary.Level {
+			summary.Tier = &t
+		}
+		if t.Level > summary.Level && summary.NextTier == nil {
+			summary.NextTier = &t
+		}
+	}
+	s.mu.RUnlock()
+
+	if strings.TrimSpace(symbol) != "" {
+		market, err := s.market(ctx, symbol)
+		if err != nil {
+			return nil, err
+		}
+		rates := s.rates(summary.Level, market, time.Now())
+		summary.Symbol, summary.Rates = market.Symbol, &rates
+	}
+	return summary, nil
+}
+
+// ListTiers 查询全部手续费等级
+func (s *FeeService) ListTiers(ctx context.Context) ([]model.FeeTier, error) {
+	return s.fees.ListTiers(ctx)
+}
+
+// SetTier 设置手续费等级，已存在时覆盖。费率立即生效，用户等级在下次重新计算时按新门槛划分。
+func (s *FeeService) SetTier(ctx context.Context, in FeeTierInput) (*model.FeeTier, error) {
+	if in.Level < 0 || in.MinVolume.IsNegative() {
+		return nil, ErrInvalidFeeTier
+	}
+	if !validFeeRates(in.MakerFeeRate, in.TakerFeeRate) {
+		return nil, ErrInvalidFeeRate
+	}
+
+	tier := &model.FeeTier{
+		Level:        in.Level,
+		MinVolume:    in.MinVolume,
+		MakerFeeRate: in.MakerFeeRate,
+		TakerFeeRate: in.TakerFeeRate,
+	}
+	if err := s.fees.UpsertTier(ctx, tier); err != nil {
+		return nil, err
+	}
+	if err := s.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return tier, nil
+}
+
+// DeleteTier 删除手续费等级，该等级的用户改用交易对自身的费率
+func (s *FeeService) DeleteTier(ctx context.Context, level int) error {
+	deleted, err := s.fees.DeleteTier(ctx, level)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrFeeTierNotFound
+	}
+	return s.Reload(ctx)
+}
+
+// ListOverrides 查询全部交易对费率
+func (s *FeeService) ListOverrides(ctx context.Context) ([]model.FeeOverride, error) {
+	return s.fees.ListOverrides(ctx)
+}
+
+// SetOverride 设置交易对在某个等级上的费率，已存在时覆盖，立即生效
+func (s *FeeService) SetOverride(ctx context.Context, in FeeOverrideInput) (*model.FeeOverride, error) {
+	market, err := s.market(ctx, in.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if in.Level < 0 {
+		return nil, ErrInvalidFeeTier
+	}
+	if !validFeeRates(in.MakerFeeRate, in.TakerFeeRate) {
+		return nil, ErrInvalidFeeRate
+	}
+
+	override := &model.FeeOverride{
+		Symbol:       market.Symbol,
+		Level:        in.Level,
+		MakerFeeRate: in.MakerFeeRate,
+		TakerFeeRate: in.TakerFeeRate,
+	}
+	if err := s.fees.UpsertOverride(ctx, override); err != nil {
+		return nil, err
+	}
+	if err := s.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return override, nil
+}
+
+// DeleteOverride 删除交易对在某个等级上的费率，立即生效
+func (s *FeeService) DeleteOverride(ctx context.Context, symbol string, level int) error {
+	deleted, err := s.fees.DeleteOverride(ctx, normalizeSymbol(symbol), level)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrFeeOverrideNotFound
+	}
+	return s.Reload(ctx)
+}
+
+// ListPromotions 查询全部免费活动，包括已结束的
+func (s *FeeService) ListPromotions(ctx context.Context) ([]model.FeePromotion, error) {
+	return s.fees.ListPromotions(ctx, time.Time{})
+}
+
+// CreatePromotion 创建免费活动，立即生效
+func (s *FeeService) CreatePromotion(ctx context.Context, in FeePromotionInput) (*model.FeePromotion, error) {
+	if !in.EndsAt.After(in.StartsAt) {
+		return nil, ErrInvalidFeePromotion
+	}
+	promotion := &model.FeePromotion{StartsAt: in.StartsAt.UTC(), EndsAt: in.EndsAt.UTC(), Memo: in.Memo}
+	if strings.TrimSpace(in.Symbol) != "" {
+		market, err := s.market(ctx, in.Symbol)
+		if err != nil {
+			return nil, err
+		}
+		promotion.Symbol = market.Symbol
+	}
+
+	if err := s.fees.CreatePromotion(ctx, promotion); err != nil {
+		return nil, err
+	}
+	if err := s.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+// DeletePromotion 删除免费活动，立即生效
+func (s *FeeService) DeletePromotion(ctx context.Context, id uint) error {
+	deleted, err := s.fees.DeletePromotion(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrFeePromotionNotFound
+	}
+	return s.Reload(ctx)
+}
+
+// rates 按缓存的配置返回等级在交易对上at时刻生效的费率
+func (s *FeeService) rates(level int, market *model.Market, at time.Time) FeeRates {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for i := range s.promotions {
+		if s.promotions[i].Active(market.Symbol, at) {
+			return FeeRates{MakerFeeRate: decimal.Zero, TakerFeeRate: decimal.Zero, Promotion: true}
+		}
+	}
+	if o, ok := s.overrides[feeKey{symbol: market.Symbol, level: level}]; ok {
+		return FeeRates{MakerFeeRate: o.MakerFeeRate, TakerFeeRate: o.TakerFeeRate}
+	}
+	for _, t := range s.tiers {
+		if t.Level == level {
+			return FeeRates{MakerFeeRate: t.MakerFeeRate, TakerFeeRate: t.TakerFeeRate}
+		}
+	}
+	return FeeRates{MakerFeeRate: market.MakerFeeRate, TakerFeeRate: market.TakerFeeRate}
+}
+
+// conversion 返回计价资产折算为计量资产的价格，没有对应交易对或尚无成交时ok为false
+func (s *FeeService) conversion(ctx context.Context, quote string, quotes map[string]string) (decimal.Decimal, bool, error) {
+	symbol := quote + "_" + s.volumeAsset
+	if _, exists := quotes[symbol]; !exists {
+		return decimal.Zero, false, nil
+	}
+	return s.marketData.LastPrice(ctx, symbol)
+}
+
+// market 获取交易对，不存在时返回ErrMarketNotFound
+func (s *FeeService) market(ctx context.Context, symbol string) (*model.Market, error) {
+	market, err := s.markets.GetBySymbol(ctx, normalizeSymbol(symbol))
+	if err != nil {
+		return nil, err
+	}
+	if market == nil {
+		return nil, ErrMarketNotFound
+	}
+	return market, nil
+}
+
+// levelFor 返回成交额达到门槛的最高等级，tiers须按等级排序，都未达到时为0级
+func levelFor(tiers []model.FeeTier, volume decimal.Decimal) int {
+	level := 0
+	for _, t := range tiers {
+		if volume.GreaterThanOrEqual(t.MinVolume) {
+			level = t.Level
+		}
+	}
+	return level
+}
+
+// feeAmount 计算收到amount时按rate收取的手续费，向正无穷舍入到手续费资产的小数位数scale，且不超过amount。
+// rate为负时为返佣，同样向正无穷舍入，即返佣金额向零舍入。
+func feeAmount(amount, rate decimal.Decimal, scale int32) decimal.Decimal {
+	fee := amount.Mul(rate).RoundWith(scale, decimal.RoundCeil)
+	if fee.GreaterThan(amount) {
+		return amount
+	}
+	return fee
+}
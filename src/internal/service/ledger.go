@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"awesome-trade/src/internal/database"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/pkg/decimal"
+)
+
+// 账本服务错误
+var (
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrUnbalancedEntry     = errors.New("ledger entry does not balance")
+	ErrInvalidAmount       = errors.New("invalid amount")
+)
+
+// Posting 记账明细，按用户、账户类型和资产定位账户，账户不存在时自动创建
+type Posting struct {
+	UserID uint
+	Kind   string
+	Asset  string
+	Bucket string
+	Amount decimal.Decimal
+}
+
+// Entry 记账请求，Type和Reference唯一标识一次业务记账
+type Entry struct {
+	Type      string
+	Reference string
+	Memo      string
+	Postings  []Posting
+}
+
+// Mismatch 账户余额缓存与分录汇总不一致的对账结果
+type Mismatch struct {
+	AccountID       uint            `json:"account_id"`
+	UserID          uint            `json:"user_id"`
+	Kind            string          `json:"kind"`
+	Asset           string          `json:"asset"`
+	CachedAvailable decimal.Decimal `json:"cached_available"`
+	LedgerAvailable decimal.Decimal `json:"ledger_available"`
+	CachedHeld      decimal.Decimal `json:"cached_held"`
+	LedgerHeld      decimal.Decimal `json:"ledger_held"`
+}
+
+// accountKey 账户定位键
+type accountKey struct {
+	userID uint
+	kind   string
+	asset  string
+}
+
+// LedgerService 复式记账服务，账户余额只能通过记账变更
+type LedgerService struct {
+	*BaseService
+	ledger *repository.LedgerRepository
+	scales decimal.Scales
+}
+
+// NewLedgerService 创建账本服务实例，scales为各资产金额的小数位数
+func NewLedgerService(tx *database.TxManager, ledger *repository.LedgerRepository, scales decimal.Scales) *LedgerService {
+	return &LedgerService{
+		BaseService: NewBaseService(tx),
+		ledger:      ledger,
+		scales:      scales,
+	}
+}
+
+// Scales 返回各资产金额的小数位数
+func (s *LedgerService) Scales() decimal.Scales {
+	return s.scales
+}
+
+// Post 记账。每种资产的借贷之和须为零，金额不能超过资产的小数位数，分录和账户余额在同一事务中写入；
+// 用户账户的可用或冻结余额变为负数时返回ErrInsufficientBalance。
+// 相同类型和业务引用的分录已存在时不重复记账并返回false。
+func (s *LedgerService) Post(ctx context.Context, e Entry) (bool, error) {
+	if err := validateEntry(e, s.scales); err != nil {
+		return false, err
+	}
+
+	// 按账户汇总变动，并按固定顺序加锁避免死锁
+	deltas := make(map[accountKey]*repository.PostingTotals)
+	for _, p := range e.Postings {
+		key := accountKey{userID: p.UserID, kind: p.Kind, asset: p.Asset}
+		d, ok := deltas[key]
+		if !ok {
+			d = &repository.PostingTotals{}
+			deltas[key] = d
+		}
+		if p.Bucket == model.BucketHeld {
+			d.Held = d.Held.Add(p.Amount)
+		} else {
+			d.Available = d.Available.Add(p.Amount)
+		}
+	}
+	keys := make([]accountKey, 0, len(deltas))
+	for key := range deltas {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.userID != b.userID {
+			return a.userID < b.userID
+		}
+		if a.kind != b.kind {
+			return a.kind < b.kind
+		}
+		return a.asset < b.asset
+	})
+
+	var created bool
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		accounts := make(map[accountKey]*model.Account, len(keys))
+		for _, key := range keys {
+			account, err := s.ledger.LockAccount(ctx, key.userID, key.kind, key.asset)
+			if err != nil {
+				return err
+			}
+			accounts[key] = account
+		}
+
+		entry := &model.LedgerEntry{Type: e.Type, Reference: e.Reference, Memo: e.Memo}
+		for _, p := range e.Postings {
+			entry.Postings = append(entry.Postings, model.LedgerPosting{
+				AccountID: accounts[accountKey{userID: p.UserID, kind: p.Kind, asset: p.Asset}].ID,
+				Asset:     p.Asset,
+				Bucket:    p.Bucket,
+				Amount:    p.Amount,
+			})
+		}
+		var err error
+		if created, err = s.ledger.CreateEntry(ctx, entry); err != nil || !created {
+			return err
+		}
+
+		for _, key := range keys {
+			account, d := accounts[key], deltas[key]
+			account.Available = account.Available.Add(d.Available)
+			account.Held = account.Held.Add(d.Held)
+			if !account.IsSystem() && (account.Available.IsNegative() || account.Held.IsNegative()) {
+				return ErrInsufficientBalance
+			}
+			if err := s.ledger.Update(ctx, account); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return created, nil
+}
+
+// Deposit 从外部清算账户向用户现货账户入账
+func (s *LedgerService) Deposit(ctx context.Context, userID uint, asset string, amount decimal.Decimal, reference string) (bool, error) {
+	if !amount.IsPositive() {
+		return false, ErrInvalidAmount
+	}
+	return s.Post(ctx, Entry{
+		Type:      model.EntryTypeDeposit,
+		Reference: reference,
+		Postings: []Posting{
+			{UserID: model.SystemUserID, Kind: model.AccountKindExternal, Asset: asset, Bucket: model.BucketAvailable, Amount: amount.Neg()},
+			{UserID: userID, Kind: model.AccountKindSpot, Asset: asset, Bucket: model.BucketAvailable, Amount: amount},
+		},
+	})
+}
+
+// Withdraw 从用户现货账户的冻结余额向外部清算账户出账
+func (s *LedgerService) Withdraw(ctx context.Context, userID uint, asset string, amount decimal.Decimal, reference string) (bool, error) {
+	if !amount.IsPositive() {
+		return false, ErrInvalidAmount
+	}
+	return s.Post(ctx, Entry{
+		Type:      model.EntryTypeWithdrawal,
+		Reference: reference,
+		Postings: []Posting{
+			{UserID: userID, Kind: model.AccountKindSpot, Asset: asset, Bucket: model.BucketHeld, Amount: amount.Neg()},
+			{UserID: model.SystemUserID, Kind: model.AccountKindExternal, Asset: asset, Bucket: model.BucketAvailable, Amount: amount},
+		},
+	})
+}
+
+// Hold 冻结用户的可用余额
+func (s *LedgerService) Hold(ctx context.Context, userID uint, asset string, amount decimal.Decimal, reference string) (bool, error) {
+	return s.move(ctx, model.EntryTypeHold, userID, asset, model.BucketAvailable, model.BucketHeld, amount, reference)
+}
+
+// Release 解冻用户的冻结余额
+func (s *LedgerService) Release(ctx context.Context, userID uint, asset string, amount decimal.Decimal, reference string) (bool, error) {
+	return s.move(ctx, model.EntryTypeRelease, userID, asset, model.BucketHeld, model.BucketAvailable, amount, reference)
+}
+
+// Account 获取用户的现货账户，不存在时返回零余额账户
+func (s *LedgerService) Account(ctx context.Context, userID uint, asset string) (*model.Account, error) {
+	account, err := s.ledger.GetAccount(ctx, userID, model.AccountKindSpot, asset)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		account = &model.Account{UserID: userID, Kind: model.AccountKindSpot, Asset: asset}
+	}
+	return account, nil
+}
+
+// Balances 查询用户的全部账户余额
+func (s *LedgerService) Balances(ctx context.Context, userID uint) ([]model.Account, error) {
+	return s.ledger.ListAccounts(ctx, userID)
+}
+
+// Reconcile 对账：逐个比较账户的余额缓存与其全部分录的汇总，返回不一致的账户
+func (s *LedgerService) Reconcile(ctx context.Context) ([]Mismatch, error) {
+	var mismatches []Mismatch
+	err := s.ReadSnapshot(ctx, func(ctx context.Context) error {
+		accounts, err := s.ledger.ListAllAccounts(ctx)
+		if err != nil {
+			return err
+		}
+		totals, err := s.ledger.SumPostings(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, a := range accounts {
+			t, ok := totals[a.ID]
+			if !ok {
+				t = &repository.PostingTotals{}
+			}
+			if !a.Available.Equal(t.Available) || !a.Held.Equal(t.Held) {
+				mismatches = append(mismatches, Mismatch{
+					AccountID:       a.ID,
+					UserID:          a.UserID,
+					Kind:            a.Kind,
+					Asset:           a.Asset,
+					CachedAvailable: a.Available,
+					LedgerAvailable: t.Available,
+					CachedHeld:      a.Held,
+					LedgerHeld:      t.Held,
+				})
+			}
+		}
+		return nil
+	})
+	return mismatches, err
+}
+
+// move 在用户现货账户的可用与冻结余额之间划转
+func (s *LedgerService) move(ctx context.Context, entryType string, userID uint, asset, from, to string, amount decimal.Decimal, reference string) (bool, error) {
+	if !amount.IsPositive() {
+		return false, ErrInvalidAmount
+	}
+	return s.Post(ctx, Entry{
+		Type:      entryType,
+		Reference: reference,
+		Postings: []Posting{
+			{UserID: userID, Kind: model.AccountKindSpot, Asset: asset, Bucket: from, Amount: amount.Neg()},
+			{UserID: userID, Kind: model.AccountKindSpot, Asset: asset, Bucket: to, Amount: amount},
+		},
+	})
+}
+
+// validateEntry 校验分录：至少两笔非零且不超过资产小数位数的明细，且每种资产借贷之和为零
+func validateEntry(e Entry, scales decimal.Scales) error {
+	if e.Type == "" || e.Reference == "" || len(e.Postings) < 2 {
+		return ErrUnbalancedEntry
+	}
+	sums := make(map[string]decimal.Decimal)
+	for _, p := range e.Postings {
+		if p.Asset == "" || p.Amount.IsZero() || !scales.Fits(p.Asset, p.Amount) {
+			return ErrInvalidAmount
+		}
+		if p.Bucket != model.BucketAvailable && p.Bucket != model.BucketHeld {
+			return ErrUnbalancedEntry
+		}
+		sums[p.Asset] = sums[p.Asset].Add(p.Amount)
+	}
+	for _, sum := range sums {
+		if !sum.IsZero() {
+			return ErrUnbalancedEntry
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"awesome-trade/src/internal/config"
+	"awesome-trade/src/internal/database"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/pkg/decimal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupLedgerService 创建使用内存数据库的账本服务
+func setupLedgerService(t *testing.T) (*LedgerService, *gorm.DB) {
+	db, err := database.Open(config.DatabaseConfig{Driver: database.DriverSQLite, Path: ":memory:"})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.Account{}, &model.LedgerEntry{}, &model.LedgerPosting{}))
+	return NewLedgerService(database.NewTxManager(db), repository.NewLedgerRepository(db), decimal.NewScales(map[string]int32{"usdt": 6})), db
+}
+
+// 测试记账校验、幂等、余额不足回滚和对账
+func TestLedgerPostAndReconcile(t *testing.T) {
+	ledger, db := setupLedgerService(t)
+	ctx := context.Background()
+	d := decimal.RequireFromString
+
+	ok, err := ledger.Deposit(ctx, 1, "USDT", d("100.5"), "deposit:1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = ledger.Deposit(ctx, 1, "USDT", d("100.5"), "deposit:1")
+	require.NoError(t, err)
+	assert.False(t, ok, "same reference must be posted once")
+
+	// 借贷不平的分录被拒绝
+	_, err = ledger.Post(ctx, Entry{Type: model.EntryTypeTrade, Reference: "bad", Postings: []Posting{
+		{UserID: 1, Kind: model.AccountKindSpot, Asset: "USDT", Bucket: model.BucketAvailable, Amount: d("-1")},
+		{UserID: 2, Kind: model.AccountKindSpot, Asset: "USDT", Bucket: model.BucketAvailable, Amount: d("2")},
+	}})
+	assert.ErrorIs(t, err, ErrUnbalancedEntry)
+
+	// 金额超过资产的小数位数时被拒绝
+	_, err = ledger.Deposit(ctx, 1, "usdt", d("0.0000001"), "deposit:2")
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	_, err = ledger.Hold(ctx, 1, "USDT", d("40"), "order:1")
+	require.NoError(t, err)
+	_, err = ledger.Hold(ctx, 1, "USDT", d("61"), "order:2")
+	assert.ErrorIs(t, err, ErrInsufficientBalance)
+
+	// 余额不足时整笔分录回滚
+	_, err = ledger.Post(ctx, Entry{Type: model.EntryTypeTrade, Reference: "trade:1", Postings: []Posting{
+		{UserID: 1, Kind: model.AccountKindSpot, Asset: "USDT", Bucket: model.BucketHeld, Amount: d("-50")},
+		{UserID: 2, Kind: model.AccountKindSpot, Asset: "USDT", Bucket: model.BucketAvailable, Amount: d("50")},
+	}})
+	assert.ErrorIs(t, err, ErrInsufficientBalance)
+	entry, err := ledger.ledger.GetEntry(ctx, model.EntryTypeTrade, "trade:1")
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+
+	account, err := ledger.Account(ctx, 1, "USDT")
+	require.NoError(t, err)
+	assert.Equal(t, "60.5", account.Available.String())
+	assert.Equal(t, "40", account.Held.String())
+	external, err := ledger.ledger.GetAccount(ctx, model.SystemUserID, model.AccountKindExternal, "USDT")
+	require.NoError(t, err)
+	assert.Equal(t, "-100.5", external.Available.String())
+
+	mismatches, err := ledger.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, mismatches)
+
+	// 绕过记账直接修改余额会被对账发现
+	require.NoError(t, db.Model(&model.Account{}).Where("id = ?", account.ID).Update("available", "61").Error)
+	mismatches, err = ledger.Reconcile(ctx)
+	require.NoError(t, err)
+	require.Len(t, mismatches, 1)
+	assert.Equal(t, account.ID, mismatches[0].AccountID)
+	assert.Equal(t, "60.5", mismatches[0].LedgerAvailable.String())
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"awesome-trade/src/internal/database"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/pkg/decimal"
+)
+
+// 交易对服务错误
+var (
+	ErrMarketNotFound        = errors.New("market not found")
+	ErrMarketExists          = errors.New("market already exists")
+	ErrMarketNotTrading      = errors.New("market is not open for trading")
+	ErrInvalidMarketStatus   = errors.New("market cannot move to the requested status")
+	ErrInvalidMarketRule     = errors.New("invalid tick size, step size or minimum notional")
+	ErrInvalidFeeRate        = errors.New("invalid fee rate")
+	ErrPriceTickViolation    = errors.New("price is not a multiple of the tick size")
+	ErrQuantityStepViolation = errors.New("quantity is not a multiple of the step size")
+	ErrMinNotionalViolation  = errors.New("order notional is below the minimum")
+)
+
+// maxFeeRate 手续费率绝对值的上限，挂单费率可为负表示返佣
+var maxFeeRate = decimal.RequireFromString("0.1")
+
+// MarketInput 交易对参数
+type MarketInput struct {
+	BaseAsset    string
+	QuoteAsset   string
+	TickSize     decimal.Decimal
+	StepSize     decimal.Decimal
+	MinNotional  decimal.Decimal
+	MakerFeeRate decimal.Decimal
+	TakerFeeRate decimal.Decimal
+	Status       string
+}
+
+// MarketService 交易对注册与状态管理
+type MarketService struct {
+	*BaseService
+	markets *repository.MarketRepository
+	orders  *OrderService
+}
+
+// NewMarketService 创建交易对服务实例，暂停或下架交易对时通过订单服务撤销挂单
+func NewMarketService(tx *database.TxManager, markets *repository.MarketRepository, orders *OrderService) *MarketService {
+	return &MarketService{
+		BaseService: NewBaseService(tx),
+		markets:     markets,
+		orders:      orders,
+	}
+}
+
+// Create 上架交易对，交易对名称为 基础资产_计价资产，初始状态默认为pre_open
+func (s *MarketService) Create(ctx context.Context, in MarketInput) (*model.Market, error) {
+	base, err := normalizeAsset(in.BaseAsset)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := normalizeAsset(in.QuoteAsset)
+	if err != nil {
+		return nil, err
+	}
+	if base == quote {
+		return nil, ErrInvalidSymbol
+	}
+	status := in.Status
+	if status == "" {
+		status = model.MarketStatusPreOpen
+	}
+	if status != model.MarketStatusPreOpen && status != model.MarketStatusTrading {
+		return nil, ErrInvalidMarketStatus
+	}
+
+	market := &model.Market{
+		Symbol:     base + "_" + quote,
+		BaseAsset:  base,
+		QuoteAsset: quote,
+		Status:     status,
+	}
+	if err := applyMarketRules(market, in); err != nil {
+		return nil, err
+	}
+	created, err := s.markets.CreateIfAbsent(ctx, market)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, ErrMarketExists
+	}
+	return market, nil
+}
+
+// Update 修改交易对的价格精度、数量精度、最小下单金额和手续费率，新规则只约束此后的订单
+func (s *MarketService) Update(ctx context.Context, symbol string, in MarketInput) (*model.Market, error) {
+	market, err := s.Get(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyMarketRules(market, in); err != nil {
+		return nil, err
+	}
+	if err := s.markets.Update(ctx, market); err != nil {
+		return nil, err
+	}
+	return market, nil
+}
+
+// SetStatus 变更交易对状态。下架时撤销全部挂单；暂停时cancelOrders为true则撤销全部挂单，
+// 否则挂单保留在订单簿中冻结，暂停期间不接受新订单，用户仍可撤单。返回撤销的订单数。
+func (s *MarketService) SetStatus(ctx context.Context, symbol, status string, cancelOrders bool) (*model.Market, int, error) {
+	market, err := s.Get(ctx, symbol)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !market.CanTransition(status) {
+		return nil, 0, ErrInvalidMarketStatus
+	}
+	market.Status = status
+	if err := s.markets.Update(ctx, market); err != nil {
+		return nil, 0, err
+	}
+
+	if status == model.MarketStatusDelisted || (status == model.MarketStatusHalted && cancelOrders) {
+		canceled, err := s.orders.CancelSymbol(ctx, symbol)
+		if err != nil {
+			log.Printf("Failed to cancel open orders of %s: %v", symbol, err)
+			return market, canceled, err
+		}
+		return market, canceled, nil
+	}
+	return market, 0, nil
+}
+
+// Get 获取交易对
+func (s *MarketService) Get(ctx context.Context, symbol string) (*model.Market, error) {
+	market, err := s.markets.GetBySymbol(ctx, normalizeSymbol(symbol))
+	if err != nil {
+		return nil, err
+	}
+	if market == nil {
+		return nil, ErrMarketNotFound
+	}
+	return market, nil
+}
+
+// List 查询交易对，status为空时返回全部
+func (s *MarketService) List(ctx context.Context, status string) ([]model.Market, error) {
+	return s.markets.ListAll(ctx, status)
+}
+
+// applyMarketRules 校验并设置交易对的交易规则。价格和数量精度不得超过撮合引擎支持的小数位数。
+func applyMarketRules(market *model.Market, in MarketInput) error {
+	if _, ok := toUnits(in.TickSize); !ok || !in.TickSize.IsPositive() {
+		return ErrInvalidMarketRule
+	}
+	if _, ok := toUnits(in.StepSize); !ok || !in.StepSize.IsPositive() {
+		return ErrInvalidMarketRule
+	}
+	if in.MinNotional.IsNegative() {
+		return ErrInvalidMarketRule
+	}
+	if !validFeeRates(in.MakerFeeRate, in.TakerFeeRate) {
+		return ErrInvalidFeeRate
+	}
+
+	market.TickSize = in.TickSize
+	market.StepSize = in.StepSize
+	market.MinNotional = in.MinNotional
+	market.MakerFeeRate = in.MakerFeeRate
+	market.TakerFeeRate = in.TakerFeeRate
+	return nil
+}
+
+// validFeeRates 校验挂单和吃单费率：吃单费率不为负，挂单费率可为负（返佣）但不超过吃单费率，
+// 绝对值都不超过上限，且不超过数据库列支持的6位小数
+func validFeeRates(maker, taker decimal.Decimal) bool {
+	if maker.Scale() > 6 || taker.Scale() > 6 {
+		return false
+	}
+	return !taker.IsNegative() && !taker.GreaterThan(maxFeeRate) &&
+		!maker.Abs().GreaterThan(maxFeeRate) && !maker.Add(taker).IsNegative()
+}
+
+// checkMarketRules 校验订单是否满足交易对的交易规则，市价单没有价格，不校验价格精度和最小下单金额。
+// 条件单的触发价和回撤价差也须符合价格精度，并按触发后子订单的类型校验。
+func checkMarketRules(market *model.Market, order *model.Order) error {
+	if !market.IsTrading() {
+		return ErrMarketNotTrading
+	}
+	if !order.Quantity.Mod(market.StepSize).IsZero() {
+		return ErrQuantityStepViolation
+	}
+	if !order.StopPrice.Mod(market.TickSize).IsZero() || !order.TrailingDelta.Mod(market.TickSize).IsZero() {
+		return ErrPriceTickViolation
+	}
+	if order.ExecType() == model.OrderTypeMarket {
+		return nil
+	}
+	if !order.Price.Mod(market.TickSize).IsZero() {
+		return ErrPriceTickViolation
+	}
+	if order.Price.Mul(order.Quantity).LessThan(market.MinNotional) {
+		return ErrMinNotionalViolation
+	}
+	return nil
+}
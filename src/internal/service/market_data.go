@@ -0,0 +1,464 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"awesome-trade/src/internal/config"
+	"awesome-trade/src/internal/matching"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/internal/sequencer"
+	"awesome-trade/src/pkg/decimal"
+)
+
+// 行情服务错误
+var ErrInvalidInterval = errors.New("invalid kline interval")
+
+const (
+	// tickerWindow 滚动行情统计的时间窗口，按1分钟K线汇总
+	tickerWindow = 24 * time.Hour
+	// defaultRecentTrades 每个交易对默认保留的最近成交数
+	defaultRecentTrades = 500
+)
+
+// keepKlines 每个周期在内存中保留的K线数，1分钟K线须覆盖滚动行情窗口
+var keepKlines = map[string]int{
+	model.KlineInterval1m: int(tickerWindow/time.Minute) + 1,
+	model.KlineInterval5m: 2,
+	model.KlineInterval1h: 2,
+	model.KlineInterval1d: 2,
+}
+
+// PublicTrade 公开成交记录，Side为主动成交（吃单）方向
+type PublicTrade struct {
+	ID       uint64          `json:"id"`
+	Price    decimal.Decimal `json:"price"`
+	Quantity decimal.Decimal `json:"quantity"`
+	Side     string          `json:"side"`
+	Time     time.Time       `json:"time"`
+}
+
+// Ticker 最近24小时的滚动行情，没有买单或卖单时对应的最优价为null
+type Ticker struct {
+	Symbol             string           `json:"symbol"`
+	LastPrice          decimal.Decimal  `json:"last_price"`
+	OpenPrice          decimal.Decimal  `json:"open_price"`
+	HighPrice          decimal.Decimal  `json:"high_price"`
+	LowPrice           decimal.Decimal  `json:"low_price"`
+	PriceChange        decimal.Decimal  `json:"price_change"`
+	PriceChangePercent decimal.Decimal  `json:"price_change_percent"`
+	Volume             decimal.Decimal  `json:"volume"`
+	QuoteVolume        decimal.Decimal  `json:"quote_volume"`
+	Trades             int              `json:"trades"`
+	BestBid            *decimal.Decimal `json:"best_bid"`
+	BestAsk            *decimal.Decimal `json:"best_ask"`
+	OpenTime           time.Time        `json:"open_time"`
+	CloseTime          time.Time        `json:"close_time"`
+}
+
+// DepthLevel 深度中的一个价位
+type DepthLevel struct {
+	Price    decimal.Decimal `json:"price"`
+	Quantity decimal.Decimal `json:"quantity"`
+}
+
+// Depth 订单簿深度快照，Seq为生成快照时定序器已处理的命令序号，与行情推送中的序号一致
+type Depth struct {
+	Symbol string       `json:"symbol"`
+	Seq    uint64       `json:"seq"`
+	Bids   []DepthLevel `json:"bids"`
+	Asks   []DepthLevel `json:"asks"`
+}
+
+// marketState 单个交易对的内存行情
+type marketState struct {
+	lastTradeID uint64                    // 已计入K线的最后一笔成交
+	trades      []PublicTrade             // 最近成交，按编号正序
+	klines      map[string][]*model.Kline // 各周期最近的K线，按开盘时间正序，最后一根为当前K线
+}
+
+// MarketDataService 行情服务。订阅撮合事件，在内存中增量汇总最近成交、K线和滚动行情，
+// K线定期写入数据库；深度直接从定序器读取。
+type MarketDataService struct {
+	markets      *repository.MarketRepository
+	klines       *repository.KlineRepository
+	engine       *sequencer.Manager
+	recentTrades int
+
+	mu     sync.Mutex
+	states map[string]*marketState
+	dirty  map[*model.Kline]struct{}
+}
+
+// NewMarketDataService 创建行情服务实例并订阅撮合事件
+func NewMarketDataService(markets *repository.MarketRepository, klines *repository.KlineRepository, engine *sequencer.Manager, cfg config.MarketDataConfig) *MarketDataService {
+	recent := cfg.RecentTrades
+	if recent <= 0 {
+		recent = defaultRecentTrades
+	}
+	s := &MarketDataService{
+		markets:      markets,
+		klines:       klines,
+		engine:       engine,
+		recentTrades: recent,
+		states:       make(map[string]*marketState),
+		dirty:        make(map[*model.Kline]struct{}),
+	}
+	engine.Subscribe(s.HandleEvent)
+	return s
+}
+
+// HandleEvent 将撮合结果中的成交计入最近成交和各周期K线，成交时间取命令的定序时间。
+// 编号不大于已计入的最后一笔成交的成交已汇总过，重放时跳过。
+func (s *MarketDataService) HandleEvent(ev sequencer.Event) {
+	if ev.Result == nil || len(ev.Result.Trades) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, err := s.state(context.Background(), ev.Symbol)
+	if err != nil {
+		log.Printf("Failed to load market data of %s: %v", ev.Symbol, err)
+		return
+	}
+	for _, t := range ev.Result.Trades {
+		trade := publicTrade(t, ev.Command.Time)
+		if n := len(st.trades); n == 0 || st.trades[n-1].ID < t.ID {
+			st.trades = append(st.trades, trade)
+			if len(st.trades) > s.recentTrades {
+				st.trades = append([]PublicTrade(nil), st.trades[len(st.trades)-s.recentTrades:]...)
+			}
+		}
+		if t.ID <= st.lastTradeID {
+			continue
+		}
+		st.lastTradeID = t.ID
+		for interval := range model.KlineIntervals {
+			s.aggregate(st, ev.Symbol, interval, trade)
+		}
+	}
+}
+
+// Flush 将有变化的K线写入数据库，写入失败的K线留待下次重试
+func (s *MarketDataService) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	pending := make([]*model.Kline, 0, len(s.dirty))
+	klines := make([]model.Kline, 0, len(s.dirty))
+	for k := range s.dirty {
+		pending = append(pending, k)
+		klines = append(klines, *k)
+	}
+	s.dirty = make(map[*model.Kline]struct{})
+	s.mu.Unlock()
+
+	if err := s.klines.Upsert(ctx, klines); err != nil {
+		s.mu.Lock()
+		for _, k := range pending {
+			s.dirty[k] = struct{}{}
+		}
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Run 按固定间隔写入K线，ctx取消时最后写入一次后返回
+func (s *MarketDataService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := s.Flush(context.WithoutCancel(ctx)); err != nil {
+				log.Printf("Failed to flush klines: %v", err)
+			}
+			return
+		case <-ticker.C:
+			if err := s.Flush(ctx); err != nil {
+				log.Printf("Failed to flush klines: %v", err)
+			}
+		}
+	}
+}
+
+// Ticker 返回交易对最近24小时的滚动行情和当前最优买卖价
+func (s *MarketDataService) Ticker(ctx context.Context, symbol string) (*Ticker, error) {
+	market, err := s.market(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	ticker := &Ticker{Symbol: market.Symbol, CloseTime: now, OpenTime: now.Add(-tickerWindow)}
+	s.mu.Lock()
+	st, err := s.state(ctx, market.Symbol)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	series := st.klines[model.KlineInterval1m]
+	if n := len(series); n > 0 {
+		ticker.LastPrice = series[n-1].Close
+	}
+	ticker.OpenPrice, ticker.HighPrice, ticker.LowPrice = ticker.LastPrice, ticker.LastPrice, ticker.LastPrice
+	first := true
+	for _, k := range series {
+		if !k.OpenTime.Add(time.Minute).After(ticker.OpenTime) {
+			continue
+		}
+		if first {
+			ticker.OpenPrice, ticker.HighPrice, ticker.LowPrice = k.Open, k.High, k.Low
+			ticker.OpenTime = k.OpenTime
+			first = false
+		}
+		ticker.HighPrice = decimal.Max(ticker.HighPrice, k.High)
+		ticker.LowPrice = decimal.Min(ticker.LowPrice, k.Low)
+		ticker.Volume = ticker.Volume.Add(k.Volume)
+		ticker.QuoteVolume = ticker.QuoteVolume.Add(k.QuoteVolume)
+		ticker.Trades += k.Trades
+	}
+	s.mu.Unlock()
+
+	ticker.PriceChange = ticker.LastPrice.Sub(ticker.OpenPrice)
+	if ticker.OpenPrice.IsPositive() {
+		ticker.PriceChangePercent = ticker.PriceChange.Shift(2).DivRound(ticker.OpenPrice, 2, decimal.RoundHalfUp)
+	}
+
+	err = s.read(ctx, market.Symbol, func(book *matching.OrderBook, _ uint64) {
+		if p, ok := book.BestBid(); ok {
+			bid := fromUnits(p)
+			ticker.BestBid = &bid
+		}
+		if p, ok := book.BestAsk(); ok {
+			ask := fromUnits(p)
+			ticker.BestAsk = &ask
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ticker, nil
+}
+
+// Depth 返回交易对买卖双方前limit个价位及对应的定序器序号
+func (s *MarketDataService) Depth(ctx context.Context, symbol string, limit int) (*Depth, error) {
+	market, err := s.market(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	depth := &Depth{Symbol: market.Symbol, Bids: []DepthLevel{}, Asks: []DepthLevel{}}
+	err = s.read(ctx, market.Symbol, func(book *matching.OrderBook, seq uint64) {
+		bids, asks := book.Depth(limit)
+		depth.Seq = seq
+		depth.Bids = depthLevels(bids)
+		depth.Asks = depthLevels(asks)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return depth, nil
+}
+
+// Trades 按编号倒序返回交易对最近limit笔成交
+func (s *MarketDataService) Trades(ctx context.Context, symbol string, limit int) ([]PublicTrade, error) {
+	market, err := s.market(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, err := s.state(ctx, market.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	trades := make([]PublicTrade, 0, limit)
+	for i := len(st.trades) - 1; i >= 0 && len(trades) < limit; i-- {
+		trades = append(trades, st.trades[i])
+	}
+	return trades, nil
+}
+
+// Klines 按开盘时间正序返回开盘时间早于end的最近limit根K线，end为零值时返回最新的K线。
+// 数据库中的K线与内存中尚未写入的K线合并，内存中的更新。
+func (s *MarketDataService) Klines(ctx context.Context, symbol, interval string, end time.Time, limit int) ([]model.Kline, error) {
+	if _, ok := model.KlineIntervals[interval]; !ok {
+		return nil, ErrInvalidInterval
+	}
+	market, err := s.market(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.klines.ListBefore(ctx, market.Symbol, interval, end, limit)
+	if err != nil {
+		return nil, err
+	}
+	merged := make(map[time.Time]model.Kline, len(stored))
+	for _, k := range stored {
+		merged[k.OpenTime.UTC()] = k
+	}
+
+	s.mu.Lock()
+	st, err := s.state(ctx, market.Symbol)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	for _, k := range st.klines[interval] {
+		if end.IsZero() || k.OpenTime.Before(end) {
+			merged[k.OpenTime.UTC()] = *k
+		}
+	}
+	s.mu.Unlock()
+
+	klines := make([]model.Kline, 0, len(merged))
+	for _, k := range merged {
+		klines = append(klines, k)
+	}
+	sort.Slice(klines, func(i, j int) bool { return klines[i].OpenTime.Before(klines[j].OpenTime) })
+	if len(klines) > limit {
+		klines = klines[len(klines)-limit:]
+	}
+	return klines, nil
+}
+
+// LastPrice 返回交易对的最新成交价，尚无成交时ok为false
+func (s *MarketDataService) LastPrice(ctx context.Context, symbol string) (price decimal.Decimal, ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, err := s.state(ctx, symbol)
+	if err != nil {
+		return decimal.Zero, false, err
+	}
+	series := st.klines[model.KlineInterval1m]
+	if len(series) == 0 {
+		return decimal.Zero, false, nil
+	}
+	return series[len(series)-1].Close, true, nil
+}
+
+// CurrentKline 返回交易对指定周期当前（最新）的K线，尚无成交时ok为false
+func (s *MarketDataService) CurrentKline(symbol, interval string) (kline model.Kline, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, found := s.states[symbol]
+	if !found {
+		return model.Kline{}, false
+	}
+	series := st.klines[interval]
+	if len(series) == 0 {
+		return model.Kline{}, false
+	}
+	return *series[len(series)-1], true
+}
+
+// aggregate 将成交计入所在周期的K线，须持有s.mu。
+// 系统时钟回拨导致成交时间早于当前K线时计入当前K线。
+func (s *MarketDataService) aggregate(st *marketState, symbol, interval string, t PublicTrade) {
+	openTime := t.Time.UTC().Truncate(model.KlineIntervals[interval])
+	series := st.klines[interval]
+	var k *model.Kline
+	if n := len(series); n > 0 && !openTime.After(series[n-1].OpenTime) {
+		k = series[n-1]
+		k.High = decimal.Max(k.High, t.Price)
+		k.Low = decimal.Min(k.Low, t.Price)
+		k.Close = t.Price
+		k.Volume = k.Volume.Add(t.Quantity)
+		k.QuoteVolume = k.QuoteVolume.Add(t.Price.Mul(t.Quantity))
+		k.Trades++
+	} else {
+		k = &model.Kline{
+			Symbol:      symbol,
+			Interval:    interval,
+			OpenTime:    openTime,
+			Open:        t.Price,
+			High:        t.Price,
+			Low:         t.Price,
+			Close:       t.Price,
+			Volume:      t.Quantity,
+			QuoteVolume: t.Price.Mul(t.Quantity),
+			Trades:      1,
+		}
+		series = append(series, k)
+		if keep := keepKlines[interval]; len(series) > keep {
+			series = append([]*model.Kline(nil), series[len(series)-keep:]...)
+		}
+		st.klines[interval] = series
+	}
+	k.LastTradeID = t.ID
+	s.dirty[k] = struct{}{}
+}
+
+// state 返回交易对的内存行情，首次访问时从数据库加载最近的K线，须持有s.mu
+func (s *MarketDataService) state(ctx context.Context, symbol string) (*marketState, error) {
+	if st, ok := s.states[symbol]; ok {
+		return st, nil
+	}
+	st := &marketState{klines: make(map[string][]*model.Kline, len(model.KlineIntervals))}
+	for interval := range model.KlineIntervals {
+		stored, err := s.klines.ListBefore(ctx, symbol, interval, time.Time{}, keepKlines[interval])
+		if err != nil {
+			return nil, err
+		}
+		series := make([]*model.Kline, len(stored))
+		for i := range stored {
+			stored[i].OpenTime = stored[i].OpenTime.UTC()
+			series[i] = &stored[i]
+			if stored[i].LastTradeID > st.lastTradeID {
+				st.lastTradeID = stored[i].LastTradeID
+			}
+		}
+		st.klines[interval] = series
+	}
+	s.states[symbol] = st
+	return st, nil
+}
+
+// market 获取交易对，不存在时返回ErrMarketNotFound
+func (s *MarketDataService) market(ctx context.Context, symbol string) (*model.Market, error) {
+	market, err := s.markets.GetBySymbol(ctx, normalizeSymbol(symbol))
+	if err != nil {
+		return nil, err
+	}
+	if market == nil {
+		return nil, ErrMarketNotFound
+	}
+	return market, nil
+}
+
+// read 在交易对的定序协程中读取订单簿
+func (s *MarketDataService) read(ctx context.Context, symbol string, fn func(book *matching.OrderBook, seq uint64)) error {
+	seq, err := s.engine.Get(symbol)
+	if err != nil {
+		return err
+	}
+	return seq.Read(ctx, fn)
+}
+
+// publicTrade 将引擎成交转换为公开成交记录
+func publicTrade(t matching.Trade, at time.Time) PublicTrade {
+	return PublicTrade{
+		ID:       t.ID,
+		Price:    fromUnits(t.Price),
+		Quantity: fromUnits(t.Quantity),
+		Side:     t.TakerSide.String(),
+		Time:     at,
+	}
+}
+
+// depthLevels 将引擎的价位转换为十进制表示
+func depthLevels(levels []matching.Level) []DepthLevel {
+	out := make([]DepthLevel, len(levels))
+	for i, l := range levels {
+		out[i] = DepthLevel{Price: fromUnits(l.Price), Quantity: fromUnits(l.Quantity)}
+	}
+	return out
+}
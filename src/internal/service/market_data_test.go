@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"awesome-trade/src/internal/config"
+	"awesome-trade/src/internal/database"
+	"awesome-trade/src/internal/matching"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/internal/sequencer"
+	"awesome-trade/src/pkg/decimal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupMarketDataService 创建使用内存数据库的行情服务，预置BTC_USDT交易对
+func setupMarketDataService(t *testing.T) (*MarketDataService, *gorm.DB) {
+	db, err := database.Open(config.DatabaseConfig{Driver: database.DriverSQLite, Path: ":memory:"})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.Market{}, &model.Kline{}))
+	require.NoError(t, db.Create(&model.Market{
+		Symbol: "BTC_USDT", BaseAsset: "BTC", QuoteAsset: "USDT",
+		TickSize: decimal.RequireFromString("0.01"), StepSize: decimal.RequireFromString("0.001"),
+		Status: model.MarketStatusTrading,
+	}).Error)
+	return newMarketDataService(t, db), db
+}
+
+// newMarketDataService 在已有数据库上创建行情服务，模拟重启
+func newMarketDataService(t *testing.T, db *gorm.DB) *MarketDataService {
+	engine := sequencer.NewManager(sequencer.Options{Dir: t.TempDir()})
+	t.Cleanup(func() { _ = engine.Close() })
+	return NewMarketDataService(repository.NewMarketRepository(db), repository.NewKlineRepository(db), engine, config.MarketDataConfig{})
+}
+
+// tradeEvent 构造一条成交事件，价格和数量为十进制字符串
+func tradeEvent(seq uint64, at time.Time, trades ...matching.Trade) sequencer.Event {
+	return sequencer.Event{
+		Symbol:  "BTC_USDT",
+		Command: sequencer.Command{Seq: seq, Time: at},
+		Result:  &matching.Result{Symbol: "BTC_USDT", Trades: trades},
+	}
+}
+
+// trade 构造一笔成交
+func trade(id uint64, price, quantity string, side matching.Side) matching.Trade {
+	p, _ := toUnits(decimal.RequireFromString(price))
+	q, _ := toUnits(decimal.RequireFromString(quantity))
+	return matching.Trade{ID: id, Price: p, Quantity: q, TakerSide: side}
+}
+
+// 测试成交汇总为K线、滚动行情与重放去重
+func TestMarketDataAggregation(t *testing.T) {
+	s, db := setupMarketDataService(t)
+	ctx := context.Background()
+	base := time.Now().UTC().Truncate(time.Hour).Add(-time.Hour)
+
+	s.HandleEvent(tradeEvent(1, base.Add(10*time.Second), trade(1, "100", "1", matching.Buy)))
+	s.HandleEvent(tradeEvent(2, base.Add(20*time.Second), trade(2, "110", "0.5", matching.Buy), trade(3, "90", "2", matching.Sell)))
+	replay := tradeEvent(3, base.Add(70*time.Second), trade(4, "95", "1", matching.Sell))
+	s.HandleEvent(replay)
+	replay.Replayed = true
+	s.HandleEvent(replay)
+
+	minutes, err := s.Klines(ctx, "btc_usdt", model.KlineInterval1m, time.Time{}, 10)
+	require.NoError(t, err)
+	require.Len(t, minutes, 2)
+	assert.Equal(t, base, minutes[0].OpenTime)
+	assert.Equal(t, "100", minutes[0].Open.String())
+	assert.Equal(t, "110", minutes[0].High.String())
+	assert.Equal(t, "90", minutes[0].Low.String())
+	assert.Equal(t, "90", minutes[0].Close.String())
+	assert.Equal(t, "3.5", minutes[0].Volume.String())
+	assert.Equal(t, "335", minutes[0].QuoteVolume.String())
+	assert.Equal(t, 3, minutes[0].Trades)
+	assert.Equal(t, 1, minutes[1].Trades)
+
+	hours, err := s.Klines(ctx, "BTC_USDT", model.KlineInterval1h, time.Time{}, 10)
+	require.NoError(t, err)
+	require.Len(t, hours, 1)
+	assert.Equal(t, "4.5", hours[0].Volume.String())
+	assert.Equal(t, 4, hours[0].Trades)
+
+	_, err = s.Klines(ctx, "BTC_USDT", "2m", time.Time{}, 10)
+	assert.ErrorIs(t, err, ErrInvalidInterval)
+
+	trades, err := s.Trades(ctx, "BTC_USDT", 2)
+	require.NoError(t, err)
+	require.Len(t, trades, 2)
+	assert.Equal(t, uint64(4), trades[0].ID)
+	assert.Equal(t, "sell", trades[0].Side)
+	assert.Equal(t, uint64(3), trades[1].ID)
+
+	ticker, err := s.Ticker(ctx, "BTC_USDT")
+	require.NoError(t, err)
+	assert.Equal(t, "95", ticker.LastPrice.String())
+	assert.Equal(t, "100", ticker.OpenPrice.String())
+	assert.Equal(t, "110", ticker.HighPrice.String())
+	assert.Equal(t, "90", ticker.LowPrice.String())
+	assert.Equal(t, "-5", ticker.PriceChange.String())
+	assert.Equal(t, "-5", ticker.PriceChangePercent.String())
+	assert.Equal(t, "4.5", ticker.Volume.String())
+	assert.Equal(t, 4, ticker.Trades)
+	assert.Nil(t, ticker.BestBid)
+	assert.Nil(t, ticker.BestAsk)
+
+	_, err = s.Ticker(ctx, "ETH_USDT")
+	assert.ErrorIs(t, err, ErrMarketNotFound)
+
+	// 写入数据库后重启，重放已汇总的成交不重复计入
+	require.NoError(t, s.Flush(ctx))
+	var count int64
+	require.NoError(t, db.Model(&model.Kline{}).Count(&count).Error)
+	assert.Equal(t, int64(5), count)
+
+	restarted := newMarketDataService(t, db)
+	restarted.HandleEvent(replay)
+	restarted.HandleEvent(tradeEvent(4, base.Add(75*time.Second), trade(5, "96", "1", matching.Buy)))
+	minutes, err = restarted.Klines(ctx, "BTC_USDT", model.KlineInterval1m, time.Time{}, 10)
+	require.NoError(t, err)
+	require.Len(t, minutes, 2)
+	assert.Equal(t, 3, minutes[0].Trades)
+	assert.Equal(t, 2, minutes[1].Trades)
+	assert.Equal(t, "96", minutes[1].Close.String())
+
+	minutes, err = restarted.Klines(ctx, "BTC_USDT", model.KlineInterval1m, base.Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, minutes, 1)
+	assert.Equal(t, base, minutes[0].OpenTime)
+}
@@ -0,0 +1,604 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"awesome-trade/src/internal/config"
+	"awesome-trade/src/internal/database"
+	"awesome-trade/src/internal/matching"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/internal/sequencer"
+	"awesome-trade/src/pkg/decimal"
+	"awesome-trade/src/pkg/utils"
+)
+
+// 订单服务错误
+var (
+	ErrOrderNotFound         = errors.New("order not found")
+	ErrOrderNotOpen          = errors.New("order is not open")
+	ErrInvalidSymbol         = errors.New("invalid symbol")
+	ErrInvalidPrice          = errors.New("invalid price")
+	ErrInvalidQuantity       = errors.New("invalid quantity")
+	ErrInvalidTimeInForce    = errors.New("time in force is not allowed for this order type")
+	ErrClientOrderIDConflict = errors.New("client order id was already used for a different order")
+	ErrReservedClientOrderID = errors.New("client order id prefix is reserved")
+	ErrInvalidStopPrice      = errors.New("invalid stop price")
+	ErrInvalidTrailingDelta  = errors.New("invalid trailing delta")
+	ErrSettlementParked      = errors.New("order was matched but its settlement is pending manual review")
+)
+
+const (
+	// engineScale 撮合引擎中价格和数量的小数位数
+	engineScale = 8
+	// engineUnit 引擎中数量1对应的整数单位
+	engineUnit = 100_000_000
+)
+
+// PlaceOrderInput 下单参数
+type PlaceOrderInput struct {
+	Symbol        string
+	Side          string
+	Type          string
+	TimeInForce   string
+	Price         decimal.Decimal
+	Quantity      decimal.Decimal
+	ClientOrderID string
+
+	// 条件单参数：StopPrice为止损或止盈的触发价，TrailingDelta为跟踪止损的回撤价差，
+	// TriggerPriceType为触发价格来源，默认为最新成交价
+	StopPrice        decimal.Decimal
+	TrailingDelta    decimal.Decimal
+	TriggerPriceType string
+}
+
+// OrderService 订单服务
+type OrderService struct {
+	*BaseService
+	orders  *repository.OrderRepository
+	markets *repository.MarketRepository
+	ledger  *LedgerService
+	risk    *RiskService
+	fees    *FeeService
+	prices  *MarketDataService
+	engine  *sequencer.Manager
+
+	triggers *triggerBook
+
+	maxBatchSize   int
+	maxCancelAfter time.Duration
+	cancelTimers   *cancelTimers
+}
+
+// NewOrderService 创建订单服务实例，作为定序器的有序消费者更新订单状态和结算资金，并订阅撮合事件检查条件单
+func NewOrderService(tx *database.TxManager, orders *repository.OrderRepository, markets *repository.MarketRepository, ledger *LedgerService, risk *RiskService, fees *FeeService, prices *MarketDataService, engine *sequencer.Manager, cfg config.OrderConfig) *OrderService {
+	maxBatchSize := cfg.MaxBatchSize
+	if maxBatchSize <= 0 {
+		maxBatchSize = defaultMaxBatchSize
+	}
+	maxCancelAfter := time.Duration(cfg.MaxCancelAfter) * time.Second
+	if maxCancelAfter <= 0 {
+		maxCancelAfter = defaultMaxCancelAfter
+	}
+	s := &OrderService{
+		BaseService: NewBaseService(tx),
+		orders:      orders,
+		markets:     markets,
+		ledger:      ledger,
+		risk:        risk,
+		fees:        fees,
+		prices:      prices,
+		engine:      engine,
+		triggers:    newTriggerBook(),
+
+		maxBatchSize:   maxBatchSize,
+		maxCancelAfter: maxCancelAfter,
+		cancelTimers:   newCancelTimers(),
+	}
+	engine.SetConsumer(s)
+	engine.Subscribe(s.watchTriggers)
+	return s
+}
+
+// Place 下单。订单须满足交易对的交易规则和用户的风控限额，写入数据库并冻结资金后提交给交易对的定序器撮合，返回撮合后的订单。
+// 条件单只写入数据库等待触发，触发后再按子订单检查风控和冻结资金。
+// 相同客户端订单ID的重复请求返回已有订单，参数不一致时返回ErrClientOrderIDConflict。
+func (s *OrderService) Place(ctx context.Context, userID uint, in PlaceOrderInput) (*model.Order, error) {
+	if strings.HasPrefix(in.ClientOrderID, triggerClientOrderIDPrefix) {
+		return nil, ErrReservedClientOrderID
+	}
+	order, err := newOrder(userID, in)
+	if err != nil {
+		return nil, err
+	}
+	return s.place(ctx, order)
+}
+
+// place 校验并提交已构建的订单
+func (s *OrderService) place(ctx context.Context, order *model.Order) (*model.Order, error) {
+	market, err := s.markets.GetBySymbol(ctx, order.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if market == nil {
+		return nil, ErrMarketNotFound
+	}
+	if err := checkMarketRules(market, order); err != nil {
+		return nil, err
+	}
+	if !s.ledger.Scales().Fits(market.BaseAsset, order.Quantity) {
+		return nil, ErrInvalidQuantity
+	}
+	if order.IsConditional() {
+		return s.placeConditional(ctx, order)
+	}
+	if err := s.checkRisk(ctx, market, order); err != nil {
+		return nil, err
+	}
+	seq, err := s.sequencer(order.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	var created bool
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		if created, err = s.create(ctx, order); err != nil || created {
+			return err
+		}
+		existing, err := s.orders.GetByClientOrderID(ctx, order.UserID, order.ClientOrderID)
+		if err != nil {
+			return err
+		}
+		if existing == nil || !sameOrder(existing, order) {
+			return ErrClientOrderIDConflict
+		}
+		order = existing
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !created && order.ParentOrderID != nil && order.Status == model.OrderStatusNew {
+		// 子订单只由条件单检查协程提交，落库后、提交前中断的子订单在补交时提交
+		submitted, err := s.submitted(ctx, seq, order)
+		if err != nil {
+			return nil, err
+		}
+		created = !submitted
+	}
+	if !created {
+		return order, nil
+	}
+	return s.submit(ctx, seq, market, order)
+}
+
+// submitted 判断已落库的订单是否已提交给定序器：已定序的命令全部结算后，订单仍为新订单且不在订单簿中说明尚未提交。
+// 交易对有被搁置的事件时订单可能已在其中成交而未结算，视为已提交，不自动补交
+func (s *OrderService) submitted(ctx context.Context, seq *sequencer.Sequencer, order *model.Order) (bool, error) {
+	if err := seq.WaitApplied(ctx); err != nil {
+		return false, err
+	}
+	parked, err := s.orders.HasSettlementFailure(ctx, order.Symbol)
+	if err != nil || parked {
+		return true, err
+	}
+	id := order.ID
+	if order, err = s.reload(ctx, id); err != nil {
+		return false, err
+	}
+	if order.Status != model.OrderStatusNew {
+		return true, nil
+	}
+	var onBook bool
+	err = seq.Read(ctx, func(book *matching.OrderBook, _ uint64) {
+		_, onBook = book.Order(uint64(id))
+	})
+	return onBook, err
+}
+
+// orderRejections 订单本身不被接受的下单错误，其余错误为数据库或定序器的临时故障
+var orderRejections = []error{
+	ErrInvalidSymbol, ErrInvalidPrice, ErrInvalidQuantity, ErrInvalidTimeInForce, ErrInvalidStopPrice, ErrInvalidTrailingDelta,
+	ErrClientOrderIDConflict, ErrReservedClientOrderID, ErrInsufficientBalance,
+	ErrMarketNotFound, ErrMarketNotTrading, ErrPriceTickViolation, ErrQuantityStepViolation, ErrMinNotionalViolation,
+	ErrRiskMaxQuantity, ErrRiskMaxNotional, ErrRiskMaxOpenOrders, ErrRiskPriceBand, ErrRiskDailyLoss,
+}
+
+// isRejection 判断下单错误是否为订单因交易规则、余额或风控等原因不被接受
+func isRejection(err error) bool {
+	for _, target := range orderRejections {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// create 写入订单并冻结资金，条件单和等待生效的订单不冻结资金。客户端订单ID已存在时不写入并返回false
+func (s *OrderService) create(ctx context.Context, order *model.Order) (bool, error) {
+	if !order.OnBook() {
+		return s.orders.CreateIfAbsent(ctx, order)
+	}
+	var err error
+	if order.HeldAmount, err = s.holdAmount(ctx, order); err != nil {
+		return false, err
+	}
+	created, err := s.orders.CreateIfAbsent(ctx, order)
+	if err != nil || !created {
+		return false, err
+	}
+	return true, s.hold(ctx, order)
+}
+
+// submit 将已落库的订单提交给定序器撮合，返回撮合后的订单
+func (s *OrderService) submit(ctx context.Context, seq *sequencer.Sequencer, market *model.Market, order *model.Order) (*model.Order, error) {
+	// 订单已落库，不再受请求取消影响，确保送达定序器；提交失败时拒绝订单并解冻资金
+	detached := context.WithoutCancel(ctx)
+	res, err := seq.Submit(detached, engineOrder(market, order))
+	if err != nil {
+		if _, rerr := s.closeOrder(detached, order.ID, model.OrderStatusRejected); rerr != nil {
+			log.Printf("Failed to reject order %d: %v", order.ID, rerr)
+		}
+		return nil, err
+	}
+	// 结算在定序器的消费协程中进行，等待结算完成后返回订单的最新状态，请求取消时不再等待
+	if err := waitSettled(ctx, seq, res.Seq); err != nil {
+		return nil, err
+	}
+	return s.reload(detached, order.ID)
+}
+
+// waitSettled 等待命令结算完成，命令的事件被搁置时返回ErrSettlementParked
+func waitSettled(ctx context.Context, seq *sequencer.Sequencer, target uint64) error {
+	err := seq.WaitSeq(ctx, target)
+	if errors.Is(err, sequencer.ErrParked) {
+		return ErrSettlementParked
+	}
+	return err
+}
+
+// checkRisk 下单前风控检查。客户端订单ID已被使用的重复请求不再检查，交由Place按幂等处理，
+// 以免已受理的订单在重试时因自身计入挂单数而被拒绝
+func (s *OrderService) checkRisk(ctx context.Context, market *model.Market, order *model.Order) error {
+	existing, err := s.orders.GetByClientOrderID(ctx, order.UserID, order.ClientOrderID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return nil
+	}
+	return s.risk.Check(ctx, market, order)
+}
+
+// Cancel 撤销用户的挂单
+func (s *OrderService) Cancel(ctx context.Context, userID, id uint) (*model.Order, error) {
+	order, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.cancelOpen(ctx, order)
+}
+
+// CancelAny 撤销任意用户的挂单，供管理员使用
+func (s *OrderService) CancelAny(ctx context.Context, id uint) (*model.Order, error) {
+	order, err := s.reload(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.cancelOpen(ctx, order)
+}
+
+// cancelOpen 撤销挂单并返回撤销后的订单，订单已结束时返回ErrOrderNotOpen
+func (s *OrderService) cancelOpen(ctx context.Context, order *model.Order) (*model.Order, error) {
+	if !order.IsOpen() {
+		return nil, ErrOrderNotOpen
+	}
+	if err := s.cancel(ctx, order); err != nil {
+		return nil, err
+	}
+	return s.reload(ctx, order.ID)
+}
+
+// CancelSymbol 撤销交易对上全部用户的挂单，返回撤销的订单数，期间已结束的订单不计入
+func (s *OrderService) CancelSymbol(ctx context.Context, symbol string) (int, error) {
+	orders, err := s.orders.ListOpenBySymbol(ctx, symbol)
+	if err != nil {
+		return 0, err
+	}
+	canceled := 0
+	for i := range orders {
+		err := s.cancel(ctx, &orders[i])
+		if errors.Is(err, ErrOrderNotOpen) {
+			continue
+		}
+		if err != nil {
+			return canceled, err
+		}
+		canceled++
+	}
+	return canceled, nil
+}
+
+// cancel 通过定序器撤单。订单在引擎中不存在但数据库中仍为挂单时
+// 说明下单命令未写入日志，直接在数据库中撤销并解冻资金。等待触发的条件单不在引擎中，直接撤销；
+// 订单组内的订单撤销整个订单组。
+func (s *OrderService) cancel(ctx context.Context, order *model.Order) error {
+	switch {
+	case order.GroupID != nil:
+		return s.cancelGroup(ctx, *order.GroupID)
+	case !order.OnBook():
+		return s.cancelOffBook(ctx, order)
+	}
+	return s.cancelOnBook(ctx, order)
+}
+
+// cancelOnBook 通过定序器撤销订单簿中的订单
+func (s *OrderService) cancelOnBook(ctx context.Context, order *model.Order) error {
+	seq, err := s.sequencer(order.Symbol)
+	if err != nil {
+		return err
+	}
+
+	detached := context.WithoutCancel(ctx)
+	res, err := seq.Cancel(detached, uint64(order.ID))
+	if err != nil {
+		return err
+	}
+	// 订单可能已在引擎中成交而尚未结算，须等待结算完成后再按数据库中的状态处理
+	if err := waitSettled(ctx, seq, res.Seq); err != nil {
+		return err
+	}
+	if res.Reason == matching.ReasonUnknownOrder {
+		// 订单可能已在被搁置的事件中成交，冻结的资金须保留到人工处理
+		parked, err := s.orders.HasSettlementFailure(detached, order.Symbol)
+		if err != nil {
+			return err
+		}
+		if parked {
+			return ErrSettlementParked
+		}
+		ok, err := s.closeOrder(detached, order.ID, model.OrderStatusCanceled)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrOrderNotOpen
+		}
+	}
+	return nil
+}
+
+// Get 获取用户的订单
+func (s *OrderService) Get(ctx context.Context, userID, id uint) (*model.Order, error) {
+	order, err := s.orders.GetForUser(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, ErrOrderNotFound
+	}
+	return order, nil
+}
+
+// GetAny 获取任意用户的订单，供管理员使用
+func (s *OrderService) GetAny(ctx context.Context, id uint) (*model.Order, error) {
+	return s.reload(ctx, id)
+}
+
+// ListOpen 查询用户的当前挂单
+func (s *OrderService) ListOpen(ctx context.Context, userID uint, symbol string) ([]model.Order, error) {
+	return s.orders.ListOpen(ctx, userID, normalizeSymbol(symbol))
+}
+
+// ListHistory 查询用户的历史订单，返回下一页游标
+func (s *OrderService) ListHistory(ctx context.Context, userID uint, q repository.OrderHistoryQuery) ([]model.Order, uint, error) {
+	_, q.Limit = utils.NormalizePage(1, q.Limit)
+	q.Symbol = normalizeSymbol(q.Symbol)
+	return s.orders.ListHistory(ctx, userID, q)
+}
+
+// sequencer 返回交易对的定序器
+func (s *OrderService) sequencer(symbol string) (*sequencer.Sequencer, error) {
+	seq, err := s.engine.Get(symbol)
+	if errors.Is(err, sequencer.ErrInvalidSymbol) {
+		return nil, ErrInvalidSymbol
+	}
+	return seq, err
+}
+
+// reload 重新读取订单
+func (s *OrderService) reload(ctx context.Context, id uint) (*model.Order, error) {
+	order, err := s.orders.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, ErrOrderNotFound
+	}
+	return order, nil
+}
+
+// newOrder 校验下单参数并构建订单
+func newOrder(userID uint, in PlaceOrderInput) (*model.Order, error) {
+	symbol := normalizeSymbol(in.Symbol)
+	if _, _, ok := splitSymbol(symbol); !ok {
+		return nil, ErrInvalidSymbol
+	}
+	if _, ok := toUnits(in.Quantity); !ok || !in.Quantity.IsPositive() {
+		return nil, ErrInvalidQuantity
+	}
+	if _, ok := toUnits(in.Price); !ok {
+		return nil, ErrInvalidPrice
+	}
+
+	typ := in.Type
+	if child, ok := model.ConditionalOrderTypes[in.Type]; ok {
+		typ = child
+	}
+	tif := in.TimeInForce
+	switch typ {
+	case model.OrderTypeLimit:
+		if !in.Price.IsPositive() {
+			return nil, ErrInvalidPrice
+		}
+		if tif == "" {
+			tif = model.TimeInForceGTC
+		}
+	case model.OrderTypeMarket:
+		if !in.Price.IsZero() {
+			return nil, ErrInvalidPrice
+		}
+		if tif == "" {
+			tif = model.TimeInForceIOC
+		}
+		if tif != model.TimeInForceIOC && tif != model.TimeInForceFOK {
+			return nil, ErrInvalidTimeInForce
+		}
+	}
+
+	status, stop, delta, priceType := model.OrderStatusNew, decimal.Zero, decimal.Zero, ""
+	if typ != in.Type {
+		var err error
+		if stop, delta, err = triggerParams(in); err != nil {
+			return nil, err
+		}
+		status, priceType = model.OrderStatusUntriggered, in.TriggerPriceType
+		if priceType == "" {
+			priceType = model.TriggerPriceLast
+		}
+	} else if !in.StopPrice.IsZero() {
+		return nil, ErrInvalidStopPrice
+	} else if !in.TrailingDelta.IsZero() {
+		return nil, ErrInvalidTrailingDelta
+	}
+
+	clientOrderID := in.ClientOrderID
+	if clientOrderID == "" {
+		id, err := utils.RandomHex(16)
+		if err != nil {
+			return nil, err
+		}
+		clientOrderID = id
+	}
+
+	return &model.Order{
+		UserID:         userID,
+		ClientOrderID:  clientOrderID,
+		Symbol:         symbol,
+		Side:           in.Side,
+		Type:           in.Type,
+		TimeInForce:    tif,
+		Price:          in.Price,
+		Quantity:       in.Quantity,
+		FilledQuantity: decimal.Zero,
+		HeldAmount:     decimal.Zero,
+		Status:         status,
+
+		StopPrice:        stop,
+		TrailingDelta:    delta,
+		TriggerPriceType: priceType,
+	}, nil
+}
+
+// triggerParams 校验条件单的触发参数：跟踪止损单只设置回撤价差，触发价随行情确定；其他条件单只设置触发价
+func triggerParams(in PlaceOrderInput) (stop, delta decimal.Decimal, err error) {
+	if in.Type == model.OrderTypeTrailingStop {
+		if _, ok := toUnits(in.TrailingDelta); !ok || !in.TrailingDelta.IsPositive() {
+			return decimal.Zero, decimal.Zero, ErrInvalidTrailingDelta
+		}
+		if !in.StopPrice.IsZero() {
+			return decimal.Zero, decimal.Zero, ErrInvalidStopPrice
+		}
+		return decimal.Zero, in.TrailingDelta, nil
+	}
+	if _, ok := toUnits(in.StopPrice); !ok || !in.StopPrice.IsPositive() {
+		return decimal.Zero, decimal.Zero, ErrInvalidStopPrice
+	}
+	if !in.TrailingDelta.IsZero() {
+		return decimal.Zero, decimal.Zero, ErrInvalidTrailingDelta
+	}
+	return in.StopPrice, decimal.Zero, nil
+}
+
+// engineOrder 将订单转换为撮合引擎的订单，价格和数量已在newOrder中校验过精度。
+// 市价买单以冻结的计价资产作为引擎中的资金上限，受该上限约束时成交数量按交易对的数量步长取整
+func engineOrder(market *model.Market, o *model.Order) matching.Order {
+	price, _ := toUnits(o.Price)
+	quantity, _ := toUnits(o.Quantity)
+	eo := matching.Order{
+		ID:          uint64(o.ID),
+		UserID:      uint64(o.UserID),
+		Side:        matching.Buy,
+		Type:        matching.Limit,
+		TimeInForce: matching.GTC,
+		Price:       price,
+		Quantity:    quantity,
+	}
+	if o.Side == model.SideSell {
+		eo.Side = matching.Sell
+	}
+	if o.Type == model.OrderTypeMarket {
+		eo.Type = matching.Market
+		if eo.Side == matching.Buy {
+			eo.Funds = fundsUnits(o.HeldAmount)
+			eo.Unit = engineUnit
+			eo.Lot, _ = toUnits(market.StepSize)
+		}
+	}
+	switch o.TimeInForce {
+	case model.TimeInForceIOC:
+		eo.TimeInForce = matching.IOC
+	case model.TimeInForceFOK:
+		eo.TimeInForce = matching.FOK
+	case model.TimeInForcePostOnly:
+		eo.TimeInForce = matching.PostOnly
+	}
+	return eo
+}
+
+// toUnits 将价格或数量转换为引擎的整数单位，小数位超过engineScale或超出范围时ok为false
+func toUnits(d decimal.Decimal) (int64, bool) {
+	scaled := d.Shift(engineScale)
+	if !scaled.Equal(scaled.Truncate(0)) || !scaled.BigInt().IsInt64() {
+		return 0, false
+	}
+	return scaled.IntPart(), true
+}
+
+// fundsUnits 将冻结金额向下取整为引擎的整数单位，超出范围时取最大值
+func fundsUnits(d decimal.Decimal) int64 {
+	scaled := d.Shift(engineScale).Truncate(0)
+	if !scaled.BigInt().IsInt64() {
+		return math.MaxInt64
+	}
+	return scaled.IntPart()
+}
+
+// fromUnits 将引擎的整数单位转换为十进制数
+func fromUnits(n int64) decimal.Decimal {
+	return decimal.New(n, -engineScale)
+}
+
+// sameOrder 判断重复请求的订单参数是否一致，跟踪止损单的触发价随行情变化，不参与比较
+func sameOrder(a, b *model.Order) bool {
+	return a.Symbol == b.Symbol &&
+		a.Side == b.Side &&
+		a.Type == b.Type &&
+		a.TimeInForce == b.TimeInForce &&
+		a.Price.Equal(b.Price) &&
+		a.Quantity.Equal(b.Quantity) &&
+		(a.Type == model.OrderTypeTrailingStop || a.StopPrice.Equal(b.StopPrice)) &&
+		a.TrailingDelta.Equal(b.TrailingDelta) &&
+		a.TriggerPriceType == b.TriggerPriceType
+}
+
+// normalizeSymbol 交易对统一使用大写
+func normalizeSymbol(symbol string) string {
+	return strings.ToUpper(strings.TrimSpace(symbol))
+}
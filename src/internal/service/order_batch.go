@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"awesome-trade/src/internal/model"
+)
+
+const (
+	// defaultMaxBatchSize 批量接口每次默认最多处理的订单数
+	defaultMaxBatchSize = 20
+	// defaultMaxCancelAfter 撤单倒计时默认的最长时间
+	defaultMaxCancelAfter = 10 * time.Minute
+	// defaultCancelAfterSweep 默认检查已到期撤单倒计时的间隔
+	defaultCancelAfterSweep = 5 * time.Second
+)
+
+// 批量操作错误
+var (
+	ErrInvalidBatchSize   = errors.New("batch must contain at least one order and no more than the maximum batch size")
+	ErrInvalidCancelAfter = errors.New("cancel after timeout is out of range")
+)
+
+// BatchInput 批量下单撤单参数，先按顺序撤单再按顺序下单，便于做市商在一次请求中替换报价
+type BatchInput struct {
+	Cancel []uint
+	Place  []PlaceOrderInput
+}
+
+// BatchResult 批量操作中单个订单的结果，Err不为nil时该订单失败，不影响其他订单
+type BatchResult struct {
+	Order *model.Order
+	Err   error
+}
+
+// Batch 批量撤单和下单，每个订单与单独调用Cancel或Place的结果相同。订单总数须在1到最大批量之间
+func (s *OrderService) Batch(ctx context.Context, userID uint, in BatchInput) (canceled, placed []BatchResult, err error) {
+	if n := len(in.Cancel) + len(in.Place); n == 0 || n > s.maxBatchSize {
+		return nil, nil, ErrInvalidBatchSize
+	}
+	canceled = make([]BatchResult, len(in.Cancel))
+	for i, id := range in.Cancel {
+		canceled[i].Order, canceled[i].Err = s.Cancel(ctx, userID, id)
+	}
+	placed = make([]BatchResult, len(in.Place))
+	for i, p := range in.Place {
+		placed[i].Order, placed[i].Err = s.Place(ctx, userID, p)
+	}
+	return canceled, placed, nil
+}
+
+// CancelAll 撤销用户的全部挂单，symbol和side不为空时只撤销匹配的挂单。订单组内的订单撤销整个订单组，
+// 按方向撤销时组内还有另一方向的已生效订单的订单组整体跳过，不撤销过滤条件之外的订单。
+// 返回撤销的订单数，期间已结束的订单不计入
+func (s *OrderService) CancelAll(ctx context.Context, userID uint, symbol, side string) (int, error) {
+	orders, err := s.orders.ListOpen(ctx, userID, normalizeSymbol(symbol))
+	if err != nil {
+		return 0, err
+	}
+	// 括号单等待生效的止盈止损订单随入场单撤销，不影响订单组能否撤销
+	mixed := make(map[uint]bool)
+	for _, o := range orders {
+		if side != "" && o.GroupID != nil && o.Side != side && o.Status != model.OrderStatusPending {
+			mixed[*o.GroupID] = true
+		}
+	}
+	canceled := 0
+	for i := range orders {
+		if side != "" && orders[i].Side != side {
+			continue
+		}
+		if orders[i].GroupID != nil && mixed[*orders[i].GroupID] {
+			continue
+		}
+		err := s.cancel(ctx, &orders[i])
+		if errors.Is(err, ErrOrderNotOpen) {
+			continue
+		}
+		if err != nil {
+			return canceled, err
+		}
+		canceled++
+	}
+	return canceled, nil
+}
+
+// CancelAfter 设置或刷新用户的撤单倒计时：timeout内未再次调用时撤销用户的全部挂单。
+// 到期时间保存在数据库中，由当前实例的定时器和各实例的定期检查撤单。
+// timeout为0时关闭倒计时并返回nil，否则返回倒计时的到期时间
+func (s *OrderService) CancelAfter(ctx context.Context, userID uint, timeout time.Duration) (*time.Time, error) {
+	if timeout < 0 || timeout > s.maxCancelAfter {
+		return nil, ErrInvalidCancelAfter
+	}
+	if timeout == 0 {
+		if err := s.orders.DeleteCancelDeadline(ctx, userID); err != nil {
+			return nil, err
+		}
+		s.cancelTimers.stop(userID)
+		return nil, nil
+	}
+	deadline := time.Now().UTC().Add(timeout)
+	if err := s.orders.SetCancelDeadline(ctx, userID, deadline); err != nil {
+		return nil, err
+	}
+	s.armCancelAfter(userID, deadline)
+	return &deadline, nil
+}
+
+// LoadCancelAfter 从数据库加载撤单倒计时并重新设置定时器，服务停止期间已到期的倒计时立即撤单
+func (s *OrderService) LoadCancelAfter(ctx context.Context) error {
+	deadlines, err := s.orders.ListCancelDeadlines(ctx, time.Time{})
+	if err != nil {
+		return err
+	}
+	for _, d := range deadlines {
+		s.armCancelAfter(d.UserID, d.Deadline)
+	}
+	return nil
+}
+
+// RunCancelAfter 定期处理已到期的撤单倒计时，覆盖在其他实例设置、该实例已停止的倒计时，直到ctx取消。
+// ctx取消时停止当前实例的全部定时器
+func (s *OrderService) RunCancelAfter(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultCancelAfterSweep
+	}
+	defer s.cancelTimers.close()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deadlines, err := s.orders.ListCancelDeadlines(ctx, time.Now().UTC())
+			if err != nil {
+				log.Printf("Failed to list expired cancel deadlines: %v", err)
+				continue
+			}
+			for _, d := range deadlines {
+				s.expireCancelAfter(ctx, d.UserID)
+			}
+		}
+	}
+}
+
+// armCancelAfter 设置当前实例在deadline撤销用户全部挂单的定时器
+func (s *OrderService) armCancelAfter(userID uint, deadline time.Time) {
+	s.cancelTimers.reset(userID, deadline, func() {
+		s.expireCancelAfter(context.Background(), userID)
+	})
+}
+
+// expireCancelAfter 倒计时到期时撤销用户的全部挂单。数据库中的倒计时已被刷新或关闭时不撤单，
+// 撤单失败时保留倒计时，由定期检查重试
+func (s *OrderService) expireCancelAfter(ctx context.Context, userID uint) {
+	d, err := s.orders.FindCancelDeadline(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to load cancel deadline of user %d: %v", userID, err)
+		return
+	}
+	if d == nil || d.Deadline.After(time.Now()) {
+		return
+	}
+	n, err := s.CancelAll(ctx, userID, "", "")
+	if err != nil {
+		log.Printf("Failed to cancel orders of user %d after timeout: %v", userID, err)
+		return
+	}
+	log.Printf("Canceled %d orders of user %d after timeout", n, userID)
+	if err := s.orders.DeleteExpiredCancelDeadline(ctx, userID, d.Deadline); err != nil {
+		log.Printf("Failed to delete cancel deadline of user %d: %v", userID, err)
+	}
+}
+
+// cancelTimers 当前实例上各用户撤单倒计时的定时器，到期时间以数据库为准
+type cancelTimers struct {
+	mu     sync.Mutex
+	timers map[uint]*time.Timer
+	closed bool
+}
+
+// newCancelTimers 创建撤单倒计时表
+func newCancelTimers() *cancelTimers {
+	return &cancelTimers{
+		timers: make(map[uint]*time.Timer),
+	}
+}
+
+// reset 停止用户已有的定时器并重新设置，到达deadline时调用fire，deadline已过时立即调用
+func (t *cancelTimers) reset(userID uint, deadline time.Time, fire func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	if timer, ok := t.timers[userID]; ok {
+		timer.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Until(deadline), func() {
+		// 到期与刷新并发时，已被替换的定时器不再撤单
+		t.mu.Lock()
+		current := t.timers[userID] == timer
+		if current {
+			delete(t.timers, userID)
+		}
+		t.mu.Unlock()
+		if current {
+			fire()
+		}
+	})
+	t.timers[userID] = timer
+}
+
+// stop 停止用户的定时器
+func (t *cancelTimers) stop(userID uint) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if timer, ok := t.timers[userID]; ok {
+		timer.Stop()
+		delete(t.timers, userID)
+	}
+}
+
+// close 停止全部定时器，之后不再设置新的定时器
+func (t *cancelTimers) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	for userID, timer := range t.timers {
+		timer.Stop()
+		delete(t.timers, userID)
+	}
+}
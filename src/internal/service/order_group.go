@@ -0,0 +1,445 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"awesome-trade/src/internal/database"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/pkg/utils"
+)
+
+// 订单组服务错误
+var (
+	ErrOrderGroupNotFound    = errors.New("order group not found")
+	ErrInvalidOrderGroup     = errors.New("invalid order group")
+	ErrClientGroupIDConflict = errors.New("client group id was already used for a different order group")
+)
+
+// OrderGroupInput 创建订单组参数。二选一订单组的止盈和止损订单方向和数量须相同，止盈订单可以是限价单；
+// 括号单的止盈和止损订单与入场单方向相反，数量为空时取入场单数量，须为条件单
+type OrderGroupInput struct {
+	Type          string
+	Symbol        string
+	ClientGroupID string
+	Entry         *PlaceOrderInput
+	TakeProfit    PlaceOrderInput
+	StopLoss      PlaceOrderInput
+}
+
+// OrderGroupService 订单组服务
+type OrderGroupService struct {
+	*BaseService
+	groups *repository.OrderGroupRepository
+	orders *OrderService
+}
+
+// NewOrderGroupService 创建订单组服务实例
+func NewOrderGroupService(tx *database.TxManager, groups *repository.OrderGroupRepository, orders *OrderService) *OrderGroupService {
+	return &OrderGroupService{
+		BaseService: NewBaseService(tx),
+		groups:      groups,
+		orders:      orders,
+	}
+}
+
+// Create 创建订单组。组内订单在一个事务中写入，订单簿中的订单冻结资金后提交撮合，条件单加入条件单簿等待触发。
+// 相同客户端订单组ID的重复请求返回已有订单组，类型或交易对不一致时返回ErrClientGroupIDConflict。
+func (s *OrderGroupService) Create(ctx context.Context, userID uint, in OrderGroupInput) (*model.OrderGroup, error) {
+	group, legs, err := newOrderGroup(userID, in)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.existing(ctx, group)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+
+	market, err := s.orders.markets.GetBySymbol(ctx, group.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if market == nil {
+		return nil, ErrMarketNotFound
+	}
+	var book *model.Order
+	for _, leg := range legs {
+		if err := checkMarketRules(market, leg); err != nil {
+			return nil, err
+		}
+		if leg.OnBook() {
+			book = leg
+		}
+	}
+	if book != nil {
+		if err := s.orders.risk.Check(ctx, market, book); err != nil {
+			return nil, err
+		}
+	}
+	seq, err := s.orders.sequencer(group.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	var created bool
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		if created, err = s.groups.CreateIfAbsent(ctx, group); err != nil || !created {
+			return err
+		}
+		for _, leg := range legs {
+			leg.GroupID = &group.ID
+			ok, err := s.orders.create(ctx, leg)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return ErrClientOrderIDConflict
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		existing, err := s.existing(ctx, group)
+		if err == nil && existing == nil {
+			err = ErrClientGroupIDConflict
+		}
+		return existing, err
+	}
+
+	// 先提交订单簿中的订单，未立即结束时再将二选一的条件单加入条件单簿，以免条件单在其之前触发
+	if book != nil {
+		if _, err := s.orders.submit(ctx, seq, market, book); err != nil {
+			if cerr := s.orders.cancelGroup(context.WithoutCancel(ctx), group.ID); cerr != nil && !errors.Is(cerr, ErrOrderNotOpen) {
+				log.Printf("Failed to cancel order group %d: %v", group.ID, cerr)
+			}
+			return nil, err
+		}
+	}
+	current, err := s.orders.orders.ListByGroup(ctx, group.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, leg := range current {
+		if leg.Status == model.OrderStatusUntriggered {
+			s.orders.triggers.add(leg)
+		}
+	}
+	return s.reload(ctx, group)
+}
+
+// Get 获取用户的订单组及组内订单
+func (s *OrderGroupService) Get(ctx context.Context, userID, id uint) (*model.OrderGroup, error) {
+	group, err := s.groups.GetForUser(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, ErrOrderGroupNotFound
+	}
+	return group, nil
+}
+
+// ListOpen 查询用户仍有未结束订单的订单组
+func (s *OrderGroupService) ListOpen(ctx context.Context, userID uint, symbol string) ([]model.OrderGroup, error) {
+	return s.groups.ListOpen(ctx, userID, normalizeSymbol(symbol))
+}
+
+// Cancel 撤销用户的订单组，组内订单已全部结束时返回ErrOrderNotOpen
+func (s *OrderGroupService) Cancel(ctx context.Context, userID, id uint) (*model.OrderGroup, error) {
+	group, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !group.IsOpen() {
+		return nil, ErrOrderNotOpen
+	}
+	if err := s.orders.cancelGroup(ctx, group.ID); err != nil {
+		return nil, err
+	}
+	return s.reload(ctx, group)
+}
+
+// existing 查询客户端订单组ID已存在的订单组，类型或交易对不一致时返回ErrClientGroupIDConflict
+func (s *OrderGroupService) existing(ctx context.Context, group *model.OrderGroup) (*model.OrderGroup, error) {
+	existing, err := s.groups.GetByClientGroupID(ctx, group.UserID, group.ClientGroupID)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+	if existing.Type != group.Type || existing.Symbol != group.Symbol {
+		return nil, ErrClientGroupIDConflict
+	}
+	return existing, nil
+}
+
+// reload 重新读取订单组
+func (s *OrderGroupService) reload(ctx context.Context, group *model.OrderGroup) (*model.OrderGroup, error) {
+	return s.Get(context.WithoutCancel(ctx), group.UserID, group.ID)
+}
+
+// newOrderGroup 校验订单组参数并构建订单组和组内订单，订单簿中的订单排在最前
+func newOrderGroup(userID uint, in OrderGroupInput) (*model.OrderGroup, []*model.Order, error) {
+	symbol := normalizeSymbol(in.Symbol)
+	if _, _, ok := splitSymbol(symbol); !ok {
+		return nil, nil, ErrInvalidSymbol
+	}
+
+	var entry *model.Order
+	tp, sl := in.TakeProfit, in.StopLoss
+	switch in.Type {
+	case model.OrderGroupOCO:
+		if in.Entry != nil || tp.Side != sl.Side || !tp.Quantity.Equal(sl.Quantity) ||
+			(tp.Type != model.OrderTypeLimit && !isTakeProfit(tp.Type)) || !isStopLoss(sl.Type) {
+			return nil, nil, ErrInvalidOrderGroup
+		}
+	case model.OrderGroupBracket:
+		if in.Entry == nil || (in.Entry.Type != model.OrderTypeLimit && in.Entry.Type != model.OrderTypeMarket) ||
+			!isTakeProfit(tp.Type) || !isStopLoss(sl.Type) || tp.Side != sl.Side || tp.Side == in.Entry.Side {
+			return nil, nil, ErrInvalidOrderGroup
+		}
+		for _, exit := range []*PlaceOrderInput{&tp, &sl} {
+			if exit.Quantity.IsZero() {
+				exit.Quantity = in.Entry.Quantity
+			}
+			if !exit.Quantity.Equal(in.Entry.Quantity) {
+				return nil, nil, ErrInvalidOrderGroup
+			}
+		}
+		var err error
+		if entry, err = newGroupLeg(userID, symbol, *in.Entry, model.GroupRoleEntry); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, ErrInvalidOrderGroup
+	}
+
+	takeProfit, err := newGroupLeg(userID, symbol, tp, model.GroupRoleTakeProfit)
+	if err != nil {
+		return nil, nil, err
+	}
+	stopLoss, err := newGroupLeg(userID, symbol, sl, model.GroupRoleStopLoss)
+	if err != nil {
+		return nil, nil, err
+	}
+	legs := []*model.Order{takeProfit, stopLoss}
+	if entry != nil {
+		takeProfit.Status = model.OrderStatusPending
+		stopLoss.Status = model.OrderStatusPending
+		legs = []*model.Order{entry, takeProfit, stopLoss}
+	}
+
+	clientGroupID := in.ClientGroupID
+	if clientGroupID == "" {
+		id, err := utils.RandomHex(16)
+		if err != nil {
+			return nil, nil, err
+		}
+		clientGroupID = id
+	}
+	return &model.OrderGroup{
+		UserID:        userID,
+		ClientGroupID: clientGroupID,
+		Symbol:        symbol,
+		Type:          in.Type,
+	}, legs, nil
+}
+
+// newGroupLeg 校验并构建订单组内的一个订单
+func newGroupLeg(userID uint, symbol string, in PlaceOrderInput, role string) (*model.Order, error) {
+	if strings.HasPrefix(in.ClientOrderID, triggerClientOrderIDPrefix) {
+		return nil, ErrReservedClientOrderID
+	}
+	in.Symbol = symbol
+	order, err := newOrder(userID, in)
+	if err != nil {
+		return nil, err
+	}
+	order.GroupRole = role
+	return order, nil
+}
+
+// isTakeProfit 判断订单类型能否作为止盈条件单
+func isTakeProfit(typ string) bool {
+	return typ == model.OrderTypeTakeProfit || typ == model.OrderTypeTakeProfitLimit
+}
+
+// isStopLoss 判断订单类型能否作为止损条件单
+func isStopLoss(typ string) bool {
+	return typ == model.OrderTypeStop || typ == model.OrderTypeStopLimit || typ == model.OrderTypeTrailingStop
+}
+
+// groupEffects 撮合事件对订单组内条件单的影响，事务提交后同步到条件单簿
+type groupEffects struct {
+	activated []model.Order
+	closed    []model.Order
+}
+
+// applyGroupEffects 将生效的条件单加入条件单簿，移除已撤销的条件单
+func (s *OrderService) applyGroupEffects(fx groupEffects) {
+	for _, o := range fx.activated {
+		s.triggers.add(o)
+	}
+	for _, o := range fx.closed {
+		s.triggers.remove(o.Symbol, o.ID)
+	}
+}
+
+// syncGroup 按订单簿中的组内订单的执行情况调整组内其他订单，与撮合结果在同一事务和定序步骤中完成：
+// 括号单的止盈止损订单数量等于入场单的已成交数量，入场单有成交后生效，未成交即结束时撤销；
+// 二选一的条件单数量等于限价单的剩余数量，限价单成交完毕或被撤销时撤销。
+// 已触发但尚未提交子订单的条件单正在等待撤销本订单，只调整数量。
+func (s *OrderService) syncGroup(ctx context.Context, leg *model.Order, fx *groupEffects) error {
+	siblings, err := s.orders.ListByGroup(ctx, *leg.GroupID)
+	if err != nil {
+		return err
+	}
+	entry := leg.GroupRole == model.GroupRoleEntry
+	qty := leg.RemainingQuantity()
+	if entry {
+		qty = leg.FilledQuantity
+	}
+	for i := range siblings {
+		sib := &siblings[i]
+		if sib.ID == leg.ID {
+			continue
+		}
+		switch {
+		case sib.Status == model.OrderStatusTriggered:
+			if qty.IsPositive() {
+				if _, err := s.orders.Resize(ctx, sib.ID, qty); err != nil {
+					return err
+				}
+			}
+		case !sib.IsOpen():
+		case !leg.IsOpen() && (!entry || qty.IsZero()):
+			ok, err := s.orders.UpdateExecution(ctx, sib.ID, sib.FilledQuantity, sib.HeldAmount, model.OrderStatusCanceled)
+			if err != nil {
+				return err
+			}
+			if ok {
+				fx.closed = append(fx.closed, *sib)
+			}
+		case qty.IsZero():
+			// 入场单尚未成交
+		default:
+			ok, err := s.orders.Resize(ctx, sib.ID, qty)
+			if err != nil || !ok {
+				return err
+			}
+			if sib.Status != model.OrderStatusPending {
+				continue
+			}
+			if ok, err = s.orders.TransitionStatus(ctx, sib.ID, []string{model.OrderStatusPending}, model.OrderStatusUntriggered); err != nil {
+				return err
+			}
+			if ok {
+				sib.Quantity, sib.Status = qty, model.OrderStatusUntriggered
+				fx.activated = append(fx.activated, *sib)
+			}
+		}
+	}
+	return nil
+}
+
+// closeOffBookSiblings 撤销组内其他未结束且不在订单簿中的订单，返回被撤销的订单
+func (s *OrderService) closeOffBookSiblings(ctx context.Context, o *model.Order) ([]model.Order, error) {
+	siblings, err := s.orders.ListByGroup(ctx, *o.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	var closed []model.Order
+	for _, sib := range siblings {
+		if sib.ID == o.ID || !sib.IsOpen() || sib.OnBook() {
+			continue
+		}
+		ok, err := s.orders.UpdateExecution(ctx, sib.ID, sib.FilledQuantity, sib.HeldAmount, model.OrderStatusCanceled)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			closed = append(closed, sib)
+		}
+	}
+	return closed, nil
+}
+
+// cancelBookSiblings 撤销组内仍在订单簿中的其他订单，其撮合结果在同一定序步骤中调整本订单的数量。
+// 二选一的限价单已全部成交或被拒绝时返回false；括号单的入场单已结束不影响止盈止损订单
+func (s *OrderService) cancelBookSiblings(ctx context.Context, o *model.Order) (bool, error) {
+	siblings, err := s.orders.ListByGroup(ctx, *o.GroupID)
+	if err != nil {
+		return false, err
+	}
+	for i := range siblings {
+		sib := &siblings[i]
+		if sib.ID == o.ID || sib.IsConditional() {
+			continue
+		}
+		if sib.OnBook() {
+			if err := s.cancelOnBook(ctx, sib); err != nil && !errors.Is(err, ErrOrderNotOpen) {
+				return false, err
+			}
+		}
+		if sib.GroupRole == model.GroupRoleEntry {
+			continue
+		}
+		current, err := s.reload(ctx, sib.ID)
+		if err != nil {
+			return false, err
+		}
+		if current.Status != model.OrderStatusCanceled {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// cancelGroup 撤销订单组：先通过定序器撤销组内仍在订单簿中的订单，组内等待触发的订单在同一定序步骤中随之撤销，
+// 再撤销其余仍未结束的订单，如入场单已有成交后生效的止盈止损订单。组内订单已全部结束时返回ErrOrderNotOpen
+func (s *OrderService) cancelGroup(ctx context.Context, groupID uint) error {
+	legs, err := s.orders.ListByGroup(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	canceled := false
+	for i := range legs {
+		if !legs[i].OnBook() {
+			continue
+		}
+		err := s.cancelOnBook(ctx, &legs[i])
+		if errors.Is(err, ErrOrderNotOpen) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		canceled = true
+	}
+
+	if legs, err = s.orders.ListByGroup(ctx, groupID); err != nil {
+		return err
+	}
+	for i := range legs {
+		if !legs[i].IsOpen() || legs[i].OnBook() {
+			continue
+		}
+		err := s.cancelOffBook(ctx, &legs[i])
+		if errors.Is(err, ErrOrderNotOpen) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		canceled = true
+	}
+	if !canceled {
+		return ErrOrderNotOpen
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"awesome-trade/src/internal/database"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+)
+
+// RBAC服务错误
+var ErrRoleNotFound = errors.New("role not found")
+
+// defaultPermissions 内置权限及说明
+var defaultPermissions = []model.Permission{
+	{Code: model.PermUsersRead, Description: "查看用户"},
+	{Code: model.PermUsersWrite, Description: "创建、修改和删除用户"},
+	{Code: model.PermRolesAssign, Description: "为用户分配角色"},
+	{Code: model.PermOrdersReadAny, Description: "查看任意用户的订单"},
+	{Code: model.PermOrdersCancelAny, Description: "撤销任意用户的订单"},
+	{Code: model.PermWithdrawalsApprove, Description: "审批提现"},
+	{Code: model.PermMarketsManage, Description: "管理交易对"},
+	{Code: model.PermLedgerRead, Description: "查看账本并对账"},
+	{Code: model.PermRiskManage, Description: "管理风控限额和用户等级"},
+	{Code: model.PermFeesManage, Description: "管理手续费等级、交易对费率和免费活动"},
+}
+
+// defaultRoles 内置角色及其权限
+var defaultRoles = []struct {
+	Name        string
+	Description string
+	Permissions []string
+}{
+	{
+		Name:        model.RoleAdmin,
+		Description: "平台管理员",
+		Permissions: []string{
+			model.PermUsersRead, model.PermUsersWrite, model.PermRolesAssign,
+			model.PermOrdersReadAny, model.PermOrdersCancelAny,
+			model.PermWithdrawalsApprove, model.PermMarketsManage, model.PermLedgerRead,
+			model.PermRiskManage, model.PermFeesManage,
+		},
+	},
+	{
+		Name:        model.RoleSupport,
+		Description: "客服",
+		Permissions: []string{model.PermUsersRead, model.PermOrdersReadAny, model.PermLedgerRead},
+	},
+	{
+		Name:        model.RoleTrader,
+		Description: "普通交易用户",
+	},
+}
+
+// RBACService 角色权限服务
+type RBACService struct {
+	*BaseService
+	roles *repository.RoleRepository
+	users *repository.UserRepository
+}
+
+// NewRBACService 创建角色权限服务实例
+func NewRBACService(tx *database.TxManager, roles *repository.RoleRepository, users *repository.UserRepository) *RBACService {
+	return &RBACService{
+		BaseService: NewBaseService(tx),
+		roles:       roles,
+		users:       users,
+	}
+}
+
+// Seed 在一个事务中写入内置权限和角色，可重复执行
+func (s *RBACService) Seed(ctx context.Context) error {
+	return s.Transaction(ctx, s.seed)
+}
+
+// seed 写入内置权限和角色
+func (s *RBACService) seed(ctx context.Context) error {
+	perms := make(map[string]model.Permission, len(defaultPermissions))
+	for _, p := range defaultPermissions {
+		perm := p
+		if err := s.roles.UpsertPermission(ctx, &perm); err != nil {
+			return err
+		}
+		perms[perm.Code] = perm
+	}
+
+	for _, r := range defaultRoles {
+		role := model.Role{Name: r.Name, Description: r.Description}
+		rolePerms := make([]model.Permission, 0, len(r.Permissions))
+		for _, code := range r.Permissions {
+			rolePerms = append(rolePerms, perms[code])
+		}
+		if err := s.roles.UpsertRole(ctx, &role, rolePerms); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListRoles 查询全部角色
+func (s *RBACService) ListRoles(ctx context.Context) ([]model.Role, error) {
+	return s.roles.ListRoles(ctx)
+}
+
+// UserRoles 查询用户的角色
+func (s *RBACService) UserRoles(ctx context.Context, userID uint) ([]model.Role, error) {
+	if err := s.ensureUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.roles.RolesForUser(ctx, userID)
+}
+
+// Permissions 查询用户拥有的权限集合
+func (s *RBACService) Permissions(ctx context.Context, userID uint) (map[string]bool, error) {
+	codes, err := s.roles.PermissionCodesForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		set[code] = true
+	}
+	return set, nil
+}
+
+// AssignRole 为用户分配角色
+func (s *RBACService) AssignRole(ctx context.Context, userID uint, roleName string) error {
+	role, err := s.resolve(ctx, userID, roleName)
+	if err != nil {
+		return err
+	}
+	return s.roles.AssignRole(ctx, userID, role.ID)
+}
+
+// RevokeRole 撤销用户的角色
+func (s *RBACService) RevokeRole(ctx context.Context, userID uint, roleName string) error {
+	role, err := s.resolve(ctx, userID, roleName)
+	if err != nil {
+		return err
+	}
+	return s.roles.RevokeRole(ctx, userID, role.ID)
+}
+
+// AssignRoleByUsername 按用户名分配角色，用于启动时初始化管理员
+func (s *RBACService) AssignRoleByUsername(ctx context.Context, username, roleName string) error {
+	user, err := s.users.GetByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	return s.AssignRole(ctx, user.ID, roleName)
+}
+
+// resolve 校验用户存在并查找角色
+func (s *RBACService) resolve(ctx context.Context, userID uint, roleName string) (*model.Role, error) {
+	if err := s.ensureUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	role, err := s.roles.GetRoleByName(ctx, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, ErrRoleNotFound
+	}
+	return role, nil
+}
+
+// ensureUser 校验用户存在
+func (s *RBACService) ensureUser(ctx context.Context, userID uint) error {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	return nil
+}
@@ -0,0 +1,335 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"awesome-trade/src/internal/config"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/pkg/decimal"
+)
+
+// 下单风控拒绝原因，每种原因对应一个业务错误码
+var (
+	ErrRiskMaxQuantity   = errors.New("order quantity exceeds the risk limit")
+	ErrRiskMaxNotional   = errors.New("order notional exceeds the risk limit")
+	ErrRiskMaxOpenOrders = errors.New("too many open orders in this market")
+	ErrRiskPriceBand     = errors.New("order price is outside the allowed band around the last trade price")
+	ErrRiskDailyLoss     = errors.New("daily loss limit reached")
+)
+
+// 风控限额管理错误
+var (
+	ErrRiskLimitNotFound = errors.New("risk limit not found")
+	ErrInvalidRiskLimit  = errors.New("risk limits must not be negative and the price band must not exceed 1")
+	ErrInvalidUserTier   = errors.New("invalid user tier")
+)
+
+var tierPattern = regexp.MustCompile(`^[a-z0-9_]{1,16}$`)
+
+// defaultLossAsset 默认的当日亏损计量资产
+const defaultLossAsset = "USDT"
+
+// RiskLimitInput 风控限额参数，Symbol为空表示该等级在所有交易对上的默认限额
+type RiskLimitInput struct {
+	Tier             string
+	Symbol           string
+	MaxOrderQuantity decimal.Decimal
+	MaxOrderNotional decimal.Decimal
+	MaxOpenOrders    int
+	PriceBand        decimal.Decimal
+	DailyLossLimit   decimal.Decimal
+}
+
+// riskKey 风控限额缓存的键
+type riskKey struct {
+	tier   string
+	symbol string
+}
+
+// RiskService 下单前风控。限额按用户等级和交易对配置，缓存在内存中，
+// 管理接口修改后立即重新加载，另可定期加载以同步其他实例的修改，无需重启。
+type RiskService struct {
+	limits     *repository.RiskLimitRepository
+	users      *repository.UserRepository
+	markets    *repository.MarketRepository
+	orders     *repository.OrderRepository
+	ledger     *repository.LedgerRepository
+	marketData *MarketDataService
+	lossAsset  string
+
+	mu    sync.RWMutex
+	cache map[riskKey]model.RiskLimit
+}
+
+// NewRiskService 创建风控服务实例，最新成交价取自行情服务
+func NewRiskService(limits *repository.RiskLimitRepository, users *repository.UserRepository, markets *repository.MarketRepository, orders *repository.OrderRepository, ledger *repository.LedgerRepository, marketData *MarketDataService, cfg config.RiskConfig) *RiskService {
+	lossAsset := strings.ToUpper(cfg.LossAsset)
+	if lossAsset == "" {
+		lossAsset = defaultLossAsset
+	}
+	return &RiskService{
+		limits:     limits,
+		users:      users,
+		markets:    markets,
+		orders:     orders,
+		ledger:     ledger,
+		marketData: marketData,
+		lossAsset:  lossAsset,
+		cache:      make(map[riskKey]model.RiskLimit),
+	}
+}
+
+// Reload 从数据库重新加载全部风控限额
+func (s *RiskService) Reload(ctx context.Context) error {
+	limits, err := s.limits.ListAll(ctx)
+	if err != nil {
+		return err
+	}
+	cache := make(map[riskKey]model.RiskLimit, len(limits))
+	for _, l := range limits {
+		cache[riskKey{tier: l.Tier, symbol: l.Symbol}] = l
+	}
+
+	s.mu.Lock()
+	s.cache = cache
+	s.mu.Unlock()
+	return nil
+}
+
+// Run 每隔interval重新加载风控限额，直到ctx取消
+func (s *RiskService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Reload(ctx); err != nil {
+				log.Printf("Failed to reload risk limits: %v", err)
+			}
+		}
+	}
+}
+
+// Limit 返回等级在交易对上生效的限额：优先使用该交易对的限额，否则使用该等级的默认限额，都没有时不限制
+func (s *RiskService) Limit(tier, symbol string) (model.RiskLimit, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if l, ok := s.cache[riskKey{tier: tier, symbol: symbol}]; ok {
+		return l, true
+	}
+	l, ok := s.cache[riskKey{tier: tier}]
+	return l, ok
+}
+
+// Check 校验订单是否满足用户等级在交易对上的限额，依次检查单笔数量、单笔金额、价格偏离、挂单数和当日亏损。
+// 市价单按最新成交价估算金额；交易对尚无成交时不检查价格偏离和市价单金额。
+func (s *RiskService) Check(ctx context.Context, market *model.Market, order *model.Order) error {
+	user, err := s.users.GetByID(ctx, order.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	limit, ok := s.Limit(user.Tier, market.Symbol)
+	if !ok {
+		return nil
+	}
+
+	if limit.MaxOrderQuantity.IsPositive() && order.Quantity.GreaterThan(limit.MaxOrderQuantity) {
+		return ErrRiskMaxQuantity
+	}
+
+	last, hasLast, err := s.marketData.LastPrice(ctx, market.Symbol)
+	if err != nil {
+		return err
+	}
+	price, hasPrice := order.Price, order.Type == model.OrderTypeLimit
+	if !hasPrice {
+		price, hasPrice = last, hasLast
+	}
+	if limit.MaxOrderNotional.IsPositive() && hasPrice && price.Mul(order.Quantity).GreaterThan(limit.MaxOrderNotional) {
+		return ErrRiskMaxNotional
+	}
+	if limit.PriceBand.IsPositive() && order.Type == model.OrderTypeLimit && hasLast &&
+		order.Price.Sub(last).Abs().GreaterThan(last.Mul(limit.PriceBand)) {
+		return ErrRiskPriceBand
+	}
+
+	// GTC和只做挂单的限价单可能留在订单簿中
+	if limit.MaxOpenOrders > 0 && order.Type == model.OrderTypeLimit &&
+		(order.TimeInForce == model.TimeInForceGTC || order.TimeInForce == model.TimeInForcePostOnly) {
+		count, err := s.orders.CountOpen(ctx, order.UserID, market.Symbol)
+		if err != nil {
+			return err
+		}
+		if count >= int64(limit.MaxOpenOrders) {
+			return ErrRiskMaxOpenOrders
+		}
+	}
+
+	if limit.DailyLossLimit.IsPositive() {
+		pnl, err := s.DailyPnL(ctx, order.UserID)
+		if err != nil {
+			return err
+		}
+		if pnl.Neg().GreaterThanOrEqual(limit.DailyLossLimit) {
+			return ErrRiskDailyLoss
+		}
+	}
+	return nil
+}
+
+// DailyPnL 计算用户当日（UTC）在全部交易对上的盈亏，以亏损计量资产计：
+// 当日成交（含手续费）带来的各资产变动，按各资产对计量资产的最新成交价估值后求和。
+// 无法估值的资产不计入并记录警告
+func (s *RiskService) DailyPnL(ctx context.Context, userID uint) (decimal.Decimal, error) {
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	sums, err := s.ledger.SumUserPostings(ctx, userID, model.EntryTypeTrade, tradeReferenceRoot, since)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	pnl := decimal.Zero
+	for asset, amount := range sums {
+		if amount.IsZero() {
+			continue
+		}
+		price, ok, err := s.lossPrice(ctx, asset)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		if !ok {
+			log.Printf("Warning: no %s price for %s, its daily P&L of user %d is not counted", s.lossAsset, asset, userID)
+			continue
+		}
+		pnl = pnl.Add(amount.Mul(price))
+	}
+	return pnl, nil
+}
+
+// lossPrice 返回资产折算为亏损计量资产的价格：优先取资产对计量资产交易对的最新成交价，
+// 其次取计量资产对该资产交易对最新成交价的倒数，都没有成交时ok为false
+func (s *RiskService) lossPrice(ctx context.Context, asset string) (decimal.Decimal, bool, error) {
+	if asset == s.lossAsset {
+		return decimal.NewFromInt(1), true, nil
+	}
+	for _, inverse := range []bool{false, true} {
+		symbol := asset + "_" + s.lossAsset
+		if inverse {
+			symbol = s.lossAsset + "_" + asset
+		}
+		market, err := s.markets.GetBySymbol(ctx, symbol)
+		if err != nil {
+			return decimal.Zero, false, err
+		}
+		if market == nil {
+			continue
+		}
+		price, ok, err := s.marketData.LastPrice(ctx, symbol)
+		if err != nil {
+			return decimal.Zero, false, err
+		}
+		if !ok || !price.IsPositive() {
+			continue
+		}
+		if inverse {
+			price = decimal.NewFromInt(1).Div(price)
+		}
+		return price, true, nil
+	}
+	return decimal.Zero, false, nil
+}
+
+// ListLimits 查询全部风控限额
+func (s *RiskService) ListLimits(ctx context.Context) ([]model.RiskLimit, error) {
+	return s.limits.ListAll(ctx)
+}
+
+// SetLimit 设置等级在交易对上的限额，已存在时覆盖，立即生效
+func (s *RiskService) SetLimit(ctx context.Context, in RiskLimitInput) (*model.RiskLimit, error) {
+	tier, symbol, err := s.limitKey(ctx, in.Tier, in.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if in.MaxOrderQuantity.IsNegative() || in.MaxOrderNotional.IsNegative() || in.MaxOpenOrders < 0 ||
+		in.PriceBand.IsNegative() || in.PriceBand.GreaterThan(decimal.NewFromInt(1)) || in.DailyLossLimit.IsNegative() {
+		return nil, ErrInvalidRiskLimit
+	}
+
+	limit := &model.RiskLimit{
+		Tier:             tier,
+		Symbol:           symbol,
+		MaxOrderQuantity: in.MaxOrderQuantity,
+		MaxOrderNotional: in.MaxOrderNotional,
+		MaxOpenOrders:    in.MaxOpenOrders,
+		PriceBand:        in.PriceBand,
+		DailyLossLimit:   in.DailyLossLimit,
+	}
+	if err := s.limits.Upsert(ctx, limit); err != nil {
+		return nil, err
+	}
+	if err := s.Reload(ctx); err != nil {
+		return nil, err
+	}
+	stored, _ := s.Limit(tier, symbol)
+	return &stored, nil
+}
+
+// DeleteLimit 删除等级在交易对上的限额，立即生效
+func (s *RiskService) DeleteLimit(ctx context.Context, tier, symbol string) error {
+	tier, symbol, err := s.limitKey(ctx, tier, symbol)
+	if err != nil {
+		return err
+	}
+	deleted, err := s.limits.DeleteByKey(ctx, tier, symbol)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrRiskLimitNotFound
+	}
+	return s.Reload(ctx)
+}
+
+// SetUserTier 修改用户的风控等级，下一笔订单起生效
+func (s *RiskService) SetUserTier(ctx context.Context, userID uint, tier string) error {
+	if !tierPattern.MatchString(tier) {
+		return ErrInvalidUserTier
+	}
+	updated, err := s.users.SetTier(ctx, userID, tier)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// limitKey 校验并规范化限额的等级和交易对，交易对须已存在
+func (s *RiskService) limitKey(ctx context.Context, tier, symbol string) (string, string, error) {
+	if !tierPattern.MatchString(tier) {
+		return "", "", ErrInvalidUserTier
+	}
+	if strings.TrimSpace(symbol) == "" {
+		return tier, "", nil
+	}
+	market, err := s.markets.GetBySymbol(ctx, normalizeSymbol(symbol))
+	if err != nil {
+		return "", "", err
+	}
+	if market == nil {
+		return "", "", ErrMarketNotFound
+	}
+	return tier, market.Symbol, nil
+}
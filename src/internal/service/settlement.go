@@ -0,0 +1,347 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"awesome-trade/src/internal/matching"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/sequencer"
+	"awesome-trade/src/pkg/decimal"
+)
+
+// Applied 返回交易对已结算的最大序号，定序器重启时从日志重放其后的命令交给Apply
+func (s *OrderService) Applied(symbol string) (uint64, error) {
+	return s.orders.GetSettledSeq(context.Background(), symbol)
+}
+
+// errSettlementRecord 结算所需的订单或交易对记录不存在
+var errSettlementRecord = errors.New("settlement record not found")
+
+// settlementFailures 重试无法解决的结算错误：记录缺失或分录违反账本约束
+var settlementFailures = []error{errSettlementRecord, ErrInsufficientBalance, ErrUnbalancedEntry, ErrInvalidAmount}
+
+// Apply 在一个事务中根据撮合结果结算成交资金、更新订单的成交数量和状态，解冻已结束订单的剩余资金，
+// 调整或撤销订单组内的其他订单，并保存已结算的序号。分录按业务引用幂等，订单写入的是累计值，重放同一事件结果不变。
+// 定序器在独立的消费协程中按序号顺序调用，不阻塞撮合：临时错误重试同一事件，记录缺失或违反账本约束的错误
+// 标记为永久错误，由定序器立即交给Park搁置。
+func (s *OrderService) Apply(ev sequencer.Event) error {
+	var fx groupEffects
+	err := s.Transaction(context.Background(), func(ctx context.Context) error {
+		if err := s.applyResult(ctx, ev.Symbol, ev.Result, &fx); err != nil {
+			return err
+		}
+		return s.orders.SetSettledSeq(ctx, ev.Symbol, ev.Command.Seq)
+	})
+	for _, target := range settlementFailures {
+		if errors.Is(err, target) {
+			return sequencer.Permanent(err)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	s.applyGroupEffects(fx)
+	return nil
+}
+
+// Park 记录无法结算的事件并保存其序号，该交易对随后继续结算后续事件。事件中的成交不记账，
+// 涉及的订单保持结算前的状态，冻结的资金不解冻也不转移，等待核对后人工处理；等待该命令的下单或撤单请求返回错误
+func (s *OrderService) Park(ev sequencer.Event, cause error) error {
+	command, err := json.Marshal(ev.Command)
+	if err != nil {
+		return err
+	}
+	result, err := json.Marshal(ev.Result)
+	if err != nil {
+		return err
+	}
+	return s.Transaction(context.Background(), func(ctx context.Context) error {
+		err := s.orders.CreateSettlementFailure(ctx, &model.SettlementFailure{
+			Symbol:  ev.Symbol,
+			Seq:     ev.Command.Seq,
+			Command: string(command),
+			Result:  string(result),
+			Error:   cause.Error(),
+		})
+		if err != nil {
+			return err
+		}
+		return s.orders.SetSettledSeq(ctx, ev.Symbol, ev.Command.Seq)
+	})
+}
+
+// applyResult 将一次下单或撤单的撮合结果写入订单和账本
+func (s *OrderService) applyResult(ctx context.Context, symbol string, res *matching.Result, fx *groupEffects) error {
+	// 引擎拒绝重复订单或未知订单时不影响已有订单
+	if res.Reason == matching.ReasonDuplicateOrder || res.Reason == matching.ReasonUnknownOrder {
+		return nil
+	}
+
+	touched := make(map[uint]*model.Order)
+	load := func(id uint64) (*model.Order, error) {
+		if o, ok := touched[uint(id)]; ok {
+			return o, nil
+		}
+		o, err := s.orders.FindByID(ctx, uint(id))
+		if err != nil {
+			return nil, err
+		}
+		if o == nil {
+			return nil, fmt.Errorf("%w: order %d", errSettlementRecord, id)
+		}
+		touched[o.ID] = o
+		return o, nil
+	}
+
+	taker, err := load(res.OrderID)
+	if err != nil {
+		return err
+	}
+	var market *model.Market
+	if len(res.Trades) > 0 {
+		if market, err = s.markets.GetBySymbol(ctx, symbol); err != nil {
+			return err
+		}
+		if market == nil {
+			return fmt.Errorf("%w: market %s", errSettlementRecord, symbol)
+		}
+	}
+	for _, t := range res.Trades {
+		maker, err := load(t.MakerOrderID)
+		if err != nil {
+			return err
+		}
+		if err := s.settleTrade(ctx, market, t, taker, maker); err != nil {
+			return err
+		}
+		maker.FilledQuantity = maker.Quantity.Sub(fromUnits(t.MakerRemaining))
+		maker.Status = model.OrderStatusPartiallyFilled
+		if t.MakerRemaining == 0 {
+			maker.Status = model.OrderStatusFilled
+		}
+	}
+	taker.FilledQuantity = fromUnits(res.Filled)
+	taker.Status = res.Status.String()
+
+	ids := make([]uint, 0, len(touched))
+	for id := range touched {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		o := touched[id]
+		if !o.IsOpen() {
+			if err := s.releaseHeld(ctx, o); err != nil {
+				return err
+			}
+		}
+		if _, err := s.orders.UpdateExecution(ctx, o.ID, o.FilledQuantity, o.HeldAmount, o.Status); err != nil {
+			return err
+		}
+	}
+	for _, id := range ids {
+		if o := touched[id]; o.GroupID != nil {
+			if err := s.syncGroup(ctx, o, fx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// settleTrade 结算一笔成交：买方冻结的计价资产转给卖方，卖方冻结的基础资产转给买方，
+// 双方按各自的费率从收到的资产中扣除手续费记入平台收入账户，返佣则从收入账户支出。
+// 成交额向零舍入到计价资产的小数位数；限价买单按委托价向上舍入冻结，成交价更优或舍入产生的差额随成交解冻。
+func (s *OrderService) settleTrade(ctx context.Context, market *model.Market, t matching.Trade, taker, maker *model.Order) error {
+	symbol, base, quote := market.Symbol, market.BaseAsset, market.QuoteAsset
+	buy, sell := taker, maker
+	if t.TakerSide == matching.Sell {
+		buy, sell = maker, taker
+	}
+
+	scales := s.ledger.Scales()
+	qty := fromUnits(t.Quantity)
+	cost := tradeCost(scales, market, t)
+	buyHold := cost
+	if buy.Type == model.OrderTypeLimit {
+		buyHold = decimal.Min(scales.Quantize(quote, buy.Price.Mul(qty), decimal.RoundCeil), buy.HeldAmount)
+	}
+
+	var postings []Posting
+	for _, p := range []Posting{
+		{UserID: buy.UserID, Kind: model.AccountKindSpot, Asset: quote, Bucket: model.BucketHeld, Amount: buyHold.Neg()},
+		{UserID: sell.UserID, Kind: model.AccountKindSpot, Asset: quote, Bucket: model.BucketAvailable, Amount: cost},
+		{UserID: sell.UserID, Kind: model.AccountKindSpot, Asset: base, Bucket: model.BucketHeld, Amount: qty.Neg()},
+		{UserID: buy.UserID, Kind: model.AccountKindSpot, Asset: base, Bucket: model.BucketAvailable, Amount: qty},
+		{UserID: buy.UserID, Kind: model.AccountKindSpot, Asset: quote, Bucket: model.BucketAvailable, Amount: buyHold.Sub(cost)},
+	} {
+		// 舍入后为零的成交额或没有差额时不记账
+		if !p.Amount.IsZero() {
+			postings = append(postings, p)
+		}
+	}
+
+	fills := make([]model.Fill, 0, 2)
+	for _, side := range []struct {
+		order     *model.Order
+		liquidity string
+	}{{taker, model.LiquidityTaker}, {maker, model.LiquidityMaker}} {
+		fill, err := s.newFill(ctx, market, t, cost, side.order, side.liquidity)
+		if err != nil {
+			return err
+		}
+		fills = append(fills, fill)
+		if !fill.Fee.IsZero() {
+			postings = append(postings,
+				Posting{UserID: fill.UserID, Kind: model.AccountKindSpot, Asset: fill.FeeAsset, Bucket: model.BucketAvailable, Amount: fill.Fee.Neg()},
+				Posting{UserID: model.SystemUserID, Kind: model.AccountKindRevenue, Asset: fill.FeeAsset, Bucket: model.BucketAvailable, Amount: fill.Fee},
+			)
+		}
+	}
+
+	posted, err := s.ledger.Post(ctx, Entry{
+		Type:      model.EntryTypeTrade,
+		Reference: tradeReference(symbol, t.ID),
+		Memo:      fmt.Sprintf("order %d x %d", taker.ID, maker.ID),
+		Postings:  postings,
+	})
+	if err != nil {
+		return err
+	}
+	// 分录已存在说明该成交已结算过，订单的冻结金额也已扣减
+	if !posted {
+		return nil
+	}
+	buy.HeldAmount = buy.HeldAmount.Sub(buyHold)
+	sell.HeldAmount = sell.HeldAmount.Sub(qty)
+	return s.fees.Record(ctx, fills)
+}
+
+// newFill 按用户当前的费率生成订单在一笔成交中的成交明细，cost为已舍入的成交额，
+// 手续费以订单收到的资产计并舍入到该资产的小数位数
+func (s *OrderService) newFill(ctx context.Context, market *model.Market, t matching.Trade, cost decimal.Decimal, order *model.Order, liquidity string) (model.Fill, error) {
+	rates, err := s.fees.Rates(ctx, order.UserID, market, time.Now())
+	if err != nil {
+		return model.Fill{}, err
+	}
+	rate := rates.TakerFeeRate
+	if liquidity == model.LiquidityMaker {
+		rate = rates.MakerFeeRate
+	}
+
+	price, qty := fromUnits(t.Price), fromUnits(t.Quantity)
+	received, asset := qty, market.BaseAsset
+	if order.Side == model.SideSell {
+		received, asset = cost, market.QuoteAsset
+	}
+	return model.Fill{
+		UserID:        order.UserID,
+		OrderID:       order.ID,
+		Symbol:        market.Symbol,
+		TradeID:       t.ID,
+		Side:          order.Side,
+		Liquidity:     liquidity,
+		Price:         price,
+		Quantity:      qty,
+		QuoteQuantity: cost,
+		Fee:           feeAmount(received, rate, s.ledger.Scales().Of(asset)),
+		FeeAsset:      asset,
+	}, nil
+}
+
+// holdAmount 计算下单需冻结的金额：卖单冻结数量，限价买单冻结价格乘数量并向上舍入到计价资产的小数位数，
+// 市价买单冻结全部可用计价资产，引擎按冻结金额限制成交额
+func (s *OrderService) holdAmount(ctx context.Context, order *model.Order) (decimal.Decimal, error) {
+	_, quote, _ := splitSymbol(order.Symbol)
+	switch {
+	case order.Side == model.SideSell:
+		return order.Quantity, nil
+	case order.Type == model.OrderTypeLimit:
+		return s.ledger.Scales().Quantize(quote, order.Price.Mul(order.Quantity), decimal.RoundCeil), nil
+	}
+	account, err := s.ledger.Account(ctx, order.UserID, quote)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	// 引擎中的资金上限为engineScale位小数，超出部分不冻结
+	return account.Available.Truncate(engineScale), nil
+}
+
+// hold 冻结新订单的资金
+func (s *OrderService) hold(ctx context.Context, order *model.Order) error {
+	if !order.HeldAmount.IsPositive() {
+		return ErrInsufficientBalance
+	}
+	base, quote, _ := splitSymbol(order.Symbol)
+	_, err := s.ledger.Hold(ctx, order.UserID, order.HoldAsset(base, quote), order.HeldAmount, orderReference(order.ID))
+	return err
+}
+
+// closeOrder 直接结束仍为挂单的订单并解冻剩余资金，订单已结束时返回false
+func (s *OrderService) closeOrder(ctx context.Context, id uint, status string) (bool, error) {
+	var closed bool
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		order, err := s.orders.FindByID(ctx, id)
+		if err != nil || order == nil || !order.IsOpen() {
+			return err
+		}
+		order.Status = status
+		if err := s.releaseHeld(ctx, order); err != nil {
+			return err
+		}
+		closed, err = s.orders.UpdateExecution(ctx, order.ID, order.FilledQuantity, order.HeldAmount, order.Status)
+		return err
+	})
+	return closed, err
+}
+
+// releaseHeld 解冻订单剩余的冻结资金
+func (s *OrderService) releaseHeld(ctx context.Context, order *model.Order) error {
+	if !order.HeldAmount.IsPositive() {
+		return nil
+	}
+	base, quote, _ := splitSymbol(order.Symbol)
+	if _, err := s.ledger.Release(ctx, order.UserID, order.HoldAsset(base, quote), order.HeldAmount, orderReference(order.ID)); err != nil {
+		return err
+	}
+	order.HeldAmount = decimal.Zero
+	return nil
+}
+
+// tradeCost 计算成交额，向零舍入到计价资产的小数位数
+func tradeCost(scales decimal.Scales, market *model.Market, t matching.Trade) decimal.Decimal {
+	return scales.Quantize(market.QuoteAsset, fromUnits(t.Price).Mul(fromUnits(t.Quantity)), decimal.RoundDown)
+}
+
+// splitSymbol 将交易对拆分为基础资产和计价资产，如BTC_USDT
+func splitSymbol(symbol string) (base, quote string, ok bool) {
+	base, quote, ok = strings.Cut(symbol, "_")
+	if !ok || base == "" || quote == "" || strings.Contains(quote, "_") {
+		return "", "", false
+	}
+	return base, quote, true
+}
+
+// orderReference 订单冻结和解冻分录的业务引用
+func orderReference(id uint) string {
+	return fmt.Sprintf("order:%d", id)
+}
+
+// tradeReference 成交结算分录的业务引用
+func tradeReference(symbol string, tradeID uint64) string {
+	return fmt.Sprintf("%s%d", tradeReferencePrefix(symbol), tradeID)
+}
+
+// tradeReferenceRoot 全部成交结算分录的业务引用前缀
+const tradeReferenceRoot = "trade:"
+
+// tradeReferencePrefix 交易对全部成交结算分录的业务引用前缀
+func tradeReferencePrefix(symbol string) string {
+	return tradeReferenceRoot + symbol + ":"
+}
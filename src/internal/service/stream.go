@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"awesome-trade/src/internal/matching"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/internal/sequencer"
+	"awesome-trade/src/internal/stream"
+	"awesome-trade/src/pkg/decimal"
+)
+
+// 成交的流动性方向
+const (
+	LiquidityMaker = "maker"
+	LiquidityTaker = "taker"
+)
+
+// DepthUpdate 深度增量，Quantity为该价位变化后的挂单总量，0表示价位被移除
+type DepthUpdate struct {
+	Symbol string       `json:"symbol"`
+	Bids   []DepthLevel `json:"bids"`
+	Asks   []DepthLevel `json:"asks"`
+}
+
+// Fill 用户自己订单的一笔成交
+type Fill struct {
+	TradeID   uint64          `json:"trade_id"`
+	Symbol    string          `json:"symbol"`
+	OrderID   uint            `json:"order_id"`
+	Side      string          `json:"side"`
+	Liquidity string          `json:"liquidity"`
+	Price     decimal.Decimal `json:"price"`
+	Quantity  decimal.Decimal `json:"quantity"`
+	Time      time.Time       `json:"time"`
+}
+
+// StreamService 将撮合事件转换为推送消息：公开频道推送深度增量、成交和当前K线，消息序号为定序器序号；
+// 私有频道在结算完成后推送订单状态、成交和余额变化，只为有订阅的用户读取订单和余额。
+type StreamService struct {
+	hub        *stream.Hub
+	orders     *repository.OrderRepository
+	ledger     *LedgerService
+	marketData *MarketDataService
+}
+
+// NewStreamService 创建推送服务实例，订阅撮合事件推送公开消息，订阅结算完成的事件推送私有消息。
+// 须在行情服务之后创建，以保证处理事件时K线已经更新。
+func NewStreamService(hub *stream.Hub, orders *repository.OrderRepository, ledger *LedgerService, marketData *MarketDataService, engine *sequencer.Manager) *StreamService {
+	s := &StreamService{
+		hub:        hub,
+		orders:     orders,
+		ledger:     ledger,
+		marketData: marketData,
+	}
+	engine.Subscribe(s.HandleEvent)
+	engine.SubscribeApplied(s.HandleSettled)
+	return s
+}
+
+// HandleEvent 在定序协程中发布一次撮合结果产生的公开消息，重放的事件也推进公开频道的序号
+func (s *StreamService) HandleEvent(ev sequencer.Event) {
+	if ev.Result == nil {
+		return
+	}
+	s.publishPublic(ev)
+}
+
+// HandleSettled 在结算完成后发布撮合结果产生的私有消息，重放的事件不发送
+func (s *StreamService) HandleSettled(ev sequencer.Event) {
+	res := ev.Result
+	if res == nil || ev.Replayed || res.Reason == matching.ReasonDuplicateOrder || res.Reason == matching.ReasonUnknownOrder {
+		return
+	}
+	if err := s.publishPrivate(context.Background(), ev); err != nil {
+		log.Printf("Failed to publish private updates of %s seq=%d: %v", ev.Symbol, ev.Command.Seq, err)
+	}
+}
+
+// publishPublic 发布深度增量、成交和受影响的K线
+func (s *StreamService) publishPublic(ev sequencer.Event) {
+	res := ev.Result
+	if len(res.Deltas) > 0 {
+		update := DepthUpdate{Symbol: ev.Symbol, Bids: []DepthLevel{}, Asks: []DepthLevel{}}
+		for _, d := range res.Deltas {
+			level := DepthLevel{Price: fromUnits(d.Price), Quantity: fromUnits(d.Quantity)}
+			if d.Side == matching.Buy {
+				update.Bids = append(update.Bids, level)
+			} else {
+				update.Asks = append(update.Asks, level)
+			}
+		}
+		s.hub.Publish(stream.DepthTopic(ev.Symbol), ev.Command.Seq, update)
+	}
+	if len(res.Trades) == 0 {
+		return
+	}
+
+	trades := make([]PublicTrade, len(res.Trades))
+	for i, t := range res.Trades {
+		trades[i] = publicTrade(t, ev.Command.Time)
+	}
+	s.hub.Publish(stream.TradesTopic(ev.Symbol), ev.Command.Seq, trades)
+	for interval := range model.KlineIntervals {
+		topic := stream.KlinesTopic(ev.Symbol, interval)
+		if k, ok := s.marketData.CurrentKline(ev.Symbol, interval); ok {
+			s.hub.Publish(topic, ev.Command.Seq, k)
+		}
+	}
+}
+
+// publishPrivate 向订单所属用户发布订单、成交和余额变化
+func (s *StreamService) publishPrivate(ctx context.Context, ev sequencer.Event) error {
+	res := ev.Result
+	ids := []uint{uint(res.OrderID)}
+	owners := map[uint]uint{uint(res.OrderID): uint(res.UserID)}
+	for _, t := range res.Trades {
+		if id := uint(t.MakerOrderID); owners[id] == 0 {
+			owners[id] = uint(t.MakerUserID)
+			ids = append(ids, id)
+		}
+	}
+
+	users := make(map[uint]struct{})
+	for _, id := range ids {
+		userID := owners[id]
+		users[userID] = struct{}{}
+		// 用户未订阅订单频道时不读取订单
+		if !s.hub.HasSubscribers(stream.PrivateTopic(stream.ChannelOrders, userID)) {
+			continue
+		}
+		order, err := s.orders.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if order != nil {
+			s.hub.Publish(stream.PrivateTopic(stream.ChannelOrders, userID), 0, order)
+		}
+	}
+
+	for _, t := range res.Trades {
+		price, qty := fromUnits(t.Price), fromUnits(t.Quantity)
+		s.publishUser(stream.ChannelFills, uint(t.TakerUserID), Fill{
+			TradeID: t.ID, Symbol: ev.Symbol, OrderID: uint(t.TakerOrderID), Side: t.TakerSide.String(),
+			Liquidity: LiquidityTaker, Price: price, Quantity: qty, Time: ev.Command.Time,
+		})
+		s.publishUser(stream.ChannelFills, uint(t.MakerUserID), Fill{
+			TradeID: t.ID, Symbol: ev.Symbol, OrderID: uint(t.MakerOrderID), Side: t.TakerSide.Opposite().String(),
+			Liquidity: LiquidityMaker, Price: price, Quantity: qty, Time: ev.Command.Time,
+		})
+		users[uint(t.TakerUserID)] = struct{}{}
+		users[uint(t.MakerUserID)] = struct{}{}
+	}
+
+	base, quote, _ := splitSymbol(ev.Symbol)
+	for _, userID := range sortedUsers(users) {
+		if !s.hub.HasSubscribers(stream.PrivateTopic(stream.ChannelBalances, userID)) {
+			continue
+		}
+		accounts := make([]model.Account, 0, 2)
+		for _, asset := range []string{base, quote} {
+			account, err := s.ledger.Account(ctx, userID, asset)
+			if err != nil {
+				return err
+			}
+			accounts = append(accounts, *account)
+		}
+		s.hub.Publish(stream.PrivateTopic(stream.ChannelBalances, userID), 0, accounts)
+	}
+	return nil
+}
+
+// publishUser 向用户的私有频道发布消息，用户未订阅时不发送
+func (s *StreamService) publishUser(channel string, userID uint, data interface{}) {
+	topic := stream.PrivateTopic(channel, userID)
+	if s.hub.HasSubscribers(topic) {
+		s.hub.Publish(topic, 0, data)
+	}
+}
+
+// sortedUsers 按用户ID排序，保证同一事件的推送顺序稳定
+func sortedUsers(users map[uint]struct{}) []uint {
+	ids := make([]uint, 0, len(users))
+	for id := range users {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -0,0 +1,493 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"awesome-trade/src/internal/chain"
+	"awesome-trade/src/internal/config"
+	"awesome-trade/src/internal/database"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/pkg/decimal"
+	"awesome-trade/src/pkg/utils"
+)
+
+// 充值提现服务错误
+var (
+	ErrTransferNotFound   = errors.New("transfer not found")
+	ErrInvalidTransition  = errors.New("transfer cannot move to the requested status")
+	ErrInvalidAsset       = errors.New("invalid asset")
+	ErrInvalidAddress     = errors.New("invalid address")
+	ErrInvalidTxHash      = errors.New("invalid transaction hash")
+	ErrDepositTxConflict  = errors.New("transaction hash was already reported for a different deposit")
+	ErrTransferInProgress = errors.New("transfer was changed by another operation")
+	ErrNoDepositAddress   = errors.New("no deposit address for this asset, request one first")
+)
+
+var (
+	assetPattern   = regexp.MustCompile(`^[A-Z0-9]{1,16}$`)
+	addressPattern = regexp.MustCompile(`^[A-Za-z0-9:_-]{8,128}$`)
+	txHashPattern  = regexp.MustCompile(`^[A-Za-z0-9]{8,128}$`)
+)
+
+// syncBatchSize 每轮轮询同步的最大转账数
+const syncBatchSize = 100
+
+// DepositInput 充值申报参数
+type DepositInput struct {
+	Asset  string
+	Amount decimal.Decimal
+	TxHash string
+}
+
+// WithdrawalInput 提现申请参数
+type WithdrawalInput struct {
+	Asset   string
+	Amount  decimal.Decimal
+	Address string
+}
+
+// TransferService 充值提现服务。转账按model中定义的状态机迁移，每次迁移都写入审计记录；
+// 提现申请时冻结资金，拒绝或失败时解冻，链上确认后从冻结余额出账。
+type TransferService struct {
+	*BaseService
+	transfers  *repository.TransferRepository
+	ledger     *LedgerService
+	chain      chain.Client
+	thresholds map[string]decimal.Decimal
+	scales     decimal.Scales
+}
+
+// NewTransferService 创建充值提现服务实例，无法解析的审批阈值视为未配置
+func NewTransferService(tx *database.TxManager, transfers *repository.TransferRepository, ledger *LedgerService, client chain.Client, cfg config.TransferConfig) *TransferService {
+	thresholds := make(map[string]decimal.Decimal, len(cfg.ApprovalThresholds))
+	for asset, value := range cfg.ApprovalThresholds {
+		threshold, err := decimal.NewFromString(value)
+		if err != nil {
+			log.Printf("Warning: ignoring invalid withdrawal approval threshold %s=%q", asset, value)
+			continue
+		}
+		thresholds[strings.ToUpper(asset)] = threshold
+	}
+	return &TransferService{
+		BaseService: NewBaseService(tx),
+		transfers:   transfers,
+		ledger:      ledger,
+		chain:       client,
+		thresholds:  thresholds,
+		scales:      ledger.Scales(),
+	}
+}
+
+// DepositAddress 获取用户在该资产上的充值地址，尚未生成时由链客户端生成并保存
+func (s *TransferService) DepositAddress(ctx context.Context, userID uint, asset string) (*model.DepositAddress, error) {
+	asset, err := normalizeAsset(asset)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.transfers.GetDepositAddress(ctx, userID, asset)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+	address, err := s.chain.NewAddress(ctx, asset, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.transfers.CreateDepositAddress(ctx, &model.DepositAddress{UserID: userID, Asset: asset, Address: address}); err != nil {
+		return nil, err
+	}
+	// 并发请求时以先保存的地址为准
+	return s.transfers.GetDepositAddress(ctx, userID, asset)
+}
+
+// RequestDeposit 申报一笔转入用户充值地址的链上交易，链上确认后按实际转入该地址的金额入账。
+// 用户须先获取该资产的充值地址。同一交易的重复申报返回已有记录，申报内容不一致时返回ErrDepositTxConflict。
+func (s *TransferService) RequestDeposit(ctx context.Context, userID uint, in DepositInput) (*model.Transfer, error) {
+	asset, err := normalizeAsset(in.Asset)
+	if err != nil {
+		return nil, err
+	}
+	if !in.Amount.IsPositive() || !s.scales.Fits(asset, in.Amount) {
+		return nil, ErrInvalidAmount
+	}
+	if !txHashPattern.MatchString(in.TxHash) {
+		return nil, ErrInvalidTxHash
+	}
+	address, err := s.transfers.GetDepositAddress(ctx, userID, asset)
+	if err != nil {
+		return nil, err
+	}
+	if address == nil {
+		return nil, ErrNoDepositAddress
+	}
+
+	transfer := &model.Transfer{
+		UserID:  userID,
+		Type:    model.TransferTypeDeposit,
+		Asset:   asset,
+		Amount:  in.Amount,
+		Address: address.Address,
+		TxHash:  &in.TxHash,
+		Status:  model.TransferStatusPending,
+	}
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		created, err := s.transfers.CreateIfAbsent(ctx, transfer)
+		if err != nil {
+			return err
+		}
+		if created {
+			return s.transfers.AddAudit(ctx, transfer, "", userID, "")
+		}
+		existing, err := s.transfers.GetByTxHash(ctx, model.TransferTypeDeposit, asset, in.TxHash)
+		if err != nil {
+			return err
+		}
+		if existing == nil || existing.UserID != userID || !existing.Amount.Equal(in.Amount) {
+			return ErrDepositTxConflict
+		}
+		transfer = existing
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// RequestWithdrawal 申请提现并冻结资金。金额不超过该资产审批阈值的提现自动批准并立即广播，
+// 否则等待管理员审批。广播失败时提现置为failed并解冻资金，不作为错误返回。
+func (s *TransferService) RequestWithdrawal(ctx context.Context, userID uint, in WithdrawalInput) (*model.Transfer, error) {
+	asset, err := normalizeAsset(in.Asset)
+	if err != nil {
+		return nil, err
+	}
+	if !in.Amount.IsPositive() || !s.scales.Fits(asset, in.Amount) {
+		return nil, ErrInvalidAmount
+	}
+	if !addressPattern.MatchString(in.Address) {
+		return nil, ErrInvalidAddress
+	}
+
+	transfer := &model.Transfer{
+		UserID:  userID,
+		Type:    model.TransferTypeWithdrawal,
+		Asset:   asset,
+		Amount:  in.Amount,
+		Address: in.Address,
+		Status:  model.TransferStatusPending,
+	}
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.transfers.Create(ctx, transfer); err != nil {
+			return err
+		}
+		if err := s.transfers.AddAudit(ctx, transfer, "", userID, ""); err != nil {
+			return err
+		}
+		if _, err := s.ledger.Hold(ctx, userID, asset, in.Amount, withdrawalReference(transfer.ID)); err != nil {
+			return err
+		}
+		if s.needsApproval(transfer) {
+			return s.transition(ctx, transfer, model.TransferStatusReviewing, model.SystemUserID, "amount exceeds approval threshold")
+		}
+		return s.transition(ctx, transfer, model.TransferStatusApproved, model.SystemUserID, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+	if transfer.Status == model.TransferStatusApproved {
+		return s.broadcast(ctx, transfer)
+	}
+	return transfer, nil
+}
+
+// Approve 管理员批准待审批的提现并广播
+func (s *TransferService) Approve(ctx context.Context, adminID, id uint) (*model.Transfer, error) {
+	var transfer *model.Transfer
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		if transfer, err = s.load(ctx, id); err != nil {
+			return err
+		}
+		if transfer.Type != model.TransferTypeWithdrawal || transfer.Status != model.TransferStatusReviewing {
+			return ErrInvalidTransition
+		}
+		return s.transition(ctx, transfer, model.TransferStatusApproved, adminID, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.broadcast(ctx, transfer)
+}
+
+// Reject 管理员拒绝尚未广播的转账，提现的冻结资金同时解冻
+func (s *TransferService) Reject(ctx context.Context, adminID, id uint, reason string) (*model.Transfer, error) {
+	var transfer *model.Transfer
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		if transfer, err = s.load(ctx, id); err != nil {
+			return err
+		}
+		if err := s.transition(ctx, transfer, model.TransferStatusRejected, adminID, reason); err != nil {
+			return err
+		}
+		return s.releaseWithdrawal(ctx, transfer)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// Sync 查询已广播的提现或待确认的充值在链上的状态并推进：
+// 确认的提现从冻结余额出账，确认的充值按链上转入的金额入账，失败的提现解冻资金。链上尚未确认时保持不变。
+// 充值交易的收款地址不是用户的充值地址或金额无效时充值失败，并清除交易哈希以便真正的收款用户申报。
+func (s *TransferService) Sync(ctx context.Context, id uint) (*model.Transfer, error) {
+	transfer, err := s.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.TxHash == nil || !s.awaitingChain(transfer) {
+		return transfer, nil
+	}
+
+	tx, err := s.chain.Lookup(ctx, transfer.Asset, *transfer.TxHash)
+	if errors.Is(err, chain.ErrUnknownTx) {
+		// 充值交易可能尚未被节点看到，提现交易可能尚未传播
+		return transfer, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		if transfer.Type == model.TransferTypeDeposit && tx.Status != chain.TxFailed {
+			if reason := s.checkDeposit(transfer, tx); reason != "" {
+				if err := s.transition(ctx, transfer, model.TransferStatusFailed, model.SystemUserID, reason); err != nil {
+					return err
+				}
+				transfer.TxHash = nil
+				return s.transfers.ClearTxHash(ctx, transfer.ID)
+			}
+		}
+		switch tx.Status {
+		case chain.TxConfirmed:
+			reason := ""
+			if transfer.Type == model.TransferTypeDeposit && !tx.Amount.Equal(transfer.Amount) {
+				reason = fmt.Sprintf("credited on-chain amount %s instead of declared %s", tx.Amount, transfer.Amount)
+				transfer.Amount = tx.Amount
+				if err := s.transfers.SetAmount(ctx, transfer.ID, tx.Amount); err != nil {
+					return err
+				}
+			}
+			if err := s.transition(ctx, transfer, model.TransferStatusConfirmed, model.SystemUserID, reason); err != nil {
+				return err
+			}
+			return s.settle(ctx, transfer)
+		case chain.TxFailed:
+			if err := s.transition(ctx, transfer, model.TransferStatusFailed, model.SystemUserID, "transaction failed on chain"); err != nil {
+				return err
+			}
+			return s.releaseWithdrawal(ctx, transfer)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// SyncPending 同步一批等待链上确认的充值和提现，单笔失败只记录日志
+func (s *TransferService) SyncPending(ctx context.Context) error {
+	for _, q := range []struct{ typ, status string }{
+		{model.TransferTypeDeposit, model.TransferStatusPending},
+		{model.TransferTypeWithdrawal, model.TransferStatusBroadcasting},
+	} {
+		transfers, err := s.transfers.ListByStatus(ctx, q.typ, q.status, syncBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, t := range transfers {
+			if _, err := s.Sync(ctx, t.ID); err != nil && !errors.Is(err, ErrTransferInProgress) {
+				log.Printf("Failed to sync transfer %d: %v", t.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Run 按固定间隔同步链上状态，直到ctx取消
+func (s *TransferService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.SyncPending(ctx); err != nil {
+				log.Printf("Failed to sync transfers: %v", err)
+			}
+		}
+	}
+}
+
+// Get 获取用户的转账
+func (s *TransferService) Get(ctx context.Context, userID, id uint) (*model.Transfer, error) {
+	transfer, err := s.transfers.GetForUser(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if transfer == nil {
+		return nil, ErrTransferNotFound
+	}
+	return transfer, nil
+}
+
+// GetWithAudits 获取转账及其状态迁移记录，供管理员查看
+func (s *TransferService) GetWithAudits(ctx context.Context, id uint) (*model.Transfer, error) {
+	transfer, err := s.transfers.GetWithAudits(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if transfer == nil {
+		return nil, ErrTransferNotFound
+	}
+	return transfer, nil
+}
+
+// Search 按条件查询转账，返回下一页游标
+func (s *TransferService) Search(ctx context.Context, q repository.TransferQuery) ([]model.Transfer, uint, error) {
+	_, q.Limit = utils.NormalizePage(1, q.Limit)
+	return s.transfers.Search(ctx, q)
+}
+
+// broadcast 广播已批准的提现。先提交broadcasting状态再调用链客户端，避免重复广播；
+// 广播失败时置为failed并解冻资金。交易哈希保存前进程退出的提现停留在broadcasting，须人工核对。
+func (s *TransferService) broadcast(ctx context.Context, transfer *model.Transfer) (*model.Transfer, error) {
+	ctx = context.WithoutCancel(ctx)
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		return s.transition(ctx, transfer, model.TransferStatusBroadcasting, model.SystemUserID, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hash, berr := s.chain.Broadcast(ctx, chain.Transfer{
+		ID:      transfer.ID,
+		Asset:   transfer.Asset,
+		Address: transfer.Address,
+		Amount:  transfer.Amount,
+	})
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		if berr != nil {
+			if err := s.transition(ctx, transfer, model.TransferStatusFailed, model.SystemUserID, "broadcast failed: "+berr.Error()); err != nil {
+				return err
+			}
+			return s.releaseWithdrawal(ctx, transfer)
+		}
+		transfer.TxHash = &hash
+		return s.transfers.SetTxHash(ctx, transfer.ID, hash)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// transition 校验状态机后迁移转账状态并写入审计记录
+func (s *TransferService) transition(ctx context.Context, transfer *model.Transfer, to string, actorID uint, reason string) error {
+	if !transfer.CanTransition(to) {
+		return ErrInvalidTransition
+	}
+	ok, err := s.transfers.Transition(ctx, transfer, to, actorID, reason)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrTransferInProgress
+	}
+	return nil
+}
+
+// settle 为已确认的转账记账：充值入账到可用余额，提现从冻结余额出账
+func (s *TransferService) settle(ctx context.Context, transfer *model.Transfer) error {
+	var err error
+	if transfer.Type == model.TransferTypeDeposit {
+		_, err = s.ledger.Deposit(ctx, transfer.UserID, transfer.Asset, transfer.Amount, depositReference(transfer.ID))
+	} else {
+		_, err = s.ledger.Withdraw(ctx, transfer.UserID, transfer.Asset, transfer.Amount, withdrawalReference(transfer.ID))
+	}
+	return err
+}
+
+// checkDeposit 校验充值交易转入的是用户的充值地址且金额有效，不通过时返回失败原因
+func (s *TransferService) checkDeposit(transfer *model.Transfer, tx chain.Tx) string {
+	if tx.Address != transfer.Address {
+		return fmt.Sprintf("transaction %s does not pay the deposit address", *transfer.TxHash)
+	}
+	if !tx.Amount.IsPositive() || !s.scales.Fits(transfer.Asset, tx.Amount) {
+		return fmt.Sprintf("transaction %s amount %s is not a valid deposit", *transfer.TxHash, tx.Amount)
+	}
+	return ""
+}
+
+// releaseWithdrawal 解冻被拒绝或失败的提现资金，充值无需处理
+func (s *TransferService) releaseWithdrawal(ctx context.Context, transfer *model.Transfer) error {
+	if transfer.Type != model.TransferTypeWithdrawal {
+		return nil
+	}
+	_, err := s.ledger.Release(ctx, transfer.UserID, transfer.Asset, transfer.Amount, withdrawalReference(transfer.ID))
+	return err
+}
+
+// needsApproval 判断提现是否超过该资产的免审批额度，未配置额度的资产均须审批
+func (s *TransferService) needsApproval(transfer *model.Transfer) bool {
+	threshold, ok := s.thresholds[transfer.Asset]
+	return !ok || transfer.Amount.GreaterThan(threshold)
+}
+
+// awaitingChain 判断转账是否在等待链上确认
+func (s *TransferService) awaitingChain(transfer *model.Transfer) bool {
+	if transfer.Type == model.TransferTypeDeposit {
+		return transfer.Status == model.TransferStatusPending
+	}
+	return transfer.Status == model.TransferStatusBroadcasting
+}
+
+// load 根据ID获取转账
+func (s *TransferService) load(ctx context.Context, id uint) (*model.Transfer, error) {
+	transfer, err := s.transfers.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if transfer == nil {
+		return nil, ErrTransferNotFound
+	}
+	return transfer, nil
+}
+
+// normalizeAsset 将资产代码转为大写并校验格式
+func normalizeAsset(asset string) (string, error) {
+	asset = strings.ToUpper(strings.TrimSpace(asset))
+	if !assetPattern.MatchString(asset) {
+		return "", ErrInvalidAsset
+	}
+	return asset, nil
+}
+
+// depositReference 充值入账分录的业务引用
+func depositReference(id uint) string {
+	return fmt.Sprintf("deposit:%d", id)
+}
+
+// withdrawalReference 提现冻结、解冻和出账分录的业务引用
+func withdrawalReference(id uint) string {
+	return fmt.Sprintf("withdrawal:%d", id)
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"awesome-trade/src/internal/chain"
+	"awesome-trade/src/internal/config"
+	"awesome-trade/src/internal/database"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/pkg/decimal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTransferService 创建使用内存数据库和内存链客户端的充值提现服务，USDT免审批额度为1000、精度为6位
+func setupTransferService(t *testing.T) (*TransferService, *chain.FakeClient) {
+	db, err := database.Open(config.DatabaseConfig{Driver: database.DriverSQLite, Path: ":memory:"})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.User{}, &model.Account{}, &model.LedgerEntry{}, &model.LedgerPosting{},
+		&model.Transfer{}, &model.TransferAudit{}, &model.DepositAddress{}))
+	require.NoError(t, db.Create(&model.User{Username: "alice", Email: "alice@example.com", Password: "x", IsActive: true}).Error)
+
+	tx := database.NewTxManager(db)
+	ledger := NewLedgerService(tx, repository.NewLedgerRepository(db), decimal.NewScales(map[string]int32{"usdt": 6}))
+	fake := chain.NewFakeClient()
+	cfg := config.TransferConfig{ApprovalThresholds: map[string]string{"usdt": "1000"}}
+	return NewTransferService(tx, repository.NewTransferRepository(db), ledger, fake, cfg), fake
+}
+
+// assertBalance 校验用户现货账户的可用和冻结余额
+func assertBalance(t *testing.T, s *TransferService, userID uint, asset, available, held string) {
+	t.Helper()
+	account, err := s.ledger.Account(context.Background(), userID, asset)
+	require.NoError(t, err)
+	assert.Equal(t, available, account.Available.String(), "available")
+	assert.Equal(t, held, account.Held.String(), "held")
+}
+
+// auditTrail 返回转账的状态迁移序列
+func auditTrail(t *testing.T, s *TransferService, id uint) []string {
+	t.Helper()
+	transfer, err := s.GetWithAudits(context.Background(), id)
+	require.NoError(t, err)
+	trail := make([]string, 0, len(transfer.Audits))
+	for _, a := range transfer.Audits {
+		trail = append(trail, a.FromStatus+">"+a.ToStatus)
+	}
+	return trail
+}
+
+// 测试充值须转入用户的充值地址、按链上金额确认入账，以及重复申报
+func TestDepositConfirm(t *testing.T) {
+	s, fake := setupTransferService(t)
+	ctx := context.Background()
+	d := decimal.RequireFromString
+
+	_, err := s.RequestDeposit(ctx, 1, DepositInput{Asset: "usdt", Amount: d("1500"), TxHash: "0xdeposit01"})
+	assert.ErrorIs(t, err, ErrNoDepositAddress)
+	address, err := s.DepositAddress(ctx, 1, "usdt")
+	require.NoError(t, err)
+	assert.Equal(t, "USDT", address.Asset)
+	again, err := s.DepositAddress(ctx, 1, "USDT")
+	require.NoError(t, err)
+	assert.Equal(t, address.ID, again.ID)
+
+	deposit, err := s.RequestDeposit(ctx, 1, DepositInput{Asset: "usdt", Amount: d("1500"), TxHash: "0xdeposit01"})
+	require.NoError(t, err)
+	assert.Equal(t, "USDT", deposit.Asset)
+	assert.Equal(t, address.Address, deposit.Address)
+	assert.Equal(t, model.TransferStatusPending, deposit.Status)
+
+	repeated, err := s.RequestDeposit(ctx, 1, DepositInput{Asset: "USDT", Amount: d("1500"), TxHash: "0xdeposit01"})
+	require.NoError(t, err)
+	assert.Equal(t, deposit.ID, repeated.ID)
+	_, err = s.RequestDeposit(ctx, 1, DepositInput{Asset: "USDT", Amount: d("1"), TxHash: "0xdeposit01"})
+	assert.ErrorIs(t, err, ErrDepositTxConflict)
+	_, err = s.RequestDeposit(ctx, 1, DepositInput{Asset: "USDT", Amount: d("1.0000001"), TxHash: "0xdeposit02"})
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	// 节点尚未看到交易或确认数不足时保持待确认
+	synced, err := s.Sync(ctx, deposit.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.TransferStatusPending, synced.Status)
+	fake.AddDeposit("0xdeposit01", address.Address, d("15"), chain.TxPending)
+	synced, err = s.Sync(ctx, deposit.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.TransferStatusPending, synced.Status)
+
+	// 按链上实际转入的金额入账，而不是申报的金额
+	fake.SetStatus("0xdeposit01", chain.TxConfirmed)
+	require.NoError(t, s.SyncPending(ctx))
+	synced, err = s.Get(ctx, 1, deposit.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.TransferStatusConfirmed, synced.Status)
+	assert.Equal(t, "15", synced.Amount.String())
+	assert.Contains(t, synced.Reason, "on-chain amount 15")
+	assertBalance(t, s, 1, "USDT", "15", "0")
+	assert.Equal(t, []string{">pending", "pending>confirmed"}, auditTrail(t, s, deposit.ID))
+
+	// 已确认的充值不能再被拒绝
+	_, err = s.Reject(ctx, 9, deposit.ID, "late")
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+}
+
+// 测试申报转入他人充值地址的交易时充值失败且不入账，真正的收款用户仍可申报该交易
+func TestDepositOtherAddress(t *testing.T) {
+	s, fake := setupTransferService(t)
+	ctx := context.Background()
+	d := decimal.RequireFromString
+	require.NoError(t, s.transfers.DB(ctx).Create(&model.User{Username: "bob", Email: "bob@example.com", Password: "x", IsActive: true}).Error)
+
+	_, err := s.DepositAddress(ctx, 1, "USDT")
+	require.NoError(t, err)
+	bobAddress, err := s.DepositAddress(ctx, 2, "USDT")
+	require.NoError(t, err)
+	fake.AddDeposit("0xdeposit03", bobAddress.Address, d("500"), chain.TxConfirmed)
+
+	claimed, err := s.RequestDeposit(ctx, 1, DepositInput{Asset: "USDT", Amount: d("500"), TxHash: "0xdeposit03"})
+	require.NoError(t, err)
+	synced, err := s.Sync(ctx, claimed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.TransferStatusFailed, synced.Status)
+	assert.Nil(t, synced.TxHash)
+	assert.Contains(t, synced.Reason, "does not pay the deposit address")
+	assertBalance(t, s, 1, "USDT", "0", "0")
+
+	deposit, err := s.RequestDeposit(ctx, 2, DepositInput{Asset: "USDT", Amount: d("500"), TxHash: "0xdeposit03"})
+	require.NoError(t, err)
+	synced, err = s.Sync(ctx, deposit.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.TransferStatusConfirmed, synced.Status)
+	assertBalance(t, s, 2, "USDT", "500", "0")
+}
+
+// 测试提现的审批、拒绝、广播失败和链上确认
+func TestWithdrawalLifecycle(t *testing.T) {
+	s, fake := setupTransferService(t)
+	ctx := context.Background()
+	d := decimal.RequireFromString
+	_, err := s.ledger.Deposit(ctx, 1, "USDT", d("5000"), "seed")
+	require.NoError(t, err)
+
+	_, err = s.RequestWithdrawal(ctx, 1, WithdrawalInput{Asset: "USDT", Amount: d("6000"), Address: "TAddress0001"})
+	assert.ErrorIs(t, err, ErrInsufficientBalance)
+
+	// 超过免审批额度的提现等待审批，拒绝后解冻
+	large, err := s.RequestWithdrawal(ctx, 1, WithdrawalInput{Asset: "USDT", Amount: d("2000"), Address: "TAddress0001"})
+	require.NoError(t, err)
+	assert.Equal(t, model.TransferStatusReviewing, large.Status)
+	assertBalance(t, s, 1, "USDT", "3000", "2000")
+	rejected, err := s.Reject(ctx, 9, large.ID, "address on watch list")
+	require.NoError(t, err)
+	assert.Equal(t, model.TransferStatusRejected, rejected.Status)
+	assertBalance(t, s, 1, "USDT", "5000", "0")
+	_, err = s.Approve(ctx, 9, large.ID)
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	assert.Equal(t, []string{">pending", "pending>reviewing", "reviewing>rejected"}, auditTrail(t, s, large.ID))
+
+	// 广播失败时提现失败并解冻
+	fake.BroadcastErr = errors.New("node unavailable")
+	failed, err := s.RequestWithdrawal(ctx, 1, WithdrawalInput{Asset: "USDT", Amount: d("100"), Address: "TAddress0001"})
+	require.NoError(t, err)
+	assert.Equal(t, model.TransferStatusFailed, failed.Status)
+	assert.Nil(t, failed.TxHash)
+	assertBalance(t, s, 1, "USDT", "5000", "0")
+	fake.BroadcastErr = nil
+
+	// 审批通过后广播，链上确认后从冻结余额出账
+	reviewed, err := s.RequestWithdrawal(ctx, 1, WithdrawalInput{Asset: "USDT", Amount: d("1200"), Address: "TAddress0001"})
+	require.NoError(t, err)
+	approved, err := s.Approve(ctx, 9, reviewed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.TransferStatusBroadcasting, approved.Status)
+	require.NotNil(t, approved.TxHash)
+	require.Len(t, fake.Broadcasts(), 1)
+	assertBalance(t, s, 1, "USDT", "3800", "1200")
+
+	fake.SetStatus(*approved.TxHash, chain.TxConfirmed)
+	confirmed, err := s.Sync(ctx, approved.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.TransferStatusConfirmed, confirmed.Status)
+	assertBalance(t, s, 1, "USDT", "3800", "0")
+	assert.Equal(t, []string{
+		">pending", "pending>reviewing", "reviewing>approved", "approved>broadcasting", "broadcasting>confirmed",
+	}, auditTrail(t, s, approved.ID))
+
+	// 免审批的提现立即广播，链上失败时解冻
+	small, err := s.RequestWithdrawal(ctx, 1, WithdrawalInput{Asset: "USDT", Amount: d("500"), Address: "TAddress0001"})
+	require.NoError(t, err)
+	assert.Equal(t, model.TransferStatusBroadcasting, small.Status)
+	fake.SetStatus(*small.TxHash, chain.TxFailed)
+	require.NoError(t, s.SyncPending(ctx))
+	assertBalance(t, s, 1, "USDT", "3800", "0")
+	assert.Equal(t, []string{
+		">pending", "pending>approved", "approved>broadcasting", "broadcasting>failed",
+	}, auditTrail(t, s, small.ID))
+
+	mismatches, err := s.ledger.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, mismatches)
+}
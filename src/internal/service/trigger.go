@@ -0,0 +1,476 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"awesome-trade/src/internal/matching"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/sequencer"
+	"awesome-trade/src/pkg/decimal"
+)
+
+const (
+	// triggerClientOrderIDPrefix 条件单触发后子订单的客户端订单ID前缀，用户下单时不可使用
+	triggerClientOrderIDPrefix = "trigger-"
+	// triggerRetryDelay 已触发的条件单因临时故障未能提交子订单时，再次补交的等待时间
+	triggerRetryDelay = time.Second
+)
+
+// priceRange 交易对自上次检查以来的成交价区间，使两次检查之间穿过触发价后又回到原处的行情也能触发条件单：
+// 止损和止盈单按最低价和最高价判断，跟踪止损单按区间内从最高价的最大回落和从最低价的最大反弹判断
+type priceRange struct {
+	last decimal.Decimal
+	low  decimal.Decimal
+	high decimal.Decimal
+	drop decimal.Decimal
+	rise decimal.Decimal
+}
+
+// pointRange 只包含一个价格的区间
+func pointRange(price decimal.Decimal) priceRange {
+	return priceRange{last: price, low: price, high: price, drop: decimal.Zero, rise: decimal.Zero}
+}
+
+// add 按成交顺序加入成交价
+func (r *priceRange) add(price decimal.Decimal) {
+	r.high = decimal.Max(r.high, price)
+	r.low = decimal.Min(r.low, price)
+	r.drop = decimal.Max(r.drop, r.high.Sub(price))
+	r.rise = decimal.Max(r.rise, price.Sub(r.low))
+	r.last = price
+}
+
+// triggerBook 内存中等待触发的条件单，按交易对索引。撮合事件记录成交价区间并标记交易对待检查，
+// 由RunTriggers在定序协程之外检查并触发，避免在事件处理中向定序器提交命令。
+type triggerBook struct {
+	mu     sync.Mutex
+	orders map[string]map[uint]*model.Order
+	fresh  map[uint]struct{} // 上次检查之后加入的条件单，首次检查只按最新成交价判断
+	prices map[string]*priceRange
+	dirty  map[string]struct{}
+	refire []model.Order // 已触发但尚未记录子订单的条件单，重启后补交子订单
+	wake   chan struct{}
+}
+
+// newTriggerBook 创建条件单簿
+func newTriggerBook() *triggerBook {
+	return &triggerBook{
+		orders: make(map[string]map[uint]*model.Order),
+		fresh:  make(map[uint]struct{}),
+		prices: make(map[string]*priceRange),
+		dirty:  make(map[string]struct{}),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// add 加入等待触发的条件单并标记交易对待检查
+func (b *triggerBook) add(order model.Order) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	orders, ok := b.orders[order.Symbol]
+	if !ok {
+		orders = make(map[uint]*model.Order)
+		b.orders[order.Symbol] = orders
+	}
+	orders[order.ID] = &order
+	b.fresh[order.ID] = struct{}{}
+	b.markLocked(order.Symbol)
+}
+
+// retry 加入已触发但尚未记录子订单的条件单
+func (b *triggerBook) retry(order model.Order) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refire = append(b.refire, order)
+	b.notifyLocked()
+}
+
+// remove 移除已触发或撤销的条件单
+func (b *triggerBook) remove(symbol string, id uint) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.orders[symbol], id)
+	delete(b.fresh, id)
+	if len(b.orders[symbol]) == 0 {
+		delete(b.orders, symbol)
+	}
+}
+
+// observe 按成交顺序记录交易对的成交价，没有成交时只标记待检查
+func (b *triggerBook) observe(symbol string, prices []decimal.Decimal) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, price := range prices {
+		if r, ok := b.prices[symbol]; ok {
+			r.add(price)
+			continue
+		}
+		r := pointRange(price)
+		b.prices[symbol] = &r
+	}
+	if len(b.orders[symbol]) > 0 {
+		b.markLocked(symbol)
+	}
+}
+
+// take 取出待检查的交易对和待补交子订单的条件单
+func (b *triggerBook) take() ([]string, []model.Order) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	symbols := make([]string, 0, len(b.dirty))
+	for symbol := range b.dirty {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	b.dirty = make(map[string]struct{})
+	refire := b.refire
+	b.refire = nil
+	return symbols, refire
+}
+
+// snapshot 按ID正序返回交易对上等待触发的条件单及其中上次检查之后加入的订单，
+// 并取出自上次检查以来的成交价区间，区间随后重置为最新成交价。尚未记录成交时ok为false
+func (b *triggerBook) snapshot(symbol string) (orders []*model.Order, fresh map[uint]bool, rng priceRange, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	orders = make([]*model.Order, 0, len(b.orders[symbol]))
+	fresh = make(map[uint]bool)
+	for id, o := range b.orders[symbol] {
+		orders = append(orders, o)
+		if _, isFresh := b.fresh[id]; isFresh {
+			fresh[id] = true
+			delete(b.fresh, id)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID < orders[j].ID })
+	if r, exists := b.prices[symbol]; exists {
+		rng, ok = *r, true
+		*r = pointRange(r.last)
+	}
+	return orders, fresh, rng, ok
+}
+
+// markLocked 标记交易对待检查并唤醒检查协程，调用方须持有锁
+func (b *triggerBook) markLocked(symbol string) {
+	b.dirty[symbol] = struct{}{}
+	b.notifyLocked()
+}
+
+// notifyLocked 唤醒检查协程，调用方须持有锁
+func (b *triggerBook) notifyLocked() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// watchTriggers 在定序协程中记录撮合事件带来的行情变化，每笔成交的价格都计入成交价区间，挂单和撤单可能改变标记价格
+func (s *OrderService) watchTriggers(ev sequencer.Event) {
+	if ev.Result == nil {
+		return
+	}
+	prices := make([]decimal.Decimal, len(ev.Result.Trades))
+	for i, t := range ev.Result.Trades {
+		prices[i] = fromUnits(t.Price)
+	}
+	s.triggers.observe(ev.Symbol, prices)
+}
+
+// LoadTriggers 从数据库加载等待触发的条件单，已触发但未记录子订单的条件单在RunTriggers启动后补交子订单
+func (s *OrderService) LoadTriggers(ctx context.Context) error {
+	orders, err := s.orders.ListPendingTriggers(ctx)
+	if err != nil {
+		return err
+	}
+	for _, o := range orders {
+		if o.Status == model.OrderStatusUntriggered {
+			s.triggers.add(o)
+			continue
+		}
+		s.triggers.retry(o)
+	}
+	return nil
+}
+
+// RunTriggers 在行情变化时检查条件单，满足条件时提交子订单，直到ctx取消
+func (s *OrderService) RunTriggers(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.triggers.wake:
+		}
+		symbols, refire := s.triggers.take()
+		for _, o := range refire {
+			s.complete(ctx, o.ID)
+		}
+		for _, symbol := range symbols {
+			if err := s.checkTriggers(ctx, symbol); err != nil {
+				log.Printf("Failed to check %s triggers: %v", symbol, err)
+			}
+		}
+	}
+}
+
+// placeConditional 保存等待触发的条件单，条件单不冻结资金
+func (s *OrderService) placeConditional(ctx context.Context, order *model.Order) (*model.Order, error) {
+	if _, err := s.sequencer(order.Symbol); err != nil {
+		return nil, err
+	}
+	created, err := s.create(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		existing, err := s.orders.GetByClientOrderID(ctx, order.UserID, order.ClientOrderID)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil || !sameOrder(existing, order) {
+			return nil, ErrClientOrderIDConflict
+		}
+		return existing, nil
+	}
+	s.triggers.add(*order)
+	return s.reload(ctx, order.ID)
+}
+
+// cancelOffBook 撤销等待触发的条件单或等待生效的订单，与触发并发时以先更新状态者为准
+func (s *OrderService) cancelOffBook(ctx context.Context, order *model.Order) error {
+	ok, err := s.closeOrder(context.WithoutCancel(ctx), order.ID, model.OrderStatusCanceled)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrOrderNotOpen
+	}
+	s.triggers.remove(order.Symbol, order.ID)
+	return nil
+}
+
+// checkTriggers 按自上次检查以来的行情检查交易对上的条件单，先判断是否触发，再移动跟踪止损单的触发价。
+// 按最新成交价触发的订单使用成交价区间；按标记价格触发的订单和上次检查之后才加入的订单只使用当前价格，
+// 不受加入之前的成交影响
+func (s *OrderService) checkTriggers(ctx context.Context, symbol string) error {
+	orders, fresh, rng, ok := s.triggers.snapshot(symbol)
+	if len(orders) == 0 {
+		return nil
+	}
+	if !ok {
+		last, found, err := s.prices.LastPrice(ctx, symbol)
+		if err != nil || !found {
+			return err
+		}
+		rng = pointRange(last)
+	}
+	mark, err := s.markPrice(ctx, symbol, rng.last)
+	if err != nil {
+		return err
+	}
+	for _, o := range orders {
+		r := rng
+		switch {
+		case o.TriggerPriceType == model.TriggerPriceMark:
+			r = pointRange(mark)
+		case fresh[o.ID]:
+			r = pointRange(rng.last)
+		}
+		hit := triggerHit(o, r)
+		if o.Type == model.OrderTypeTrailingStop {
+			hit = hit || trailHit(o, r)
+			if err := s.trail(ctx, o, r); err != nil {
+				return err
+			}
+		}
+		if hit {
+			s.fire(ctx, o)
+		}
+	}
+	return nil
+}
+
+// markPrice 返回交易对的标记价格，即限制在买一和卖一之间的最新成交价
+func (s *OrderService) markPrice(ctx context.Context, symbol string, last decimal.Decimal) (decimal.Decimal, error) {
+	seq, err := s.sequencer(symbol)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	var bid, ask int64
+	var hasBid, hasAsk bool
+	if err := seq.Read(ctx, func(book *matching.OrderBook, _ uint64) {
+		bid, hasBid = book.BestBid()
+		ask, hasAsk = book.BestAsk()
+	}); err != nil {
+		return decimal.Zero, err
+	}
+	mark := last
+	if hasBid && mark.LessThan(fromUnits(bid)) {
+		mark = fromUnits(bid)
+	}
+	if hasAsk && mark.GreaterThan(fromUnits(ask)) {
+		mark = fromUnits(ask)
+	}
+	return mark, nil
+}
+
+// trail 按行情向有利方向移动跟踪止损单的触发价：卖单为区间最高价减回撤价差，买单为区间最低价加回撤价差。
+// 订单已不在等待触发时从条件单簿中移除。
+func (s *OrderService) trail(ctx context.Context, o *model.Order, r priceRange) error {
+	next := r.high.Sub(o.TrailingDelta)
+	better := next.GreaterThan(o.StopPrice)
+	if o.Side == model.SideBuy {
+		next = r.low.Add(o.TrailingDelta)
+		better = o.StopPrice.IsZero() || next.LessThan(o.StopPrice)
+	}
+	if !better || !next.IsPositive() {
+		return nil
+	}
+	ok, err := s.orders.UpdateStopPrice(ctx, o.ID, next)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		s.triggers.remove(o.Symbol, o.ID)
+		return nil
+	}
+	o.StopPrice = next
+	return nil
+}
+
+// fire 将条件单标记为已触发并提交子订单，订单已被撤销时只从条件单簿中移除。
+// 订单组内的条件单在同一事务中撤销组内其他不在订单簿中的订单
+func (s *OrderService) fire(ctx context.Context, o *model.Order) {
+	var ok bool
+	var closed []model.Order
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		if ok, err = s.orders.MarkTriggered(ctx, o.ID, time.Now()); err != nil || !ok || o.GroupID == nil {
+			return err
+		}
+		closed, err = s.closeOffBookSiblings(ctx, o)
+		return err
+	})
+	if err != nil {
+		log.Printf("Failed to trigger order %d: %v", o.ID, err)
+		return
+	}
+	s.triggers.remove(o.Symbol, o.ID)
+	for _, c := range closed {
+		s.triggers.remove(c.Symbol, c.ID)
+	}
+	if ok {
+		s.complete(ctx, o.ID)
+	}
+}
+
+// complete 为已触发的条件单提交子订单。订单组内的条件单先撤销组内仍在订单簿中的订单，
+// 二选一的另一订单已结束时不再提交子订单并撤销条件单；撤销后子订单的数量以同一定序步骤中调整后的数量为准。
+// 临时故障时条件单保持已触发，稍后再次补交
+func (s *OrderService) complete(ctx context.Context, id uint) {
+	o, err := s.reload(ctx, id)
+	if err != nil {
+		log.Printf("Failed to load triggered order %d: %v", id, err)
+		s.retryTrigger(id)
+		return
+	}
+	if o.Status != model.OrderStatusTriggered || o.ChildOrderID != nil {
+		return
+	}
+	if o.GroupID != nil {
+		proceed, err := s.cancelBookSiblings(ctx, o)
+		if err != nil {
+			log.Printf("Failed to cancel siblings of triggered order %d: %v", o.ID, err)
+			s.retryTrigger(id)
+			return
+		}
+		if !proceed {
+			if _, err := s.orders.TransitionStatus(ctx, o.ID, []string{model.OrderStatusTriggered}, model.OrderStatusCanceled); err != nil {
+				log.Printf("Failed to cancel triggered order %d: %v", o.ID, err)
+			}
+			return
+		}
+		if o, err = s.reload(ctx, id); err != nil {
+			log.Printf("Failed to load triggered order %d: %v", id, err)
+			s.retryTrigger(id)
+			return
+		}
+	}
+	s.submitChild(ctx, o)
+}
+
+// submitChild 以条件单的参数提交子订单并记录到条件单上。子订单的客户端订单ID由条件单ID确定，
+// 重复提交返回同一子订单，已落库但未送达定序器的子订单在补交时提交。子订单因交易规则、余额或风控被拒绝时
+// 条件单记为已拒绝；数据库或定序器的临时故障不拒绝条件单，稍后再次补交
+func (s *OrderService) submitChild(ctx context.Context, parent *model.Order) {
+	parentID := parent.ID
+	child := &model.Order{
+		UserID:         parent.UserID,
+		ClientOrderID:  fmt.Sprintf("%s%d", triggerClientOrderIDPrefix, parent.ID),
+		Symbol:         parent.Symbol,
+		Side:           parent.Side,
+		Type:           parent.ExecType(),
+		TimeInForce:    parent.TimeInForce,
+		Price:          parent.Price,
+		Quantity:       parent.Quantity,
+		FilledQuantity: decimal.Zero,
+		HeldAmount:     decimal.Zero,
+		Status:         model.OrderStatusNew,
+		ParentOrderID:  &parentID,
+	}
+	placed, err := s.place(ctx, child)
+	if err != nil && !isRejection(err) {
+		log.Printf("Failed to place child of triggered order %d, will retry: %v", parent.ID, err)
+		s.retryTrigger(parent.ID)
+		return
+	}
+	if err != nil {
+		log.Printf("Child of triggered order %d was rejected: %v", parent.ID, err)
+		if _, err := s.orders.TransitionStatus(ctx, parent.ID, []string{model.OrderStatusTriggered}, model.OrderStatusRejected); err != nil {
+			log.Printf("Failed to reject triggered order %d: %v", parent.ID, err)
+		}
+		return
+	}
+	if err := s.orders.SetChildOrder(ctx, parent.ID, placed.ID); err != nil {
+		log.Printf("Failed to link child order %d to %d: %v", placed.ID, parent.ID, err)
+		s.retryTrigger(parent.ID)
+	}
+}
+
+// retryTrigger 在triggerRetryDelay后再次为已触发的条件单补交子订单
+func (s *OrderService) retryTrigger(id uint) {
+	time.AfterFunc(triggerRetryDelay, func() {
+		var o model.Order
+		o.ID = id
+		s.triggers.retry(o)
+	})
+}
+
+// triggerHit 判断条件单在价格区间内是否触发：止损单卖出在最低价跌至触发价时触发、买入在最高价涨至触发价时触发，止盈单相反。
+// 跟踪止损单尚无触发价时不触发
+func triggerHit(o *model.Order, r priceRange) bool {
+	if o.StopPrice.IsZero() {
+		return false
+	}
+	falling := o.Side == model.SideSell
+	if o.Type == model.OrderTypeTakeProfit || o.Type == model.OrderTypeTakeProfitLimit {
+		falling = !falling
+	}
+	if falling {
+		return r.low.LessThanOrEqual(o.StopPrice)
+	}
+	return r.high.GreaterThanOrEqual(o.StopPrice)
+}
+
+// trailHit 判断跟踪止损单是否在区间内回撤了回撤价差：卖单为从区间内的最高价回落，买单为从最低价反弹，
+// 即区间内某一时刻的价格穿过了按此前极值移动后的触发价
+func trailHit(o *model.Order, r priceRange) bool {
+	if o.Side == model.SideBuy {
+		return r.rise.GreaterThanOrEqual(o.TrailingDelta)
+	}
+	return r.drop.GreaterThanOrEqual(o.TrailingDelta)
+}
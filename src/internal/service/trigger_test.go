@@ -0,0 +1,76 @@
+package service
+
+import (
+	"testing"
+
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/pkg/decimal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试两次检查之间穿过触发价又回到原处的成交价仍能触发条件单，跟踪止损单按区间内的回落触发，
+// 以及上次检查之后加入的条件单不受加入之前的成交影响
+func TestTriggerBookPriceRange(t *testing.T) {
+	d := decimal.RequireFromString
+	prices := func(values ...string) []decimal.Decimal {
+		out := make([]decimal.Decimal, len(values))
+		for i, v := range values {
+			out[i] = d(v)
+		}
+		return out
+	}
+	book := newTriggerBook()
+	stop := model.Order{Symbol: "BTC_USDT", Side: model.SideSell, Type: model.OrderTypeStop, StopPrice: d("29000")}
+	stop.ID = 1
+	trailing := model.Order{Symbol: "BTC_USDT", Side: model.SideSell, Type: model.OrderTypeTrailingStop,
+		StopPrice: d("29000"), TrailingDelta: d("1000")}
+	trailing.ID = 2
+	book.observe("BTC_USDT", prices("30000"))
+	book.add(stop)
+	book.add(trailing)
+
+	orders, fresh, rng, ok := book.snapshot("BTC_USDT")
+	require.True(t, ok)
+	require.Len(t, orders, 2)
+	assert.True(t, fresh[1])
+	assert.Equal(t, "30000", rng.last.String())
+
+	// 吃单扫过多档后价格又被拉回
+	book.observe("BTC_USDT", prices("30500", "28900", "28800"))
+	book.observe("BTC_USDT", prices("30100"))
+	orders, fresh, rng, ok = book.snapshot("BTC_USDT")
+	require.True(t, ok)
+	assert.Empty(t, fresh)
+	assert.Equal(t, "30100", rng.last.String())
+	assert.Equal(t, "28800", rng.low.String())
+	assert.Equal(t, "30500", rng.high.String())
+	assert.Equal(t, "1700", rng.drop.String())
+	assert.Equal(t, "1300", rng.rise.String())
+	assert.True(t, triggerHit(orders[0], rng))
+	assert.False(t, triggerHit(orders[0], pointRange(rng.last)))
+	assert.True(t, trailHit(orders[1], rng))
+
+	// 检查后区间重置为最新成交价，新加入的条件单只按最新成交价判断
+	book.observe("BTC_USDT", prices("29500", "30000"))
+	_, _, rng, _ = book.snapshot("BTC_USDT")
+	assert.Equal(t, "600", rng.drop.String())
+	assert.False(t, trailHit(orders[1], rng))
+	assert.False(t, triggerHit(orders[0], rng))
+
+	buy := model.Order{Symbol: "BTC_USDT", Side: model.SideBuy, Type: model.OrderTypeStop, StopPrice: d("30200")}
+	buy.ID = 3
+	book.observe("BTC_USDT", prices("30300", "30000"))
+	book.add(buy)
+	_, fresh, rng, _ = book.snapshot("BTC_USDT")
+	assert.True(t, fresh[3])
+	assert.True(t, triggerHit(&buy, rng))
+	assert.False(t, triggerHit(&buy, pointRange(rng.last)))
+
+	book.remove("BTC_USDT", 1)
+	book.remove("BTC_USDT", 2)
+	book.remove("BTC_USDT", 3)
+	orders, _, _, _ = book.snapshot("BTC_USDT")
+	assert.Empty(t, orders)
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"awesome-trade/src/internal/config"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/pkg/totp"
+	"awesome-trade/src/pkg/utils"
+)
+
+// 两步验证服务错误
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled    = errors.New("two-factor authentication is not enrolled")
+	ErrTwoFactorRequired       = errors.New("two-factor authentication is required")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrTwoFactorLocked         = errors.New("too many failed two-factor attempts, try again later")
+)
+
+// 恢复码参数
+const (
+	recoveryCodeCount    = 10
+	recoveryCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// 连续验证失败的默认锁定参数
+const (
+	defaultTwoFactorMaxAttempts = 5
+	defaultTwoFactorLockout     = 15 * time.Minute
+)
+
+// Enrollment 两步验证登记信息
+type Enrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// TwoFactorService TOTP两步验证服务
+type TwoFactorService struct {
+	*BaseService
+	repo   *repository.TwoFactorRepository
+	users  *repository.UserRepository
+	cipher *utils.Cipher
+	issuer string
+	window time.Duration
+	now    func() time.Time
+
+	maxAttempts int
+	lockout     time.Duration
+}
+
+// NewTwoFactorService 创建两步验证服务实例
+func NewTwoFactorService(repo *repository.TwoFactorRepository, users *repository.UserRepository, cipher *utils.Cipher, cfg config.TwoFactorConfig) *TwoFactorService {
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultTwoFactorMaxAttempts
+	}
+	lockout := time.Duration(cfg.LockoutTime) * time.Second
+	if lockout <= 0 {
+		lockout = defaultTwoFactorLockout
+	}
+	return &TwoFactorService{
+		BaseService: NewBaseService(nil),
+		repo:        repo,
+		users:       users,
+		cipher:      cipher,
+		issuer:      cfg.Issuer,
+		window:      time.Duration(cfg.FreshWindow) * time.Second,
+		now:         time.Now,
+		maxAttempts: maxAttempts,
+		lockout:     lockout,
+	}
+}
+
+// Enroll 为用户生成新的TOTP密钥，需调用Confirm后才会启用
+func (s *TwoFactorService) Enroll(ctx context.Context, userID uint) (*Enrollment, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	tf, err := s.repo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf != nil && tf.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if tf == nil {
+		tf = &model.TwoFactor{UserID: userID}
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if tf.SecretEncrypted, err = s.cipher.Encrypt(secret); err != nil {
+		return nil, err
+	}
+	tf.LastUsedStep = 0
+	if err := s.repo.Save(ctx, tf); err != nil {
+		return nil, err
+	}
+
+	return &Enrollment{
+		Secret: secret,
+		URI:    totp.URI(s.issuer, user.Username, secret),
+	}, nil
+}
+
+// Confirm 使用第一个验证码确认登记，启用两步验证并返回一次性的恢复码明文。
+// 与Verify共用失败计数和锁定，锁定期间返回ErrTwoFactorLocked
+func (s *TwoFactorService) Confirm(ctx context.Context, userID uint, code string) ([]string, error) {
+	tf, err := s.repo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	if tf.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	var step int64
+	err = s.attempt(ctx, tf, func() error {
+		var err error
+		step, err = s.validateTOTP(tf, code)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashed, err := generateRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	tf.Enabled = true
+	tf.FailedAttempts = 0
+	tf.ConfirmedAt = &now
+	tf.LastUsedStep = step
+	tf.LastVerifiedAt = &now
+	if err := s.repo.Enable(ctx, tf, hashed); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable 校验验证码后关闭两步验证
+func (s *TwoFactorService) Disable(ctx context.Context, userID uint, code string) error {
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, userID)
+}
+
+// IsEnabled 判断用户是否已启用两步验证
+func (s *TwoFactorService) IsEnabled(ctx context.Context, userID uint) (bool, error) {
+	tf, err := s.repo.GetByUser(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return tf != nil && tf.Enabled, nil
+}
+
+// Verify 校验TOTP验证码或恢复码。同一验证码不能重复使用，恢复码使用后立即作废。
+// 连续失败达到上限后锁定一段时间，锁定期间返回ErrTwoFactorLocked，防止暴力猜测验证码。
+func (s *TwoFactorService) Verify(ctx context.Context, userID uint, code string) error {
+	tf, err := s.repo.GetByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if tf == nil || !tf.Enabled {
+		return ErrTwoFactorNotEnrolled
+	}
+	err = s.attempt(ctx, tf, func() error {
+		return s.verifyCode(ctx, tf, code)
+	})
+	if err != nil {
+		return err
+	}
+	return s.repo.ResetFailures(ctx, tf.ID)
+}
+
+// attempt 在锁定检查和失败计数下执行一次验证码校验：锁定期间返回ErrTwoFactorLocked，
+// check返回ErrInvalidTwoFactorCode时记录失败，连续失败达到上限时锁定并返回ErrTwoFactorLocked
+func (s *TwoFactorService) attempt(ctx context.Context, tf *model.TwoFactor, check func() error) error {
+	if tf.LockedUntil != nil && s.now().Before(*tf.LockedUntil) {
+		return ErrTwoFactorLocked
+	}
+	err := check()
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		locked, ferr := s.repo.RecordFailure(ctx, tf.ID, s.maxAttempts, s.now().Add(s.lockout))
+		if ferr != nil {
+			return ferr
+		}
+		if locked {
+			return ErrTwoFactorLocked
+		}
+	}
+	return err
+}
+
+// verifyCode 校验TOTP验证码或恢复码并记录使用
+func (s *TwoFactorService) verifyCode(ctx context.Context, tf *model.TwoFactor, code string) error {
+	userID := tf.UserID
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		step, err := s.validateTOTP(tf, code)
+		if err != nil {
+			return err
+		}
+		ok, err := s.repo.AdvanceStep(ctx, tf.ID, step, s.now())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	ok, err := s.repo.UseRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(code)), s.now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// VerifiedRecently 判断用户是否在新鲜度窗口内完成过两步验证
+func (s *TwoFactorService) VerifiedRecently(ctx context.Context, userID uint) (bool, error) {
+	tf, err := s.repo.GetByUser(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if tf == nil || !tf.Enabled {
+		return false, ErrTwoFactorRequired
+	}
+	return tf.LastVerifiedAt != nil && s.now().Sub(*tf.LastVerifiedAt) <= s.window, nil
+}
+
+// RemainingRecoveryCodes 返回剩余可用的恢复码数量
+func (s *TwoFactorService) RemainingRecoveryCodes(ctx context.Context, userID uint) (int64, error) {
+	return s.repo.CountUnusedRecoveryCodes(ctx, userID)
+}
+
+// validateTOTP 解密密钥并校验验证码，成功时返回匹配的时间步
+func (s *TwoFactorService) validateTOTP(tf *model.TwoFactor, code string) (int64, error) {
+	secret, err := s.cipher.Decrypt(tf.SecretEncrypted)
+	if err != nil {
+		return 0, err
+	}
+	step, ok := totp.Validate(secret, code, s.now(), 1)
+	if !ok || step <= tf.LastUsedStep {
+		return 0, ErrInvalidTwoFactorCode
+	}
+	return step, nil
+}
+
+// generateRecoveryCodes 生成恢复码，返回明文和待保存的哈希记录
+func generateRecoveryCodes(userID uint) ([]string, []model.RecoveryCode, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashed := make([]model.RecoveryCode, 0, recoveryCodeCount)
+	buf := make([]byte, 10)
+	for i := 0; i < recoveryCodeCount; i++ {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		var b strings.Builder
+		for j, v := range buf {
+			if j == 5 {
+				b.WriteByte('-')
+			}
+			b.WriteByte(recoveryCodeAlphabet[int(v)%len(recoveryCodeAlphabet)])
+		}
+		code := b.String()
+		codes = append(codes, code)
+		hashed = append(hashed, model.RecoveryCode{
+			UserID:   userID,
+			CodeHash: hashToken(normalizeRecoveryCode(code)),
+		})
+	}
+	return codes, hashed, nil
+}
+
+// normalizeRecoveryCode 忽略大小写和分隔符
+func normalizeRecoveryCode(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
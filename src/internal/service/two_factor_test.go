@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"awesome-trade/src/pkg/totp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试两步验证登记、登录第二步、验证码防重放和恢复码
+func TestTwoFactorLogin(t *testing.T) {
+	auth := setupAuthService(t, 3600)
+	tf := auth.twoFactor
+	ctx := context.Background()
+
+	now := time.Unix(1700000000, 0)
+	tf.now = func() time.Time { return now }
+
+	user, err := auth.Register(ctx, "dave", "dave@example.com", "password123")
+	require.NoError(t, err)
+
+	enrollment, err := tf.Enroll(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/Awesome%20Trade:dave?")
+
+	// 确认前登录不需要第二步
+	result, err := auth.Login(ctx, "dave", "password123")
+	require.NoError(t, err)
+	assert.False(t, result.MFARequired)
+
+	_, err = tf.Confirm(ctx, user.ID, "000000")
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+	code, _ := totp.Code(enrollment.Secret, totp.Step(now))
+	recovery, err := tf.Confirm(ctx, user.ID, code)
+	require.NoError(t, err)
+	assert.Len(t, recovery, recoveryCodeCount)
+
+	result, err = auth.Login(ctx, "dave", "password123")
+	require.NoError(t, err)
+	require.True(t, result.MFARequired)
+	assert.Nil(t, result.TokenPair)
+
+	// 挑战令牌不能当作访问令牌使用
+	_, err = auth.ParseAccessToken(ctx, result.MFAToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// 确认时用过的验证码不能再次使用
+	_, err = auth.LoginWithTwoFactor(ctx, result.MFAToken, code)
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+
+	now = now.Add(totp.Period * time.Second)
+	code, _ = totp.Code(enrollment.Secret, totp.Step(now))
+	final, err := auth.LoginWithTwoFactor(ctx, result.MFAToken, code)
+	require.NoError(t, err)
+	assert.NotEmpty(t, final.AccessToken)
+
+	// 挑战令牌只能使用一次
+	_, err = auth.LoginWithTwoFactor(ctx, result.MFAToken, recovery[0])
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+
+	// 恢复码可替代验证码，且只能使用一次
+	result, err = auth.Login(ctx, "dave", "password123")
+	require.NoError(t, err)
+	_, err = auth.LoginWithTwoFactor(ctx, result.MFAToken, recovery[0])
+	require.NoError(t, err)
+	assert.ErrorIs(t, tf.Verify(ctx, user.ID, recovery[0]), ErrInvalidTwoFactorCode)
+	remaining, err := tf.RemainingRecoveryCodes(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(recoveryCodeCount-1), remaining)
+}
+
+// 测试敏感操作的验证新鲜度
+func TestTwoFactorFreshness(t *testing.T) {
+	auth := setupAuthService(t, 3600)
+	tf := auth.twoFactor
+	ctx := context.Background()
+
+	now := time.Unix(1700000000, 0)
+	tf.now = func() time.Time { return now }
+
+	user, err := auth.Register(ctx, "erin", "erin@example.com", "password123")
+	require.NoError(t, err)
+	_, err = tf.VerifiedRecently(ctx, user.ID)
+	assert.ErrorIs(t, err, ErrTwoFactorRequired)
+
+	enrollment, err := tf.Enroll(ctx, user.ID)
+	require.NoError(t, err)
+	code, _ := totp.Code(enrollment.Secret, totp.Step(now))
+	_, err = tf.Confirm(ctx, user.ID, code)
+	require.NoError(t, err)
+
+	fresh, err := tf.VerifiedRecently(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, fresh)
+
+	now = now.Add(10 * time.Minute)
+	fresh, err = tf.VerifiedRecently(ctx, user.ID)
+	require.NoError(t, err)
+	assert.False(t, fresh)
+
+	code, _ = totp.Code(enrollment.Secret, totp.Step(now))
+	require.NoError(t, tf.Verify(ctx, user.ID, code))
+	fresh, err = tf.VerifiedRecently(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, fresh)
+}
+
+// 测试验证码连续错误后吊销挑战令牌并锁定两步验证，确认登记同样受锁定限制
+func TestTwoFactorLockout(t *testing.T) {
+	auth := setupAuthService(t, 3600)
+	tf := auth.twoFactor
+	ctx := context.Background()
+
+	now := time.Unix(1700000000, 0)
+	tf.now = func() time.Time { return now }
+
+	user, err := auth.Register(ctx, "frank", "frank@example.com", "password123")
+	require.NoError(t, err)
+	enrollment, err := tf.Enroll(ctx, user.ID)
+	require.NoError(t, err)
+	code, _ := totp.Code(enrollment.Secret, totp.Step(now))
+	_, err = tf.Confirm(ctx, user.ID, code)
+	require.NoError(t, err)
+	now = now.Add(totp.Period * time.Second)
+
+	// 同一挑战令牌错误3次后被吊销
+	first, err := auth.Login(ctx, "frank", "password123")
+	require.NoError(t, err)
+	for i := 0; i < mfaTokenMaxAttempts; i++ {
+		_, err = auth.LoginWithTwoFactor(ctx, first.MFAToken, "000000")
+		assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+	}
+	code, _ = totp.Code(enrollment.Secret, totp.Step(now))
+	_, err = auth.LoginWithTwoFactor(ctx, first.MFAToken, code)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+
+	// 用户累计错误5次后锁定，锁定期间正确的验证码也被拒绝
+	second, err := auth.Login(ctx, "frank", "password123")
+	require.NoError(t, err)
+	_, err = auth.LoginWithTwoFactor(ctx, second.MFAToken, "000000")
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+	_, err = auth.LoginWithTwoFactor(ctx, second.MFAToken, "000000")
+	assert.ErrorIs(t, err, ErrTwoFactorLocked)
+	_, err = auth.LoginWithTwoFactor(ctx, second.MFAToken, code)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+	assert.ErrorIs(t, tf.Verify(ctx, user.ID, code), ErrTwoFactorLocked)
+
+	// 锁定到期后恢复
+	now = now.Add(defaultTwoFactorLockout)
+	code, _ = totp.Code(enrollment.Secret, totp.Step(now))
+	require.NoError(t, tf.Verify(ctx, user.ID, code))
+
+	// 确认登记同样计入失败次数，锁定期间正确的验证码也不能启用
+	other, err := auth.Register(ctx, "grace", "grace@example.com", "password123")
+	require.NoError(t, err)
+	enrollment, err = tf.Enroll(ctx, other.ID)
+	require.NoError(t, err)
+	for i := 1; i < defaultTwoFactorMaxAttempts; i++ {
+		_, err = tf.Confirm(ctx, other.ID, "000000")
+		assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+	}
+	_, err = tf.Confirm(ctx, other.ID, "000000")
+	assert.ErrorIs(t, err, ErrTwoFactorLocked)
+	code, _ = totp.Code(enrollment.Secret, totp.Step(now))
+	_, err = tf.Confirm(ctx, other.ID, code)
+	assert.ErrorIs(t, err, ErrTwoFactorLocked)
+	// 重新登记不清除锁定
+	enrollment, err = tf.Enroll(ctx, other.ID)
+	require.NoError(t, err)
+	code, _ = totp.Code(enrollment.Secret, totp.Step(now))
+	_, err = tf.Confirm(ctx, other.ID, code)
+	assert.ErrorIs(t, err, ErrTwoFactorLocked)
+
+	now = now.Add(defaultTwoFactorLockout)
+	code, _ = totp.Code(enrollment.Secret, totp.Step(now))
+	_, err = tf.Confirm(ctx, other.ID, code)
+	require.NoError(t, err)
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"awesome-trade/src/internal/database"
+	"awesome-trade/src/internal/model"
+	"awesome-trade/src/internal/repository"
+	"awesome-trade/src/pkg/utils"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 用户服务错误
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserExists   = errors.New("username or email already exists")
+)
+
+// CreateUserInput 创建用户参数
+type CreateUserInput struct {
+	Username string
+	Email    string
+	Password string
+	IsActive *bool
+}
+
+// UpdateUserInput 更新用户参数，nil字段表示不修改
+type UpdateUserInput struct {
+	Username *string
+	Email    *string
+	Password *string
+	IsActive *bool
+}
+
+// UserService 用户服务
+type UserService struct {
+	*BaseService
+	users *repository.UserRepository
+}
+
+// NewUserService 创建用户服务实例
+func NewUserService(tx *database.TxManager, users *repository.UserRepository) *UserService {
+	return &UserService{
+		BaseService: NewBaseService(tx),
+		users:       users,
+	}
+}
+
+// List 分页查询用户列表
+func (s *UserService) List(ctx context.Context, q repository.UserQuery) ([]model.User, int64, error) {
+	q.Page, q.PageSize = utils.NormalizePage(q.Page, q.PageSize)
+	return s.users.List(ctx, q)
+}
+
+// Get 获取用户详情
+func (s *UserService) Get(ctx context.Context, id uint) (*model.User, error) {
+	user, err := s.users.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// Create 创建用户，唯一性检查与写入在同一事务中完成
+func (s *UserService) Create(ctx context.Context, in CreateUserInput) (*model.User, error) {
+	hash, err := hashPassword(in.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		Username: in.Username,
+		Email:    in.Email,
+		Password: hash,
+		IsActive: true,
+	}
+	if in.IsActive != nil {
+		user.IsActive = *in.IsActive
+	}
+
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		exists, err := s.users.ExistsByUsernameOrEmail(ctx, in.Username, in.Email, 0)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrUserExists
+		}
+		return s.users.Create(ctx, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Update 更新用户，读取、唯一性检查与保存在同一事务中完成
+func (s *UserService) Update(ctx context.Context, id uint, in UpdateUserInput) (*model.User, error) {
+	var hash string
+	if in.Password != nil {
+		h, err := hashPassword(*in.Password)
+		if err != nil {
+			return nil, err
+		}
+		hash = h
+	}
+
+	var user *model.User
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		if user, err = s.Get(ctx, id); err != nil {
+			return err
+		}
+		return s.applyUpdate(ctx, user, in, hash)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// applyUpdate 将更新参数写入用户并保存
+func (s *UserService) applyUpdate(ctx context.Context, user *model.User, in UpdateUserInput, hash string) error {
+	if in.Username != nil {
+		user.Username = *in.Username
+	}
+	if in.Email != nil {
+		user.Email = *in.Email
+	}
+	if in.Username != nil || in.Email != nil {
+		exists, err := s.users.ExistsByUsernameOrEmail(ctx, user.Username, user.Email, user.ID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrUserExists
+		}
+	}
+	if in.Password != nil {
+		user.Password = hash
+		user.TokenVersion++
+	}
+	if in.IsActive != nil {
+		user.IsActive = *in.IsActive
+	}
+	return s.users.Update(ctx, user)
+}
+
+// Delete 软删除用户
+func (s *UserService) Delete(ctx context.Context, id uint) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	return s.users.Delete(ctx, id)
+}
+
+// hashPassword 使用bcrypt生成密码哈希
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
@@ -0,0 +1,88 @@
+package stream
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"awesome-trade/src/internal/model"
+)
+
+// 频道错误
+var (
+	ErrInvalidChannel = errors.New("invalid channel")
+	ErrAuthRequired   = errors.New("channel requires authentication")
+)
+
+// 频道类型。公开频道名带交易对，如depth:BTC_USDT、klines:BTC_USDT:1m；私有频道只推送当前用户的数据。
+const (
+	ChannelDepth    = "depth"    // 深度增量
+	ChannelTrades   = "trades"   // 公开成交
+	ChannelKlines   = "klines"   // 当前K线
+	ChannelOrders   = "orders"   // 自己的订单变化
+	ChannelFills    = "fills"    // 自己的成交
+	ChannelBalances = "balances" // 自己的余额变化
+)
+
+// symbolPattern 频道中交易对名称的格式
+var symbolPattern = regexp.MustCompile(`^[A-Z0-9]{1,16}_[A-Z0-9]{1,16}$`)
+
+// Topic 订阅主题，私有频道按用户区分
+type Topic struct {
+	Channel string // 客户端可见的频道名
+	UserID  uint   // 私有频道所属用户，公开频道为0
+}
+
+// DepthTopic 交易对的深度增量主题
+func DepthTopic(symbol string) Topic {
+	return Topic{Channel: ChannelDepth + ":" + symbol}
+}
+
+// TradesTopic 交易对的公开成交主题
+func TradesTopic(symbol string) Topic {
+	return Topic{Channel: ChannelTrades + ":" + symbol}
+}
+
+// KlinesTopic 交易对指定周期的K线主题
+func KlinesTopic(symbol, interval string) Topic {
+	return Topic{Channel: ChannelKlines + ":" + symbol + ":" + interval}
+}
+
+// PrivateTopic 用户的私有频道主题
+func PrivateTopic(channel string, userID uint) Topic {
+	return Topic{Channel: channel, UserID: userID}
+}
+
+// ParseChannel 解析客户端订阅的频道名，交易对不区分大小写。私有频道须已认证，主题归属于userID。
+func ParseChannel(name string, userID uint) (Topic, error) {
+	parts := strings.Split(strings.TrimSpace(name), ":")
+	switch parts[0] {
+	case ChannelOrders, ChannelFills, ChannelBalances:
+		if len(parts) != 1 {
+			return Topic{}, ErrInvalidChannel
+		}
+		if userID == 0 {
+			return Topic{}, ErrAuthRequired
+		}
+		return PrivateTopic(parts[0], userID), nil
+	case ChannelDepth, ChannelTrades:
+		if len(parts) != 2 {
+			return Topic{}, ErrInvalidChannel
+		}
+	case ChannelKlines:
+		if len(parts) != 3 {
+			return Topic{}, ErrInvalidChannel
+		}
+		if _, ok := model.KlineIntervals[parts[2]]; !ok {
+			return Topic{}, ErrInvalidChannel
+		}
+	default:
+		return Topic{}, ErrInvalidChannel
+	}
+
+	parts[1] = strings.ToUpper(parts[1])
+	if !symbolPattern.MatchString(parts[1]) {
+		return Topic{}, ErrInvalidChannel
+	}
+	return Topic{Channel: strings.Join(parts, ":")}, nil
+}
@@ -0,0 +1,317 @@
+// Package stream 实时推送的频道与订阅管理。发布方按主题发布消息，每个订阅者有一个有界发送缓冲，
+// 缓冲已满的慢消费者会被断开，由客户端重连后通过REST快照重新同步。
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 订阅错误
+var (
+	ErrTooManySubscriptions = errors.New("too many subscriptions")
+	ErrSubscriberClosed     = errors.New("subscriber is closed")
+	ErrInvalidEventID       = errors.New("invalid event id")
+)
+
+// Message 推送消息。Seq在主题内单调递增，PrevSeq为同一主题上一条消息的Seq，
+// 客户端发现PrevSeq与自己收到的上一条Seq不一致时说明有消息丢失，应重新同步。
+// 公开频道的Seq为产生该消息的定序器命令序号，可与REST深度快照的seq直接比较。
+type Message struct {
+	Channel string      `json:"channel"`
+	Seq     uint64      `json:"seq"`
+	PrevSeq uint64      `json:"prev_seq"`
+	Data    interface{} `json:"data"`
+}
+
+// Event 一条已编码的推送消息。公开频道的消息带有全局递增的ID并保留在重放缓冲中，私有频道的ID为0。
+type Event struct {
+	ID      uint64
+	Channel string
+	Data    []byte
+}
+
+// EventID 公开消息对客户端的续传ID，格式为 <纪元>-<序号>。纪元在Hub创建时生成，
+// 服务重启后序号从头开始，纪元不同的ID无法续传
+type EventID struct {
+	Epoch string
+	Seq   uint64
+}
+
+// String 返回 <纪元>-<序号> 格式的ID
+func (id EventID) String() string {
+	return id.Epoch + "-" + strconv.FormatUint(id.Seq, 10)
+}
+
+// ParseEventID 解析续传ID，只有序号的旧格式ID纪元为空，续传时总是报告缺口
+func ParseEventID(s string) (EventID, error) {
+	i := strings.LastIndexByte(s, '-')
+	seq, err := strconv.ParseUint(s[i+1:], 10, 64)
+	if err != nil {
+		return EventID{}, ErrInvalidEventID
+	}
+	if i < 0 {
+		return EventID{Seq: seq}, nil
+	}
+	return EventID{Epoch: s[:i], Seq: seq}, nil
+}
+
+// Subscriber 一个推送连接的订阅者。发送缓冲满时被移出全部主题并关闭Done。
+type Subscriber struct {
+	UserID uint // 已认证的用户，匿名为0，只能由连接自身的协程修改
+
+	send   chan Event
+	done   chan struct{}
+	once   sync.Once
+	max    int
+	topics map[Topic]struct{} // 由Hub.mu保护
+}
+
+// C 返回待发送的消息
+func (s *Subscriber) C() <-chan Event {
+	return s.send
+}
+
+// Done 订阅者因发送缓冲满或被移除而关闭时关闭
+func (s *Subscriber) Done() <-chan struct{} {
+	return s.done
+}
+
+// close 关闭订阅者，可重复调用
+func (s *Subscriber) close() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// Hub 主题与订阅者的注册表，并发安全
+type Hub struct {
+	bufferSize int
+	epoch      string // 本次启动的纪元，创建后不变
+
+	mu      sync.Mutex
+	topics  map[Topic]map[*Subscriber]struct{}
+	seqs    map[Topic]uint64
+	eventID uint64 // 最后一条公开消息的ID
+	replay  *ring
+}
+
+// NewHub 创建推送注册表，bufferSize为每个订阅者的发送缓冲消息数，
+// replaySize为重放缓冲保留的最近公开消息数，0表示不保留
+func NewHub(bufferSize, replaySize int) *Hub {
+	if bufferSize <= 0 {
+		bufferSize = 256
+	}
+	h := &Hub{
+		bufferSize: bufferSize,
+		epoch:      strconv.FormatInt(time.Now().UnixNano(), 36),
+		topics:     make(map[Topic]map[*Subscriber]struct{}),
+		seqs:       make(map[Topic]uint64),
+	}
+	if replaySize > 0 {
+		h.replay = newRing(replaySize)
+	}
+	return h
+}
+
+// EventID 返回公开消息对客户端的续传ID
+func (h *Hub) EventID(ev Event) EventID {
+	return EventID{Epoch: h.epoch, Seq: ev.ID}
+}
+
+// NewSubscriber 创建订阅者，maxTopics为最多订阅的主题数，0表示不限制
+func (h *Hub) NewSubscriber(userID uint, maxTopics int) *Subscriber {
+	return &Subscriber{
+		UserID: userID,
+		send:   make(chan Event, h.bufferSize),
+		done:   make(chan struct{}),
+		max:    maxTopics,
+		topics: make(map[Topic]struct{}),
+	}
+}
+
+// Subscribe 订阅主题，重复订阅不报错
+func (h *Hub) Subscribe(sub *Subscriber, topic Topic) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.subscribe(sub, topic)
+}
+
+// Unsubscribe 取消订阅主题
+func (h *Hub) Unsubscribe(sub *Subscriber, topic Topic) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.unsubscribe(sub, topic)
+}
+
+// Remove 取消订阅者的全部订阅并关闭订阅者
+func (h *Hub) Remove(sub *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(sub)
+}
+
+// HasSubscribers 判断主题是否有订阅者，发布方可据此跳过代价较高的消息构造
+func (h *Hub) HasSubscribers(topic Topic) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.topics[topic]) > 0
+}
+
+// Publish 向主题的全部订阅者发布消息。seq为0时使用主题上一条消息的Seq加1，
+// 否则使用给定的seq（公开频道传入定序器序号）。公开消息分配全局ID并写入重放缓冲；
+// 没有订阅者也不需要重放时只记录seq，不构造消息。
+func (h *Hub) Publish(topic Topic, seq uint64, data interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	prev := h.seqs[topic]
+	if seq == 0 {
+		seq = prev + 1
+	}
+	h.seqs[topic] = seq
+	subs := h.topics[topic]
+	replay := h.replay != nil && topic.UserID == 0
+	if len(subs) == 0 && !replay {
+		return
+	}
+
+	b, err := json.Marshal(Message{Channel: topic.Channel, Seq: seq, PrevSeq: prev, Data: data})
+	if err != nil {
+		log.Printf("Failed to encode %s message: %v", topic.Channel, err)
+		return
+	}
+	ev := Event{Channel: topic.Channel, Data: b}
+	if topic.UserID == 0 {
+		h.eventID++
+		ev.ID = h.eventID
+	}
+	if replay {
+		h.replay.add(ev)
+	}
+	for sub := range subs {
+		if !sub.offer(ev) {
+			h.remove(sub)
+		}
+	}
+}
+
+// Replay 续传结果
+type Replay struct {
+	Events []Event // 需要补发的消息，按ID正序
+	Gap    bool    // 所需消息已不在重放缓冲中或纪元不同（服务已重启），客户端须重新同步
+	LastID EventID // 订阅生效时最后一条公开消息的续传ID
+}
+
+// Resume 订阅一组公开主题，并取出重放缓冲中ID在last之后的相关消息，两者在同一临界区内完成，
+// 之后的消息经发送缓冲送达，不重不漏。last为零值表示不续传，纪元与本次启动不同时报告缺口。
+func (h *Hub) Resume(sub *Subscriber, topics []Topic, last EventID) (Replay, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, topic := range topics {
+		if err := h.subscribe(sub, topic); err != nil {
+			return Replay{}, err
+		}
+	}
+	replay := Replay{LastID: EventID{Epoch: h.epoch, Seq: h.eventID}}
+	if last == (EventID{}) {
+		return replay, nil
+	}
+	lastID := last.Seq
+	if last.Epoch != h.epoch || h.replay == nil || lastID > h.eventID {
+		replay.Gap = true
+		return replay, nil
+	}
+	if oldest, found := h.replay.oldest(); found && lastID+1 < oldest {
+		replay.Gap = true
+		return replay, nil
+	}
+
+	wanted := make(map[string]bool, len(topics))
+	for _, topic := range topics {
+		wanted[topic.Channel] = true
+	}
+	h.replay.each(func(ev Event) {
+		if ev.ID > lastID && wanted[ev.Channel] {
+			replay.Events = append(replay.Events, ev)
+		}
+	})
+	return replay, nil
+}
+
+// Send 直接向订阅者发送一条消息（如订阅应答），发送缓冲满时关闭订阅者并返回false
+func (h *Hub) Send(sub *Subscriber, v interface{}) bool {
+	b, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("Failed to encode reply: %v", err)
+		return false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if sub.offer(Event{Data: b}) {
+		return true
+	}
+	h.remove(sub)
+	return false
+}
+
+// offer 不阻塞地放入发送缓冲，订阅者已关闭或缓冲已满时返回false
+func (s *Subscriber) offer(ev Event) bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+	select {
+	case s.send <- ev:
+		return true
+	default:
+		return false
+	}
+}
+
+// subscribe 订阅主题，须持有h.mu
+func (h *Hub) subscribe(sub *Subscriber, topic Topic) error {
+	select {
+	case <-sub.done:
+		return ErrSubscriberClosed
+	default:
+	}
+	if _, ok := sub.topics[topic]; ok {
+		return nil
+	}
+	if sub.max > 0 && len(sub.topics) >= sub.max {
+		return ErrTooManySubscriptions
+	}
+	subs, ok := h.topics[topic]
+	if !ok {
+		subs = make(map[*Subscriber]struct{})
+		h.topics[topic] = subs
+	}
+	subs[sub] = struct{}{}
+	sub.topics[topic] = struct{}{}
+	return nil
+}
+
+// unsubscribe 取消一个订阅，须持有h.mu
+func (h *Hub) unsubscribe(sub *Subscriber, topic Topic) {
+	delete(sub.topics, topic)
+	if subs, ok := h.topics[topic]; ok {
+		delete(subs, sub)
+		if len(subs) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+}
+
+// remove 取消全部订阅并关闭订阅者，须持有h.mu
+func (h *Hub) remove(sub *Subscriber) {
+	for topic := range sub.topics {
+		h.unsubscribe(sub, topic)
+	}
+	sub.close()
+}
@@ -0,0 +1,170 @@
+package stream
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receive 取出订阅者缓冲中的下一条消息
+func receive(t *testing.T, sub *Subscriber) Message {
+	t.Helper()
+	select {
+	case ev := <-sub.C():
+		var msg Message
+		require.NoError(t, json.Unmarshal(ev.Data, &msg))
+		return msg
+	default:
+		t.Fatal("no message")
+		return Message{}
+	}
+}
+
+// 测试消息序号、私有主题隔离与取消订阅
+func TestHubPublish(t *testing.T) {
+	hub := NewHub(8, 0)
+	alice := hub.NewSubscriber(1, 0)
+	bob := hub.NewSubscriber(2, 0)
+	depth := DepthTopic("BTC_USDT")
+	require.NoError(t, hub.Subscribe(alice, depth))
+	require.NoError(t, hub.Subscribe(alice, PrivateTopic(ChannelOrders, 1)))
+	require.NoError(t, hub.Subscribe(bob, PrivateTopic(ChannelOrders, 2)))
+
+	// 没有订阅者时也推进序号
+	hub.Publish(TradesTopic("BTC_USDT"), 3, "ignored")
+	hub.Publish(depth, 5, "a")
+	hub.Publish(depth, 9, "b")
+	msg := receive(t, alice)
+	assert.Equal(t, Message{Channel: "depth:BTC_USDT", Seq: 5, PrevSeq: 0, Data: "a"}, msg)
+	msg = receive(t, alice)
+	assert.Equal(t, uint64(9), msg.Seq)
+	assert.Equal(t, uint64(5), msg.PrevSeq)
+
+	hub.Publish(PrivateTopic(ChannelOrders, 2), 0, "bob-1")
+	hub.Publish(PrivateTopic(ChannelOrders, 2), 0, "bob-2")
+	assert.Len(t, alice.C(), 0)
+	receive(t, bob)
+	msg = receive(t, bob)
+	assert.Equal(t, Message{Channel: "orders", Seq: 2, PrevSeq: 1, Data: "bob-2"}, msg)
+
+	hub.Unsubscribe(alice, depth)
+	assert.False(t, hub.HasSubscribers(depth))
+	hub.Publish(depth, 10, "c")
+	assert.Len(t, alice.C(), 0)
+}
+
+// 测试发送缓冲满的订阅者被移出并关闭，订阅数上限
+func TestHubDropsSlowConsumer(t *testing.T) {
+	hub := NewHub(2, 0)
+	slow := hub.NewSubscriber(0, 1)
+	fast := hub.NewSubscriber(0, 0)
+	topic := TradesTopic("ETH_USDT")
+	require.NoError(t, hub.Subscribe(slow, topic))
+	require.NoError(t, hub.Subscribe(fast, topic))
+	assert.ErrorIs(t, hub.Subscribe(slow, DepthTopic("ETH_USDT")), ErrTooManySubscriptions)
+
+	for seq := uint64(1); seq <= 3; seq++ {
+		hub.Publish(topic, seq, seq)
+		if seq < 3 {
+			receive(t, fast)
+		}
+	}
+	select {
+	case <-slow.Done():
+	default:
+		t.Fatal("slow consumer was not dropped")
+	}
+	assert.Equal(t, uint64(3), receive(t, fast).Seq)
+	assert.ErrorIs(t, hub.Subscribe(slow, topic), ErrSubscriberClosed)
+	assert.False(t, hub.Send(slow, "reply"))
+}
+
+// 测试频道名解析
+func TestParseChannel(t *testing.T) {
+	cases := []struct {
+		name    string
+		userID  uint
+		want    Topic
+		wantErr error
+	}{
+		{"depth:btc_usdt", 0, Topic{Channel: "depth:BTC_USDT"}, nil},
+		{"trades:ETH_USDT", 0, Topic{Channel: "trades:ETH_USDT"}, nil},
+		{"klines:BTC_USDT:1h", 0, Topic{Channel: "klines:BTC_USDT:1h"}, nil},
+		{"orders", 7, Topic{Channel: "orders", UserID: 7}, nil},
+		{"fills", 0, Topic{}, ErrAuthRequired},
+		{"klines:BTC_USDT:2m", 0, Topic{}, ErrInvalidChannel},
+		{"depth:BTCUSDT", 0, Topic{}, ErrInvalidChannel},
+		{"depth", 0, Topic{}, ErrInvalidChannel},
+		{"balances:BTC_USDT", 7, Topic{}, ErrInvalidChannel},
+		{"ticker:BTC_USDT", 0, Topic{}, ErrInvalidChannel},
+	}
+	for _, tc := range cases {
+		got, err := ParseChannel(tc.name, tc.userID)
+		if tc.wantErr != nil {
+			assert.ErrorIs(t, err, tc.wantErr, tc.name)
+			continue
+		}
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.want, got, tc.name)
+	}
+}
+
+// 测试按Last-Event-ID续传：补发订阅频道中错过的消息，超出重放缓冲或纪元不同时报告缺口
+func TestHubResume(t *testing.T) {
+	hub := NewHub(8, 3)
+	depth, trades := DepthTopic("BTC_USDT"), TradesTopic("BTC_USDT")
+	hub.Publish(depth, 1, "d1")                        // ID 1
+	hub.Publish(trades, 2, "t2")                       // ID 2
+	hub.Publish(PrivateTopic(ChannelOrders, 1), 0, "") // 私有消息不分配ID、不进入重放缓冲
+	hub.Publish(depth, 3, "d3")                        // ID 3
+	hub.Publish(trades, 4, "t4")                       // ID 4，挤出ID 1
+
+	id := func(seq uint64) EventID { return EventID{Epoch: hub.epoch, Seq: seq} }
+	sub := hub.NewSubscriber(0, 0)
+	replay, err := hub.Resume(sub, []Topic{depth}, id(1))
+	require.NoError(t, err)
+	assert.False(t, replay.Gap)
+	assert.Equal(t, id(4), replay.LastID)
+	require.Len(t, replay.Events, 1)
+	assert.Equal(t, uint64(3), replay.Events[0].ID)
+
+	// 订阅已生效，之后的消息经发送缓冲送达
+	hub.Publish(depth, 5, "d5")
+	msg := receive(t, sub)
+	assert.Equal(t, uint64(5), msg.Seq)
+
+	// 不续传、序号超前以及其他纪元（服务重启前）的ID
+	for _, lastID := range []EventID{{}, id(9), {Epoch: "old", Seq: 2}, {Seq: 2}} {
+		replay, err = hub.Resume(hub.NewSubscriber(0, 0), []Topic{depth, trades}, lastID)
+		require.NoError(t, err)
+		assert.Equal(t, lastID != EventID{}, replay.Gap, "last id %v", lastID)
+		assert.Empty(t, replay.Events)
+	}
+	replay, err = hub.Resume(hub.NewSubscriber(0, 0), []Topic{depth, trades}, id(1))
+	require.NoError(t, err)
+	assert.True(t, replay.Gap)
+	replay, err = hub.Resume(hub.NewSubscriber(0, 0), []Topic{depth, trades}, id(2))
+	require.NoError(t, err)
+	assert.False(t, replay.Gap)
+	assert.Len(t, replay.Events, 3)
+}
+
+// 测试续传ID的格式和解析
+func TestParseEventID(t *testing.T) {
+	id, err := ParseEventID("lx3k2a-42")
+	require.NoError(t, err)
+	assert.Equal(t, EventID{Epoch: "lx3k2a", Seq: 42}, id)
+	assert.Equal(t, "lx3k2a-42", id.String())
+
+	// 旧格式只有序号，纪元为空
+	id, err = ParseEventID("42")
+	require.NoError(t, err)
+	assert.Equal(t, EventID{Seq: 42}, id)
+
+	for _, s := range []string{"", "abc", "lx3k2a-", "lx3k2a-x"} {
+		_, err = ParseEventID(s)
+		assert.ErrorIs(t, err, ErrInvalidEventID, s)
+	}
+}
@@ -0,0 +1,39 @@
+package stream
+
+// ring 定长的环形重放缓冲，保留最近的公开消息，满时覆盖最早的消息。由Hub.mu保护。
+type ring struct {
+	events []Event
+	start  int // 最早一条消息的位置
+	size   int
+}
+
+// newRing 创建容量为capacity的重放缓冲
+func newRing(capacity int) *ring {
+	return &ring{events: make([]Event, capacity)}
+}
+
+// add 追加一条消息
+func (r *ring) add(ev Event) {
+	if r.size < len(r.events) {
+		r.events[(r.start+r.size)%len(r.events)] = ev
+		r.size++
+		return
+	}
+	r.events[r.start] = ev
+	r.start = (r.start + 1) % len(r.events)
+}
+
+// oldest 返回最早一条消息的ID，缓冲为空时found为false
+func (r *ring) oldest() (id uint64, found bool) {
+	if r.size == 0 {
+		return 0, false
+	}
+	return r.events[r.start].ID, true
+}
+
+// each 按从早到晚的顺序遍历缓冲中的消息
+func (r *ring) each(fn func(Event)) {
+	for i := 0; i < r.size; i++ {
+		fn(r.events[(r.start+i)%len(r.events)])
+	}
+}
@@ -0,0 +1,427 @@
+// Package decimal 定点十进制数，用于价格、数量和金额，避免float64的二进制舍入误差。
+// 值表示为整数系数乘以10的负小数位数次方：加、减、乘和取模精确计算，除法和舍入须给出保留的
+// 小数位数与舍入模式。可表示的范围与数据库列numeric(36,18)一致，即最多18位整数和18位小数，
+// 超出范围的值在解析、JSON解码和写入数据库时报错；运算的中间结果不受限制。
+package decimal
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// 可表示的范围，对应numeric(36,18)
+const (
+	MaxScale     = 18 // 最多小数位数
+	MaxIntDigits = 18 // 最多整数位数
+)
+
+// 解析和范围错误
+var (
+	ErrSyntax    = errors.New("decimal: invalid syntax")
+	ErrOverflow  = errors.New("decimal: more than 18 integer digits")
+	ErrPrecision = errors.New("decimal: more than 18 decimal places")
+)
+
+// maxExponent 科学计数法指数的绝对值上限，防止构造过大的系数
+const maxExponent = 1000
+
+// Decimal 定点十进制数，值为 coef × 10^-scale。零值表示0，可直接使用。
+// Decimal是不可变的值类型，所有运算都返回新值。
+type Decimal struct {
+	coef  *big.Int // nil表示0，创建后不再修改
+	scale int32    // 小数位数，非负
+}
+
+// Zero 0
+var Zero = Decimal{}
+
+// New 返回 value × 10^exp
+func New(value int64, exp int32) Decimal {
+	return newDecimal(big.NewInt(value), exp)
+}
+
+// NewFromInt 由整数创建
+func NewFromInt(value int64) Decimal {
+	return New(value, 0)
+}
+
+// NewFromBigInt 返回 value × 10^exp，不会修改value
+func NewFromBigInt(value *big.Int, exp int32) Decimal {
+	return newDecimal(new(big.Int).Set(value), exp)
+}
+
+// NewFromString 解析十进制字符串，支持可选的正负号和科学计数法（如 "-1.5"、"2e-8"），
+// 超出可表示范围时返回ErrOverflow或ErrPrecision
+func NewFromString(s string) (Decimal, error) {
+	d, err := parse(s)
+	if err != nil {
+		return Zero, err
+	}
+	if err := d.Check(); err != nil {
+		return Zero, err
+	}
+	return d, nil
+}
+
+// RequireFromString 解析十进制字符串，失败时panic，用于常量和测试
+func RequireFromString(s string) Decimal {
+	d, err := NewFromString(s)
+	if err != nil {
+		panic(fmt.Sprintf("decimal: cannot parse %q: %v", s, err))
+	}
+	return d
+}
+
+// Min 返回最小值
+func Min(first Decimal, rest ...Decimal) Decimal {
+	m := first
+	for _, d := range rest {
+		if d.LessThan(m) {
+			m = d
+		}
+	}
+	return m
+}
+
+// Max 返回最大值
+func Max(first Decimal, rest ...Decimal) Decimal {
+	m := first
+	for _, d := range rest {
+		if d.GreaterThan(m) {
+			m = d
+		}
+	}
+	return m
+}
+
+// Add 返回 d + d2
+func (d Decimal) Add(d2 Decimal) Decimal {
+	a, b, scale := align(d, d2)
+	return Decimal{coef: a.Add(a, b), scale: scale}
+}
+
+// Sub 返回 d - d2
+func (d Decimal) Sub(d2 Decimal) Decimal {
+	a, b, scale := align(d, d2)
+	return Decimal{coef: a.Sub(a, b), scale: scale}
+}
+
+// Mul 返回 d × d2，结果的小数位数为两者之和，不舍入
+func (d Decimal) Mul(d2 Decimal) Decimal {
+	if d.IsZero() || d2.IsZero() {
+		return Zero
+	}
+	return Decimal{coef: new(big.Int).Mul(d.coef, d2.coef), scale: d.scale + d2.scale}
+}
+
+// Div 返回 d ÷ d2，保留MaxScale位小数、四舍五入。d2为0时panic。
+func (d Decimal) Div(d2 Decimal) Decimal {
+	return d.DivRound(d2, MaxScale, RoundHalfUp)
+}
+
+// DivRound 返回 d ÷ d2，按mode保留places位小数。d2为0时panic。
+func (d Decimal) DivRound(d2 Decimal, places int32, mode RoundingMode) Decimal {
+	if d2.IsZero() {
+		panic("decimal: division by zero")
+	}
+	if d.IsZero() {
+		return Zero
+	}
+	// d/d2 = (c1/c2) × 10^(s2-s1)，放大10^places后取整
+	num, den := new(big.Int).Set(d.coef), new(big.Int).Set(d2.coef)
+	if k := places + d2.scale - d.scale; k >= 0 {
+		num.Mul(num, pow10(k))
+	} else {
+		den.Mul(den, pow10(-k))
+	}
+	return fromScaled(roundQuo(num, den, mode), places)
+}
+
+// Mod 返回 d 除以 d2 的余数，符号与d相同。d2为0时panic。
+func (d Decimal) Mod(d2 Decimal) Decimal {
+	if d2.IsZero() {
+		panic("decimal: division by zero")
+	}
+	a, b, scale := align(d, d2)
+	return Decimal{coef: a.Rem(a, b), scale: scale}
+}
+
+// Neg 返回 -d
+func (d Decimal) Neg() Decimal {
+	if d.IsZero() {
+		return Zero
+	}
+	return Decimal{coef: new(big.Int).Neg(d.coef), scale: d.scale}
+}
+
+// Abs 返回 |d|
+func (d Decimal) Abs() Decimal {
+	if d.Sign() >= 0 {
+		return d
+	}
+	return d.Neg()
+}
+
+// Shift 返回 d × 10^n
+func (d Decimal) Shift(n int32) Decimal {
+	if d.IsZero() {
+		return Zero
+	}
+	return newDecimal(new(big.Int).Set(d.coef), n-d.scale)
+}
+
+// Sign 返回-1、0或1
+func (d Decimal) Sign() int {
+	if d.coef == nil {
+		return 0
+	}
+	return d.coef.Sign()
+}
+
+// IsZero 判断是否为0
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// IsPositive 判断是否大于0
+func (d Decimal) IsPositive() bool {
+	return d.Sign() > 0
+}
+
+// IsNegative 判断是否小于0
+func (d Decimal) IsNegative() bool {
+	return d.Sign() < 0
+}
+
+// Cmp 比较大小，d < d2 返回-1，相等返回0，d > d2 返回1
+func (d Decimal) Cmp(d2 Decimal) int {
+	a, b, _ := align(d, d2)
+	return a.Cmp(b)
+}
+
+// Equal 判断数值是否相等，与小数位数无关（1.50等于1.5）
+func (d Decimal) Equal(d2 Decimal) bool {
+	return d.Cmp(d2) == 0
+}
+
+// GreaterThan 判断 d > d2
+func (d Decimal) GreaterThan(d2 Decimal) bool {
+	return d.Cmp(d2) > 0
+}
+
+// GreaterThanOrEqual 判断 d >= d2
+func (d Decimal) GreaterThanOrEqual(d2 Decimal) bool {
+	return d.Cmp(d2) >= 0
+}
+
+// LessThan 判断 d < d2
+func (d Decimal) LessThan(d2 Decimal) bool {
+	return d.Cmp(d2) < 0
+}
+
+// LessThanOrEqual 判断 d <= d2
+func (d Decimal) LessThanOrEqual(d2 Decimal) bool {
+	return d.Cmp(d2) <= 0
+}
+
+// Scale 返回去掉末尾0后的小数位数，如1.50为1，整数为0
+func (d Decimal) Scale() int32 {
+	return d.trim().scale
+}
+
+// IntPart 返回向零截断后的整数部分，超出int64范围时结果无意义
+func (d Decimal) IntPart() int64 {
+	return d.BigInt().Int64()
+}
+
+// BigInt 返回向零截断后的整数部分
+func (d Decimal) BigInt() *big.Int {
+	if d.IsZero() {
+		return new(big.Int)
+	}
+	return new(big.Int).Quo(d.coef, pow10(d.scale))
+}
+
+// Float64 返回最接近的float64，仅用于展示和粗略比较，不能参与金额计算
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// Check 检查是否在可表示范围内：整数部分不超过MaxIntDigits位，去掉末尾0后小数不超过MaxScale位
+func (d Decimal) Check() error {
+	t := d.trim()
+	if t.scale > MaxScale {
+		return ErrPrecision
+	}
+	if t.IsZero() {
+		return nil
+	}
+	limit := pow10(MaxIntDigits + t.scale)
+	if new(big.Int).Abs(t.coef).Cmp(limit) >= 0 {
+		return ErrOverflow
+	}
+	return nil
+}
+
+// String 返回不含末尾0的十进制表示，如 "30000.5"、"-0.001"、"0"
+func (d Decimal) String() string {
+	t := d.trim()
+	if t.IsZero() {
+		return "0"
+	}
+	return format(t.coef, t.scale)
+}
+
+// StringFixed 按四舍五入保留places位小数，不足时补0，如 StringFixed(2) 将1.5格式化为 "1.50"
+func (d Decimal) StringFixed(places int32) string {
+	if places < 0 {
+		places = 0
+	}
+	r := d.Round(places)
+	coef := r.coef
+	if coef == nil {
+		coef = new(big.Int)
+	}
+	if r.scale < places {
+		coef = new(big.Int).Mul(coef, pow10(places-r.scale))
+	}
+	return format(coef, places)
+}
+
+// newDecimal 返回 coef × 10^exp，保证小数位数非负，会修改coef
+func newDecimal(coef *big.Int, exp int32) Decimal {
+	if coef.Sign() == 0 {
+		return Zero
+	}
+	if exp >= 0 {
+		return Decimal{coef: coef.Mul(coef, pow10(exp))}
+	}
+	return Decimal{coef: coef, scale: -exp}
+}
+
+// fromScaled 由系数和小数位数创建，places为负时系数放大为整数
+func fromScaled(coef *big.Int, places int32) Decimal {
+	return newDecimal(coef, -places)
+}
+
+// align 返回按较大的小数位数对齐后的两个系数（新分配）及该小数位数
+func align(a, b Decimal) (*big.Int, *big.Int, int32) {
+	ca, cb := a.bigCoef(), b.bigCoef()
+	switch {
+	case a.scale < b.scale:
+		ca.Mul(ca, pow10(b.scale-a.scale))
+		return ca, cb, b.scale
+	case a.scale > b.scale:
+		cb.Mul(cb, pow10(a.scale-b.scale))
+	}
+	return ca, cb, a.scale
+}
+
+// bigCoef 返回系数的副本
+func (d Decimal) bigCoef() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(d.coef)
+}
+
+// trim 去掉系数末尾的0
+func (d Decimal) trim() Decimal {
+	if d.IsZero() {
+		return Zero
+	}
+	if d.scale == 0 {
+		return d
+	}
+	coef, scale := new(big.Int).Set(d.coef), d.scale
+	ten, r := big.NewInt(10), new(big.Int)
+	for scale > 0 {
+		q, rem := new(big.Int).QuoRem(coef, ten, r)
+		if rem.Sign() != 0 {
+			break
+		}
+		coef, scale = q, scale-1
+	}
+	return Decimal{coef: coef, scale: scale}
+}
+
+// parse 解析十进制字符串，不检查范围
+func parse(s string) (Decimal, error) {
+	if s == "" {
+		return Zero, ErrSyntax
+	}
+	mantissa, exp := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return Zero, ErrSyntax
+		}
+		if e > maxExponent || e < -maxExponent {
+			return Zero, ErrOverflow
+		}
+		mantissa, exp = s[:i], e
+	}
+
+	neg := false
+	switch {
+	case strings.HasPrefix(mantissa, "-"):
+		neg, mantissa = true, mantissa[1:]
+	case strings.HasPrefix(mantissa, "+"):
+		mantissa = mantissa[1:]
+	}
+	intPart, fracPart, _ := strings.Cut(mantissa, ".")
+	if intPart == "" && fracPart == "" {
+		return Zero, ErrSyntax
+	}
+	digits := intPart + fracPart
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return Zero, ErrSyntax
+		}
+	}
+
+	coef, _ := new(big.Int).SetString(digits, 10)
+	if neg {
+		coef.Neg(coef)
+	}
+	return newDecimal(coef, int32(exp)-int32(len(fracPart))), nil
+}
+
+// format 将系数和小数位数格式化为十进制字符串
+func format(coef *big.Int, scale int32) string {
+	digits := new(big.Int).Abs(coef).String()
+	sign := ""
+	if coef.Sign() < 0 {
+		sign = "-"
+	}
+	if scale == 0 {
+		return sign + digits
+	}
+	if pad := int(scale) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	point := len(digits) - int(scale)
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// powers 常用的10的幂
+var powers = func() []*big.Int {
+	p := make([]*big.Int, 2*(MaxScale+MaxIntDigits)+1)
+	p[0] = big.NewInt(1)
+	for i := 1; i < len(p); i++ {
+		p[i] = new(big.Int).Mul(p[i-1], big.NewInt(10))
+	}
+	return p
+}()
+
+// pow10 返回10^n，返回值不可修改
+func pow10(n int32) *big.Int {
+	if int(n) < len(powers) {
+		return powers[n]
+	}
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
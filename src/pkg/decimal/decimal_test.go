@@ -0,0 +1,180 @@
+package decimal
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试解析、格式化与范围检查
+func TestParse(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+		err  error
+	}{
+		{"0", "0", nil},
+		{"-0.00", "0", nil},
+		{"30000.50", "30000.5", nil},
+		{"+1.", "1", nil},
+		{".25", "0.25", nil},
+		{"-0.001", "-0.001", nil},
+		{"2e-8", "0.00000002", nil},
+		{"1.5E3", "1500", nil},
+		{"0.000000000000000001", "0.000000000000000001", nil},
+		{"999999999999999999.999999999999999999", "999999999999999999.999999999999999999", nil},
+		{"1.0000000000000000000000", "1", nil},
+		{"0.0000000000000000001", "", ErrPrecision},
+		{"1000000000000000000", "", ErrOverflow},
+		{"1e18", "", ErrOverflow},
+		{"", "", ErrSyntax},
+		{".", "", ErrSyntax},
+		{"1.2.3", "", ErrSyntax},
+		{"0x10", "", ErrSyntax},
+		{"NaN", "", ErrSyntax},
+		{"1e", "", ErrSyntax},
+		{" 1", "", ErrSyntax},
+	}
+	for _, tc := range cases {
+		d, err := NewFromString(tc.in)
+		if tc.err != nil {
+			assert.ErrorIs(t, err, tc.err, tc.in)
+			continue
+		}
+		require.NoError(t, err, tc.in)
+		assert.Equal(t, tc.want, d.String(), tc.in)
+	}
+	assert.Equal(t, "1.50", RequireFromString("1.5").StringFixed(2))
+	assert.Equal(t, "-0.01", RequireFromString("-0.005").StringFixed(2))
+	assert.Equal(t, "120", New(12, 1).String())
+	assert.Equal(t, "0.012", New(12, -3).String())
+}
+
+// 测试精确运算
+func TestArithmetic(t *testing.T) {
+	d := RequireFromString
+	// float64下 0.1 + 0.2 != 0.3
+	assert.True(t, d("0.1").Add(d("0.2")).Equal(d("0.3")))
+	assert.Equal(t, "-0.9", d("0.1").Sub(d("1")).String())
+	assert.Equal(t, "0.00000001", d("0.0001").Mul(d("0.0001")).String())
+	assert.Equal(t, "3750.0625", d("30000.5").Mul(d("0.125")).String())
+	assert.Equal(t, "0.333333333333333333", d("1").Div(d("3")).String())
+	assert.Equal(t, "0.67", d("2").DivRound(d("3"), 2, RoundHalfUp).String())
+	assert.Equal(t, "0.005", d("0.105").Mod(d("0.01")).String())
+	assert.Equal(t, "-0.005", d("-0.105").Mod(d("0.01")).String())
+	assert.True(t, d("100").Mod(d("0.01")).IsZero())
+	assert.Equal(t, "12.5", d("0.125").Shift(2).String())
+	assert.Equal(t, "0.0125", d("1.25").Shift(-2).String())
+	assert.Equal(t, "1.5", d("-1.5").Abs().String())
+	assert.Equal(t, int64(-3), d("-3.99").IntPart())
+	assert.Equal(t, int32(1), d("1.50").Scale())
+
+	assert.True(t, d("1.50").Equal(d("1.5")))
+	assert.True(t, d("-2").LessThan(d("-1.999")))
+	assert.Equal(t, "-2", Min(d("1"), d("-2"), d("0")).String())
+	assert.Equal(t, "1", Max(d("1"), d("-2"), Zero).String())
+	assert.True(t, Zero.Add(Zero).IsZero())
+	assert.Panics(t, func() { d("1").Div(Zero) })
+
+	// 中间结果不受限制，写入数据库时检查范围
+	huge := d("999999999999999999").Add(d("1"))
+	assert.ErrorIs(t, huge.Check(), ErrOverflow)
+	_, err := huge.Value()
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = d("0.000000001").Mul(d("0.000000001")).Mul(d("0.1")).Value()
+	assert.ErrorIs(t, err, ErrPrecision)
+}
+
+// 测试各舍入模式
+func TestRounding(t *testing.T) {
+	modes := []RoundingMode{RoundDown, RoundUp, RoundFloor, RoundCeil, RoundHalfUp, RoundHalfEven}
+	cases := map[string][6]string{
+		"2.5":   {"2", "3", "2", "3", "3", "2"},
+		"3.5":   {"3", "4", "3", "4", "4", "4"},
+		"-2.5":  {"-2", "-3", "-3", "-2", "-3", "-2"},
+		"2.51":  {"2", "3", "2", "3", "3", "3"},
+		"-0.4":  {"0", "-1", "-1", "0", "0", "0"},
+		"7":     {"7", "7", "7", "7", "7", "7"},
+		"-2.49": {"-2", "-3", "-3", "-2", "-2", "-2"},
+	}
+	for in, want := range cases {
+		for i, mode := range modes {
+			assert.Equal(t, want[i], RequireFromString(in).RoundWith(0, mode).String(), "%s mode %d", in, mode)
+		}
+	}
+	assert.Equal(t, "1.23", RequireFromString("1.2345").Truncate(2).String())
+	assert.Equal(t, "1.24", RequireFromString("1.235").Round(2).String())
+	assert.Equal(t, "1200", RequireFromString("1250").RoundWith(-2, RoundHalfEven).String())
+	assert.Equal(t, "0.34", RequireFromString("1").DivRound(RequireFromString("3"), 2, RoundCeil).String())
+}
+
+// 测试JSON、数据库和资产小数位数
+func TestEncoding(t *testing.T) {
+	var v struct {
+		Price  Decimal `json:"price"`
+		Amount Decimal `json:"amount"`
+		Fee    Decimal `json:"fee"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"price":"30000.10","amount":0.1,"fee":null}`), &v))
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	assert.Equal(t, `{"price":"30000.1","amount":"0.1","fee":"0"}`, string(b))
+	assert.Error(t, json.Unmarshal([]byte(`{"price":"1e30"}`), &v))
+	assert.Error(t, json.Unmarshal([]byte(`{"price":"abc"}`), &v))
+
+	var d Decimal
+	for _, tc := range []struct {
+		in   interface{}
+		want string
+	}{
+		{"30000.500000000000000000", "30000.5"},
+		{[]byte("-1.25"), "-1.25"},
+		{int64(42), "42"},
+		{float64(0.1), "0.1"},
+	} {
+		require.NoError(t, d.Scan(tc.in))
+		assert.Equal(t, tc.want, d.String())
+	}
+	assert.Error(t, d.Scan(nil))
+	value, err := RequireFromString("0.125").Value()
+	require.NoError(t, err)
+	assert.Equal(t, "0.125", value)
+
+	scales := NewScales(map[string]int32{"usdt": 6, "btc": 8})
+	assert.Equal(t, int32(6), scales.Of("USDT"))
+	assert.Equal(t, int32(6), scales.Of("usdt"))
+	assert.Equal(t, int32(MaxScale), scales.Of("ETH"))
+	assert.True(t, scales.Fits("USDT", RequireFromString("1.123456")))
+	assert.False(t, scales.Fits("USDT", RequireFromString("1.1234567")))
+	assert.Equal(t, "1.123456", scales.Quantize("USDT", RequireFromString("1.1234567"), RoundDown).String())
+}
+
+// 测试validator标签
+func TestValidation(t *testing.T) {
+	v := validator.New()
+	RegisterValidation(v)
+	type request struct {
+		Quantity Decimal `validate:"required,gt=0"`
+		Price    Decimal `validate:"gte=0,lte=1000000"`
+		Band     Decimal `validate:"omitempty,max=1"`
+	}
+	d := RequireFromString
+
+	assert.NoError(t, v.Struct(request{Quantity: d("0.001"), Price: d("0")}))
+	assert.NoError(t, v.Struct(request{Quantity: d("1"), Price: d("1000000"), Band: d("1")}))
+	for _, bad := range []request{
+		{Quantity: Zero},
+		{Quantity: d("-1")},
+		{Quantity: d("1"), Price: d("-0.01")},
+		{Quantity: d("1"), Price: d("1000000.01")},
+		{Quantity: d("1"), Band: d("1.5")},
+	} {
+		err := v.Struct(bad)
+		assert.Error(t, err, "%+v", bad)
+		assert.False(t, strings.Contains(err.Error(), "Bad field type"), err.Error())
+	}
+}
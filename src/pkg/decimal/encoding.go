@@ -0,0 +1,79 @@
+package decimal
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+)
+
+// MarshalJSON 编码为JSON字符串，如 "30000.5"，避免客户端按浮点数解析损失精度
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON 解码JSON字符串或数字，null解码为0，超出可表示范围时报错
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		*d = Zero
+		return nil
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	v, err := NewFromString(s)
+	if err != nil {
+		return fmt.Errorf("cannot unmarshal %s into decimal: %w", data, err)
+	}
+	*d = v
+	return nil
+}
+
+// MarshalText 实现encoding.TextMarshaler
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText 实现encoding.TextUnmarshaler，用于查询参数和配置等文本来源
+func (d *Decimal) UnmarshalText(text []byte) error {
+	v, err := NewFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// Value 实现driver.Valuer，以字符串写入NUMERIC列，超出numeric(36,18)范围时报错而不是由数据库截断
+func (d Decimal) Value() (driver.Value, error) {
+	if err := d.Check(); err != nil {
+		return nil, err
+	}
+	return d.String(), nil
+}
+
+// Scan 实现sql.Scanner，读取NUMERIC列。SQLite可能以整数或浮点数返回NUMERIC列的值。
+func (d *Decimal) Scan(value interface{}) error {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case int64:
+		*d = NewFromInt(v)
+		return nil
+	case float64:
+		s = strconv.FormatFloat(v, 'g', -1, 64)
+	case nil:
+		return fmt.Errorf("decimal: cannot scan NULL")
+	default:
+		return fmt.Errorf("decimal: cannot scan %T", value)
+	}
+	v, err := parse(s)
+	if err != nil {
+		return fmt.Errorf("decimal: cannot scan %q: %w", s, err)
+	}
+	*d = v
+	return nil
+}
@@ -0,0 +1,70 @@
+package decimal
+
+import "math/big"
+
+// RoundingMode 舍入模式
+type RoundingMode int
+
+// 舍入模式。Down和Up按绝对值舍入，Floor和Ceil按数轴方向舍入。
+const (
+	RoundDown     RoundingMode = iota // 向零舍入，即截断
+	RoundUp                           // 远离零舍入
+	RoundFloor                        // 向负无穷舍入
+	RoundCeil                         // 向正无穷舍入
+	RoundHalfUp                       // 四舍五入，恰好一半时远离零
+	RoundHalfEven                     // 四舍六入五成双（银行家舍入）
+)
+
+// Round 四舍五入保留places位小数，places为负时舍入到整十、整百等
+func (d Decimal) Round(places int32) Decimal {
+	return d.RoundWith(places, RoundHalfUp)
+}
+
+// Truncate 向零截断保留places位小数
+func (d Decimal) Truncate(places int32) Decimal {
+	return d.RoundWith(places, RoundDown)
+}
+
+// RoundWith 按mode保留places位小数，places为负时舍入到整十、整百等。小数位数不超过places时原样返回。
+func (d Decimal) RoundWith(places int32, mode RoundingMode) Decimal {
+	if d.IsZero() || d.scale <= places {
+		return d
+	}
+	q := roundQuo(new(big.Int).Set(d.coef), pow10(d.scale-places), mode)
+	return fromScaled(q, places)
+}
+
+// roundQuo 返回按mode舍入为整数的 num ÷ den，会修改num，不修改den
+func roundQuo(num, den *big.Int, mode RoundingMode) *big.Int {
+	negative := num.Sign()*den.Sign() < 0
+	q, r := num.QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	var away bool
+	switch mode {
+	case RoundUp:
+		away = true
+	case RoundFloor:
+		away = negative
+	case RoundCeil:
+		away = !negative
+	case RoundHalfUp, RoundHalfEven:
+		// 比较 2|r| 与 |den|
+		twice := r.Abs(r).Lsh(r, 1)
+		switch twice.CmpAbs(den) {
+		case 1:
+			away = true
+		case 0:
+			away = mode == RoundHalfUp || q.Bit(0) == 1
+		}
+	}
+	if !away {
+		return q
+	}
+	if negative {
+		return q.Sub(q, big.NewInt(1))
+	}
+	return q.Add(q, big.NewInt(1))
+}
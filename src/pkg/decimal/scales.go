@@ -0,0 +1,40 @@
+package decimal
+
+import "strings"
+
+// Scales 各资产金额的固定小数位数，键为大写资产代码，未登记的资产按MaxScale处理
+type Scales map[string]int32
+
+// NewScales 由配置创建资产小数位数表，资产代码转为大写（配置文件中的键不区分大小写），
+// 超出[0, MaxScale]的值被截断到该范围
+func NewScales(m map[string]int32) Scales {
+	s := make(Scales, len(m))
+	for asset, scale := range m {
+		if scale < 0 {
+			scale = 0
+		}
+		if scale > MaxScale {
+			scale = MaxScale
+		}
+		s[strings.ToUpper(asset)] = scale
+	}
+	return s
+}
+
+// Of 返回资产的小数位数，资产代码不区分大小写
+func (s Scales) Of(asset string) int32 {
+	if scale, ok := s[strings.ToUpper(asset)]; ok {
+		return scale
+	}
+	return MaxScale
+}
+
+// Fits 判断金额的小数位数是否不超过资产的小数位数
+func (s Scales) Fits(asset string, d Decimal) bool {
+	return d.Scale() <= s.Of(asset)
+}
+
+// Quantize 按mode将金额舍入到资产的小数位数
+func (s Scales) Quantize(asset string, d Decimal, mode RoundingMode) Decimal {
+	return d.RoundWith(s.Of(asset), mode)
+}
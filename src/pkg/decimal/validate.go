@@ -0,0 +1,19 @@
+package decimal
+
+import (
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// RegisterValidation 使validator的内置标签支持Decimal字段：required要求非零，
+// min、max、gt、gte、lt、lte、eq、ne按数值比较，如 binding:"required,gt=0"。
+// 比较时转换为float64，足以判断标签中给出的边界，精确的业务校验仍由服务层完成。
+func RegisterValidation(v *validator.Validate) {
+	v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		if d, ok := field.Interface().(Decimal); ok {
+			return d.Float64()
+		}
+		return nil
+	}, Decimal{})
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+// ErrCiphertextInvalid 密文格式错误或校验失败
+var ErrCiphertextInvalid = errors.New("invalid ciphertext")
+
+// Cipher 基于AES-256-GCM的对称加密工具，用于加密需要可逆保存的敏感数据
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher 使用给定口令派生256位密钥并创建加密工具实例
+func NewCipher(secret string) *Cipher {
+	key := sha256.Sum256([]byte(secret))
+	// 密钥长度固定为32字节，以下调用不会失败
+	block, _ := aes.NewCipher(key[:])
+	aead, _ := cipher.NewGCM(block)
+	return &Cipher{aead: aead}
+}
+
+// Encrypt 加密明文，返回base64编码的nonce+密文
+func (c *Cipher) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密Encrypt生成的密文
+func (c *Cipher) Decrypt(ciphertext string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", ErrCiphertextInvalid
+	}
+	size := c.aead.NonceSize()
+	if len(data) < size {
+		return "", ErrCiphertextInvalid
+	}
+	plain, err := c.aead.Open(nil, data[:size], data[size:], nil)
+	if err != nil {
+		return "", ErrCiphertextInvalid
+	}
+	return string(plain), nil
+}
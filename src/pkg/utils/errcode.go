@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// 业务错误码，与HTTP状态码一起在Response.Code中返回，便于客户端区分同一状态码下的不同原因
+const (
+	CodeMarketNotFound   = 10001 // 交易对不存在
+	CodeMarketNotTrading = 10002 // 交易对未开放交易或已暂停
+	CodePriceTick        = 10003 // 价格不是最小价格变动单位的整数倍
+	CodeQuantityStep     = 10004 // 数量不是最小数量变动单位的整数倍
+	CodeMinNotional      = 10005 // 订单金额低于最小下单金额
+
+	CodeRiskMaxQuantity   = 10101 // 单笔数量超过风控限额
+	CodeRiskMaxNotional   = 10102 // 单笔金额超过风控限额
+	CodeRiskMaxOpenOrders = 10103 // 交易对上的挂单数已达上限
+	CodeRiskPriceBand     = 10104 // 限价偏离最新成交价超过允许范围
+	CodeRiskDailyLoss     = 10105 // 当日亏损已达上限
+
+	CodeSettlementParked = 10201 // 订单已撮合但结算被搁置，等待人工处理
+)
+
+// Fail 以指定的HTTP状态码返回业务错误码
+func Fail(c *gin.Context, status, code int, message string) {
+	c.JSON(status, Response{
+		Code:    code,
+		Message: message,
+	})
+}
@@ -0,0 +1,36 @@
+package utils
+
+// 分页默认值
+const (
+	DefaultPage     = 1
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// PageData 分页响应数据
+type PageData struct {
+	List     interface{} `json:"list"`
+	Total    int64       `json:"total"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+}
+
+// CursorData 游标分页响应数据，NextCursor为0表示没有更多数据
+type CursorData struct {
+	List       interface{} `json:"list"`
+	NextCursor uint        `json:"next_cursor"`
+}
+
+// NormalizePage 规范化分页参数
+func NormalizePage(page, pageSize int) (int, int) {
+	if page < 1 {
+		page = DefaultPage
+	}
+	if pageSize < 1 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+	return page, pageSize
+}
@@ -53,3 +53,35 @@ func InternalServerError(c *gin.Context, message string) {
 		Message: message,
 	})
 }
+
+// Forbidden 403错误响应
+func Forbidden(c *gin.Context, message string) {
+	c.JSON(http.StatusForbidden, Response{
+		Code:    403,
+		Message: message,
+	})
+}
+
+// NotFound 404错误响应
+func NotFound(c *gin.Context, message string) {
+	c.JSON(http.StatusNotFound, Response{
+		Code:    404,
+		Message: message,
+	})
+}
+
+// Conflict 409错误响应
+func Conflict(c *gin.Context, message string) {
+	c.JSON(http.StatusConflict, Response{
+		Code:    409,
+		Message: message,
+	})
+}
+
+// UnprocessableEntity 422错误响应，请求格式正确但业务条件不满足
+func UnprocessableEntity(c *gin.Context, message string) {
+	c.JSON(http.StatusUnprocessableEntity, Response{
+		Code:    422,
+		Message: message,
+	})
+}